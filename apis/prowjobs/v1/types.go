@@ -518,8 +518,25 @@ type GCSConfiguration struct {
 	// LocalOutputDir specifies a directory where files should be copied INSTEAD of uploading to GCS.
 	// This option is useful for testing jobs that use the pod-utilities without actually uploading.
 	LocalOutputDir string `json:"local_output_dir,omitempty"`
+
+	// RetentionClass hints to GCS lifecycle tooling how long the artifacts of
+	// the job's runs are worth keeping. It is uploaded with each run as
+	// retention.json. Can be "short" or "long".
+	RetentionClass string `json:"retention_class,omitempty"`
+
+	// JUnitSummary merges the junit*.xml files of the run into a single
+	// junit_summary.xml that is uploaded to the root of the run, next to
+	// started.json. It is not uploaded into artifacts/ as the JUnit lens and
+	// TestGrid read every artifacts/junit*.xml and would count each test twice.
+	JUnitSummary bool `json:"junit_summary,omitempty"`
 }
 
+// Retention classes of the artifacts of a job.
+const (
+	RetentionClassShort = "short"
+	RetentionClassLong  = "long"
+)
+
 // ApplyDefault applies the defaults for GCSConfiguration decorations. If a field has a zero value,
 // it replaces that with the value set in def.
 func (g *GCSConfiguration) ApplyDefault(def *GCSConfiguration) *GCSConfiguration {
@@ -562,6 +579,12 @@ func (g *GCSConfiguration) ApplyDefault(def *GCSConfiguration) *GCSConfiguration
 	if merged.LocalOutputDir == "" {
 		merged.LocalOutputDir = def.LocalOutputDir
 	}
+	if merged.RetentionClass == "" {
+		merged.RetentionClass = def.RetentionClass
+	}
+	if !merged.JUnitSummary {
+		merged.JUnitSummary = def.JUnitSummary
+	}
 	return &merged
 }
 
@@ -578,6 +601,9 @@ func (g *GCSConfiguration) Validate() error {
 	if g.PathStrategy != PathStrategyExplicit && (g.DefaultOrg == "" || g.DefaultRepo == "") {
 		return fmt.Errorf("default org and repo must be provided for GCS strategy %q", g.PathStrategy)
 	}
+	if g.RetentionClass != "" && g.RetentionClass != RetentionClassShort && g.RetentionClass != RetentionClassLong {
+		return fmt.Errorf("retention_class must be one of %q or %q", RetentionClassShort, RetentionClassLong)
+	}
 	return nil
 }
 
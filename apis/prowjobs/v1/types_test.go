@@ -137,6 +137,30 @@ func TestDecorationDefaulting(t *testing.T) {
 				return def
 			},
 		},
+		{
+			name: "gcs retention class provided",
+			provided: &DecorationConfig{
+				GCSConfiguration: &GCSConfiguration{
+					RetentionClass: RetentionClassShort,
+				},
+			},
+			expected: func(orig, def *DecorationConfig) *DecorationConfig {
+				def.GCSConfiguration.RetentionClass = orig.GCSConfiguration.RetentionClass
+				return def
+			},
+		},
+		{
+			name: "gcs junit summary provided",
+			provided: &DecorationConfig{
+				GCSConfiguration: &GCSConfiguration{
+					JUnitSummary: true,
+				},
+			},
+			expected: func(orig, def *DecorationConfig) *DecorationConfig {
+				def.GCSConfiguration.JUnitSummary = orig.GCSConfiguration.JUnitSummary
+				return def
+			},
+		},
 		{
 			name: "skip_cloning provided",
 			provided: &DecorationConfig{
@@ -172,11 +196,12 @@ func TestDecorationDefaulting(t *testing.T) {
 					Sidecar:    "sidecar",
 				},
 				GCSConfiguration: &GCSConfiguration{
-					Bucket:       "bucket",
-					PathPrefix:   "prefix",
-					PathStrategy: PathStrategyLegacy,
-					DefaultOrg:   "org",
-					DefaultRepo:  "repo",
+					Bucket:         "bucket",
+					PathPrefix:     "prefix",
+					PathStrategy:   PathStrategyLegacy,
+					DefaultOrg:     "org",
+					DefaultRepo:    "repo",
+					RetentionClass: RetentionClassLong,
 				},
 				GCSCredentialsSecret: "secretName",
 				SSHKeySecrets:        []string{"first", "second"},
@@ -14,18 +14,20 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
-package main
+// Package branchprotection renders the branch protection policies in the
+// Prow config into the requests the GitHub API expects.
+package branchprotection
 
 import (
-	branchprotection "github.com/clarketm/prow/config"
+	"github.com/clarketm/prow/config"
 	"github.com/clarketm/prow/github"
 
 	"github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/util/sets"
 )
 
-// makeRequest renders a branch protection policy into the corresponding GitHub api request.
-func makeRequest(policy branchprotection.Policy) github.BranchProtectionRequest {
+// MakeRequest renders a branch protection policy into the corresponding GitHub api request.
+func MakeRequest(policy config.Policy) github.BranchProtectionRequest {
 	return github.BranchProtectionRequest{
 		EnforceAdmins:              makeAdmins(policy.Admins),
 		RequiredPullRequestReviews: makeReviews(policy.RequiredPullRequestReviews),
@@ -56,7 +58,7 @@ func makeBool(val *bool) bool {
 //
 // Returns nil when input policy is nil.
 // Otherwise returns non-nil Contexts (empty if unset) and Strict iff Strict is true
-func makeChecks(cp *branchprotection.ContextPolicy) *github.RequiredStatusChecks {
+func makeChecks(cp *config.ContextPolicy) *github.RequiredStatusChecks {
 	if cp == nil {
 		return nil
 	}
@@ -70,7 +72,7 @@ func makeChecks(cp *branchprotection.ContextPolicy) *github.RequiredStatusChecks
 //
 // Returns nil when input restrictions is nil.
 // Otherwise Teams and Users are both non-nil (empty list if unset)
-func makeRestrictions(rp *branchprotection.Restrictions) *github.RestrictionsRequest {
+func makeRestrictions(rp *config.Restrictions) *github.RestrictionsRequest {
 	if rp == nil {
 		return nil
 	}
@@ -85,7 +87,7 @@ func makeRestrictions(rp *branchprotection.Restrictions) *github.RestrictionsReq
 // makeReviews renders review policy into the corresponding GitHub api object.
 //
 // Returns nil if the policy is nil, or approvals is nil or 0.
-func makeReviews(rp *branchprotection.ReviewPolicy) *github.RequiredPullRequestReviewsRequest {
+func makeReviews(rp *config.ReviewPolicy) *github.RequiredPullRequestReviewsRequest {
 	switch {
 	case rp == nil:
 		return nil
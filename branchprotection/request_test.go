@@ -14,13 +14,13 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
-package main
+package branchprotection
 
 import (
 	"reflect"
 	"testing"
 
-	branchprotection "github.com/clarketm/prow/config"
+	"github.com/clarketm/prow/config"
 	"github.com/clarketm/prow/github"
 )
 
@@ -58,7 +58,7 @@ func TestMakeReviews(t *testing.T) {
 	yes := true
 	cases := []struct {
 		name     string
-		input    *branchprotection.ReviewPolicy
+		input    *config.ReviewPolicy
 		expected *github.RequiredPullRequestReviewsRequest
 	}{
 		{
@@ -66,19 +66,19 @@ func TestMakeReviews(t *testing.T) {
 		},
 		{
 			name: "nil apporvals returns nil",
-			input: &branchprotection.ReviewPolicy{
+			input: &config.ReviewPolicy{
 				Approvals: nil,
 			},
 		},
 		{
 			name: "0 approvals returns nil",
-			input: &branchprotection.ReviewPolicy{
+			input: &config.ReviewPolicy{
 				Approvals: &zero,
 			},
 		},
 		{
 			name: "approvals set",
-			input: &branchprotection.ReviewPolicy{
+			input: &config.ReviewPolicy{
 				Approvals: &three,
 			},
 			expected: &github.RequiredPullRequestReviewsRequest{
@@ -87,11 +87,11 @@ func TestMakeReviews(t *testing.T) {
 		},
 		{
 			name: "set all",
-			input: &branchprotection.ReviewPolicy{
+			input: &config.ReviewPolicy{
 				Approvals:     &one,
 				RequireOwners: &yes,
 				DismissStale:  &yes,
-				DismissalRestrictions: &branchprotection.Restrictions{
+				DismissalRestrictions: &config.Restrictions{
 					Users: []string{"fred", "jane"},
 					Teams: []string{"megacorp", "startup"},
 				},
@@ -121,7 +121,7 @@ func TestMakeRequest(t *testing.T) {
 	no := false
 	cases := []struct {
 		name     string
-		policy   branchprotection.Policy
+		policy   config.Policy
 		expected github.BranchProtectionRequest
 	}{
 		{
@@ -132,8 +132,8 @@ func TestMakeRequest(t *testing.T) {
 		},
 		{
 			name: "teams != nil => users != nil",
-			policy: branchprotection.Policy{
-				Restrictions: &branchprotection.Restrictions{
+			policy: config.Policy{
+				Restrictions: &config.Restrictions{
 					Teams: []string{"hello"},
 				},
 			},
@@ -147,8 +147,8 @@ func TestMakeRequest(t *testing.T) {
 		},
 		{
 			name: "users != nil => teams != nil",
-			policy: branchprotection.Policy{
-				Restrictions: &branchprotection.Restrictions{
+			policy: config.Policy{
+				Restrictions: &config.Restrictions{
 					Users: []string{"there"},
 				},
 			},
@@ -162,8 +162,8 @@ func TestMakeRequest(t *testing.T) {
 		},
 		{
 			name: "Strict => Contexts != nil",
-			policy: branchprotection.Policy{
-				RequiredStatusChecks: &branchprotection.ContextPolicy{
+			policy: config.Policy{
+				RequiredStatusChecks: &config.ContextPolicy{
 					Strict: &yes,
 				},
 			},
@@ -178,7 +178,7 @@ func TestMakeRequest(t *testing.T) {
 	}
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			actual := makeRequest(tc.policy)
+			actual := MakeRequest(tc.policy)
 			expected := tc.expected
 			if !reflect.DeepEqual(actual, expected) {
 				t.Errorf("actual %+v != expected %+v", actual, expected)
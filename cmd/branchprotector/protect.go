@@ -29,6 +29,7 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"k8s.io/apimachinery/pkg/util/sets"
+	"github.com/clarketm/prow/branchprotection"
 	"github.com/clarketm/prow/config"
 	"github.com/clarketm/prow/config/secret"
 	"github.com/clarketm/prow/errorutil"
@@ -385,7 +386,7 @@ func (p *protector) UpdateBranch(orgName, repo string, branchName string, branch
 
 	var req *github.BranchProtectionRequest
 	if *bp.Protect {
-		r := makeRequest(*bp)
+		r := branchprotection.MakeRequest(*bp)
 		req = &r
 	}
 
@@ -242,13 +242,14 @@ func main() {
 			logrus.WithError(err).Fatal("Error getting GitHub client.")
 		}
 
-		githubReporter := githubreporter.NewReporter(githubClient, cfg, v1.ProwJobAgent(o.reportAgent))
+		informer := prowjobInformerFactory.Prow().V1().ProwJobs()
+		githubReporter := githubreporter.NewReporter(githubClient, cfg, v1.ProwJobAgent(o.reportAgent), informer.Lister())
 		controllers = append(
 			controllers,
 			crier.NewController(
 				prowjobClientset,
 				kube.RateLimiter(githubReporter.GetName()),
-				prowjobInformerFactory.Prow().V1().ProwJobs(),
+				informer,
 				githubReporter,
 				o.githubWorkers))
 	}
@@ -0,0 +1,124 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/clarketm/prow/config"
+	"github.com/clarketm/prow/spyglass/lenses"
+)
+
+// artifactFetcher is the subset of Spyglass needed to archive the artifacts
+// of a job run.
+type artifactFetcher interface {
+	ResolveSymlink(src string) (string, error)
+	ListArtifacts(src string) ([]string, error)
+	FetchArtifacts(src string, podName string, sizeLimit int64, artifactNames []string) ([]lenses.Artifact, error)
+}
+
+// handleArtifactsArchive streams all artifacts of the job run given by the src
+// query parameter as a gzipped tar archive. The artifacts are read one at a
+// time while the archive is written. Runs whose artifacts are larger than the
+// Spyglass size limit in total are rejected.
+func handleArtifactsArchive(af artifactFetcher, cfg config.Getter, log *logrus.Entry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setHeadersNoCaching(w)
+		src := r.URL.Query().Get("src")
+		if src == "" {
+			http.Error(w, "The src query parameter is required.", http.StatusBadRequest)
+			return
+		}
+		src, err := af.ResolveSymlink(src)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to resolve src: %v", err), http.StatusBadRequest)
+			return
+		}
+		names, err := af.ListArtifacts(src)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to list artifacts: %v", err), http.StatusInternalServerError)
+			return
+		}
+		sizeLimit := cfg().Deck.Spyglass.SizeLimit
+		artifacts, err := af.FetchArtifacts(src, "", sizeLimit, names)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to retrieve artifacts: %v", err), http.StatusInternalServerError)
+			return
+		}
+		var total int64
+		for _, artifact := range artifacts {
+			size, err := artifact.Size()
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Failed to get the size of %s: %v", artifact.JobPath(), err), http.StatusInternalServerError)
+				return
+			}
+			total += size
+		}
+		if total > sizeLimit {
+			http.Error(w, fmt.Sprintf("The artifacts are too large to archive (%d bytes, the limit is %d bytes).", total, sizeLimit), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/gzip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", archiveName(src)))
+		gzw := gzip.NewWriter(w)
+		tw := tar.NewWriter(gzw)
+		for _, artifact := range artifacts {
+			contents, err := artifact.ReadAll()
+			if err != nil {
+				// The response has already started, so the archive can only be cut short.
+				log.WithError(err).WithField("artifact", artifact.JobPath()).Error("Error reading artifact, aborting the archive.")
+				return
+			}
+			header := &tar.Header{
+				Name:    artifact.JobPath(),
+				Mode:    0644,
+				Size:    int64(len(contents)),
+				ModTime: time.Now(),
+			}
+			if err := tw.WriteHeader(header); err != nil {
+				log.WithError(err).Debug("Error writing archive, closing it.")
+				return
+			}
+			if _, err := tw.Write(contents); err != nil {
+				log.WithError(err).Debug("Error writing archive, closing it.")
+				return
+			}
+		}
+		if err := tw.Close(); err != nil {
+			log.WithError(err).Debug("Error closing archive.")
+			return
+		}
+		if err := gzw.Close(); err != nil {
+			log.WithError(err).Debug("Error closing archive.")
+		}
+	}
+}
+
+// archiveName names the archive of a run after its job and build ID.
+func archiveName(src string) string {
+	src = strings.TrimSuffix(src, "/")
+	return fmt.Sprintf("%s-%s.tar.gz", path.Base(path.Dir(src)), path.Base(src))
+}
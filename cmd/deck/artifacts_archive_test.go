@@ -0,0 +1,153 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/clarketm/prow/config"
+	"github.com/clarketm/prow/spyglass/lenses"
+)
+
+type fakeArtifact struct {
+	path    string
+	content string
+}
+
+func (a *fakeArtifact) ReadAt(p []byte, off int64) (int, error) { return 0, nil }
+func (a *fakeArtifact) ReadAtMost(n int64) ([]byte, error)      { return nil, nil }
+func (a *fakeArtifact) CanonicalLink() string                   { return "" }
+func (a *fakeArtifact) JobPath() string                         { return a.path }
+func (a *fakeArtifact) ReadAll() ([]byte, error)                { return []byte(a.content), nil }
+func (a *fakeArtifact) ReadTail(n int64) ([]byte, error)        { return nil, nil }
+func (a *fakeArtifact) Size() (int64, error)                    { return int64(len(a.content)), nil }
+
+type fakeArtifactFetcher struct {
+	artifacts map[string]string
+}
+
+func (f *fakeArtifactFetcher) ResolveSymlink(src string) (string, error) {
+	return src, nil
+}
+
+func (f *fakeArtifactFetcher) ListArtifacts(src string) ([]string, error) {
+	var names []string
+	for name := range f.artifacts {
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func (f *fakeArtifactFetcher) FetchArtifacts(src string, podName string, sizeLimit int64, artifactNames []string) ([]lenses.Artifact, error) {
+	var artifacts []lenses.Artifact
+	for _, name := range artifactNames {
+		// Like Spyglass, skip the artifacts that do not exist.
+		content, ok := f.artifacts[name]
+		if !ok {
+			continue
+		}
+		artifacts = append(artifacts, &fakeArtifact{path: name, content: content})
+	}
+	return artifacts, nil
+}
+
+func TestHandleArtifactsArchive(t *testing.T) {
+	fetcher := &fakeArtifactFetcher{artifacts: map[string]string{
+		"build-log.txt":              "the build log",
+		"finished.json":              `{"passed": true}`,
+		"artifacts/junit_runner.xml": "<testsuites></testsuites>",
+	}}
+	testCases := []struct {
+		name         string
+		query        string
+		sizeLimit    int64
+		expectedCode int
+	}{
+		{
+			name:         "archive of all artifacts",
+			query:        "src=gcs/bucket/logs/job/123",
+			sizeLimit:    1000,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "artifacts exceed the size limit",
+			query:        "src=gcs/bucket/logs/job/123",
+			sizeLimit:    10,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "missing src",
+			sizeLimit:    1000,
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.Deck.Spyglass.SizeLimit = tc.sizeLimit
+			handler := handleArtifactsArchive(fetcher, func() *config.Config { return cfg }, logrus.WithField("handler", "/artifacts-archive"))
+			req, err := http.NewRequest(http.MethodGet, "/artifacts-archive?"+tc.query, nil)
+			if err != nil {
+				t.Fatalf("Error making request: %v", err)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != tc.expectedCode {
+				t.Fatalf("Expected status code %d, got %d", tc.expectedCode, rr.Code)
+			}
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+			if disposition := rr.Header().Get("Content-Disposition"); disposition != `attachment; filename="job-123.tar.gz"` {
+				t.Errorf("Unexpected Content-Disposition %q", disposition)
+			}
+
+			gzr, err := gzip.NewReader(rr.Body)
+			if err != nil {
+				t.Fatalf("Error reading gzipped archive: %v", err)
+			}
+			tr := tar.NewReader(gzr)
+			entries := map[string]string{}
+			for {
+				header, err := tr.Next()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("Error reading archive: %v", err)
+				}
+				contents, err := ioutil.ReadAll(tr)
+				if err != nil {
+					t.Fatalf("Error reading archive entry %s: %v", header.Name, err)
+				}
+				entries[header.Name] = string(contents)
+			}
+			if !reflect.DeepEqual(entries, fetcher.artifacts) {
+				t.Errorf("Expected archive entries %v, got %v", fetcher.artifacts, entries)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/labels"
+	toolscache "k8s.io/client-go/tools/cache"
+
+	prowapi "github.com/clarketm/prow/apis/prowjobs/v1"
+)
+
+const (
+	prowJobAdded   = "added"
+	prowJobUpdated = "updated"
+	prowJobDeleted = "deleted"
+
+	// eventBufferSize is the number of events buffered per subscriber.
+	// Events for subscribers that fall further behind are dropped so that
+	// a slow client can not stall the informer.
+	eventBufferSize = 100
+)
+
+// prowJobEvent is a ProwJob state change that is sent to clients.
+type prowJobEvent struct {
+	Type    string          `json:"type"`
+	ProwJob prowapi.ProwJob `json:"prowjob"`
+}
+
+// prowJobInformer is the subset of the controller-runtime informer we need.
+type prowJobInformer interface {
+	AddEventHandler(handler toolscache.ResourceEventHandler)
+}
+
+// prowJobEventBroadcaster fans out ProwJob informer notifications to all
+// subscribed clients.
+type prowJobEventBroadcaster struct {
+	lock        sync.Mutex
+	subscribers map[chan prowJobEvent]struct{}
+}
+
+func newProwJobEventBroadcaster(informer prowJobInformer) *prowJobEventBroadcaster {
+	b := &prowJobEventBroadcaster{subscribers: map[chan prowJobEvent]struct{}{}}
+	informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			b.publish(prowJobAdded, obj)
+		},
+		UpdateFunc: func(_, newObj interface{}) {
+			b.publish(prowJobUpdated, newObj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			b.publish(prowJobDeleted, obj)
+		},
+	})
+	return b
+}
+
+func (b *prowJobEventBroadcaster) publish(eventType string, obj interface{}) {
+	pj, ok := obj.(*prowapi.ProwJob)
+	if !ok {
+		logrus.Warnf("Ignoring %s event for unexpected type %T.", eventType, obj)
+		return
+	}
+	event := prowJobEvent{Type: eventType, ProwJob: *pj.DeepCopy()}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	for subscriber := range b.subscribers {
+		select {
+		case subscriber <- event:
+		default:
+			logrus.WithField("prowjob", pj.Name).Debug("Subscriber is not keeping up, dropping event.")
+		}
+	}
+}
+
+// subscribe registers a new subscriber. The returned function must be called
+// once the subscriber is no longer interested in events.
+func (b *prowJobEventBroadcaster) subscribe() (<-chan prowJobEvent, func()) {
+	events := make(chan prowJobEvent, eventBufferSize)
+	b.lock.Lock()
+	b.subscribers[events] = struct{}{}
+	b.lock.Unlock()
+	return events, func() {
+		b.lock.Lock()
+		delete(b.subscribers, events)
+		b.lock.Unlock()
+	}
+}
+
+// handleProwJobEvents streams ProwJob state changes as server-sent events.
+// The optional selector query parameter scopes the events by label.
+func handleProwJobEvents(b *prowJobEventBroadcaster, visible func(prowapi.ProwJob) bool, log *logrus.Entry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		selector, err := labels.Parse(r.URL.Query().Get("selector"))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid selector: %v", err), http.StatusBadRequest)
+			return
+		}
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "Streaming is not supported.", http.StatusInternalServerError)
+			return
+		}
+
+		events, unsubscribe := b.subscribe()
+		defer unsubscribe()
+
+		setHeadersNoCaching(w)
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case event := <-events:
+				if !selector.Matches(labels.Set(event.ProwJob.Labels)) || !visible(event.ProwJob) {
+					continue
+				}
+				data, err := json.Marshal(event)
+				if err != nil {
+					log.WithError(err).Error("Error marshaling event.")
+					continue
+				}
+				if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+					log.WithError(err).Debug("Error writing event, closing stream.")
+					return
+				}
+				flusher.Flush()
+			}
+		}
+	}
+}
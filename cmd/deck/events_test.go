@@ -0,0 +1,114 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	toolscache "k8s.io/client-go/tools/cache"
+
+	prowapi "github.com/clarketm/prow/apis/prowjobs/v1"
+)
+
+type fakeProwJobInformer struct {
+	handler toolscache.ResourceEventHandler
+}
+
+func (f *fakeProwJobInformer) AddEventHandler(handler toolscache.ResourceEventHandler) {
+	f.handler = handler
+}
+
+func TestHandleProwJobEvents(t *testing.T) {
+	informer := &fakeProwJobInformer{}
+	broadcaster := newProwJobEventBroadcaster(informer)
+	visible := func(pj prowapi.ProwJob) bool { return !pj.Spec.Hidden }
+	ts := httptest.NewServer(traceHandler(handleProwJobEvents(broadcaster, visible, logrus.WithField("handler", "/prowjobs-events"))))
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "?selector=" + "type%3Dperiodic")
+	if err != nil {
+		t.Fatalf("Error requesting events: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+	if contentType := resp.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Fatalf("Expected content type text/event-stream, got %q", contentType)
+	}
+
+	newJob := func(name, jobType string, hidden bool) *prowapi.ProwJob {
+		return &prowapi.ProwJob{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"type": jobType}},
+			Spec:       prowapi.ProwJobSpec{Hidden: hidden},
+		}
+	}
+	old := newJob("wanted", "periodic", false)
+	updated := old.DeepCopy()
+	updated.Status.State = prowapi.SuccessState
+	// The headers were flushed after subscribing, so these are all delivered.
+	informer.handler.OnUpdate(newJob("presubmit", "presubmit", false), newJob("presubmit", "presubmit", false))
+	informer.handler.OnAdd(newJob("hidden", "periodic", true))
+	informer.handler.OnUpdate(old, updated)
+
+	scanner := bufio.NewScanner(resp.Body)
+	var eventType, data string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "event: ") {
+			eventType = strings.TrimPrefix(line, "event: ")
+		}
+		if strings.HasPrefix(line, "data: ") {
+			data = strings.TrimPrefix(line, "data: ")
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("Error reading events: %v", err)
+	}
+	if eventType != prowJobUpdated {
+		t.Errorf("Expected event type %q, got %q", prowJobUpdated, eventType)
+	}
+	var event prowJobEvent
+	if err := json.Unmarshal([]byte(data), &event); err != nil {
+		t.Fatalf("Error unmarshaling event %q: %v", data, err)
+	}
+	if event.ProwJob.Name != "wanted" || event.ProwJob.Status.State != prowapi.SuccessState {
+		t.Errorf("Expected the update of the wanted job, got job %q in state %q", event.ProwJob.Name, event.ProwJob.Status.State)
+	}
+}
+
+func TestHandleProwJobEventsInvalidSelector(t *testing.T) {
+	broadcaster := newProwJobEventBroadcaster(&fakeProwJobInformer{})
+	handler := traceHandler(handleProwJobEvents(broadcaster, func(prowapi.ProwJob) bool { return true }, logrus.WithField("handler", "/prowjobs-events")))
+	req, err := http.NewRequest(http.MethodGet, "/prowjobs-events?selector=%3D%3D%3D", nil)
+	if err != nil {
+		t.Fatalf("Error making request: %v", err)
+	}
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+}
@@ -26,11 +26,13 @@ import (
 	"flag"
 	"fmt"
 	"html/template"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"os"
 	"path"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -56,11 +58,13 @@ import (
 	"sigs.k8s.io/yaml"
 
 	prowapi "github.com/clarketm/prow/apis/prowjobs/v1"
+	"github.com/clarketm/prow/branchprotection"
 	prowv1 "github.com/clarketm/prow/client/clientset/versioned/typed/prowjobs/v1"
 	"github.com/clarketm/prow/config"
 	"github.com/clarketm/prow/config/secret"
 	"github.com/clarketm/prow/deck/jobs"
 	prowflagutil "github.com/clarketm/prow/flagutil"
+	"github.com/clarketm/prow/gcsupload"
 	"github.com/clarketm/prow/git"
 	prowgithub "github.com/clarketm/prow/github"
 	"github.com/clarketm/prow/githuboauth"
@@ -71,6 +75,7 @@ import (
 	"github.com/clarketm/prow/pluginhelp"
 	"github.com/clarketm/prow/plugins"
 	"github.com/clarketm/prow/plugins/trigger"
+	"github.com/clarketm/prow/pod-utils/downwardapi"
 	"github.com/clarketm/prow/prstatus"
 	"github.com/clarketm/prow/spyglass"
 
@@ -221,6 +226,9 @@ var (
 
 type authCfgGetter func(*prowapi.Refs) prowapi.RerunAuthConfig
 
+// rerunAgentAllowed tells whether jobs run by an agent can be rerun from Deck.
+type rerunAgentAllowed func(prowapi.ProwJobAgent) bool
+
 type traceResponseWriter struct {
 	http.ResponseWriter
 	statusCode int
@@ -238,6 +246,14 @@ func (trw *traceResponseWriter) Write(data []byte) (int, error) {
 	return size, err
 }
 
+// Flush flushes the wrapped writer if it supports flushing, so that streaming
+// handlers keep working behind the trace handler.
+func (trw *traceResponseWriter) Flush() {
+	if flusher, ok := trw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
 func init() {
 	prometheus.MustRegister(deckMetrics.httpRequestDuration)
 	prometheus.MustRegister(deckMetrics.httpResponseSize)
@@ -257,16 +273,22 @@ func traceHandler(h http.Handler) http.Handler {
 }
 
 var simplifier = simplifypath.NewSimplifier(l("", // shadow element mimicing the root
+	l("artifacts-archive"),
 	l("badge.svg"),
+	l("branch-protection"),
 	l("command-help"),
 	l("config"),
+	l("config-diff"),
 	l("data.js"),
 	l("favicon.ico"),
+	l("gcs-path"),
 	l("github-login",
 		l("redirect")),
+	l("github-throttles.js"),
 	l("job-history",
 		v("job")),
 	l("log"),
+	l("merge-stats.js"),
 	l("plugin-config"),
 	l("plugin-help"),
 	l("plugins"),
@@ -276,6 +298,7 @@ var simplifier = simplifypath.NewSimplifier(l("", // shadow element mimicing the
 	l("prowjob"),
 	l("prowjobs.js"),
 	l("rerun"),
+	l("retention"),
 	l("spyglass",
 		l("static",
 			v("path")),
@@ -283,6 +306,7 @@ var simplifier = simplifypath.NewSimplifier(l("", // shadow element mimicing the
 			v("lens",
 				v("job")),
 		)),
+	l("stale-jobs.js"),
 	l("static",
 		v("path")),
 	l("tide"),
@@ -343,6 +367,10 @@ func main() {
 	mux.Handle("/static/", http.StripPrefix("/static", staticHandlerFromDir(o.staticFilesLocation)))
 	mux.Handle("/config", gziphandler.GzipHandler(handleConfig(cfg, logrus.WithField("handler", "/config"))))
 	mux.Handle("/plugin-config", gziphandler.GzipHandler(handlePluginConfig(pluginAgent, logrus.WithField("handler", "/plugin-config"))))
+	mux.Handle("/validate-prow-yaml", gziphandler.GzipHandler(handleValidateProwYAML(cfg, logrus.WithField("handler", "/validate-prow-yaml"))))
+	mux.Handle("/context-policy", gziphandler.GzipHandler(handleContextPolicy(cfg, logrus.WithField("handler", "/context-policy"))))
+	mux.Handle("/branch-protection", gziphandler.GzipHandler(handleBranchProtection(cfg, logrus.WithField("handler", "/branch-protection"))))
+	mux.Handle("/config-diff", gziphandler.GzipHandler(handleConfigDiff(cfg, logrus.WithField("handler", "/config-diff"))))
 	mux.Handle("/favicon.ico", gziphandler.GzipHandler(handleFavicon(o.staticFilesLocation, cfg)))
 
 	// Set up handlers for template pages.
@@ -473,6 +501,8 @@ type filteringProwJobLister struct {
 	hiddenRepos func() sets.String
 	hiddenOnly  bool
 	showHidden  bool
+	// maxJobAge optionally limits how old listed ProwJobs can be.
+	maxJobAge func() time.Duration
 }
 
 func (c *filteringProwJobLister) ListProwJobs(selector string) ([]prowapi.ProwJob, error) {
@@ -486,25 +516,52 @@ func (c *filteringProwJobLister) ListProwJobs(selector string) ([]prowapi.ProwJo
 		return nil, err
 	}
 
+	var maxJobAge time.Duration
+	if c.maxJobAge != nil {
+		maxJobAge = c.maxJobAge()
+	}
 	var filtered []prowapi.ProwJob
 	for _, item := range prowJobList.Items {
-		shouldHide := item.Spec.Hidden || c.pjHasHiddenRefs(item)
-		if shouldHide && c.showHidden {
-			filtered = append(filtered, item)
-		} else if shouldHide == c.hiddenOnly {
-			// this is a hidden job, show it if we're asked
-			// to only show hidden jobs otherwise hide it
+		if maxJobAge > 0 && time.Since(item.Status.StartTime.Time) > maxJobAge {
+			continue
+		}
+		if c.visible(item) {
 			filtered = append(filtered, item)
 		}
 	}
 	return filtered, nil
 }
 
+// visible determines whether the ProwJob may be shown, taking hidden jobs
+// and repos into account.
+func (c *filteringProwJobLister) visible(pj prowapi.ProwJob) bool {
+	return c.shown(pj.Spec.Hidden || c.pjHasHiddenRefs(pj))
+}
+
+// periodicVisible determines whether the configured periodic may be shown,
+// the same way visible does for its ProwJobs.
+func (c *filteringProwJobLister) periodicVisible(p config.Periodic) bool {
+	return c.shown(p.Hidden || c.hasHiddenRefs(p.ExtraRefs))
+}
+
+func (c *filteringProwJobLister) shown(shouldHide bool) bool {
+	if shouldHide && c.showHidden {
+		return true
+	}
+	// this is a hidden job, show it if we're asked
+	// to only show hidden jobs otherwise hide it
+	return shouldHide == c.hiddenOnly
+}
+
 func (c *filteringProwJobLister) pjHasHiddenRefs(pj prowapi.ProwJob) bool {
 	allRefs := pj.Spec.ExtraRefs
 	if pj.Spec.Refs != nil {
 		allRefs = append(allRefs, *pj.Spec.Refs)
 	}
+	return c.hasHiddenRefs(allRefs)
+}
+
+func (c *filteringProwJobLister) hasHiddenRefs(allRefs []prowapi.Refs) bool {
 	for _, refs := range allRefs {
 		if c.hiddenRepos().HasAny(fmt.Sprintf("%s/%s", refs.Org, refs.Repo), refs.Org) {
 			return true
@@ -551,7 +608,7 @@ func prodOnlyMain(cfg config.Getter, pluginAgent *plugins.ConfigAgent, authCfgGe
 		}
 	}()
 
-	buildClusterClients, err := o.kubernetes.BuildClusterClients(cfg().PodNamespace, false)
+	buildClusterClients, err := o.kubernetes.BuildClusterClients(cfg().PodNamespaceForCluster, false)
 	if err != nil {
 		logrus.WithError(err).Fatal("Error getting Kubernetes client.")
 	}
@@ -561,23 +618,52 @@ func prodOnlyMain(cfg config.Getter, pluginAgent *plugins.ConfigAgent, authCfgGe
 		podLogClients[clusterContext] = &podLogClient{client: client}
 	}
 
-	ja := jobs.NewJobAgent(&filteringProwJobLister{
+	buildClusterCoreV1Clients, err := o.kubernetes.BuildClusterCoreV1Clients(false)
+	if err != nil {
+		logrus.WithError(err).Fatal("Error getting Kubernetes client.")
+	}
+	podEventClients := map[string]corev1.EventInterface{}
+	for clusterContext, client := range buildClusterCoreV1Clients {
+		podEventClients[clusterContext] = client.Events(cfg().PodNamespaceForCluster(clusterContext))
+	}
+
+	pjLister := &filteringProwJobLister{
 		client: &pjListingClientWrapper{mgr.GetClient()},
 		hiddenRepos: func() sets.String {
 			return sets.NewString(cfg().Deck.HiddenRepos...)
 		},
 		hiddenOnly: o.hiddenOnly,
 		showHidden: o.showHidden,
-	}, podLogClients, cfg)
+		maxJobAge: func() time.Duration {
+			if age := cfg().Deck.MaxJobAge; age != nil {
+				return age.Duration
+			}
+			return 0
+		},
+	}
+	ja := jobs.NewJobAgent(pjLister, podLogClients, cfg)
 	ja.Start()
 
+	pjInformer, err := mgr.GetCache().GetInformer(&prowapi.ProwJob{})
+	if err != nil {
+		logrus.WithError(err).Fatal("Error getting ProwJob informer.")
+	}
+	pjEvents := newProwJobEventBroadcaster(pjInformer)
+
 	// setup prod only handlers
 	mux.Handle("/data.js", gziphandler.GzipHandler(handleData(ja, logrus.WithField("handler", "/data.js"))))
 	mux.Handle("/prowjobs.js", gziphandler.GzipHandler(handleProwJobs(ja, logrus.WithField("handler", "/prowjobs.js"))))
+	// Server-sent events must reach the client as soon as they are written, so this is not gzipped.
+	mux.Handle("/prowjobs-events", handleProwJobEvents(pjEvents, pjLister.visible, logrus.WithField("handler", "/prowjobs-events")))
 	mux.Handle("/badge.svg", gziphandler.GzipHandler(handleBadge(ja)))
+	mux.Handle("/last-green", gziphandler.GzipHandler(handleLastGreen(ja, logrus.WithField("handler", "/last-green"))))
+	mux.Handle("/aborted-jobs.js", gziphandler.GzipHandler(handleAbortedJobs(ja, logrus.WithField("handler", "/aborted-jobs.js"))))
+	mux.Handle("/stale-jobs.js", gziphandler.GzipHandler(handleStaleJobs(ja, cfg, pjLister.periodicVisible, logrus.WithField("handler", "/stale-jobs.js"))))
 	mux.Handle("/log", gziphandler.GzipHandler(handleLog(ja, logrus.WithField("handler", "/log"))))
 
 	mux.Handle("/prowjob", gziphandler.GzipHandler(handleProwJob(prowJobClient, logrus.WithField("handler", "/prowjob"))))
+	mux.Handle("/pod-events", gziphandler.GzipHandler(handlePodEvents(prowJobClient, podEventClients, logrus.WithField("handler", "/pod-events"))))
+	mux.Handle("/gcs-path", gziphandler.GzipHandler(handleGCSPath(prowJobClient, logrus.WithField("handler", "/gcs-path"))))
 
 	// We use the GH client to resolve GH teams when determining who is permitted to rerun a job.
 	// When inrepoconfig is enabled, both the GitHubClient and the gitClient are used to resolve
@@ -589,10 +675,13 @@ func prodOnlyMain(cfg config.Getter, pluginAgent *plugins.ConfigAgent, authCfgGe
 		if err := secretAgent.Start([]string{o.github.TokenPath}); err != nil {
 			logrus.WithError(err).Fatal("Error starting secrets agent.")
 		}
-		githubClient, err = o.github.GitHubClient(secretAgent, o.dryRun)
+		var ghc prowgithub.Client
+		ghc, err = o.github.GitHubClient(secretAgent, o.dryRun)
 		if err != nil {
 			logrus.WithError(err).Fatal("Error getting GitHub client.")
 		}
+		githubClient = ghc
+		mux.Handle("/github-throttles.js", gziphandler.GzipHandler(handleGitHubThrottles(ghc, logrus.WithField("handler", "/github-throttles.js"))))
 		gitClient, err = o.github.GitClient(secretAgent, o.dryRun)
 		if err != nil {
 			logrus.WithError(err).Fatal("Error getting Git client.")
@@ -624,6 +713,7 @@ func prodOnlyMain(cfg config.Getter, pluginAgent *plugins.ConfigAgent, authCfgGe
 		ta.start()
 		mux.Handle("/tide.js", gziphandler.GzipHandler(handleTidePools(cfg, ta, logrus.WithField("handler", "/tide.js"))))
 		mux.Handle("/tide-history.js", gziphandler.GzipHandler(handleTideHistory(ta, logrus.WithField("handler", "/tide-history.js"))))
+		mux.Handle("/merge-stats.js", gziphandler.GzipHandler(handleMergeStats(ta, logrus.WithField("handler", "/merge-stats.js"))))
 	}
 
 	// Enable Git OAuth feature if oauthURL is provided.
@@ -683,7 +773,10 @@ func prodOnlyMain(cfg config.Getter, pluginAgent *plugins.ConfigAgent, authCfgGe
 		mux.Handle("/github-login/redirect", goa.HandleRedirect(oauthClient, &o.github, secure))
 	}
 
-	mux.Handle("/rerun", gziphandler.GzipHandler(handleRerun(prowJobClient, o.rerunCreatesJob, authCfgGetter, goa, &o.github, githubClient, pluginAgent, logrus.WithField("handler", "/rerun"))))
+	agentAllowed := func(agent prowapi.ProwJobAgent) bool {
+		return cfg().Deck.RerunAllowsAgent(agent)
+	}
+	mux.Handle("/rerun", gziphandler.GzipHandler(handleRerun(prowJobClient, o.rerunCreatesJob, authCfgGetter, agentAllowed, goa, &o.github, githubClient, pluginAgent, logrus.WithField("handler", "/rerun"))))
 
 	// optionally inject http->https redirect handler when behind loadbalancer
 	if o.redirectHTTPTo != "" {
@@ -727,6 +820,8 @@ func initSpyglass(cfg config.Getter, o options, mux *http.ServeMux, ja *jobs.Job
 
 	mux.Handle("/spyglass/static/", http.StripPrefix("/spyglass/static", staticHandlerFromDir(o.spyglassFilesLocation)))
 	mux.Handle("/spyglass/lens/", gziphandler.GzipHandler(http.StripPrefix("/spyglass/lens/", handleArtifactView(o, sg, cfg))))
+	mux.Handle("/artifacts-archive", handleArtifactsArchive(sg, cfg, logrus.WithField("handler", "/artifacts-archive")))
+	mux.Handle("/retention", gziphandler.GzipHandler(handleRetention(sg, cfg, logrus.WithField("handler", "/retention"))))
 	mux.Handle("/view/", gziphandler.GzipHandler(handleRequestJobViews(sg, cfg, o, logrus.WithField("handler", "/view"))))
 	mux.Handle("/job-history/", gziphandler.GzipHandler(handleJobHistory(o, cfg, c, logrus.WithField("handler", "/job-history"))))
 	mux.Handle("/pr-history/", gziphandler.GzipHandler(handlePRHistory(o, cfg, c, gitHubClient, gitClient, logrus.WithField("handler", "/pr-history"))))
@@ -866,6 +961,182 @@ func handleBadge(ja *jobs.JobAgent) http.HandlerFunc {
 	}
 }
 
+// lastGreenBuild points at the most recent successful build of a job.
+type lastGreenBuild struct {
+	BuildID string `json:"build_id"`
+	SHA     string `json:"sha,omitempty"`
+	URL     string `json:"url"`
+}
+
+// handleLastGreen handles requests for the most recent successful build of a job
+// The url must look like this:
+//
+// /last-green?job=<job-name>
+func handleLastGreen(ja *jobs.JobAgent, log *logrus.Entry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setHeadersNoCaching(w)
+		job := r.URL.Query().Get("job")
+		if job == "" {
+			http.Error(w, "request did not provide the 'job' query parameter", http.StatusBadRequest)
+			return
+		}
+
+		var latest *prowapi.ProwJob
+		allJobs := ja.ProwJobs()
+		for i, pj := range allJobs {
+			if pj.Spec.Job != job || pj.Status.State != prowapi.SuccessState {
+				continue
+			}
+			if latest == nil || pj.Status.StartTime.After(latest.Status.StartTime.Time) {
+				latest = &allJobs[i]
+			}
+		}
+		if latest == nil {
+			http.Error(w, fmt.Sprintf("no successful build found for job %q", job), http.StatusNotFound)
+			return
+		}
+
+		build := lastGreenBuild{BuildID: latest.Status.BuildID, URL: latest.Status.URL}
+		if refs := latest.Spec.Refs; refs != nil {
+			build.SHA = refs.BaseSHA
+			if len(refs.Pulls) > 0 {
+				build.SHA = refs.Pulls[0].SHA
+			}
+		}
+		b, err := json.Marshal(build)
+		if err != nil {
+			log.WithError(err).Error("Error marshaling last green build.")
+			http.Error(w, "failed to marshal last green build", http.StatusInternalServerError)
+			return
+		}
+		writeJSONResponse(w, r, b)
+	}
+}
+
+// abortedJob is a ProwJob that was aborted, along with why it was aborted.
+type abortedJob struct {
+	Job         string              `json:"job"`
+	BuildID     string              `json:"build_id"`
+	Type        prowapi.ProwJobType `json:"type"`
+	Refs        *prowapi.Refs       `json:"refs,omitempty"`
+	URL         string              `json:"url"`
+	AbortedAt   time.Time           `json:"aborted_at"`
+	Description string              `json:"description,omitempty"`
+	Annotations map[string]string   `json:"annotations,omitempty"`
+}
+
+// handleAbortedJobs serves the aborted ProwJobs, most recently aborted first.
+// The since query parameter only keeps jobs aborted more recently than the
+// given duration or time.
+func handleAbortedJobs(ja *jobs.JobAgent, log *logrus.Entry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setHeadersNoCaching(w)
+		since, err := parseSince(r.URL.Query().Get("since"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		aborted := []abortedJob{}
+		for _, pj := range ja.ProwJobs() {
+			if pj.Status.State != prowapi.AbortedState {
+				continue
+			}
+			var abortedAt time.Time
+			if pj.Status.CompletionTime != nil {
+				abortedAt = pj.Status.CompletionTime.Time
+			}
+			if abortedAt.Before(since) {
+				continue
+			}
+			aborted = append(aborted, abortedJob{
+				Job:         pj.Spec.Job,
+				BuildID:     pj.Status.BuildID,
+				Type:        pj.Spec.Type,
+				Refs:        pj.Spec.Refs,
+				URL:         pj.Status.URL,
+				AbortedAt:   abortedAt,
+				Description: pj.Status.Description,
+				Annotations: pj.Annotations,
+			})
+		}
+		sort.SliceStable(aborted, func(i, j int) bool {
+			return aborted[i].AbortedAt.After(aborted[j].AbortedAt)
+		})
+
+		b, err := json.Marshal(aborted)
+		if err != nil {
+			log.WithError(err).Error("Error marshaling aborted jobs.")
+			b = []byte("[]")
+		}
+		writeJSONResponse(w, r, b)
+	}
+}
+
+// staleJob is a configured periodic that did not run recently.
+type staleJob struct {
+	Job      string `json:"job"`
+	Cron     string `json:"cron,omitempty"`
+	Interval string `json:"interval,omitempty"`
+	// LastRun is when the most recent known run of the periodic started, if
+	// the job agent knows of any.
+	LastRun *time.Time `json:"last_run,omitempty"`
+}
+
+// handleStaleJobs serves the configured periodics that have no ProwJob
+// started more recently than the duration or time given by the since query
+// parameter. Without since, only the periodics without any known run are
+// served. Periodics that are not visible are left out like their ProwJobs.
+func handleStaleJobs(ja *jobs.JobAgent, cfg config.Getter, visible func(config.Periodic) bool, log *logrus.Entry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setHeadersNoCaching(w)
+		since, err := parseSince(r.URL.Query().Get("since"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		lastRuns := map[string]time.Time{}
+		for _, pj := range ja.ProwJobs() {
+			if pj.Spec.Type != prowapi.PeriodicJob {
+				continue
+			}
+			if started := pj.Status.StartTime.Time; started.After(lastRuns[pj.Spec.Job]) {
+				lastRuns[pj.Spec.Job] = started
+			}
+		}
+
+		stale := []staleJob{}
+		for _, periodic := range cfg().Periodics {
+			if !visible(periodic) {
+				continue
+			}
+			job := staleJob{
+				Job:      periodic.Name,
+				Cron:     periodic.Cron,
+				Interval: periodic.Interval,
+			}
+			if lastRun, ok := lastRuns[periodic.Name]; ok {
+				if !lastRun.Before(since) {
+					continue
+				}
+				job.LastRun = &lastRun
+			}
+			stale = append(stale, job)
+		}
+		sort.Slice(stale, func(i, j int) bool {
+			return stale[i].Job < stale[j].Job
+		})
+
+		b, err := json.Marshal(stale)
+		if err != nil {
+			log.WithError(err).Error("Error marshaling stale jobs.")
+			b = []byte("[]")
+		}
+		writeJSONResponse(w, r, b)
+	}
+}
+
 // handleJobHistory handles requests to get the history of a given job
 // The url must look like this for presubmits:
 //
@@ -945,35 +1216,23 @@ func handleRequestJobViews(sg *spyglass.Spyglass, cfg config.Getter, o options,
 	}
 }
 
-// renderSpyglass returns a pre-rendered Spyglass page from the given source string
-func renderSpyglass(sg *spyglass.Spyglass, cfg config.Getter, src string, o options, csrfToken string, log *logrus.Entry) (string, error) {
-	renderStart := time.Now()
-
-	src = strings.TrimSuffix(src, "/")
-	realPath, err := sg.ResolveSymlink(src)
-	if err != nil {
-		return "", fmt.Errorf("error when resolving real path: %v", err)
-	}
-	src = realPath
-
-	artifactNames, err := sg.ListArtifacts(src)
-	if err != nil {
-		return "", fmt.Errorf("error listing artifacts: %v", err)
-	}
-	if len(artifactNames) == 0 {
-		return "", fmt.Errorf("found no artifacts for %s", src)
-	}
-
-	regexCache := cfg().Deck.Spyglass.RegexCache
+// matchLenses returns the indexes of the lenses whose required files are all
+// among the artifacts, along with the artifacts each of them matched. The
+// fallback lenses are only matched when no other lens is, and they are the
+// only lenses matched when there are no artifacts.
+func matchLenses(sgConfig config.Spyglass, artifactNames []string) ([]int, map[int][]string) {
 	lensCache := map[int][]string{}
-	var lensIndexes []int
+	var lensIndexes, fallbackIndexes []int
 lensesLoop:
-	for i, lfc := range cfg().Deck.Spyglass.Lenses {
+	for i, lfc := range sgConfig.Lenses {
+		if len(artifactNames) == 0 && !lfc.Fallback {
+			continue
+		}
 		matches := map[string]struct{}{}
 		for _, re := range lfc.RequiredFiles {
 			found := false
 			for _, a := range artifactNames {
-				if regexCache[re].MatchString(a) {
+				if sgConfig.RegexCache[re].MatchString(a) {
 					matches[a] = struct{}{}
 					found = true
 				}
@@ -985,7 +1244,7 @@ lensesLoop:
 
 		for _, re := range lfc.OptionalFiles {
 			for _, a := range artifactNames {
-				if regexCache[re].MatchString(a) {
+				if sgConfig.RegexCache[re].MatchString(a) {
 					matches[a] = struct{}{}
 				}
 			}
@@ -997,7 +1256,39 @@ lensesLoop:
 		}
 
 		lensCache[i] = matchSlice
-		lensIndexes = append(lensIndexes, i)
+		if lfc.Fallback {
+			fallbackIndexes = append(fallbackIndexes, i)
+		} else {
+			lensIndexes = append(lensIndexes, i)
+		}
+	}
+	if len(lensIndexes) == 0 {
+		return fallbackIndexes, lensCache
+	}
+	for _, i := range fallbackIndexes {
+		delete(lensCache, i)
+	}
+	return lensIndexes, lensCache
+}
+
+// renderSpyglass returns a pre-rendered Spyglass page from the given source string
+func renderSpyglass(sg *spyglass.Spyglass, cfg config.Getter, src string, o options, csrfToken string, log *logrus.Entry) (string, error) {
+	renderStart := time.Now()
+
+	src = strings.TrimSuffix(src, "/")
+	realPath, err := sg.ResolveSymlink(src)
+	if err != nil {
+		return "", fmt.Errorf("error when resolving real path: %v", err)
+	}
+	src = realPath
+
+	artifactNames, err := sg.ListArtifacts(src)
+	if err != nil {
+		return "", fmt.Errorf("error listing artifacts: %v", err)
+	}
+	lensIndexes, lensCache := matchLenses(cfg().Deck.Spyglass, artifactNames)
+	if len(artifactNames) == 0 && len(lensIndexes) == 0 {
+		return "", fmt.Errorf("found no artifacts for %s", src)
 	}
 
 	lensIndexes, ls := sg.Lenses(lensIndexes)
@@ -1249,16 +1540,33 @@ func handleTidePools(cfg config.Getter, ta *tideAgent, log *logrus.Entry) http.H
 	}
 }
 
+// handleTideHistory serves Tide's action history. The org, repo and branch
+// query parameters restrict the served pools and the since parameter only
+// keeps records that are more recent than the given duration or time.
 func handleTideHistory(ta *tideAgent, log *logrus.Entry) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		setHeadersNoCaching(w)
+		since, err := parseSince(r.URL.Query().Get("since"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		filter := tideHistoryFilter{
+			org:    r.URL.Query().Get("org"),
+			repo:   r.URL.Query().Get("repo"),
+			branch: r.URL.Query().Get("branch"),
+			since:  since,
+		}
 
 		ta.Lock()
 		history := ta.history
 		ta.Unlock()
 
 		payload := tideHistory{
-			History: history,
+			History: filter.filter(history),
+		}
+		for _, records := range payload.History {
+			payload.Count += len(records)
 		}
 		pd, err := json.Marshal(payload)
 		if err != nil {
@@ -1269,7 +1577,60 @@ func handleTideHistory(ta *tideAgent, log *logrus.Entry) http.HandlerFunc {
 	}
 }
 
-func handlePluginHelp(ha *helpAgent, log *logrus.Entry) http.HandlerFunc {
+// handleMergeStats serves the merge statistics of the repo given by the org
+// and repo query parameters, computed from Tide's action history. The since
+// parameter only counts merges more recent than the given duration or time.
+func handleMergeStats(ta *tideAgent, log *logrus.Entry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setHeadersNoCaching(w)
+		org, repo := r.URL.Query().Get("org"), r.URL.Query().Get("repo")
+		if org == "" || repo == "" {
+			http.Error(w, "The org and repo query parameters are required.", http.StatusBadRequest)
+			return
+		}
+		since, err := parseSince(r.URL.Query().Get("since"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		ta.Lock()
+		history := ta.history
+		ta.Unlock()
+
+		pd, err := json.Marshal(computeMergeStats(history, org, repo, since))
+		if err != nil {
+			log.WithError(err).Error("Error marshaling payload.")
+			pd = []byte("{}")
+		}
+		writeJSONResponse(w, r, pd)
+	}
+}
+
+// parseSince parses the value of a since query parameter, which is either a
+// duration relative to now (e.g. 24h) or an RFC3339 timestamp. An empty value
+// yields the zero time.
+func parseSince(since string) (time.Time, error) {
+	if since == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(since); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, since)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("since must be a duration or an RFC3339 time, got %q", since)
+	}
+	return t, nil
+}
+
+type helpGetter interface {
+	getHelp() (*pluginhelp.Help, error)
+}
+
+// handlePluginHelp serves the plugin help from hook. If the plugin query
+// parameter is set, only the help of that plugin is served.
+func handlePluginHelp(ha helpGetter, log *logrus.Entry) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		setHeadersNoCaching(w)
 		help, err := ha.getHelp()
@@ -1277,6 +1638,13 @@ func handlePluginHelp(ha *helpAgent, log *logrus.Entry) http.HandlerFunc {
 			log.WithError(err).Error("Getting plugin help from hook.")
 			help = &pluginhelp.Help{}
 		}
+		if plugin := r.URL.Query().Get("plugin"); plugin != "" {
+			var ok bool
+			if help, ok = pluginHelpFor(help, plugin); !ok {
+				http.Error(w, fmt.Sprintf("Plugin %q not found.", plugin), http.StatusNotFound)
+				return
+			}
+		}
 		b, err := json.Marshal(*help)
 		if err != nil {
 			log.WithError(err).Error("Marshaling plugin help.")
@@ -1286,6 +1654,59 @@ func handlePluginHelp(ha *helpAgent, log *logrus.Entry) http.HandlerFunc {
 	}
 }
 
+// pluginHelpFor returns the help filtered down to a single plugin or
+// external plugin, and false if there is no help for the plugin.
+func pluginHelpFor(help *pluginhelp.Help, plugin string) (*pluginhelp.Help, bool) {
+	filtered := &pluginhelp.Help{AllRepos: help.AllRepos}
+	if h, ok := help.PluginHelp[plugin]; ok {
+		filtered.PluginHelp = map[string]pluginhelp.PluginHelp{plugin: h}
+		filtered.RepoPlugins = reposWithPlugin(help.RepoPlugins, plugin)
+	} else if h, ok := help.ExternalPluginHelp[plugin]; ok {
+		filtered.ExternalPluginHelp = map[string]pluginhelp.PluginHelp{plugin: h}
+		filtered.RepoExternalPlugins = reposWithPlugin(help.RepoExternalPlugins, plugin)
+	} else {
+		return nil, false
+	}
+	return filtered, true
+}
+
+// reposWithPlugin returns the orgs and repos of repoPlugins that enable the
+// plugin, each mapped to just the plugin.
+func reposWithPlugin(repoPlugins map[string][]string, plugin string) map[string][]string {
+	repos := map[string][]string{}
+	for repo, plugins := range repoPlugins {
+		for _, p := range plugins {
+			if p == plugin {
+				repos[repo] = []string{plugin}
+				break
+			}
+		}
+	}
+	return repos
+}
+
+type throttleStatusGetter interface {
+	ThrottleStatus() []prowgithub.ThrottleStatus
+}
+
+// handleGitHubThrottles serves the configuration and current state of the
+// throttles of Deck's GitHub client, to help diagnose rate limiting.
+func handleGitHubThrottles(tsg throttleStatusGetter, log *logrus.Entry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setHeadersNoCaching(w)
+		statuses := tsg.ThrottleStatus()
+		if statuses == nil {
+			statuses = []prowgithub.ThrottleStatus{}
+		}
+		b, err := json.Marshal(statuses)
+		if err != nil {
+			log.WithError(err).Error("Error marshaling throttle statuses.")
+			b = []byte("[]")
+		}
+		writeJSONResponse(w, r, b)
+	}
+}
+
 type logClient interface {
 	GetJobLog(job, id string) ([]byte, error)
 }
@@ -1362,6 +1783,111 @@ func handleProwJob(prowJobClient prowv1.ProwJobInterface, log *logrus.Entry) htt
 	}
 }
 
+// handlePodEvents returns the Kubernetes events of the pod running a ProwJob
+// as JSON, to help debug jobs that are stuck scheduling.
+func handlePodEvents(prowJobClient prowv1.ProwJobInterface, eventClients map[string]corev1.EventInterface, log *logrus.Entry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setHeadersNoCaching(w)
+		name := r.URL.Query().Get("prowjob")
+		l := log.WithField("prowjob", name)
+		if name == "" {
+			http.Error(w, "request did not provide the 'prowjob' query parameter", http.StatusBadRequest)
+			return
+		}
+
+		pj, err := prowJobClient.Get(name, metav1.GetOptions{})
+		if err != nil {
+			http.Error(w, fmt.Sprintf("ProwJob not found: %v", err), http.StatusNotFound)
+			if !kerrors.IsNotFound(err) {
+				// admins only care about errors other than not found
+				l.WithError(err).Warning("ProwJob not found.")
+			}
+			return
+		}
+
+		events := []coreapi.Event{}
+		// The pod may not have been created yet, in which case there are no events.
+		if pj.Status.PodName != "" {
+			client, ok := eventClients[pj.ClusterAlias()]
+			if !ok {
+				http.Error(w, fmt.Sprintf("Unknown cluster alias %q.", pj.ClusterAlias()), http.StatusNotFound)
+				return
+			}
+			list, err := client.List(metav1.ListOptions{FieldSelector: fmt.Sprintf("involvedObject.kind=Pod,involvedObject.name=%s", pj.Status.PodName)})
+			if err != nil {
+				l.WithError(err).Error("Error listing pod events.")
+				http.Error(w, fmt.Sprintf("Error listing pod events: %v", err), http.StatusInternalServerError)
+				return
+			}
+			for _, event := range list.Items {
+				if event.InvolvedObject.Kind == "Pod" && event.InvolvedObject.Name == pj.Status.PodName {
+					events = append(events, event)
+				}
+			}
+		}
+
+		b, err := json.Marshal(events)
+		if err != nil {
+			l.WithError(err).Error("Error marshaling pod events.")
+			http.Error(w, "Error marshaling pod events.", http.StatusInternalServerError)
+			return
+		}
+		writeJSONResponse(w, r, b)
+	}
+}
+
+// gcsPath is where the artifacts of a decorated ProwJob are uploaded.
+type gcsPath struct {
+	Bucket string `json:"bucket"`
+	// Path is the prefix of the paths of the artifacts in the bucket.
+	Path string `json:"path"`
+	// URL is the gs:// URL of the artifacts.
+	URL string `json:"url"`
+}
+
+// handleGCSPath serves where the artifacts of the ProwJob are uploaded,
+// resolved from its decoration config and refs like gcsupload does.
+func handleGCSPath(prowJobClient prowv1.ProwJobInterface, log *logrus.Entry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setHeadersNoCaching(w)
+		name := r.URL.Query().Get("prowjob")
+		l := log.WithField("prowjob", name)
+		if name == "" {
+			http.Error(w, "request did not provide the 'prowjob' query parameter", http.StatusBadRequest)
+			return
+		}
+
+		pj, err := prowJobClient.Get(name, metav1.GetOptions{})
+		if err != nil {
+			http.Error(w, fmt.Sprintf("ProwJob not found: %v", err), http.StatusNotFound)
+			if !kerrors.IsNotFound(err) {
+				// admins only care about errors other than not found
+				l.WithError(err).Warning("ProwJob not found.")
+			}
+			return
+		}
+		if pj.Spec.DecorationConfig == nil || pj.Spec.DecorationConfig.GCSConfiguration == nil {
+			http.Error(w, fmt.Sprintf("ProwJob %s is not decorated with a GCS configuration, so it does not upload artifacts.", name), http.StatusNotFound)
+			return
+		}
+
+		gcsConfig := pj.Spec.DecorationConfig.GCSConfiguration
+		spec := downwardapi.NewJobSpec(pj.Spec, pj.Status.BuildID, pj.Name)
+		_, artifactsPath, _ := gcsupload.PathsForJob(gcsConfig, &spec, "")
+		b, err := json.Marshal(gcsPath{
+			Bucket: gcsConfig.Bucket,
+			Path:   artifactsPath,
+			URL:    fmt.Sprintf("gs://%s", path.Join(gcsConfig.Bucket, artifactsPath)),
+		})
+		if err != nil {
+			l.WithError(err).Error("Error marshaling GCS path.")
+			http.Error(w, "Error marshaling GCS path.", http.StatusInternalServerError)
+			return
+		}
+		writeJSONResponse(w, r, b)
+	}
+}
+
 // canTriggerJob determines whether the given user can trigger any job.
 func canTriggerJob(user string, pj prowapi.ProwJob, cfg prowapi.RerunAuthConfig, cli prowgithub.RerunClient, pluginAgent *plugins.ConfigAgent, log *logrus.Entry) (bool, error) {
 	auth, err := cfg.IsAuthorized(user, cli)
@@ -1404,7 +1930,7 @@ func canTriggerJob(user string, pj prowapi.ProwJob, cfg prowapi.RerunAuthConfig,
 // handleRerun triggers a rerun of the given job if that features is enabled, it receives a
 // POST request, and the user has the necessary permissions. Otherwise, it writes the config
 // for a new job but does not trigger it.
-func handleRerun(prowJobClient prowv1.ProwJobInterface, createProwJob bool, cfg authCfgGetter, goa *githuboauth.Agent, ghc githuboauth.GitHubClientGetter, cli prowgithub.RerunClient, pluginAgent *plugins.ConfigAgent, log *logrus.Entry) http.HandlerFunc {
+func handleRerun(prowJobClient prowv1.ProwJobInterface, createProwJob bool, cfg authCfgGetter, agentAllowed rerunAgentAllowed, goa *githuboauth.Agent, ghc githuboauth.GitHubClientGetter, cli prowgithub.RerunClient, pluginAgent *plugins.ConfigAgent, log *logrus.Entry) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		name := r.URL.Query().Get("prowjob")
 		l := log.WithField("prowjob", name)
@@ -1431,6 +1957,14 @@ func handleRerun(prowJobClient prowv1.ProwJobInterface, createProwJob bool, cfg
 				http.Error(w, "Direct rerun feature is not enabled. Enable with the '--rerun-creates-job' flag.", http.StatusMethodNotAllowed)
 				return
 			}
+			if !agentAllowed(pj.Spec.Agent) {
+				http.Error(w, fmt.Sprintf("Jobs run by the %q agent cannot be rerun from Deck. Allow the agent with 'deck.rerun_allowed_agents'.", pj.Spec.Agent), http.StatusBadRequest)
+				return
+			}
+			if err := applyRerunRefOverride(r.Body, &newPJ); err != nil {
+				http.Error(w, fmt.Sprintf("Invalid ref override: %v", err), http.StatusBadRequest)
+				return
+			}
 			authConfig := cfg(pj.Spec.Refs)
 			var allowed bool
 			if authConfig.AllowAnyone || pj.Spec.RerunAuthConfig.AllowAnyone {
@@ -1486,6 +2020,49 @@ func handleRerun(prowJobClient prowv1.ProwJobInterface, createProwJob bool, cfg
 	}
 }
 
+// rerunRefOverride is the optional body of a rerun request that reruns a
+// periodic or postsubmit against a different base.
+type rerunRefOverride struct {
+	BaseRef string `json:"base_ref,omitempty"`
+	BaseSHA string `json:"base_sha,omitempty"`
+}
+
+// applyRerunRefOverride applies the ref override in the request body, if any,
+// to the refs of the rerun. Presubmits are tied to their PR and cannot be
+// overridden.
+func applyRerunRefOverride(body io.Reader, pj *prowapi.ProwJob) error {
+	raw, err := ioutil.ReadAll(io.LimitReader(body, 1<<10))
+	if err != nil {
+		return fmt.Errorf("failed to read request body: %v", err)
+	}
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return nil
+	}
+	var override rerunRefOverride
+	if err := json.Unmarshal(raw, &override); err != nil {
+		return fmt.Errorf("failed to unmarshal request body: %v", err)
+	}
+	if override.BaseRef == "" && override.BaseSHA == "" {
+		return nil
+	}
+	if pj.Spec.Type != prowapi.PeriodicJob && pj.Spec.Type != prowapi.PostsubmitJob {
+		return fmt.Errorf("only periodic and postsubmit jobs can be rerun against a different base, not %s jobs", pj.Spec.Type)
+	}
+	refs := pj.Spec.Refs
+	if refs == nil && len(pj.Spec.ExtraRefs) > 0 {
+		refs = &pj.Spec.ExtraRefs[0]
+	}
+	if refs == nil {
+		return errors.New("the job does not clone any refs")
+	}
+	if override.BaseRef != "" {
+		refs.BaseRef = override.BaseRef
+	}
+	// Without a SHA the head of the base ref is tested.
+	refs.BaseSHA = override.BaseSHA
+	return nil
+}
+
 func handleSerialize(w http.ResponseWriter, name string, data interface{}, l *logrus.Entry) {
 	setHeadersNoCaching(w)
 	b, err := yaml.Marshal(data)
@@ -1512,6 +2089,200 @@ func handleConfig(cfg config.Getter, log *logrus.Entry) http.HandlerFunc {
 	}
 }
 
+// readRequestBody reads at most limit bytes of the request body. It rejects
+// larger bodies with a 413 instead of silently truncating them and reports
+// whether the handler may go on.
+func readRequestBody(w http.ResponseWriter, r *http.Request, limit int64) ([]byte, bool) {
+	content, err := ioutil.ReadAll(io.LimitReader(r.Body, limit+1))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to read request body: %v", err), http.StatusBadRequest)
+		return nil, false
+	}
+	if int64(len(content)) > limit {
+		http.Error(w, fmt.Sprintf("request body exceeds the limit of %d bytes", limit), http.StatusRequestEntityTooLarge)
+		return nil, false
+	}
+	return content, true
+}
+
+// maxProwYAMLSize bounds the size of the .prow.yaml content accepted for validation.
+const maxProwYAMLSize = 1 << 20
+
+// prowYAMLValidation is the result of validating a .prow.yaml file.
+type prowYAMLValidation struct {
+	Presubmits []config.Presubmit `json:"presubmits,omitempty"`
+	Errors     []string           `json:"errors,omitempty"`
+}
+
+// handleValidateProwYAML decodes and validates the .prow.yaml content POSTed
+// for a repo the same way inrepoconfig does, returning the parsed jobs or the
+// validation errors.
+// The url must look like this:
+//
+// /validate-prow-yaml?org=<org>&repo=<repo>
+func handleValidateProwYAML(cfg config.Getter, log *logrus.Entry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setHeadersNoCaching(w)
+		if r.Method != http.MethodPost {
+			http.Error(w, fmt.Sprintf("bad verb %s", r.Method), http.StatusMethodNotAllowed)
+			return
+		}
+		org, repo := r.URL.Query().Get("org"), r.URL.Query().Get("repo")
+		if org == "" || repo == "" {
+			http.Error(w, "request did not provide the 'org' and 'repo' query parameters", http.StatusBadRequest)
+			return
+		}
+		content, ok := readRequestBody(w, r, maxProwYAMLSize)
+		if !ok {
+			return
+		}
+
+		var result prowYAMLValidation
+		code := http.StatusOK
+		if prowYAML, err := config.ParseProwYAML(cfg(), content, org+"/"+repo); err != nil {
+			code = http.StatusUnprocessableEntity
+			result.Errors = []string{err.Error()}
+		} else {
+			result.Presubmits = prowYAML.Presubmits
+		}
+		b, err := json.Marshal(result)
+		if err != nil {
+			log.WithError(err).Error("Error marshaling validation result.")
+			http.Error(w, "failed to marshal validation result", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		w.Write(b)
+	}
+}
+
+// handleContextPolicy handles requests for the context policy Tide applies to
+// a branch, as resolved from the Tide and branch protection configuration.
+// Only statically configured presubmits are considered, so repos that use
+// inrepoconfig are rejected.
+// The url must look like this:
+//
+// /context-policy?org=<org>&repo=<repo>&branch=<branch>
+func handleContextPolicy(cfg config.Getter, log *logrus.Entry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setHeadersNoCaching(w)
+		org, repo, branch := r.URL.Query().Get("org"), r.URL.Query().Get("repo"), r.URL.Query().Get("branch")
+		if org == "" || repo == "" || branch == "" {
+			http.Error(w, "request did not provide the 'org', 'repo' and 'branch' query parameters", http.StatusBadRequest)
+			return
+		}
+		c := cfg()
+		if c.InRepoConfigEnabled(org + "/" + repo) {
+			http.Error(w, fmt.Sprintf("cannot resolve the context policy for %s/%s as it uses inrepoconfig", org, repo), http.StatusBadRequest)
+			return
+		}
+		baseSHAGetter := func() (string, error) {
+			return "", errors.New("inrepoconfig is not supported")
+		}
+		policy, err := c.GetTideContextPolicy(nil, org, repo, branch, baseSHAGetter, "")
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to resolve the context policy: %v", err), http.StatusInternalServerError)
+			return
+		}
+		b, err := json.Marshal(policy)
+		if err != nil {
+			log.WithError(err).Error("Error marshaling context policy.")
+			http.Error(w, "failed to marshal context policy", http.StatusInternalServerError)
+			return
+		}
+		writeJSONResponse(w, r, b)
+	}
+}
+
+// handleBranchProtection handles requests for the branch protection request
+// branchprotector would send to GitHub for a branch, so that the protection
+// can be reviewed before it is applied. A null response means branchprotector
+// removes the protection of the branch.
+// The url must look like this:
+//
+// /branch-protection?org=<org>&repo=<repo>&branch=<branch>
+func handleBranchProtection(cfg config.Getter, log *logrus.Entry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setHeadersNoCaching(w)
+		org, repo, branch := r.URL.Query().Get("org"), r.URL.Query().Get("repo"), r.URL.Query().Get("branch")
+		if org == "" || repo == "" || branch == "" {
+			http.Error(w, "request did not provide the 'org', 'repo' and 'branch' query parameters", http.StatusBadRequest)
+			return
+		}
+		c := cfg()
+		policy, err := c.GetBranchProtection(org, repo, branch, c.PresubmitsStatic[org+"/"+repo])
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to resolve the branch protection policy: %v", err), http.StatusInternalServerError)
+			return
+		}
+		if policy == nil || policy.Protect == nil {
+			http.Error(w, fmt.Sprintf("branch protection is not managed for %s/%s=%s", org, repo, branch), http.StatusNotFound)
+			return
+		}
+		var req *prowgithub.BranchProtectionRequest
+		if *policy.Protect {
+			r := branchprotection.MakeRequest(*policy)
+			req = &r
+		}
+		b, err := json.Marshal(req)
+		if err != nil {
+			log.WithError(err).Error("Error marshaling branch protection request.")
+			http.Error(w, "failed to marshal branch protection request", http.StatusInternalServerError)
+			return
+		}
+		writeJSONResponse(w, r, b)
+	}
+}
+
+// maxJobConfigSize bounds the size of the job config accepted for comparison.
+const maxJobConfigSize = 10 << 20
+
+// handleConfigDiff compares the loaded job config with the job config YAML
+// POSTed to it, returning the presubmits, postsubmits and periodics that the
+// posted config adds, removes or modifies. The posted jobs are defaulted and
+// validated against the loaded prow config first.
+// The url must look like this:
+//
+// /config-diff
+func handleConfigDiff(cfg config.Getter, log *logrus.Entry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setHeadersNoCaching(w)
+		if r.Method != http.MethodPost {
+			http.Error(w, fmt.Sprintf("bad verb %s", r.Method), http.StatusMethodNotAllowed)
+			return
+		}
+		content, ok := readRequestBody(w, r, maxJobConfigSize)
+		if !ok {
+			return
+		}
+		var jc config.JobConfig
+		if err := yaml.Unmarshal(content, &jc); err != nil {
+			http.Error(w, fmt.Sprintf("failed to unmarshal job config: %v", err), http.StatusBadRequest)
+			return
+		}
+		c := cfg()
+		expanded, err := c.ExpandJobConfig(jc)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid job config: %v", err), http.StatusUnprocessableEntity)
+			return
+		}
+		diff, err := config.DiffJobConfigs(c.JobConfig, *expanded)
+		if err != nil {
+			log.WithError(err).Error("Error comparing job configs.")
+			http.Error(w, "failed to compare job configs", http.StatusInternalServerError)
+			return
+		}
+		b, err := json.Marshal(diff)
+		if err != nil {
+			log.WithError(err).Error("Error marshaling job config diff.")
+			http.Error(w, "failed to marshal job config diff", http.StatusInternalServerError)
+			return
+		}
+		writeJSONResponse(w, r, b)
+	}
+}
+
 func handlePluginConfig(pluginAgent *plugins.ConfigAgent, log *logrus.Entry) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if pluginAgent != nil {
@@ -30,7 +30,10 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"reflect"
+	"regexp"
+	"sort"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -43,14 +46,19 @@ import (
 
 	"github.com/google/go-github/github"
 
+	coreapi "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
 	prowapi "github.com/clarketm/prow/apis/prowjobs/v1"
 	"github.com/clarketm/prow/client/clientset/versioned/fake"
 	"github.com/clarketm/prow/config"
+	"github.com/clarketm/prow/deck/jobs"
 	"github.com/clarketm/prow/flagutil"
+	prowgithub "github.com/clarketm/prow/github"
 	"github.com/clarketm/prow/pluginhelp"
 	_ "github.com/clarketm/prow/spyglass/lenses/buildlog"
 	_ "github.com/clarketm/prow/spyglass/lenses/junit"
@@ -264,6 +272,574 @@ func TestProwJob(t *testing.T) {
 	}
 }
 
+func TestMatchLenses(t *testing.T) {
+	sgConfig := config.Spyglass{
+		Lenses: []config.LensFileConfig{
+			{Lens: config.LensConfig{Name: "metadata"}, RequiredFiles: []string{"started.json"}, OptionalFiles: []string{"finished.json"}},
+			{Lens: config.LensConfig{Name: "buildlog"}, RequiredFiles: []string{"build-log.txt"}},
+			{Lens: config.LensConfig{Name: "browser"}, OptionalFiles: []string{".*"}, Fallback: true},
+		},
+		RegexCache: map[string]*regexp.Regexp{},
+	}
+	for _, lfc := range sgConfig.Lenses {
+		for _, re := range append(lfc.RequiredFiles, lfc.OptionalFiles...) {
+			sgConfig.RegexCache[re] = regexp.MustCompile(re)
+		}
+	}
+	testCases := []struct {
+		name            string
+		artifacts       []string
+		expectedIndexes []int
+		expectedCache   map[int][]string
+	}{
+		{
+			name:            "matching lenses take precedence over the fallback",
+			artifacts:       []string{"started.json", "finished.json", "build-log.txt"},
+			expectedIndexes: []int{0, 1},
+			expectedCache: map[int][]string{
+				0: {"finished.json", "started.json"},
+				1: {"build-log.txt"},
+			},
+		},
+		{
+			name:            "one matching lens is enough to skip the fallback",
+			artifacts:       []string{"build-log.txt", "artifacts/junit.xml"},
+			expectedIndexes: []int{1},
+			expectedCache:   map[int][]string{1: {"build-log.txt"}},
+		},
+		{
+			name:            "fallback is selected when nothing else matches",
+			artifacts:       []string{"artifacts/junit.xml", "artifacts/dump.tar"},
+			expectedIndexes: []int{2},
+			expectedCache:   map[int][]string{2: {"artifacts/dump.tar", "artifacts/junit.xml"}},
+		},
+		{
+			name:            "fallback is selected without artifacts",
+			expectedIndexes: []int{2},
+			expectedCache:   map[int][]string{2: {}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			indexes, cache := matchLenses(sgConfig, tc.artifacts)
+			for _, matches := range cache {
+				sort.Strings(matches)
+			}
+			if !reflect.DeepEqual(indexes, tc.expectedIndexes) {
+				t.Errorf("Expected lenses %v, got %v", tc.expectedIndexes, indexes)
+			}
+			if !reflect.DeepEqual(cache, tc.expectedCache) {
+				t.Errorf("Expected lens artifacts %v, got %v", tc.expectedCache, cache)
+			}
+		})
+	}
+
+	sgConfig.Lenses = sgConfig.Lenses[:2]
+	if indexes, _ := matchLenses(sgConfig, nil); len(indexes) != 0 {
+		t.Errorf("Expected no lens without artifacts or a fallback lens, got %v", indexes)
+	}
+}
+
+func TestHandleGCSPath(t *testing.T) {
+	decoration := &prowapi.DecorationConfig{
+		GCSConfiguration: &prowapi.GCSConfiguration{
+			Bucket:       "kubernetes-jenkins",
+			PathStrategy: prowapi.PathStrategyLegacy,
+			DefaultOrg:   "kubernetes",
+			DefaultRepo:  "kubernetes",
+		},
+	}
+	fakeProwJobClient := fake.NewSimpleClientset(
+		&prowapi.ProwJob{
+			ObjectMeta: metav1.ObjectMeta{Name: "presubmit", Namespace: "prowjobs"},
+			Spec: prowapi.ProwJobSpec{
+				Job:              "pull-test-infra-bazel",
+				Type:             prowapi.PresubmitJob,
+				DecorationConfig: decoration,
+				Refs: &prowapi.Refs{
+					Org:   "kubernetes",
+					Repo:  "test-infra",
+					Pulls: []prowapi.Pull{{Number: 1234}},
+				},
+			},
+			Status: prowapi.ProwJobStatus{BuildID: "100"},
+		},
+		&prowapi.ProwJob{
+			ObjectMeta: metav1.ObjectMeta{Name: "periodic", Namespace: "prowjobs"},
+			Spec: prowapi.ProwJobSpec{
+				Job:              "ci-test-infra-bazel",
+				Type:             prowapi.PeriodicJob,
+				DecorationConfig: decoration,
+			},
+			Status: prowapi.ProwJobStatus{BuildID: "200"},
+		},
+		&prowapi.ProwJob{
+			ObjectMeta: metav1.ObjectMeta{Name: "undecorated", Namespace: "prowjobs"},
+			Spec:       prowapi.ProwJobSpec{Job: "ci-undecorated", Type: prowapi.PeriodicJob},
+		},
+	)
+	handler := handleGCSPath(fakeProwJobClient.ProwV1().ProwJobs("prowjobs"), logrus.WithField("handler", "/gcs-path"))
+
+	testCases := []struct {
+		name         string
+		prowjob      string
+		expectedCode int
+		expected     gcsPath
+	}{
+		{
+			name:         "presubmit",
+			prowjob:      "presubmit",
+			expectedCode: http.StatusOK,
+			expected: gcsPath{
+				Bucket: "kubernetes-jenkins",
+				Path:   "pr-logs/pull/test-infra/1234/pull-test-infra-bazel/100",
+				URL:    "gs://kubernetes-jenkins/pr-logs/pull/test-infra/1234/pull-test-infra-bazel/100",
+			},
+		},
+		{
+			name:         "periodic",
+			prowjob:      "periodic",
+			expectedCode: http.StatusOK,
+			expected: gcsPath{
+				Bucket: "kubernetes-jenkins",
+				Path:   "logs/ci-test-infra-bazel/200",
+				URL:    "gs://kubernetes-jenkins/logs/ci-test-infra-bazel/200",
+			},
+		},
+		{
+			name:         "undecorated job does not upload artifacts",
+			prowjob:      "undecorated",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "unknown job",
+			prowjob:      "missing",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "missing prowjob parameter",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/gcs-path?prowjob="+tc.prowjob, nil)
+			if err != nil {
+				t.Fatalf("Error making request: %v", err)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != tc.expectedCode {
+				t.Fatalf("Bad error code: %d, expected %d", rr.Code, tc.expectedCode)
+			}
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+			var res gcsPath
+			if err := json.Unmarshal(rr.Body.Bytes(), &res); err != nil {
+				t.Fatalf("Error unmarshaling: %v", err)
+			}
+			if res != tc.expected {
+				t.Errorf("Expected GCS path %#v, got %#v", tc.expected, res)
+			}
+		})
+	}
+}
+
+func TestPodEvents(t *testing.T) {
+	fakeProwJobClient := fake.NewSimpleClientset(
+		&prowapi.ProwJob{
+			ObjectMeta: metav1.ObjectMeta{Name: "scheduled", Namespace: "prowjobs"},
+			Spec:       prowapi.ProwJobSpec{Cluster: "build"},
+			Status:     prowapi.ProwJobStatus{PodName: "scheduled-pod"},
+		},
+		&prowapi.ProwJob{
+			ObjectMeta: metav1.ObjectMeta{Name: "triggered", Namespace: "prowjobs"},
+			Spec:       prowapi.ProwJobSpec{Cluster: "build"},
+		},
+	)
+	fakeKubeClient := kubefake.NewSimpleClientset(
+		&coreapi.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "scheduled-pod.1", Namespace: "pods"},
+			InvolvedObject: coreapi.ObjectReference{Kind: "Pod", Name: "scheduled-pod"},
+			Reason:         "FailedScheduling",
+		},
+		&coreapi.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "other-pod.1", Namespace: "pods"},
+			InvolvedObject: coreapi.ObjectReference{Kind: "Pod", Name: "other-pod"},
+			Reason:         "Scheduled",
+		},
+	)
+	eventClients := map[string]corev1.EventInterface{"build": fakeKubeClient.CoreV1().Events("pods")}
+	handler := handlePodEvents(fakeProwJobClient.ProwV1().ProwJobs("prowjobs"), eventClients, logrus.WithField("handler", "/pod-events"))
+
+	testCases := []struct {
+		name            string
+		prowjob         string
+		expectedCode    int
+		expectedReasons []string
+	}{
+		{
+			name:            "events of the job's pod are returned",
+			prowjob:         "scheduled",
+			expectedCode:    http.StatusOK,
+			expectedReasons: []string{"FailedScheduling"},
+		},
+		{
+			name:            "job without a pod has no events",
+			prowjob:         "triggered",
+			expectedCode:    http.StatusOK,
+			expectedReasons: []string{},
+		},
+		{
+			name:         "unknown job",
+			prowjob:      "missing",
+			expectedCode: http.StatusNotFound,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/pod-events?prowjob="+tc.prowjob, nil)
+			if err != nil {
+				t.Fatalf("Error making request: %v", err)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != tc.expectedCode {
+				t.Fatalf("Bad error code: %d", rr.Code)
+			}
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+			var events []coreapi.Event
+			if err := json.Unmarshal(rr.Body.Bytes(), &events); err != nil {
+				t.Fatalf("Error unmarshaling: %v", err)
+			}
+			reasons := []string{}
+			for _, event := range events {
+				reasons = append(reasons, event.Reason)
+			}
+			if !reflect.DeepEqual(reasons, tc.expectedReasons) {
+				t.Errorf("Expected events with reasons %v, got %v", tc.expectedReasons, reasons)
+			}
+		})
+	}
+}
+
+type fakeProwJobLister []prowapi.ProwJob
+
+func (f fakeProwJobLister) ListProwJobs(selector string) ([]prowapi.ProwJob, error) {
+	return f, nil
+}
+
+func TestLastGreen(t *testing.T) {
+	now := time.Now()
+	build := func(job, id string, state prowapi.ProwJobState, age time.Duration) prowapi.ProwJob {
+		return prowapi.ProwJob{
+			ObjectMeta: metav1.ObjectMeta{Name: job + "-" + id},
+			Spec: prowapi.ProwJobSpec{
+				Job:  job,
+				Type: prowapi.PostsubmitJob,
+				Refs: &prowapi.Refs{Org: "org", Repo: "repo", BaseSHA: "sha-" + id},
+			},
+			Status: prowapi.ProwJobStatus{
+				State:     state,
+				BuildID:   id,
+				URL:       "https://prow.example.com/view/gcs/bucket/logs/" + job + "/" + id,
+				StartTime: metav1.NewTime(now.Add(-age)),
+			},
+		}
+	}
+	ja := jobs.NewJobAgent(fakeProwJobLister{
+		build("mixed", "1", prowapi.SuccessState, 4*time.Hour),
+		build("mixed", "2", prowapi.SuccessState, 3*time.Hour),
+		build("mixed", "3", prowapi.FailureState, 2*time.Hour),
+		build("mixed", "4", prowapi.PendingState, time.Hour),
+		build("red", "5", prowapi.FailureState, time.Hour),
+	}, nil, (&config.Agent{}).Config)
+	ja.Start()
+	handler := handleLastGreen(ja, logrus.WithField("handler", "/last-green"))
+
+	testCases := []struct {
+		name         string
+		job          string
+		expectedCode int
+		expected     lastGreenBuild
+	}{
+		{
+			name:         "newest successful build is returned",
+			job:          "mixed",
+			expectedCode: http.StatusOK,
+			expected: lastGreenBuild{
+				BuildID: "2",
+				SHA:     "sha-2",
+				URL:     "https://prow.example.com/view/gcs/bucket/logs/mixed/2",
+			},
+		},
+		{
+			name:         "job without successful builds is not found",
+			job:          "red",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "unknown job is not found",
+			job:          "unknown",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "missing job is a bad request",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/last-green?job="+tc.job, nil)
+			if err != nil {
+				t.Fatalf("Error making request: %v", err)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != tc.expectedCode {
+				t.Fatalf("Bad error code: %d, expected %d", rr.Code, tc.expectedCode)
+			}
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+			var res lastGreenBuild
+			if err := json.Unmarshal(rr.Body.Bytes(), &res); err != nil {
+				t.Fatalf("Error unmarshaling: %v", err)
+			}
+			if res != tc.expected {
+				t.Errorf("Wrong last green build, expected %+v, got %+v", tc.expected, res)
+			}
+		})
+	}
+}
+
+func TestHandleAbortedJobs(t *testing.T) {
+	now := time.Now()
+	build := func(job, id string, state prowapi.ProwJobState, age time.Duration) prowapi.ProwJob {
+		completion := metav1.NewTime(now.Add(-age))
+		return prowapi.ProwJob{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        job + "-" + id,
+				Annotations: map[string]string{"prow.k8s.io/job": job},
+			},
+			Spec: prowapi.ProwJobSpec{
+				Job:  job,
+				Type: prowapi.PresubmitJob,
+			},
+			Status: prowapi.ProwJobStatus{
+				State:          state,
+				BuildID:        id,
+				Description:    "Job " + string(state),
+				StartTime:      metav1.NewTime(now.Add(-age - time.Hour)),
+				CompletionTime: &completion,
+			},
+		}
+	}
+	ja := jobs.NewJobAgent(fakeProwJobLister{
+		build("job", "1", prowapi.AbortedState, 48*time.Hour),
+		build("job", "2", prowapi.AbortedState, 2*time.Hour),
+		build("job", "3", prowapi.SuccessState, time.Hour),
+		build("other", "4", prowapi.AbortedState, time.Hour),
+		build("other", "5", prowapi.FailureState, time.Hour),
+	}, nil, (&config.Agent{}).Config)
+	ja.Start()
+	handler := handleAbortedJobs(ja, logrus.WithField("handler", "/aborted-jobs.js"))
+
+	testCases := []struct {
+		name             string
+		since            string
+		expectedCode     int
+		expectedBuildIDs []string
+	}{
+		{
+			name:             "all aborted jobs, most recent first",
+			expectedCode:     http.StatusOK,
+			expectedBuildIDs: []string{"4", "2", "1"},
+		},
+		{
+			name:             "since restricts the window",
+			since:            "24h",
+			expectedCode:     http.StatusOK,
+			expectedBuildIDs: []string{"4", "2"},
+		},
+		{
+			name:             "nothing aborted in the window",
+			since:            "30m",
+			expectedCode:     http.StatusOK,
+			expectedBuildIDs: []string{},
+		},
+		{
+			name:         "invalid since is a bad request",
+			since:        "yesterday",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/aborted-jobs.js?since="+tc.since, nil)
+			if err != nil {
+				t.Fatalf("Error making request: %v", err)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != tc.expectedCode {
+				t.Fatalf("Bad error code: %d, expected %d", rr.Code, tc.expectedCode)
+			}
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+			var res []abortedJob
+			if err := json.Unmarshal(rr.Body.Bytes(), &res); err != nil {
+				t.Fatalf("Error unmarshaling: %v", err)
+			}
+			buildIDs := []string{}
+			for _, job := range res {
+				buildIDs = append(buildIDs, job.BuildID)
+				if job.Description != "Job aborted" {
+					t.Errorf("Expected the description of build %s to be served, got %q", job.BuildID, job.Description)
+				}
+				if job.Annotations["prow.k8s.io/job"] != job.Job {
+					t.Errorf("Expected the annotations of build %s to be served, got %v", job.BuildID, job.Annotations)
+				}
+			}
+			if !reflect.DeepEqual(buildIDs, tc.expectedBuildIDs) {
+				t.Errorf("Expected aborted builds %v, got %v", tc.expectedBuildIDs, buildIDs)
+			}
+		})
+	}
+}
+
+func TestHandleStaleJobs(t *testing.T) {
+	now := time.Now()
+	build := func(job, id string, jobType prowapi.ProwJobType, age time.Duration) prowapi.ProwJob {
+		return prowapi.ProwJob{
+			ObjectMeta: metav1.ObjectMeta{Name: job + "-" + id},
+			Spec: prowapi.ProwJobSpec{
+				Job:  job,
+				Type: jobType,
+			},
+			Status: prowapi.ProwJobStatus{
+				State:     prowapi.SuccessState,
+				BuildID:   id,
+				StartTime: metav1.NewTime(now.Add(-age)),
+			},
+		}
+	}
+	cfg := &config.Config{
+		JobConfig: config.JobConfig{
+			Periodics: []config.Periodic{
+				{JobBase: config.JobBase{Name: "recent"}, Interval: "1h"},
+				{JobBase: config.JobBase{Name: "stale"}, Cron: "0 0 * * *"},
+				{JobBase: config.JobBase{Name: "never-run"}, Interval: "24h"},
+				{JobBase: config.JobBase{Name: "presubmit-only"}, Interval: "1h"},
+				{JobBase: config.JobBase{Name: "hidden", Hidden: true}, Interval: "24h"},
+				{JobBase: config.JobBase{Name: "hidden-repo", UtilityConfig: config.UtilityConfig{ExtraRefs: []prowapi.Refs{{Org: "secret", Repo: "repo"}}}}, Interval: "24h"},
+			},
+		},
+	}
+	cfgGetter := func() *config.Config { return cfg }
+	ja := jobs.NewJobAgent(fakeProwJobLister{
+		build("recent", "1", prowapi.PeriodicJob, 48*time.Hour),
+		build("recent", "2", prowapi.PeriodicJob, time.Hour),
+		build("stale", "3", prowapi.PeriodicJob, 72*time.Hour),
+		build("stale", "4", prowapi.PeriodicJob, 48*time.Hour),
+		build("presubmit-only", "5", prowapi.PresubmitJob, time.Hour),
+	}, nil, cfgGetter)
+	ja.Start()
+	lister := &filteringProwJobLister{hiddenRepos: func() sets.String { return sets.NewString("secret") }}
+	handler := handleStaleJobs(ja, cfgGetter, lister.periodicVisible, logrus.WithField("handler", "/stale-jobs.js"))
+
+	testCases := []struct {
+		name         string
+		since        string
+		expectedCode int
+		expected     map[string]*time.Duration
+	}{
+		{
+			name:         "without since only jobs that never ran are stale",
+			expectedCode: http.StatusOK,
+			expected: map[string]*time.Duration{
+				"never-run":      nil,
+				"presubmit-only": nil,
+			},
+		},
+		{
+			name:         "jobs that did not run within since are stale",
+			since:        "24h",
+			expectedCode: http.StatusOK,
+			expected: map[string]*time.Duration{
+				"never-run":      nil,
+				"presubmit-only": nil,
+				"stale":          durationPtr(48 * time.Hour),
+			},
+		},
+		{
+			name:         "a short window makes all jobs stale",
+			since:        "30m",
+			expectedCode: http.StatusOK,
+			expected: map[string]*time.Duration{
+				"never-run":      nil,
+				"presubmit-only": nil,
+				"recent":         durationPtr(time.Hour),
+				"stale":          durationPtr(48 * time.Hour),
+			},
+		},
+		{
+			name:         "invalid since is a bad request",
+			since:        "last week",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/stale-jobs.js?since="+url.QueryEscape(tc.since), nil)
+			if err != nil {
+				t.Fatalf("Error making request: %v", err)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != tc.expectedCode {
+				t.Fatalf("Bad error code: %d, expected %d", rr.Code, tc.expectedCode)
+			}
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+			var res []staleJob
+			if err := json.Unmarshal(rr.Body.Bytes(), &res); err != nil {
+				t.Fatalf("Error unmarshaling: %v", err)
+			}
+			if len(res) != len(tc.expected) {
+				t.Fatalf("Expected %d stale jobs, got %v", len(tc.expected), res)
+			}
+			for i, job := range res {
+				if i > 0 && res[i-1].Job >= job.Job {
+					t.Errorf("Expected stale jobs to be sorted by name, got %s after %s", job.Job, res[i-1].Job)
+				}
+				age, ok := tc.expected[job.Job]
+				if !ok {
+					t.Errorf("Unexpected stale job %s", job.Job)
+					continue
+				}
+				switch {
+				case age == nil && job.LastRun != nil:
+					t.Errorf("Expected job %s to have no last run, got %v", job.Job, job.LastRun)
+				case age != nil && job.LastRun == nil:
+					t.Errorf("Expected job %s to have a last run", job.Job)
+				case age != nil && !job.LastRun.Equal(now.Add(-*age)):
+					t.Errorf("Expected job %s to have last run %v ago, got %v", job.Job, *age, job.LastRun)
+				}
+			}
+		})
+	}
+}
+
+func durationPtr(d time.Duration) *time.Duration {
+	return &d
+}
+
 type mockGitHubConfigGetter struct {
 	githubLogin string
 }
@@ -288,6 +864,10 @@ func TestRerun(t *testing.T) {
 		shouldCreateProwJob bool
 		httpCode            int
 		httpMethod          string
+		agent               prowapi.ProwJobAgent
+		jobType             prowapi.ProwJobType
+		body                string
+		expectedRefs        *prowapi.Refs
 	}{
 		{
 			name:                "Handler returns ProwJob",
@@ -369,28 +949,88 @@ func TestRerun(t *testing.T) {
 			httpCode:            http.StatusOK,
 			httpMethod:          http.MethodPost,
 		},
+		{
+			name:                "Allowed agent",
+			login:               "authorized",
+			authorized:          []string{"authorized"},
+			allowAnyone:         false,
+			rerunCreatesJob:     true,
+			shouldCreateProwJob: true,
+			httpCode:            http.StatusOK,
+			httpMethod:          http.MethodPost,
+			agent:               prowapi.TektonAgent,
+		},
+		{
+			name:                "Disallowed agent",
+			login:               "authorized",
+			authorized:          []string{"authorized"},
+			allowAnyone:         false,
+			rerunCreatesJob:     true,
+			shouldCreateProwJob: false,
+			httpCode:            http.StatusBadRequest,
+			httpMethod:          http.MethodPost,
+			agent:               prowapi.JenkinsAgent,
+		},
+		{
+			name:                "Periodic rerun with base ref override",
+			login:               "authorized",
+			authorized:          []string{"authorized"},
+			rerunCreatesJob:     true,
+			shouldCreateProwJob: true,
+			httpCode:            http.StatusOK,
+			httpMethod:          http.MethodPost,
+			jobType:             prowapi.PeriodicJob,
+			body:                `{"base_ref": "release-1.0", "base_sha": "abcdef"}`,
+			expectedRefs:        &prowapi.Refs{Org: "org", Repo: "repo", BaseRef: "release-1.0", BaseSHA: "abcdef"},
+		},
+		{
+			name:                "Presubmit rerun with base ref override is rejected",
+			login:               "authorized",
+			authorized:          []string{"authorized"},
+			rerunCreatesJob:     true,
+			shouldCreateProwJob: false,
+			httpCode:            http.StatusBadRequest,
+			httpMethod:          http.MethodPost,
+			body:                `{"base_ref": "release-1.0"}`,
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
+			agent := tc.agent
+			if agent == "" {
+				agent = prowapi.KubernetesAgent
+			}
+			jobType := tc.jobType
+			if jobType == "" {
+				jobType = prowapi.PresubmitJob
+			}
+			refs := &prowapi.Refs{
+				Org:  "org",
+				Repo: "repo",
+				Pulls: []prowapi.Pull{
+					{
+						Number: 1,
+						Author: tc.login,
+					},
+				},
+			}
+			var extraRefs []prowapi.Refs
+			if jobType == prowapi.PeriodicJob {
+				extraRefs = []prowapi.Refs{{Org: "org", Repo: "repo", BaseRef: "master", BaseSHA: "123456"}}
+				refs = nil
+			}
 			fakeProwJobClient := fake.NewSimpleClientset(&prowapi.ProwJob{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "wowsuch",
 					Namespace: "prowjobs",
 				},
 				Spec: prowapi.ProwJobSpec{
-					Job:  "whoa",
-					Type: prowapi.PresubmitJob,
-					Refs: &prowapi.Refs{
-						Org:  "org",
-						Repo: "repo",
-						Pulls: []prowapi.Pull{
-							{
-								Number: 1,
-								Author: tc.login,
-							},
-						},
-					},
+					Job:       "whoa",
+					Type:      jobType,
+					Agent:     agent,
+					Refs:      refs,
+					ExtraRefs: extraRefs,
 					RerunAuthConfig: &prowapi.RerunAuthConfig{
 						AllowAnyone:   false,
 						GitHubUsers:   []string{"authorized", "alsoauthorized"},
@@ -408,7 +1048,7 @@ func TestRerun(t *testing.T) {
 				}
 			}
 
-			req, err := http.NewRequest(tc.httpMethod, "/rerun?prowjob=wowsuch", nil)
+			req, err := http.NewRequest(tc.httpMethod, "/rerun?prowjob=wowsuch", bytes.NewBufferString(tc.body))
 			if err != nil {
 				t.Fatalf("Error making request: %v", err)
 			}
@@ -434,7 +1074,8 @@ func TestRerun(t *testing.T) {
 			ghc := mockGitHubConfigGetter{githubLogin: tc.login}
 			rc := &fakegithub.FakeClient{OrgMembers: map[string][]string{"org": {"org-member"}}}
 			pca := plugins.NewFakeConfigAgent()
-			handler := handleRerun(fakeProwJobClient.ProwV1().ProwJobs("prowjobs"), tc.rerunCreatesJob, authCfgGetter, goa, ghc, rc, &pca, logrus.WithField("handler", "/rerun"))
+			deckConfig := config.Deck{RerunAllowedAgents: []prowapi.ProwJobAgent{prowapi.KubernetesAgent, prowapi.TektonAgent}}
+			handler := handleRerun(fakeProwJobClient.ProwV1().ProwJobs("prowjobs"), tc.rerunCreatesJob, authCfgGetter, deckConfig.RerunAllowsAgent, goa, ghc, rc, &pca, logrus.WithField("handler", "/rerun"))
 			handler.ServeHTTP(rr, req)
 			if rr.Code != tc.httpCode {
 				t.Fatalf("Bad error code: %d", rr.Code)
@@ -448,6 +1089,16 @@ func TestRerun(t *testing.T) {
 				if numPJs := len(pjs.Items); numPJs != 2 {
 					t.Errorf("expected to get two prowjobs, got %d", numPJs)
 				}
+				if tc.expectedRefs != nil {
+					for _, pj := range pjs.Items {
+						if pj.Name == "wowsuch" {
+							continue
+						}
+						if actual := pj.Spec.ExtraRefs[0]; !reflect.DeepEqual(&actual, tc.expectedRefs) {
+							t.Errorf("expected rerun refs %+v, got %+v", tc.expectedRefs, actual)
+						}
+					}
+				}
 
 			} else if !tc.rerunCreatesJob && tc.httpCode == http.StatusOK {
 				resp := rr.Result()
@@ -588,12 +1239,224 @@ func TestTideHistory(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Error reading response body: %v", err)
 	}
-	var res tideHistory
-	if err := json.Unmarshal(body, &res); err != nil {
-		t.Fatalf("Error unmarshaling: %v", err)
+	var res tideHistory
+	if err := json.Unmarshal(body, &res); err != nil {
+		t.Fatalf("Error unmarshaling: %v", err)
+	}
+	if !reflect.DeepEqual(res.History, testHist) {
+		t.Fatalf("Expected /tide-history.js:\n%#v\n,but got:\n%#v\n", testHist, res.History)
+	}
+}
+
+func TestTideHistoryFiltering(t *testing.T) {
+	now := time.Now()
+	old := now.Add(-48 * time.Hour)
+	recent := now.Add(-time.Hour)
+	ta := tideAgent{
+		history: map[string][]history.Record{
+			"o/r:master": {
+				{Time: recent, Action: "MERGE"}, {Time: old, Action: "TRIGGER"},
+			},
+			"o/r:release": {
+				{Time: recent, Action: "MERGE_BATCH"},
+			},
+			"o/other:master": {
+				{Time: old, Action: "MERGE"},
+			},
+			"other/r:master": {
+				{Time: recent, Action: "TRIGGER_BATCH"},
+			},
+		},
+	}
+	testCases := []struct {
+		name          string
+		query         string
+		expectedCode  int
+		expectedPools map[string][]string
+		expectedCount int
+	}{
+		{
+			name:         "no filter returns everything",
+			expectedCode: http.StatusOK,
+			expectedPools: map[string][]string{
+				"o/r:master":     {"MERGE", "TRIGGER"},
+				"o/r:release":    {"MERGE_BATCH"},
+				"o/other:master": {"MERGE"},
+				"other/r:master": {"TRIGGER_BATCH"},
+			},
+			expectedCount: 5,
+		},
+		{
+			name:         "filter by org",
+			query:        "org=o",
+			expectedCode: http.StatusOK,
+			expectedPools: map[string][]string{
+				"o/r:master":     {"MERGE", "TRIGGER"},
+				"o/r:release":    {"MERGE_BATCH"},
+				"o/other:master": {"MERGE"},
+			},
+			expectedCount: 4,
+		},
+		{
+			name:         "filter by org, repo and branch",
+			query:        "org=o&repo=r&branch=master",
+			expectedCode: http.StatusOK,
+			expectedPools: map[string][]string{
+				"o/r:master": {"MERGE", "TRIGGER"},
+			},
+			expectedCount: 2,
+		},
+		{
+			name:         "filter by duration drops old records and empty pools",
+			query:        "repo=r&since=24h",
+			expectedCode: http.StatusOK,
+			expectedPools: map[string][]string{
+				"o/r:master":     {"MERGE"},
+				"o/r:release":    {"MERGE_BATCH"},
+				"other/r:master": {"TRIGGER_BATCH"},
+			},
+			expectedCount: 3,
+		},
+		{
+			name:         "filter by timestamp",
+			query:        "org=o&since=" + url.QueryEscape(now.Add(-24*time.Hour).Format(time.RFC3339)),
+			expectedCode: http.StatusOK,
+			expectedPools: map[string][]string{
+				"o/r:master":  {"MERGE"},
+				"o/r:release": {"MERGE_BATCH"},
+			},
+			expectedCount: 2,
+		},
+		{
+			name:         "invalid since",
+			query:        "since=yesterday",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := handleTideHistory(&ta, logrus.WithField("handler", "/tide-history.js"))
+			req, err := http.NewRequest(http.MethodGet, "/tide-history.js?"+tc.query, nil)
+			if err != nil {
+				t.Fatalf("Error making request: %v", err)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != tc.expectedCode {
+				t.Fatalf("Expected status code %d, got %d", tc.expectedCode, rr.Code)
+			}
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+			var res tideHistory
+			if err := json.Unmarshal(rr.Body.Bytes(), &res); err != nil {
+				t.Fatalf("Error unmarshaling: %v", err)
+			}
+			actualPools := map[string][]string{}
+			for pool, records := range res.History {
+				for _, record := range records {
+					actualPools[pool] = append(actualPools[pool], record.Action)
+				}
+			}
+			if !reflect.DeepEqual(actualPools, tc.expectedPools) {
+				t.Errorf("Expected pools %v, got %v", tc.expectedPools, actualPools)
+			}
+			if res.Count != tc.expectedCount {
+				t.Errorf("Expected count %d, got %d", tc.expectedCount, res.Count)
+			}
+		})
+	}
+}
+
+func TestHandleMergeStats(t *testing.T) {
+	now := time.Now()
+	pull := func(number int) prowapi.Pull {
+		return prowapi.Pull{Number: number, SHA: fmt.Sprintf("sha-%d", number)}
+	}
+	ta := tideAgent{
+		history: map[string][]history.Record{
+			"o/r:master": {
+				{Time: now.Add(-time.Hour), Action: "MERGE_BATCH", Target: []prowapi.Pull{pull(2), pull(3)}},
+				{Time: now.Add(-2 * time.Hour), Action: "MERGE", Target: []prowapi.Pull{pull(1)}},
+				{Time: now.Add(-3 * time.Hour), Action: "TRIGGER_BATCH", Target: []prowapi.Pull{pull(2), pull(3)}},
+				{Time: now.Add(-4 * time.Hour), Action: "TRIGGER", Target: []prowapi.Pull{pull(1)}},
+				// The first trigger of PR 2 failed.
+				{Time: now.Add(-5 * time.Hour), Action: "TRIGGER", Target: []prowapi.Pull{pull(2)}, Err: "failed to create ProwJob"},
+				// PR 4 was merged before the window.
+				{Time: now.Add(-48 * time.Hour), Action: "MERGE", Target: []prowapi.Pull{pull(4)}},
+				{Time: now.Add(-50 * time.Hour), Action: "TRIGGER", Target: []prowapi.Pull{pull(4)}},
+			},
+			"o/r:release": {
+				// PR 5 was merged without Tide triggering tests for it.
+				{Time: now.Add(-time.Hour), Action: "MERGE", Target: []prowapi.Pull{pull(5)}},
+				// The merge of PR 6 failed.
+				{Time: now.Add(-time.Hour), Action: "MERGE", Target: []prowapi.Pull{pull(6)}, Err: "merge conflict"},
+			},
+			"o/other:master": {
+				{Time: now.Add(-time.Hour), Action: "MERGE", Target: []prowapi.Pull{pull(7)}},
+			},
+		},
 	}
-	if !reflect.DeepEqual(res.History, testHist) {
-		t.Fatalf("Expected /tide-history.js:\n%#v\n,but got:\n%#v\n", testHist, res.History)
+	testCases := []struct {
+		name          string
+		query         string
+		expectedCode  int
+		expectedStats mergeStats
+	}{
+		{
+			name:         "all merges",
+			query:        "org=o&repo=r",
+			expectedCode: http.StatusOK,
+			// PRs 1-3 waited 2h each and PR 4 waited 2h.
+			expectedStats: mergeStats{Org: "o", Repo: "r", Merges: 5, AveragePoolWaitSeconds: 7200},
+		},
+		{
+			name:         "merges in the last day",
+			query:        "org=o&repo=r&since=24h",
+			expectedCode: http.StatusOK,
+			// PR 1 waited 2h, PRs 2 and 3 waited 2h each.
+			expectedStats: mergeStats{Org: "o", Repo: "r", Merges: 4, AveragePoolWaitSeconds: 7200},
+		},
+		{
+			name:          "repo without history",
+			query:         "org=o&repo=unknown",
+			expectedCode:  http.StatusOK,
+			expectedStats: mergeStats{Org: "o", Repo: "unknown"},
+		},
+		{
+			name:         "missing repo",
+			query:        "org=o",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid since",
+			query:        "org=o&repo=r&since=yesterday",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := handleMergeStats(&ta, logrus.WithField("handler", "/merge-stats.js"))
+			req, err := http.NewRequest(http.MethodGet, "/merge-stats.js?"+tc.query, nil)
+			if err != nil {
+				t.Fatalf("Error making request: %v", err)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != tc.expectedCode {
+				t.Fatalf("Expected status code %d, got %d", tc.expectedCode, rr.Code)
+			}
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+			var res mergeStats
+			if err := json.Unmarshal(rr.Body.Bytes(), &res); err != nil {
+				t.Fatalf("Error unmarshaling: %v", err)
+			}
+			if !reflect.DeepEqual(res, tc.expectedStats) {
+				t.Errorf("Expected merge stats %+v, got %+v", tc.expectedStats, res)
+			}
+		})
 	}
 }
 
@@ -649,6 +1512,128 @@ func TestHelp(t *testing.T) {
 	handleAndCheck()
 }
 
+type fakeHelpAgent struct {
+	help *pluginhelp.Help
+}
+
+func (f *fakeHelpAgent) getHelp() (*pluginhelp.Help, error) {
+	return f.help, nil
+}
+
+func TestHelpForPlugin(t *testing.T) {
+	help := &pluginhelp.Help{
+		AllRepos:            []string{"org/repo"},
+		RepoPlugins:         map[string][]string{"org": {"plugin", "other-plugin"}, "org/other": {"other-plugin"}},
+		RepoExternalPlugins: map[string][]string{"org/repo": {"external-plugin"}},
+		PluginHelp: map[string]pluginhelp.PluginHelp{
+			"plugin":       {Description: "plugin"},
+			"other-plugin": {Description: "other-plugin"},
+		},
+		ExternalPluginHelp: map[string]pluginhelp.PluginHelp{"external-plugin": {Description: "external-plugin"}},
+	}
+	testCases := []struct {
+		name   string
+		plugin string
+
+		expectedCode int
+		expectedHelp pluginhelp.Help
+	}{
+		{
+			name:         "plugin",
+			plugin:       "plugin",
+			expectedCode: http.StatusOK,
+			expectedHelp: pluginhelp.Help{
+				AllRepos:    []string{"org/repo"},
+				RepoPlugins: map[string][]string{"org": {"plugin"}},
+				PluginHelp:  map[string]pluginhelp.PluginHelp{"plugin": {Description: "plugin"}},
+			},
+		},
+		{
+			name:         "external plugin",
+			plugin:       "external-plugin",
+			expectedCode: http.StatusOK,
+			expectedHelp: pluginhelp.Help{
+				AllRepos:            []string{"org/repo"},
+				RepoExternalPlugins: map[string][]string{"org/repo": {"external-plugin"}},
+				ExternalPluginHelp:  map[string]pluginhelp.PluginHelp{"external-plugin": {Description: "external-plugin"}},
+			},
+		},
+		{
+			name:         "unknown plugin",
+			plugin:       "unknown",
+			expectedCode: http.StatusNotFound,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := handlePluginHelp(&fakeHelpAgent{help: help}, logrus.WithField("handler", "/plugin-help.js"))
+			req, err := http.NewRequest(http.MethodGet, "/plugin-help.js?plugin="+tc.plugin, nil)
+			if err != nil {
+				t.Fatalf("Error making request: %v", err)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != tc.expectedCode {
+				t.Fatalf("Expected code %d, got %d", tc.expectedCode, rr.Code)
+			}
+			if rr.Code != http.StatusOK {
+				return
+			}
+			var res pluginhelp.Help
+			if err := json.Unmarshal(rr.Body.Bytes(), &res); err != nil {
+				t.Fatalf("Error unmarshaling: %v", err)
+			}
+			if !reflect.DeepEqual(tc.expectedHelp, res) {
+				t.Errorf("Invalid plugin help. Got %v, expected %v", res, tc.expectedHelp)
+			}
+		})
+	}
+}
+
+type fakeThrottleStatusGetter []prowgithub.ThrottleStatus
+
+func (f fakeThrottleStatusGetter) ThrottleStatus() []prowgithub.ThrottleStatus {
+	return f
+}
+
+func TestHandleGitHubThrottles(t *testing.T) {
+	testCases := []struct {
+		name     string
+		statuses []prowgithub.ThrottleStatus
+		expected string
+	}{
+		{
+			name:     "unthrottled client",
+			expected: `[]`,
+		},
+		{
+			name: "throttled client",
+			statuses: []prowgithub.ThrottleStatus{
+				{HourlyTokens: 300, Burst: 100, AvailableTokens: 0, Throttled: true},
+				{Org: "org", HourlyTokens: 100, Burst: 10, AvailableTokens: 4},
+			},
+			expected: `[{"hourly_tokens":300,"burst":100,"available_tokens":0,"throttled":true},{"org":"org","hourly_tokens":100,"burst":10,"available_tokens":4,"throttled":false}]`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := handleGitHubThrottles(fakeThrottleStatusGetter(tc.statuses), logrus.WithField("handler", "/github-throttles.js"))
+			req, err := http.NewRequest(http.MethodGet, "/github-throttles.js", nil)
+			if err != nil {
+				t.Fatalf("Error making request: %v", err)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != http.StatusOK {
+				t.Fatalf("Bad error code: %d", rr.Code)
+			}
+			if body := rr.Body.String(); body != tc.expected {
+				t.Errorf("Expected body %s, got %s", tc.expected, body)
+			}
+		})
+	}
+}
+
 func TestListProwJobs(t *testing.T) {
 	templateJob := &prowapi.ProwJob{
 		ObjectMeta: metav1.ObjectMeta{
@@ -664,6 +1649,7 @@ func TestListProwJobs(t *testing.T) {
 		hiddenRepos sets.String
 		hiddenOnly  bool
 		showHidden  bool
+		maxJobAge   time.Duration
 		expected    sets.String
 		expectedErr bool
 	}{
@@ -866,6 +1852,23 @@ func TestListProwJobs(t *testing.T) {
 			},
 			hiddenRepos: sets.NewString("hide/me", "hidden-org"),
 		},
+		{
+			name: "jobs older than the max job age are filtered",
+			prowJobs: []func(*prowapi.ProwJob) runtime.Object{
+				func(in *prowapi.ProwJob) runtime.Object {
+					in.Name = "old"
+					in.Status.StartTime = metav1.NewTime(time.Now().Add(-2 * time.Hour))
+					return in
+				},
+				func(in *prowapi.ProwJob) runtime.Object {
+					in.Name = "recent"
+					in.Status.StartTime = metav1.NewTime(time.Now().Add(-time.Minute))
+					return in
+				},
+			},
+			maxJobAge: time.Hour,
+			expected:  sets.NewString("recent"),
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -884,6 +1887,9 @@ func TestListProwJobs(t *testing.T) {
 			},
 			hiddenOnly: testCase.hiddenOnly,
 			showHidden: testCase.showHidden,
+			maxJobAge: func() time.Duration {
+				return testCase.maxJobAge
+			},
 		}
 
 		filtered, err := lister.ListProwJobs(testCase.selector)
@@ -1004,6 +2010,112 @@ func Test_gatherOptions(t *testing.T) {
 
 }
 
+func TestHandleValidateProwYAML(t *testing.T) {
+	cfg := func() *config.Config {
+		return &config.Config{ProwConfig: config.ProwConfig{PodNamespace: "pods"}}
+	}
+	handler := handleValidateProwYAML(cfg, logrus.WithField("handler", "/validate-prow-yaml"))
+
+	testCases := []struct {
+		name           string
+		method         string
+		query          string
+		body           string
+		expectedCode   int
+		expectedJobs   []string
+		expectedErrors int
+	}{
+		{
+			name:   "valid prow.yaml returns the parsed jobs",
+			method: http.MethodPost,
+			query:  "?org=org&repo=repo",
+			body: `presubmits:
+- name: hello-world
+  always_run: true
+  spec:
+    containers:
+    - image: alpine
+      command: ["echo", "hello"]
+`,
+			expectedCode: http.StatusOK,
+			expectedJobs: []string{"hello-world"},
+		},
+		{
+			name:   "invalid prow.yaml returns the validation errors",
+			method: http.MethodPost,
+			query:  "?org=org&repo=repo",
+			body: `presubmits:
+- name: hello-world
+  always_run: true
+  branches: ["master"]
+  spec:
+    containers:
+    - image: alpine
+      command: ["echo", "hello"]
+`,
+			expectedCode:   http.StatusUnprocessableEntity,
+			expectedErrors: 1,
+		},
+		{
+			name:           "malformed prow.yaml returns an error",
+			method:         http.MethodPost,
+			query:          "?org=org&repo=repo",
+			body:           "presubmits: {",
+			expectedCode:   http.StatusUnprocessableEntity,
+			expectedErrors: 1,
+		},
+		{
+			name:         "missing repo is a bad request",
+			method:       http.MethodPost,
+			query:        "?org=org",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "oversized prow.yaml is rejected instead of truncated",
+			method:       http.MethodPost,
+			query:        "?org=org&repo=repo",
+			body:         "presubmits: []\n" + strings.Repeat("#", maxProwYAMLSize),
+			expectedCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:         "GET is not allowed",
+			method:       http.MethodGet,
+			query:        "?org=org&repo=repo",
+			expectedCode: http.StatusMethodNotAllowed,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, "/validate-prow-yaml"+tc.query, bytes.NewBufferString(tc.body))
+			if err != nil {
+				t.Fatalf("Error making request: %v", err)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != tc.expectedCode {
+				t.Fatalf("Bad error code: %d, expected %d: %s", rr.Code, tc.expectedCode, rr.Body.String())
+			}
+			if tc.expectedCode == http.StatusBadRequest || tc.expectedCode == http.StatusMethodNotAllowed || tc.expectedCode == http.StatusRequestEntityTooLarge {
+				return
+			}
+			var res prowYAMLValidation
+			if err := json.Unmarshal(rr.Body.Bytes(), &res); err != nil {
+				t.Fatalf("Error unmarshaling: %v", err)
+			}
+			var jobs []string
+			for _, ps := range res.Presubmits {
+				jobs = append(jobs, ps.Name)
+			}
+			if !reflect.DeepEqual(jobs, tc.expectedJobs) {
+				t.Errorf("Expected jobs %v, got %v", tc.expectedJobs, jobs)
+			}
+			if len(res.Errors) != tc.expectedErrors {
+				t.Errorf("Expected %d errors, got %v", tc.expectedErrors, res.Errors)
+			}
+		})
+	}
+}
+
 func TestHandleConfig(t *testing.T) {
 	trueVal := true
 	c := config.Config{
@@ -1132,3 +2244,290 @@ func (p *possiblyErroringFakeCtrlRuntimeClient) List(
 	}
 	return p.Client.List(ctx, pjl, opts...)
 }
+
+func TestHandleContextPolicy(t *testing.T) {
+	yes := true
+	cfg := func() *config.Config {
+		return &config.Config{
+			JobConfig: config.JobConfig{
+				PresubmitsStatic: map[string][]config.Presubmit{
+					"org/repo": {
+						{
+							JobBase:   config.JobBase{Name: "unit"},
+							AlwaysRun: true,
+							Reporter:  config.Reporter{Context: "unit"},
+						},
+						{
+							JobBase:  config.JobBase{Name: "lint"},
+							Reporter: config.Reporter{Context: "lint"},
+							Optional: true,
+						},
+					},
+				},
+			},
+			ProwConfig: config.ProwConfig{
+				Tide: config.Tide{
+					ContextOptions: config.TideContextPolicyOptions{
+						TideContextPolicy: config.TideContextPolicy{
+							SkipUnknownContexts: &yes,
+							RequiredContexts:    []string{"cla"},
+						},
+					},
+				},
+			},
+		}
+	}
+	handler := handleContextPolicy(cfg, logrus.WithField("handler", "/context-policy"))
+
+	testCases := []struct {
+		name           string
+		query          string
+		expectedCode   int
+		expectedPolicy config.TideContextPolicy
+	}{
+		{
+			name:         "policy merges configured and job contexts",
+			query:        "?org=org&repo=repo&branch=master",
+			expectedCode: http.StatusOK,
+			expectedPolicy: config.TideContextPolicy{
+				SkipUnknownContexts: &yes,
+				RequiredContexts:    []string{"cla", "unit"},
+				OptionalContexts:    []string{"lint"},
+			},
+		},
+		{
+			name:         "missing branch is a bad request",
+			query:        "?org=org&repo=repo",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/context-policy"+tc.query, nil)
+			if err != nil {
+				t.Fatalf("Error making request: %v", err)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != tc.expectedCode {
+				t.Fatalf("Bad error code: %d, expected %d: %s", rr.Code, tc.expectedCode, rr.Body.String())
+			}
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+			var policy config.TideContextPolicy
+			if err := json.Unmarshal(rr.Body.Bytes(), &policy); err != nil {
+				t.Fatalf("Error unmarshaling: %v", err)
+			}
+			if !reflect.DeepEqual(policy, tc.expectedPolicy) {
+				t.Errorf("Got policy %+v, expected %+v", policy, tc.expectedPolicy)
+			}
+		})
+	}
+}
+
+func TestHandleBranchProtection(t *testing.T) {
+	yes := true
+	no := false
+	one := 1
+	cfg := func() *config.Config {
+		return &config.Config{
+			JobConfig: config.JobConfig{
+				PresubmitsStatic: map[string][]config.Presubmit{
+					"org/repo": {
+						{
+							JobBase:   config.JobBase{Name: "unit"},
+							AlwaysRun: true,
+							Reporter:  config.Reporter{Context: "unit"},
+						},
+					},
+				},
+			},
+			ProwConfig: config.ProwConfig{
+				BranchProtection: config.BranchProtection{
+					AllowDisabledPolicies: true,
+					Orgs: map[string]config.Org{
+						"org": {
+							Policy: config.Policy{
+								Protect: &yes,
+								RequiredStatusChecks: &config.ContextPolicy{
+									Contexts: []string{"cla"},
+								},
+							},
+							Repos: map[string]config.Repo{
+								"repo": {
+									Policy: config.Policy{
+										RequiredPullRequestReviews: &config.ReviewPolicy{Approvals: &one},
+									},
+								},
+								"unprotected": {
+									Policy: config.Policy{Protect: &no},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+	handler := handleBranchProtection(cfg, logrus.WithField("handler", "/branch-protection"))
+
+	testCases := []struct {
+		name            string
+		query           string
+		expectedCode    int
+		expectedRequest *prowgithub.BranchProtectionRequest
+	}{
+		{
+			name:         "request merges the org, repo and job policies",
+			query:        "?org=org&repo=repo&branch=master",
+			expectedCode: http.StatusOK,
+			expectedRequest: &prowgithub.BranchProtectionRequest{
+				EnforceAdmins: &no,
+				RequiredPullRequestReviews: &prowgithub.RequiredPullRequestReviewsRequest{
+					RequiredApprovingReviewCount: 1,
+				},
+				RequiredStatusChecks: &prowgithub.RequiredStatusChecks{
+					Contexts: []string{"cla", "unit"},
+				},
+			},
+		},
+		{
+			name:         "unprotected branch has no request",
+			query:        "?org=org&repo=unprotected&branch=master",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "unmanaged org is not found",
+			query:        "?org=other&repo=repo&branch=master",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "missing branch is a bad request",
+			query:        "?org=org&repo=repo",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/branch-protection"+tc.query, nil)
+			if err != nil {
+				t.Fatalf("Error making request: %v", err)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != tc.expectedCode {
+				t.Fatalf("Bad error code: %d, expected %d: %s", rr.Code, tc.expectedCode, rr.Body.String())
+			}
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+			var request *prowgithub.BranchProtectionRequest
+			if err := json.Unmarshal(rr.Body.Bytes(), &request); err != nil {
+				t.Fatalf("Error unmarshaling: %v", err)
+			}
+			if !reflect.DeepEqual(request, tc.expectedRequest) {
+				t.Errorf("Got request %s, expected %+v", rr.Body.String(), tc.expectedRequest)
+			}
+		})
+	}
+}
+
+func TestHandleConfigDiff(t *testing.T) {
+	loaded := &config.Config{ProwConfig: config.ProwConfig{PodNamespace: "default"}}
+	jc, err := loaded.ExpandJobConfig(config.JobConfig{
+		PresubmitsStatic: map[string][]config.Presubmit{
+			"org/repo": {
+				{JobBase: config.JobBase{Name: "unit", Spec: &coreapi.PodSpec{Containers: []coreapi.Container{{Image: "alpine"}}}}},
+				{JobBase: config.JobBase{Name: "e2e", Spec: &coreapi.PodSpec{Containers: []coreapi.Container{{Image: "alpine"}}}}},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to expand job config: %v", err)
+	}
+	loaded.JobConfig = *jc
+	cfg := func() *config.Config { return loaded }
+
+	testCases := []struct {
+		name         string
+		method       string
+		body         string
+		expectedCode int
+		expected     *config.JobConfigDiff
+	}{
+		{
+			name:   "changed jobs are reported",
+			method: http.MethodPost,
+			body: `presubmits:
+  org/repo:
+  - name: unit
+    always_run: true
+    spec:
+      containers:
+      - image: alpine
+  - name: lint
+    spec:
+      containers:
+      - image: alpine
+`,
+			expectedCode: http.StatusOK,
+			expected: &config.JobConfigDiff{
+				Presubmits: config.JobChanges{
+					Added:    []string{"org/repo:lint"},
+					Removed:  []string{"org/repo:e2e"},
+					Modified: []string{"org/repo:unit"},
+				},
+			},
+		},
+		{
+			name:         "GET is rejected",
+			method:       http.MethodGet,
+			expectedCode: http.StatusMethodNotAllowed,
+		},
+		{
+			name:         "malformed yaml is rejected",
+			method:       http.MethodPost,
+			body:         "presubmits: [",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:   "invalid jobs are rejected",
+			method: http.MethodPost,
+			body: `presubmits:
+  org/repo:
+  - name: no-spec
+`,
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:         "oversized job config is rejected",
+			method:       http.MethodPost,
+			body:         strings.Repeat("#", maxJobConfigSize+1),
+			expectedCode: http.StatusRequestEntityTooLarge,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, "/config-diff", bytes.NewBufferString(tc.body))
+			if err != nil {
+				t.Fatalf("Error making request: %v", err)
+			}
+			rr := httptest.NewRecorder()
+			handleConfigDiff(cfg, logrus.WithField("handler", "/config-diff")).ServeHTTP(rr, req)
+			if rr.Code != tc.expectedCode {
+				t.Fatalf("Expected status code %d, got %d: %s", tc.expectedCode, rr.Code, rr.Body.String())
+			}
+			if tc.expected == nil {
+				return
+			}
+			var actual config.JobConfigDiff
+			if err := json.Unmarshal(rr.Body.Bytes(), &actual); err != nil {
+				t.Fatalf("Error unmarshaling response: %v", err)
+			}
+			if !reflect.DeepEqual(&actual, tc.expected) {
+				t.Errorf("Expected diff %#v, got %#v", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/clarketm/prow/config"
+	"github.com/clarketm/prow/pod-utils/gcs"
+)
+
+// handleRetention serves the retention metadata that the job run given by
+// the src query parameter uploaded, so that GCS lifecycle tooling can tell
+// how long its artifacts are worth keeping. Runs without retention metadata
+// are answered with a 404.
+func handleRetention(af artifactFetcher, cfg config.Getter, log *logrus.Entry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setHeadersNoCaching(w)
+		src := r.URL.Query().Get("src")
+		if src == "" {
+			http.Error(w, "The src query parameter is required.", http.StatusBadRequest)
+			return
+		}
+		src, err := af.ResolveSymlink(src)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to resolve src: %v", err), http.StatusBadRequest)
+			return
+		}
+		artifacts, err := af.FetchArtifacts(src, "", cfg().Deck.Spyglass.SizeLimit, []string{gcs.RetentionMetadataName})
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to retrieve retention metadata: %v", err), http.StatusInternalServerError)
+			return
+		}
+		if len(artifacts) == 0 {
+			http.Error(w, "The run has no retention metadata.", http.StatusNotFound)
+			return
+		}
+		contents, err := artifacts[0].ReadAll()
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to read retention metadata: %v", err), http.StatusInternalServerError)
+			return
+		}
+		var retention gcs.Retention
+		if err := json.Unmarshal(contents, &retention); err != nil {
+			log.WithError(err).WithField("src", src).Warn("Error unmarshaling retention metadata.")
+			http.Error(w, fmt.Sprintf("Failed to unmarshal retention metadata: %v", err), http.StatusInternalServerError)
+			return
+		}
+		b, err := json.Marshal(retention)
+		if err != nil {
+			log.WithError(err).Error("Error marshaling retention metadata.")
+			http.Error(w, "Failed to marshal retention metadata.", http.StatusInternalServerError)
+			return
+		}
+		writeJSONResponse(w, r, b)
+	}
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/clarketm/prow/config"
+	"github.com/clarketm/prow/pod-utils/gcs"
+)
+
+func TestHandleRetention(t *testing.T) {
+	testCases := []struct {
+		name          string
+		query         string
+		artifacts     map[string]string
+		expectedCode  int
+		expectedClass string
+	}{
+		{
+			name:          "retention metadata is served",
+			query:         "src=gcs/bucket/logs/job/123",
+			artifacts:     map[string]string{"retention.json": `{"class": "long"}`, "build-log.txt": "the build log"},
+			expectedCode:  http.StatusOK,
+			expectedClass: "long",
+		},
+		{
+			name:         "run without retention metadata",
+			query:        "src=gcs/bucket/logs/job/123",
+			artifacts:    map[string]string{"build-log.txt": "the build log"},
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "malformed retention metadata",
+			query:        "src=gcs/bucket/logs/job/123",
+			artifacts:    map[string]string{"retention.json": "long"},
+			expectedCode: http.StatusInternalServerError,
+		},
+		{
+			name:         "missing src",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.Deck.Spyglass.SizeLimit = 1000
+			fetcher := &fakeArtifactFetcher{artifacts: tc.artifacts}
+			handler := handleRetention(fetcher, func() *config.Config { return cfg }, logrus.WithField("handler", "/retention"))
+			req, err := http.NewRequest(http.MethodGet, "/retention?"+tc.query, nil)
+			if err != nil {
+				t.Fatalf("Error making request: %v", err)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != tc.expectedCode {
+				t.Fatalf("Expected status code %d, got %d", tc.expectedCode, rr.Code)
+			}
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+			var retention gcs.Retention
+			if err := json.Unmarshal(rr.Body.Bytes(), &retention); err != nil {
+				t.Fatalf("Error unmarshaling response: %v", err)
+			}
+			if retention.Class != tc.expectedClass {
+				t.Errorf("Expected retention class %q, got %q", tc.expectedClass, retention.Class)
+			}
+		})
+	}
+}
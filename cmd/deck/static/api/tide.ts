@@ -8,6 +8,9 @@ export interface TideQuery {
   includedBranches?: string[];
   labels?: string[];
   missingLabels?: string[];
+  requiredMergeLabels?: string[];
+  autoMergeLabelRequired?: boolean;
+  requiredStatusContexts?: string[];
   milestone?: string;
   reviewApprovedRequired?: boolean;
 }
@@ -30,7 +33,7 @@ export interface PullRequest extends BasePullRequest {
   };
 }
 
-export type Action = "WAIT" | "TRIGGER" | "TRIGGER_BATCH" | "MERGE" | "MERGE_BATCH" | "BLOCKED";
+export type Action = "WAIT" | "TRIGGER" | "TRIGGER_BATCH" | "MERGE" | "MERGE_BATCH" | "BLOCKED" | "PAUSED";
 
 export interface Blocker {
   Number: number;
@@ -52,6 +55,8 @@ export interface TidePool {
   Action: Action;
   Target: PullRequest[];
   Blockers: Blocker[];
+  Freezes: Blocker[];
+  Pauses: Blocker[];
 }
 
 export interface TideData {
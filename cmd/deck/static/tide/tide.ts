@@ -1,4 +1,4 @@
-import {PullRequest, TideData, TidePool} from '../api/tide';
+import {Blocker, PullRequest, TideData, TidePool} from '../api/tide';
 import {tidehistory, tooltip} from '../common/common';
 
 declare const tideData: TideData;
@@ -183,6 +183,14 @@ function redrawQueries(): void {
         fillDetail(tideQuery.labels, "labels", "with ", li, (data) => createLabelEl(data));
         // required to be not present labels
         fillDetail(tideQuery.missingLabels, "labels", "without ", li, (data) => createLabelEl(data));
+        // labels required to enter the merge pool
+        fillDetail(tideQuery.requiredMergeLabels, "labels", "merging only with ", li, (data) => createLabelEl(data));
+        // PRs opt in to merging with the auto merge label
+        if (tideQuery.autoMergeLabelRequired) {
+            li.appendChild(createStrong("merging only "));
+            li.appendChild(document.createTextNode("the PRs that opt in with the auto merge label"));
+            li.appendChild(document.createElement("br"));
+        }
         // list milestone if existed
         fillDetail(tideQuery.milestone, "milestone", "with ", li, (data) => document.createTextNode(data));
         // list all excluded branches
@@ -248,8 +256,9 @@ function createRepoCell(pool: TidePool): HTMLTableDataCellElement {
 function createActionCell(pool: TidePool): HTMLTableDataCellElement {
     const targeted = pool.Target && pool.Target.length;
     const blocked = pool.Blockers && pool.Blockers.length;
+    const paused = pool.Pauses && pool.Pauses.length;
     let action = pool.Action.replace("_", " ");
-    if (targeted || blocked) {
+    if (targeted || blocked || paused) {
         action += ": ";
     }
     const c = document.createElement("td");
@@ -257,10 +266,19 @@ function createActionCell(pool: TidePool): HTMLTableDataCellElement {
 
     if (blocked) {
         c.classList.add("blocked");
-        addBlockersToElem(c, pool);
+        addBlockersToElem(c, pool, pool.Blockers);
+    } else if (paused) {
+        c.classList.add("blocked");
+        addBlockersToElem(c, pool, pool.Pauses);
     } else if (targeted) {
         addPRsToElem(c, pool, pool.Target);
     }
+    if (!blocked && pool.Freezes && pool.Freezes.length) {
+        c.classList.add("blocked");
+        c.appendChild(document.createTextNode(" (merges frozen by "));
+        addBlockersToElem(c, pool, pool.Freezes);
+        c.appendChild(document.createTextNode(")"));
+    }
     return c;
 }
 
@@ -321,13 +339,13 @@ function addPRsToElem(elem: HTMLElement, pool: TidePool, prs?: PullRequest[]): v
 }
 
 // addBlockersToElem adds a space separated list of Issue numbers that link to the
-// corresponding Issues on github that are blocking merge.
-function addBlockersToElem(elem: HTMLElement, pool: TidePool): void {
-    if (!pool.Blockers) {
+// corresponding Issues on github that are blocking or freezing merge.
+function addBlockersToElem(elem: HTMLElement, pool: TidePool, blockers?: Blocker[]): void {
+    if (!blockers) {
         return;
     }
-    for (let i = 0; i < pool.Blockers.length; i++) {
-        const b = pool.Blockers[i];
+    for (let i = 0; i < blockers.length; i++) {
+        const b = blockers[i];
         const a = document.createElement("a");
         a.href = b.URL;
         a.appendChild(document.createTextNode("#" + b.Number));
@@ -336,7 +354,7 @@ function addBlockersToElem(elem: HTMLElement, pool: TidePool): void {
 
         elem.appendChild(a);
         // Add a space after each PR number except the last.
-        if (i + 1 < pool.Blockers.length) {
+        if (i + 1 < blockers.length) {
             elem.appendChild(document.createTextNode(" "));
         }
     }
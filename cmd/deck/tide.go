@@ -40,6 +40,106 @@ type tidePools struct {
 
 type tideHistory struct {
 	History map[string][]history.Record
+	// Count is the total number of records in History.
+	Count int
+}
+
+// tideHistoryFilter selects the history records served by /tide-history.js.
+// Empty fields match everything.
+type tideHistoryFilter struct {
+	org    string
+	repo   string
+	branch string
+	since  time.Time
+}
+
+// filter returns the records from the pools matching the filter that were
+// recorded after f.since, dropping pools left without any records.
+func (f tideHistoryFilter) filter(hist map[string][]history.Record) map[string][]history.Record {
+	if f == (tideHistoryFilter{}) {
+		return hist
+	}
+	filtered := make(map[string][]history.Record, len(hist))
+	for pool, records := range hist {
+		orgRepo, branch := pool, ""
+		if i := strings.LastIndex(pool, ":"); i >= 0 {
+			orgRepo, branch = pool[:i], pool[i+1:]
+		}
+		org, repo := orgRepo, ""
+		if i := strings.Index(orgRepo, "/"); i >= 0 {
+			org, repo = orgRepo[:i], orgRepo[i+1:]
+		}
+		if (f.org != "" && f.org != org) || (f.repo != "" && f.repo != repo) || (f.branch != "" && f.branch != branch) {
+			continue
+		}
+		var kept []history.Record
+		for _, record := range records {
+			if record.Time.Before(f.since) {
+				continue
+			}
+			kept = append(kept, record)
+		}
+		if len(kept) > 0 {
+			filtered[pool] = kept
+		}
+	}
+	return filtered
+}
+
+// mergeStats summarizes the merges Tide made in a repo.
+type mergeStats struct {
+	Org  string `json:"org"`
+	Repo string `json:"repo"`
+	// Merges is the number of PRs Tide merged.
+	Merges int `json:"merges"`
+	// AveragePoolWaitSeconds is the average time between Tide first
+	// triggering tests for a merged PR and merging it. PRs Tide never
+	// triggered tests for are not taken into account.
+	AveragePoolWaitSeconds float64 `json:"average_pool_wait_seconds"`
+}
+
+// computeMergeStats computes the merge statistics of a repo from the history
+// records of its pools, counting the merges made after since. Failed actions
+// are ignored.
+func computeMergeStats(hist map[string][]history.Record, org, repo string, since time.Time) mergeStats {
+	type pullKey struct {
+		number int
+		sha    string
+	}
+	stats := mergeStats{Org: org, Repo: repo}
+	var totalWait time.Duration
+	var waited int
+	for _, records := range (tideHistoryFilter{org: org, repo: repo}).filter(hist) {
+		// Triggers older than since still count towards the wait of later merges.
+		firstTriggered := map[pullKey]time.Time{}
+		for _, record := range records {
+			if (record.Action != tide.Trigger && record.Action != tide.TriggerBatch) || record.Err != "" {
+				continue
+			}
+			for _, pull := range record.Target {
+				key := pullKey{number: pull.Number, sha: pull.SHA}
+				if t, ok := firstTriggered[key]; !ok || record.Time.Before(t) {
+					firstTriggered[key] = record.Time
+				}
+			}
+		}
+		for _, record := range records {
+			if (record.Action != tide.Merge && record.Action != tide.MergeBatch) || record.Err != "" || record.Time.Before(since) {
+				continue
+			}
+			for _, pull := range record.Target {
+				stats.Merges++
+				if t, ok := firstTriggered[pullKey{number: pull.Number, sha: pull.SHA}]; ok {
+					totalWait += record.Time.Sub(t)
+					waited++
+				}
+			}
+		}
+	}
+	if waited > 0 {
+		stats.AveragePoolWaitSeconds = (totalWait / time.Duration(waited)).Seconds()
+	}
+	return stats
 }
 
 type tideAgent struct {
@@ -18,6 +18,8 @@ package main
 
 import (
 	"flag"
+	"fmt"
+	"net/url"
 	"os"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -34,14 +36,16 @@ import (
 )
 
 type options struct {
-	configPath string
-	kubernetes prowflagutil.KubernetesOptions
+	configPath   string
+	otelEndpoint string
+	kubernetes   prowflagutil.KubernetesOptions
 }
 
 func gatherOptions(fs *flag.FlagSet, args ...string) options {
 	var o options
 
 	fs.StringVar(&o.configPath, "config-path", "", "Path to config.yaml.")
+	fs.StringVar(&o.otelEndpoint, "otel-endpoint", "", "If set, additionally push metrics to the OpenTelemetry collector at this OTLP/HTTP endpoint, e.g. http://otel-collector:4318/v1/metrics.")
 
 	o.kubernetes.AddFlags(fs)
 	if err := fs.Parse(os.Args[1:]); err != nil {
@@ -52,6 +56,11 @@ func gatherOptions(fs *flag.FlagSet, args ...string) options {
 }
 
 func (o *options) Validate() error {
+	if o.otelEndpoint != "" {
+		if _, err := url.ParseRequestURI(o.otelEndpoint); err != nil {
+			return fmt.Errorf("invalid --otel-endpoint: %v", err)
+		}
+	}
 	return o.kubernetes.Validate(false)
 }
 
@@ -101,6 +110,12 @@ func main() {
 	// Expose prometheus metrics
 	metrics.ExposeMetricsWithRegistry("exporter", cfg().PushGateway, registry)
 
+	// Optionally push the same metrics to an OpenTelemetry collector
+	if exporter := initOTLPExporter(o.otelEndpoint, registry); exporter != nil {
+		logrus.WithField("endpoint", o.otelEndpoint).Info("Exporting metrics to OpenTelemetry collector.")
+		interrupts.TickLiteral(exporter.export, otlpExportInterval)
+	}
+
 	logrus.Info("exporter is running ...")
 	health.ServeReady()
 }
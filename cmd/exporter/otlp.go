@@ -0,0 +1,202 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/sirupsen/logrus"
+)
+
+const otlpExportInterval = time.Minute
+
+// processStart is when the counters of the exporter started accumulating.
+var processStart = time.Now()
+
+// otlpExporter periodically pushes the metrics gathered from a Prometheus
+// registry to an OpenTelemetry collector using the OTLP/HTTP JSON encoding.
+// Only gauges and counters are exported; other metric types are skipped.
+type otlpExporter struct {
+	endpoint string
+	gatherer prometheus.Gatherer
+	client   *http.Client
+	now      func() time.Time
+	// start is reported as the start time of the cumulative sums.
+	start time.Time
+}
+
+// initOTLPExporter returns an exporter pushing to endpoint, or nil if no
+// endpoint is configured.
+func initOTLPExporter(endpoint string, gatherer prometheus.Gatherer) *otlpExporter {
+	if endpoint == "" {
+		return nil
+	}
+	return &otlpExporter{
+		endpoint: endpoint,
+		gatherer: gatherer,
+		client:   &http.Client{Timeout: 30 * time.Second},
+		now:      time.Now,
+		start:    processStart,
+	}
+}
+
+// The following types model the subset of the OTLP metrics JSON encoding
+// that the exporter needs.
+// See https://github.com/open-telemetry/opentelemetry-proto/blob/main/opentelemetry/proto/metrics/v1/metrics.proto
+type otlpRequest struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     otlpResource       `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlpScope    `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpMetric struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Gauge       *otlpGauge `json:"gauge,omitempty"`
+	Sum         *otlpSum   `json:"sum,omitempty"`
+}
+
+type otlpGauge struct {
+	DataPoints []otlpDataPoint `json:"dataPoints"`
+}
+
+type otlpSum struct {
+	DataPoints             []otlpDataPoint `json:"dataPoints"`
+	AggregationTemporality int             `json:"aggregationTemporality"`
+	IsMonotonic            bool            `json:"isMonotonic"`
+}
+
+// otlpCumulative is AGGREGATION_TEMPORALITY_CUMULATIVE, which matches the
+// semantics of Prometheus counters.
+const otlpCumulative = 2
+
+type otlpDataPoint struct {
+	Attributes []otlpAttribute `json:"attributes,omitempty"`
+	// StartTimeUnixNano is only set for cumulative sums.
+	StartTimeUnixNano string  `json:"startTimeUnixNano,omitempty"`
+	TimeUnixNano      string  `json:"timeUnixNano"`
+	AsDouble          float64 `json:"asDouble"`
+}
+
+type otlpAttribute struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+// export gathers the current metrics and pushes them to the collector.
+func (e *otlpExporter) export() {
+	if err := e.push(); err != nil {
+		logrus.WithError(err).Warn("Failed to export metrics to the OpenTelemetry collector.")
+	}
+}
+
+func (e *otlpExporter) push() error {
+	families, err := e.gatherer.Gather()
+	if err != nil {
+		return fmt.Errorf("failed to gather metrics: %v", err)
+	}
+	body, err := json.Marshal(e.convert(families))
+	if err != nil {
+		return fmt.Errorf("failed to marshal metrics: %v", err)
+	}
+	resp, err := e.client.Post(e.endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("collector responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (e *otlpExporter) convert(families []*dto.MetricFamily) otlpRequest {
+	timestamp := strconv.FormatInt(e.now().UnixNano(), 10)
+	start := strconv.FormatInt(e.start.UnixNano(), 10)
+	var metrics []otlpMetric
+	for _, family := range families {
+		var points []otlpDataPoint
+		for _, m := range family.GetMetric() {
+			var value float64
+			switch family.GetType() {
+			case dto.MetricType_GAUGE:
+				value = m.GetGauge().GetValue()
+			case dto.MetricType_COUNTER:
+				value = m.GetCounter().GetValue()
+			case dto.MetricType_UNTYPED:
+				value = m.GetUntyped().GetValue()
+			default:
+				continue
+			}
+			var attributes []otlpAttribute
+			for _, label := range m.GetLabel() {
+				attributes = append(attributes, otlpAttribute{Key: label.GetName(), Value: otlpAnyValue{StringValue: label.GetValue()}})
+			}
+			point := otlpDataPoint{Attributes: attributes, TimeUnixNano: timestamp, AsDouble: value}
+			if family.GetType() == dto.MetricType_COUNTER {
+				point.StartTimeUnixNano = start
+			}
+			points = append(points, point)
+		}
+		if len(points) == 0 {
+			continue
+		}
+		metric := otlpMetric{Name: family.GetName(), Description: family.GetHelp()}
+		if family.GetType() == dto.MetricType_COUNTER {
+			metric.Sum = &otlpSum{DataPoints: points, AggregationTemporality: otlpCumulative, IsMonotonic: true}
+		} else {
+			metric.Gauge = &otlpGauge{DataPoints: points}
+		}
+		metrics = append(metrics, metric)
+	}
+	return otlpRequest{ResourceMetrics: []otlpResourceMetrics{{
+		Resource: otlpResource{Attributes: []otlpAttribute{
+			{Key: "service.name", Value: otlpAnyValue{StringValue: "exporter"}},
+		}},
+		ScopeMetrics: []otlpScopeMetrics{{
+			Scope:   otlpScope{Name: "github.com/clarketm/prow/cmd/exporter"},
+			Metrics: metrics,
+		}},
+	}}}
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestInitOTLPExporter(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	if exporter := initOTLPExporter("", registry); exporter != nil {
+		t.Errorf("expected no exporter without an endpoint, got %#v", exporter)
+	}
+	exporter := initOTLPExporter("http://collector:4318/v1/metrics", registry)
+	if exporter == nil {
+		t.Fatal("expected an exporter to be initialized when an endpoint is set")
+	}
+	if exporter.endpoint != "http://collector:4318/v1/metrics" {
+		t.Errorf("expected endpoint to be set, got %q", exporter.endpoint)
+	}
+}
+
+func TestOTLPExporterPush(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "some_gauge", Help: "A gauge."}, []string{"job_name"})
+	gauge.WithLabelValues("some-job").Set(1)
+	counter := prometheus.NewCounter(prometheus.CounterOpts{Name: "some_counter", Help: "A counter."})
+	counter.Add(3)
+	summary := prometheus.NewSummary(prometheus.SummaryOpts{Name: "some_summary", Help: "A summary."})
+	summary.Observe(1)
+	registry.MustRegister(gauge, counter, summary)
+
+	var got otlpRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("expected POST, got %s", r.Method)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("expected JSON content type, got %q", ct)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	exporter := initOTLPExporter(server.URL, registry)
+	exporter.now = func() time.Time { return time.Unix(0, 42) }
+	exporter.start = time.Unix(0, 7)
+	if err := exporter.push(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(got.ResourceMetrics) != 1 || len(got.ResourceMetrics[0].ScopeMetrics) != 1 {
+		t.Fatalf("unexpected request shape: %#v", got)
+	}
+	metrics := got.ResourceMetrics[0].ScopeMetrics[0].Metrics
+	if len(metrics) != 2 {
+		t.Fatalf("expected the counter and the gauge to be exported, got %#v", metrics)
+	}
+	byName := map[string]otlpMetric{}
+	for _, m := range metrics {
+		byName[m.Name] = m
+	}
+	counterMetric := byName["some_counter"]
+	if counterMetric.Sum == nil || !counterMetric.Sum.IsMonotonic || counterMetric.Sum.DataPoints[0].AsDouble != 3 {
+		t.Fatalf("unexpected counter: %#v", counterMetric)
+	}
+	if start := counterMetric.Sum.DataPoints[0].StartTimeUnixNano; start != "7" {
+		t.Errorf("expected the counter to start at the process start, got %q", start)
+	}
+	gaugeMetric := byName["some_gauge"]
+	if gaugeMetric.Gauge == nil || len(gaugeMetric.Gauge.DataPoints) != 1 {
+		t.Fatalf("unexpected gauge: %#v", gaugeMetric)
+	}
+	point := gaugeMetric.Gauge.DataPoints[0]
+	if point.AsDouble != 1 || point.TimeUnixNano != "42" || point.StartTimeUnixNano != "" {
+		t.Errorf("unexpected gauge data point: %#v", point)
+	}
+	if len(point.Attributes) != 1 || point.Attributes[0].Key != "job_name" || point.Attributes[0].Value.StringValue != "some-job" {
+		t.Errorf("unexpected gauge attributes: %#v", point.Attributes)
+	}
+}
+
+func TestOTLPExporterPushError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	if err := initOTLPExporter(server.URL, prometheus.NewRegistry()).push(); err == nil {
+		t.Error("expected an error when the collector fails")
+	}
+}
@@ -49,6 +49,7 @@ type options struct {
 	lastSyncFallback   string
 	dryRun             bool
 	kubernetes         prowflagutil.KubernetesOptions
+	logLevel           logrusutil.LogLevelOptions
 }
 
 func (o *options) Validate() error {
@@ -64,6 +65,10 @@ func (o *options) Validate() error {
 		return errors.New("--config-path must be set")
 	}
 
+	if err := o.logLevel.Validate(); err != nil {
+		return err
+	}
+
 	if o.lastSyncFallback == "" {
 		return errors.New("--last-sync-fallback must be set")
 	}
@@ -85,6 +90,7 @@ func gatherOptions(fs *flag.FlagSet, args ...string) options {
 	fs.StringVar(&o.gcsCredentialsFile, "gcs-credentials-file", "", "Path to GCS credentials. Required for a --last-sync-fallback=gs://path")
 	fs.BoolVar(&o.dryRun, "dry-run", false, "Run in dry-run mode, performing no modifying actions.")
 	o.kubernetes.AddFlags(fs)
+	o.logLevel.AddFlags(fs)
 	fs.Parse(args)
 	return o
 }
@@ -223,8 +229,11 @@ func main() {
 	if err := o.Validate(); err != nil {
 		logrus.Fatalf("Invalid options: %v", err)
 	}
+	if err := o.logLevel.Apply(); err != nil {
+		logrus.WithError(err).Fatal("Error setting log level.")
+	}
 
-	ca := &config.Agent{}
+	ca := &config.Agent{LogLevel: o.logLevel.LogLevel}
 	if err := ca.Start(o.configPath, o.jobConfigPath); err != nil {
 		logrus.WithError(err).Fatal("Error starting config agent.")
 	}
@@ -78,6 +78,22 @@ func TestFlags(t *testing.T) {
 			del:      sets.NewString("--dry-run"),
 			expected: func(o *options) {},
 		},
+		{
+			name: "explicitly set --log-level",
+			args: map[string]string{
+				"--log-level": "debug",
+			},
+			expected: func(o *options) {
+				o.logLevel.LogLevel = "debug"
+			},
+		},
+		{
+			name: "unknown --log-level is rejected",
+			args: map[string]string{
+				"--log-level": "chatty",
+			},
+			err: true,
+		},
 	}
 
 	for _, tc := range cases {
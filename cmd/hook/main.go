@@ -18,6 +18,7 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"net/http"
 	"os"
 	"strconv"
@@ -49,17 +50,21 @@ type options struct {
 	jobConfigPath string
 	pluginConfig  string
 
-	dryRun      bool
-	gracePeriod time.Duration
-	kubernetes  prowflagutil.KubernetesOptions
-	github      prowflagutil.GitHubOptions
-	bugzilla    prowflagutil.BugzillaOptions
+	dryRun            bool
+	gracePeriod       time.Duration
+	pluginConcurrency int
+	kubernetes        prowflagutil.KubernetesOptions
+	github            prowflagutil.GitHubOptions
+	bugzilla          prowflagutil.BugzillaOptions
 
 	webhookSecretFile string
 	slackTokenFile    string
 }
 
 func (o *options) Validate() error {
+	if o.pluginConcurrency < 0 {
+		return fmt.Errorf("--plugin-concurrency must not be negative, got %d", o.pluginConcurrency)
+	}
 	for _, group := range []flagutil.OptionGroup{&o.kubernetes, &o.github, &o.bugzilla} {
 		if err := group.Validate(o.dryRun); err != nil {
 			return err
@@ -79,6 +84,7 @@ func gatherOptions(fs *flag.FlagSet, args ...string) options {
 
 	fs.BoolVar(&o.dryRun, "dry-run", true, "Dry run for testing. Uses API tokens but does not mutate.")
 	fs.DurationVar(&o.gracePeriod, "grace-period", 180*time.Second, "On shutdown, try to handle remaining events for the specified duration. ")
+	fs.IntVar(&o.pluginConcurrency, "plugin-concurrency", 0, "Maximum number of plugins handling a single event in parallel. Zero means no limit.")
 	for _, group := range []flagutil.OptionGroup{&o.kubernetes, &o.github, &o.bugzilla} {
 		group.AddFlags(fs)
 	}
@@ -202,11 +208,12 @@ func main() {
 	pjutil.ServePProf()
 
 	server := &hook.Server{
-		ClientAgent:    clientAgent,
-		ConfigAgent:    configAgent,
-		Plugins:        pluginAgent,
-		Metrics:        promMetrics,
-		TokenGenerator: secretAgent.GetTokenGenerator(o.webhookSecretFile),
+		ClientAgent:       clientAgent,
+		ConfigAgent:       configAgent,
+		Plugins:           pluginAgent,
+		Metrics:           promMetrics,
+		TokenGenerator:    secretAgent.GetTokenGenerator(o.webhookSecretFile),
+		PluginConcurrency: o.pluginConcurrency,
 	}
 	interrupts.OnInterrupt(func() {
 		server.GracefulShutdown()
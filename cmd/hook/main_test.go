@@ -90,6 +90,21 @@ func Test_gatherOptions(t *testing.T) {
 			expected: func(o *options) {
 				o.pluginConfig = "/random/value"
 			},
+		}, {
+			name: "explicitly set --plugin-concurrency",
+			args: map[string]string{
+				"--plugin-concurrency": "5",
+			},
+			expected: func(o *options) {
+				o.pluginConcurrency = 5
+			},
+		},
+		{
+			name: "negative --plugin-concurrency is rejected",
+			args: map[string]string{
+				"--plugin-concurrency": "-1",
+			},
+			err: true,
 		},
 	}
 	for _, tc := range cases {
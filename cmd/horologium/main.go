@@ -21,6 +21,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"sort"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -43,6 +44,7 @@ type options struct {
 
 	kubernetes flagutil.KubernetesOptions
 	dryRun     flagutil.Bool
+	logLevel   logrusutil.LogLevelOptions
 }
 
 func gatherOptions(fs *flag.FlagSet, args ...string) options {
@@ -53,6 +55,7 @@ func gatherOptions(fs *flag.FlagSet, args ...string) options {
 	// TODO(fejta): switch dryRun to be a bool, defaulting to true after March 15, 2019.
 	fs.Var(&o.dryRun, "dry-run", "Whether or not to make mutating API calls to Kubernetes.")
 	o.kubernetes.AddFlags(fs)
+	o.logLevel.AddFlags(fs)
 
 	fs.Parse(args)
 	o.configPath = config.ConfigPath(o.configPath)
@@ -64,6 +67,10 @@ func (o *options) Validate() error {
 		return err
 	}
 
+	if err := o.logLevel.Validate(); err != nil {
+		return err
+	}
+
 	if o.configPath == "" {
 		return errors.New("--config-path is required")
 	}
@@ -78,6 +85,9 @@ func main() {
 	if err := o.Validate(); err != nil {
 		logrus.WithError(err).Fatal("Invalid options")
 	}
+	if err := o.logLevel.Apply(); err != nil {
+		logrus.WithError(err).Fatal("Error setting log level.")
+	}
 
 	defer interrupts.WaitForGracefulShutdown()
 
@@ -88,7 +98,7 @@ func main() {
 		logrus.Warning("--dry-run will soon default to true. Set --dry-run=false by March 15.")
 	}
 
-	configAgent := config.Agent{}
+	configAgent := config.Agent{LogLevel: o.logLevel.LogLevel}
 	if err := configAgent.Start(o.configPath, o.jobConfigPath); err != nil {
 		logrus.WithError(err).Fatal("Error starting config agent.")
 	}
@@ -120,6 +130,33 @@ type cronClient interface {
 	QueuedJobs() []string
 }
 
+// periodicLabels returns the labels of the periodic merged over the extra
+// labels configured for all periodics.
+func periodicLabels(cfg *config.Config, p config.Periodic) map[string]string {
+	if len(cfg.Horologium.ExtraLabels) == 0 {
+		return p.Labels
+	}
+	labels := make(map[string]string, len(cfg.Horologium.ExtraLabels)+len(p.Labels))
+	for k, v := range cfg.Horologium.ExtraLabels {
+		labels[k] = v
+	}
+	for k, v := range p.Labels {
+		labels[k] = v
+	}
+	return labels
+}
+
+// periodicsByPriority returns the periodics ordered by descending startup
+// priority, keeping the config order for equal priorities.
+func periodicsByPriority(periodics []config.Periodic) []config.Periodic {
+	sorted := make([]config.Periodic, len(periodics))
+	copy(sorted, periodics)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].StartupPriority > sorted[j].StartupPriority
+	})
+	return sorted
+}
+
 func sync(prowJobClient prowJobClient, cfg *config.Config, cr cronClient, now time.Time) error {
 	jobs, err := prowJobClient.List(metav1.ListOptions{LabelSelector: labels.Everything().String()})
 	if err != nil {
@@ -137,7 +174,7 @@ func sync(prowJobClient prowJobClient, cfg *config.Config, cr cronClient, now ti
 	}
 
 	var errs []error
-	for _, p := range cfg.Periodics {
+	for _, p := range periodicsByPriority(cfg.Periodics) {
 		j, previousFound := latestJobs[p.Name]
 		logger := logrus.WithFields(logrus.Fields{
 			"job":            p.Name,
@@ -148,7 +185,7 @@ func sync(prowJobClient prowJobClient, cfg *config.Config, cr cronClient, now ti
 			shouldTrigger := j.Complete() && now.Sub(j.Status.StartTime.Time) > p.GetInterval()
 			logger = logger.WithField("should-trigger", shouldTrigger)
 			if !previousFound || shouldTrigger {
-				prowJob := pjutil.NewProwJob(pjutil.PeriodicSpec(p), p.Labels, p.Annotations)
+				prowJob := pjutil.NewProwJob(pjutil.PeriodicSpec(p), periodicLabels(cfg, p), p.Annotations)
 				logger.WithFields(pjutil.ProwJobFields(&prowJob)).Info("Triggering new run of interval periodic.")
 				if _, err := prowJobClient.Create(&prowJob); err != nil {
 					errs = append(errs, err)
@@ -158,7 +195,7 @@ func sync(prowJobClient prowJobClient, cfg *config.Config, cr cronClient, now ti
 			shouldTrigger := j.Complete()
 			logger = logger.WithField("should-trigger", shouldTrigger)
 			if !previousFound || shouldTrigger {
-				prowJob := pjutil.NewProwJob(pjutil.PeriodicSpec(p), p.Labels, p.Annotations)
+				prowJob := pjutil.NewProwJob(pjutil.PeriodicSpec(p), periodicLabels(cfg, p), p.Annotations)
 				logger.WithFields(pjutil.ProwJobFields(&prowJob)).Info("Triggering new run of cron periodic.")
 				if _, err := prowJobClient.Create(&prowJob); err != nil {
 					errs = append(errs, err)
@@ -159,6 +159,84 @@ func TestSync(t *testing.T) {
 	}
 }
 
+func TestSyncExtraLabels(t *testing.T) {
+	cfg := config.Config{
+		ProwConfig: config.ProwConfig{
+			ProwJobNamespace: "prowjobs",
+			Horologium: config.Horologium{
+				ExtraLabels: map[string]string{"cost-center": "ci", "team": "default"},
+			},
+		},
+		JobConfig: config.JobConfig{
+			Periodics: []config.Periodic{{JobBase: config.JobBase{
+				Name:   "j",
+				Labels: map[string]string{"team": "sig-testing"},
+			}}},
+		},
+	}
+	cfg.Periodics[0].SetInterval(time.Minute)
+
+	fakeProwJobClient := fake.NewSimpleClientset()
+	if err := sync(fakeProwJobClient.ProwV1().ProwJobs(cfg.ProwJobNamespace), &cfg, &fakeCron{}, time.Now()); err != nil {
+		t.Fatalf("didn't expect error: %v", err)
+	}
+	pjs, err := fakeProwJobClient.ProwV1().ProwJobs(cfg.ProwJobNamespace).List(metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("failed to list prowjobs: %v", err)
+	}
+	if len(pjs.Items) != 1 {
+		t.Fatalf("expected one prowjob, got %d", len(pjs.Items))
+	}
+	labels := pjs.Items[0].Labels
+	if labels["cost-center"] != "ci" {
+		t.Errorf("expected extra label cost-center=ci, got labels %v", labels)
+	}
+	if labels["team"] != "sig-testing" {
+		t.Errorf("expected the periodic's team label to take precedence, got labels %v", labels)
+	}
+	if len(cfg.Periodics[0].Labels) != 1 {
+		t.Errorf("extra labels leaked into the periodic config: %v", cfg.Periodics[0].Labels)
+	}
+}
+
+func TestSyncStartupPriority(t *testing.T) {
+	cfg := config.Config{
+		ProwConfig: config.ProwConfig{
+			ProwJobNamespace: "prowjobs",
+		},
+		JobConfig: config.JobConfig{
+			Periodics: []config.Periodic{
+				{JobBase: config.JobBase{Name: "low"}, StartupPriority: -1},
+				{JobBase: config.JobBase{Name: "default"}},
+				{JobBase: config.JobBase{Name: "high"}, StartupPriority: 10},
+				{JobBase: config.JobBase{Name: "also-default"}},
+				{JobBase: config.JobBase{Name: "medium"}, StartupPriority: 5},
+			},
+		},
+	}
+	for i := range cfg.Periodics {
+		cfg.Periodics[i].SetInterval(time.Minute)
+	}
+
+	fakeProwJobClient := fake.NewSimpleClientset()
+	if err := sync(fakeProwJobClient.ProwV1().ProwJobs(cfg.ProwJobNamespace), &cfg, &fakeCron{}, time.Now()); err != nil {
+		t.Fatalf("didn't expect error: %v", err)
+	}
+	var created []string
+	for _, action := range fakeProwJobClient.Actions() {
+		if create, ok := action.(clienttesting.CreateAction); ok {
+			created = append(created, create.GetObject().(*prowapi.ProwJob).Spec.Job)
+		}
+	}
+	expected := []string{"high", "medium", "default", "also-default", "low"}
+	if !reflect.DeepEqual(created, expected) {
+		t.Errorf("expected periodics to be triggered in order %v, got %v", expected, created)
+	}
+	if cfg.Periodics[0].Name != "low" {
+		t.Errorf("sync reordered the periodics in the config: %v", cfg.Periodics)
+	}
+}
+
 // Test sync periodic job scheduled by cron.
 func TestSyncCron(t *testing.T) {
 	testcases := []struct {
@@ -310,6 +388,22 @@ func TestFlags(t *testing.T) {
 				o.dryRun = flagutil.Bool{}
 			},
 		},
+		{
+			name: "explicitly set --log-level",
+			args: map[string]string{
+				"--log-level": "debug",
+			},
+			expected: func(o *options) {
+				o.logLevel.LogLevel = "debug"
+			},
+		},
+		{
+			name: "unknown --log-level is rejected",
+			args: map[string]string{
+				"--log-level": "chatty",
+			},
+			err: true,
+		},
 	}
 
 	for _, tc := range cases {
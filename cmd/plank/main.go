@@ -118,7 +118,7 @@ func main() {
 		logrus.WithError(err).Fatal("Error getting prowjob client.")
 	}
 
-	buildClusterClients, err := o.kubernetes.BuildClusterClients(cfg().PodNamespace, o.dryRun)
+	buildClusterClients, err := o.kubernetes.BuildClusterClients(cfg().PodNamespaceForCluster, o.dryRun)
 	if err != nil {
 		logrus.WithError(err).Fatal("Error creating build cluster clients.")
 	}
@@ -30,9 +30,11 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/test-infra/pkg/io"
 	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	ctrlruntimelog "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+	"sigs.k8s.io/yaml"
 
 	prowapi "github.com/clarketm/prow/apis/prowjobs/v1"
 	"github.com/clarketm/prow/config"
@@ -45,11 +47,13 @@ import (
 )
 
 type options struct {
-	runOnce       bool
-	configPath    string
-	jobConfigPath string
-	dryRun        flagutil.Bool
-	kubernetes    flagutil.KubernetesOptions
+	runOnce            bool
+	configPath         string
+	jobConfigPath      string
+	gcsCredentialsFile string
+	dryRun             flagutil.Bool
+	kubernetes         flagutil.KubernetesOptions
+	logLevel           logrusutil.LogLevelOptions
 }
 
 const (
@@ -58,6 +62,8 @@ const (
 
 	reasonProwJobAged         = "aged"
 	reasonProwJobAgedPeriodic = "aged-periodic"
+
+	reasonProwJobArchiveFailed = "archive-failed"
 )
 
 func gatherOptions(fs *flag.FlagSet, args ...string) options {
@@ -65,11 +71,13 @@ func gatherOptions(fs *flag.FlagSet, args ...string) options {
 	fs.BoolVar(&o.runOnce, "run-once", false, "If true, run only once then quit.")
 	fs.StringVar(&o.configPath, "config-path", "", "Path to config.yaml.")
 	fs.StringVar(&o.jobConfigPath, "job-config-path", "", "Path to prow job configs.")
+	fs.StringVar(&o.gcsCredentialsFile, "gcs-credentials-file", "", "Path to GCS credentials used to archive ProwJobs to sinker.archive_bucket.")
 
 	// TODO(fejta): switch dryRun to be a bool, defaulting to true after March 15, 2019.
 	fs.Var(&o.dryRun, "dry-run", "Whether or not to make mutating API calls to Kubernetes.")
 
 	o.kubernetes.AddFlags(fs)
+	o.logLevel.AddFlags(fs)
 	fs.Parse(args)
 	o.configPath = config.ConfigPath(o.configPath)
 	return o
@@ -80,6 +88,10 @@ func (o *options) Validate() error {
 		return err
 	}
 
+	if err := o.logLevel.Validate(); err != nil {
+		return err
+	}
+
 	if o.configPath == "" {
 		return errors.New("--config-path is required")
 	}
@@ -94,6 +106,9 @@ func main() {
 	if err := o.Validate(); err != nil {
 		logrus.WithError(err).Fatal("Invalid options")
 	}
+	if err := o.logLevel.Apply(); err != nil {
+		logrus.WithError(err).Fatal("Error setting log level.")
+	}
 
 	defer interrupts.WaitForGracefulShutdown()
 
@@ -104,7 +119,7 @@ func main() {
 		logrus.Warning("--dry-run will soon default to true. Set --dry-run=false by March 15.")
 	}
 
-	configAgent := &config.Agent{}
+	configAgent := &config.Agent{LogLevel: o.logLevel.LogLevel}
 	if err := configAgent.Start(o.configPath, o.jobConfigPath); err != nil {
 		logrus.WithError(err).Fatal("Error starting config agent.")
 	}
@@ -133,7 +148,7 @@ func main() {
 		logrus.WithError(err).Fatal("Error creating manager")
 	}
 
-	buildClusterClients, err := o.kubernetes.BuildClusterClients(cfg().PodNamespace, o.dryRun.Value)
+	buildClusterClients, err := o.kubernetes.BuildClusterClients(cfg().PodNamespaceForCluster, o.dryRun.Value)
 	if err != nil {
 		logrus.WithError(err).Fatal("Error creating build cluster clients.")
 	}
@@ -144,13 +159,20 @@ func main() {
 		podClients = append(podClients, client)
 	}
 
+	opener, err := io.NewOpener(context.Background(), o.gcsCredentialsFile)
+	if err != nil {
+		logrus.WithError(err).Fatal("Error creating opener.")
+	}
+
 	c := controller{
 		ctx:           context.Background(),
 		logger:        logrus.NewEntry(logrus.StandardLogger()),
 		prowJobClient: mgr.GetClient(),
 		podClients:    podClients,
+		opener:        opener,
 		config:        cfg,
 		runOnce:       o.runOnce,
+		dryRun:        o.dryRun.Value,
 	}
 	if err := mgr.Add(&c); err != nil {
 		logrus.WithError(err).Fatal("failed to add controller to manager")
@@ -166,8 +188,12 @@ type controller struct {
 	logger        *logrus.Entry
 	prowJobClient ctrlruntimeclient.Client
 	podClients    []corev1.PodInterface
+	opener        io.Opener
 	config        config.Getter
 	runOnce       bool
+	// dryRun keeps archive from uploading ProwJobs. The clients are already
+	// created in dry-run mode, so deletions need no extra guard.
+	dryRun bool
 }
 
 func (c *controller) Start(stopChan <-chan struct{}) error {
@@ -280,6 +306,41 @@ func (m *sinkerReconciliationMetrics) getTimeUsed() time.Duration {
 	return m.finishedAt.Sub(m.startAt)
 }
 
+// archivePath returns where the ProwJob is archived in the bucket.
+func archivePath(bucket string, pj *prowapi.ProwJob) string {
+	return fmt.Sprintf("gs://%s/prowjobs/%s/%s.yaml", bucket, pj.Status.StartTime.UTC().Format("2006-01-02"), pj.Name)
+}
+
+// archive uploads the YAML of the ProwJob to the configured archive bucket,
+// if any. In dry-run mode it only logs where the ProwJob would be archived.
+func (c *controller) archive(pj *prowapi.ProwJob) error {
+	bucket := c.config().Sinker.ArchiveBucket
+	if bucket == "" {
+		return nil
+	}
+	b, err := yaml.Marshal(pj)
+	if err != nil {
+		return fmt.Errorf("marshal: %v", err)
+	}
+	path := archivePath(bucket, pj)
+	if c.dryRun {
+		c.logger.WithFields(pjutil.ProwJobFields(pj)).WithField("path", path).Info("Would archive prowjob (dry-run).")
+		return nil
+	}
+	w, err := c.opener.Writer(c.ctx, path)
+	if err != nil {
+		return fmt.Errorf("open for write %q: %v", path, err)
+	}
+	if _, err := w.Write(b); err != nil {
+		io.LogClose(w)
+		return fmt.Errorf("write %q: %v", path, err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("close %q: %v", path, err)
+	}
+	return nil
+}
+
 func (c *controller) clean() {
 
 	metrics := sinkerReconciliationMetrics{
@@ -315,6 +376,11 @@ func (c *controller) clean() {
 		if time.Since(prowJob.Status.StartTime.Time) <= maxProwJobAge {
 			continue
 		}
+		if err := c.archive(&prowJob); err != nil {
+			c.logger.WithFields(pjutil.ProwJobFields(&prowJob)).WithError(err).Error("Error archiving prowjob.")
+			metrics.prowJobsCleaningErrors[reasonProwJobArchiveFailed]++
+			continue
+		}
 		if err := c.prowJobClient.Delete(c.ctx, &prowJob); err == nil {
 			c.logger.WithFields(pjutil.ProwJobFields(&prowJob)).Info("Deleted prowjob.")
 			metrics.prowJobsCleaned[reasonProwJobAged]++
@@ -351,6 +417,11 @@ func (c *controller) clean() {
 		if time.Since(prowJob.Status.StartTime.Time) <= maxProwJobAge {
 			continue
 		}
+		if err := c.archive(&prowJob); err != nil {
+			c.logger.WithFields(pjutil.ProwJobFields(&prowJob)).WithError(err).Error("Error archiving prowjob.")
+			metrics.prowJobsCleaningErrors[reasonProwJobArchiveFailed]++
+			continue
+		}
 		if err := c.prowJobClient.Delete(c.ctx, &prowJob); err == nil {
 			c.logger.WithFields(pjutil.ProwJobFields(&prowJob)).Info("Deleted prowjob.")
 			metrics.prowJobsCleaned[reasonProwJobAgedPeriodic]++
@@ -371,7 +442,7 @@ func (c *controller) clean() {
 		metrics.podsCreated += len(pods.Items)
 		maxPodAge := c.config().Sinker.MaxPodAge.Duration
 		for _, pod := range pods.Items {
-			clean := !pod.Status.StartTime.IsZero() && time.Since(pod.Status.StartTime.Time) > maxPodAge
+			clean := !pod.Status.StartTime.IsZero() && time.Since(pod.Status.StartTime.Time) > c.podTTL(pod.ObjectMeta, maxPodAge)
 			reason := reasonPodAged
 
 			// by default, use the pod name as the key to match the associated prow job
@@ -399,10 +470,10 @@ func (c *controller) clean() {
 
 			// Delete old finished or orphan pods. Don't quit if we fail to delete one.
 			if err := client.Delete(pod.ObjectMeta.Name, &metav1.DeleteOptions{}); err == nil {
-				c.logger.WithField("pod", pod.ObjectMeta.Name).Info("Deleted old completed pod.")
+				c.logger.WithField("pod", pod.Name).Info("Deleted old completed pod.")
 				metrics.podsRemoved[reason]++
 			} else {
-				c.logger.WithField("pod", pod.ObjectMeta.Name).WithError(err).Error("Error deleting pod.")
+				c.logger.WithField("pod", pod.Name).WithError(err).Error("Error deleting pod.")
 				metrics.podRemovalErrors[string(k8serrors.ReasonForError(err))]++
 			}
 		}
@@ -426,3 +497,18 @@ func (c *controller) clean() {
 	}
 	c.logger.Info("Sinker reconciliation complete.")
 }
+
+// podTTL returns how long the pod should be kept, honoring a per-job TTL set
+// with the kube.PodTTLAnnotation over the configured maximum pod age.
+func (c *controller) podTTL(pod metav1.ObjectMeta, maxPodAge time.Duration) time.Duration {
+	raw, ok := pod.Annotations[kube.PodTTLAnnotation]
+	if !ok {
+		return maxPodAge
+	}
+	ttl, err := time.ParseDuration(raw)
+	if err != nil {
+		c.logger.WithField("pod", pod.Name).WithError(err).Warnf("Invalid %s annotation, using the default max pod age.", kube.PodTTLAnnotation)
+		return maxPodAge
+	}
+	return ttl
+}
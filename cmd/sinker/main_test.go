@@ -17,9 +17,13 @@ limitations under the License.
 package main
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"flag"
+	"io"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -32,6 +36,7 @@ import (
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	clienttesting "k8s.io/client-go/testing"
 	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/yaml"
 
 	prowv1 "github.com/clarketm/prow/apis/prowjobs/v1"
 	"github.com/clarketm/prow/config"
@@ -193,6 +198,57 @@ func TestClean(t *testing.T) {
 				StartTime: startTime(time.Now().Add(-maxPodAge).Add(-time.Second)),
 			},
 		},
+		&corev1api.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "job-complete-pod-short-ttl",
+				Namespace: "ns",
+				Labels: map[string]string{
+					kube.CreatedByProw:  "true",
+					kube.ProwJobIDLabel: "job-complete",
+				},
+				Annotations: map[string]string{
+					kube.PodTTLAnnotation: "1h",
+				},
+			},
+			Status: corev1api.PodStatus{
+				Phase:     corev1api.PodSucceeded,
+				StartTime: startTime(time.Now().Add(-2 * time.Hour)),
+			},
+		},
+		&corev1api.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "job-complete-pod-long-ttl",
+				Namespace: "ns",
+				Labels: map[string]string{
+					kube.CreatedByProw:  "true",
+					kube.ProwJobIDLabel: "job-complete",
+				},
+				Annotations: map[string]string{
+					kube.PodTTLAnnotation: "72h",
+				},
+			},
+			Status: corev1api.PodStatus{
+				Phase:     corev1api.PodSucceeded,
+				StartTime: startTime(time.Now().Add(-maxPodAge).Add(-time.Second)),
+			},
+		},
+		&corev1api.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "job-complete-pod-invalid-ttl",
+				Namespace: "ns",
+				Labels: map[string]string{
+					kube.CreatedByProw:  "true",
+					kube.ProwJobIDLabel: "job-complete",
+				},
+				Annotations: map[string]string{
+					kube.PodTTLAnnotation: "forever",
+				},
+			},
+			Status: corev1api.PodStatus{
+				Phase:     corev1api.PodSucceeded,
+				StartTime: startTime(time.Now().Add(-maxPodAge).Add(-time.Second)),
+			},
+		},
 		&corev1api.Pod{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:      "old-failed",
@@ -325,6 +381,8 @@ func TestClean(t *testing.T) {
 		"job-complete-pod-failed",
 		"job-complete-pod-pending",
 		"job-complete-pod-succeeded",
+		"job-complete-pod-short-ttl",
+		"job-complete-pod-invalid-ttl",
 		"job-unknown-pod-failed",
 		"job-unknown-pod-pending",
 		"job-unknown-pod-succeeded",
@@ -554,6 +612,120 @@ func TestClean(t *testing.T) {
 	assertSetsEqual(deletedProwJobs, actuallyDeletedProwJobs, t, "did not delete correct ProwJobs")
 }
 
+type fakeOpener struct {
+	written map[string][]byte
+	failFor sets.String
+}
+
+type fakeWriter struct {
+	bytes.Buffer
+	path   string
+	opener *fakeOpener
+}
+
+func (w *fakeWriter) Close() error {
+	w.opener.written[w.path] = w.Bytes()
+	return nil
+}
+
+func (o *fakeOpener) Reader(ctx context.Context, path string) (io.ReadCloser, error) {
+	return nil, errors.New("do not call Reader")
+}
+
+func (o *fakeOpener) Writer(ctx context.Context, path string) (io.WriteCloser, error) {
+	if o.failFor.Has(path) {
+		return nil, errors.New("injected failure")
+	}
+	return &fakeWriter{path: path, opener: o}, nil
+}
+
+func TestCleanArchivesProwJobs(t *testing.T) {
+	start := time.Date(2020, 3, 4, 5, 6, 7, 0, time.UTC)
+	oldJob := func(name string) *prowv1.ProwJob {
+		return &prowv1.ProwJob{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns"},
+			Spec:       prowv1.ProwJobSpec{Type: prowv1.PresubmitJob, Job: name},
+			Status: prowv1.ProwJobStatus{
+				State:          prowv1.SuccessState,
+				StartTime:      metav1.NewTime(start),
+				CompletionTime: startTime(start.Add(time.Hour)),
+			},
+		}
+	}
+	testCases := []struct {
+		name             string
+		bucket           string
+		dryRun           bool
+		failFor          sets.String
+		expectedArchived sets.String
+		expectedDeleted  sets.String
+	}{
+		{
+			name:             "no bucket archives nothing",
+			expectedArchived: sets.NewString(),
+			expectedDeleted:  sets.NewString("first", "second"),
+		},
+		{
+			name:             "ProwJobs are archived before deletion",
+			bucket:           "archive",
+			expectedArchived: sets.NewString("gs://archive/prowjobs/2020-03-04/first.yaml", "gs://archive/prowjobs/2020-03-04/second.yaml"),
+			expectedDeleted:  sets.NewString("first", "second"),
+		},
+		{
+			name:             "ProwJobs that fail to archive are kept",
+			bucket:           "archive",
+			failFor:          sets.NewString("gs://archive/prowjobs/2020-03-04/second.yaml"),
+			expectedArchived: sets.NewString("gs://archive/prowjobs/2020-03-04/first.yaml"),
+			expectedDeleted:  sets.NewString("first"),
+		},
+		{
+			name:             "dry-run archives nothing",
+			bucket:           "archive",
+			dryRun:           true,
+			expectedArchived: sets.NewString(),
+			expectedDeleted:  sets.NewString("first", "second"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fpjc := fakectrlruntimeclient.NewFakeClient(oldJob("first"), oldJob("second"))
+			fca := newFakeConfigAgent()
+			fca.c.Sinker.ArchiveBucket = tc.bucket
+			opener := &fakeOpener{written: map[string][]byte{}, failFor: tc.failFor}
+			c := controller{
+				logger:        logrus.WithField("component", "sinker"),
+				prowJobClient: fpjc,
+				opener:        opener,
+				config:        fca.Config,
+				dryRun:        tc.dryRun,
+			}
+			c.clean()
+
+			archived := sets.NewString()
+			for path, content := range opener.written {
+				archived.Insert(path)
+				var pj prowv1.ProwJob
+				if err := yaml.Unmarshal(content, &pj); err != nil {
+					t.Errorf("failed to unmarshal archived %s: %v", path, err)
+				} else if !strings.HasSuffix(path, "/"+pj.Name+".yaml") || pj.Spec.Job != pj.Name {
+					t.Errorf("archived %s has unexpected content: %s", path, content)
+				}
+			}
+			assertSetsEqual(tc.expectedArchived, archived, t, "did not archive correct ProwJobs")
+
+			remaining := &prowv1.ProwJobList{}
+			if err := fpjc.List(context.Background(), remaining); err != nil {
+				t.Fatalf("failed to get remaining prowjobs: %v", err)
+			}
+			deleted := sets.NewString("first", "second")
+			for _, pj := range remaining.Items {
+				deleted.Delete(pj.Name)
+			}
+			assertSetsEqual(tc.expectedDeleted, deleted, t, "did not delete correct ProwJobs")
+		})
+	}
+}
+
 func getDeletedObjectNames(actions []clienttesting.Action) sets.String {
 	names := sets.NewString()
 	for _, action := range actions {
@@ -647,6 +819,22 @@ func TestFlags(t *testing.T) {
 				o.dryRun = flagutil.Bool{}
 			},
 		},
+		{
+			name: "explicitly set --log-level",
+			args: map[string]string{
+				"--log-level": "debug",
+			},
+			expected: func(o *options) {
+				o.logLevel.LogLevel = "debug"
+			},
+		},
+		{
+			name: "unknown --log-level is rejected",
+			args: map[string]string{
+				"--log-level": "chatty",
+			},
+			err: true,
+		},
 	}
 
 	for _, tc := range cases {
@@ -86,7 +86,7 @@ func gatherOptions(fs *flag.FlagSet, args ...string) options {
 	fs.IntVar(&o.port, "port", 8888, "Port to listen on.")
 	fs.StringVar(&o.configPath, "config-path", "", "Path to config.yaml.")
 	fs.StringVar(&o.jobConfigPath, "job-config-path", "", "Path to prow job configs.")
-	fs.BoolVar(&o.dryRun, "dry-run", true, "Whether to mutate any real-world state.")
+	fs.BoolVar(&o.dryRun, "dry-run", true, "Whether to mutate any real-world state. In dry-run mode Tide serves the merges and ProwJob changes it would make on /dry-run.")
 	fs.BoolVar(&o.runOnce, "run-once", false, "If true, run only once then quit.")
 	for _, group := range []flagutil.OptionGroup{&o.kubernetes, &o.github} {
 		group.AddFlags(fs)
@@ -170,7 +170,7 @@ func main() {
 	if err != nil {
 		logrus.WithError(err).Fatal("Error constructing mgr.")
 	}
-	c, err := tide.NewController(githubSync, githubStatus, mgr, cfg, gitClient, o.maxRecordsPerPool, opener, o.historyURI, o.statusURI, nil)
+	c, err := tide.NewController(githubSync, githubStatus, mgr, cfg, gitClient, o.maxRecordsPerPool, opener, o.historyURI, o.statusURI, o.dryRun, nil)
 	if err != nil {
 		logrus.WithError(err).Fatal("Error creating Tide controller.")
 	}
@@ -193,6 +193,9 @@ func main() {
 	})
 	http.Handle("/", c)
 	http.Handle("/history", c.History)
+	if o.dryRun {
+		http.HandleFunc("/dry-run", c.ServeDryRunReport)
+	}
 	server := &http.Server{Addr: ":" + strconv.Itoa(o.port)}
 
 	// Push metrics to the configured prometheus pushgateway endpoint or serve them
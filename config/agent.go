@@ -35,6 +35,10 @@ type DeltaChan = chan<- Delta
 // Agent watches a path and automatically loads the config stored
 // therein.
 type Agent struct {
+	// LogLevel, if set, overrides the log_level of every loaded config, so
+	// that a level set by flag survives config reloads.
+	LogLevel string
+
 	mut           sync.RWMutex // do not export Lock, etc methods
 	c             *Config
 	subscriptions []DeltaChan
@@ -44,7 +48,7 @@ type Agent struct {
 // fails, Start will return the error and abort. Future load failures will log
 // the failure message but continue attempting to load.
 func (ca *Agent) Start(prowConfig, jobConfig string) error {
-	c, err := Load(prowConfig, jobConfig)
+	c, err := ca.load(prowConfig, jobConfig)
 	if err != nil {
 		return err
 	}
@@ -86,7 +90,7 @@ func (ca *Agent) Start(prowConfig, jobConfig string) error {
 				}
 				lastModTime = recentModTime
 			}
-			if c, err := Load(prowConfig, jobConfig); err != nil {
+			if c, err := ca.load(prowConfig, jobConfig); err != nil {
 				logrus.WithField("prowConfig", prowConfig).
 					WithField("jobConfig", jobConfig).
 					WithError(err).Error("Error loading config.")
@@ -99,6 +103,24 @@ func (ca *Agent) Start(prowConfig, jobConfig string) error {
 	return nil
 }
 
+// load loads the config and applies the log level override, as loading the
+// config sets the log level of the standard logger to its log_level.
+func (ca *Agent) load(prowConfig, jobConfig string) (*Config, error) {
+	c, err := Load(prowConfig, jobConfig)
+	if err != nil {
+		return nil, err
+	}
+	if ca.LogLevel != "" {
+		lvl, err := logrus.ParseLevel(ca.LogLevel)
+		if err != nil {
+			return nil, err
+		}
+		logrus.SetLevel(lvl)
+		c.LogLevel = ca.LogLevel
+	}
+	return c, nil
+}
+
 // Subscribe registers the channel for messages on config reload.
 // The caller can expect a copy of the previous and current config
 // to be sent down the subscribed channel when a new configuration
@@ -0,0 +1,92 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestAgentLogLevel(t *testing.T) {
+	testCases := []struct {
+		name          string
+		configLevel   string
+		agentLevel    string
+		expectedLevel logrus.Level
+		expectErr     bool
+	}{
+		{
+			name:          "config level applies without an override",
+			configLevel:   "warn",
+			expectedLevel: logrus.WarnLevel,
+		},
+		{
+			name:          "override wins over the config level",
+			configLevel:   "warn",
+			agentLevel:    "debug",
+			expectedLevel: logrus.DebugLevel,
+		},
+		{
+			name:          "override wins over the default level",
+			agentLevel:    "error",
+			expectedLevel: logrus.ErrorLevel,
+		},
+		{
+			name:       "invalid override is rejected",
+			agentLevel: "chatty",
+			expectErr:  true,
+		},
+	}
+
+	originalLevel := logrus.GetLevel()
+	defer logrus.SetLevel(originalLevel)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "agent-log-level")
+			if err != nil {
+				t.Fatalf("failed to create temp dir: %v", err)
+			}
+			defer os.RemoveAll(dir)
+			prowConfig := filepath.Join(dir, "config.yaml")
+			if err := ioutil.WriteFile(prowConfig, []byte("log_level: "+tc.configLevel), 0666); err != nil {
+				t.Fatalf("failed to write config: %v", err)
+			}
+
+			ca := &Agent{LogLevel: tc.agentLevel}
+			// Load twice to check that the override survives config reloads.
+			for i := 0; i < 2; i++ {
+				_, err := ca.load(prowConfig, "")
+				if tc.expectErr {
+					if err == nil {
+						t.Fatal("expected an error, got none")
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if level := logrus.GetLevel(); level != tc.expectedLevel {
+					t.Errorf("expected level %v after load %d, got %v", tc.expectedLevel, i, level)
+				}
+			}
+		})
+	}
+}
@@ -95,6 +95,7 @@ type ProwConfig struct {
 	Tide             Tide             `json:"tide,omitempty"`
 	Plank            Plank            `json:"plank,omitempty"`
 	Sinker           Sinker           `json:"sinker,omitempty"`
+	Horologium       Horologium       `json:"horologium,omitempty"`
 	Deck             Deck             `json:"deck,omitempty"`
 	BranchProtection BranchProtection `json:"branch-protection,omitempty"`
 	Gerrit           Gerrit           `json:"gerrit,omitempty"`
@@ -117,6 +118,10 @@ type ProwConfig struct {
 	// The namespace needs to exist and will not be created by prow.
 	// Defaults to "default".
 	PodNamespace string `json:"pod_namespace,omitempty"`
+	// BuildClusterPodNamespaces maps build cluster aliases to the namespace
+	// that prow components will use for Pods owned by ProwJobs in that
+	// cluster. Clusters without an entry use PodNamespace.
+	BuildClusterPodNamespaces map[string]string `json:"build_cluster_pod_namespaces,omitempty"`
 
 	// LogLevel enables dynamically updating the log level of the
 	// standard logger that is used by all prow components.
@@ -382,6 +387,11 @@ type Plank struct {
 	// PodRunningTimeout is after how long the controller will abort a prowjob pod
 	// stuck in running state. Defaults to two days.
 	PodRunningTimeout *metav1.Duration `json:"pod_running_timeout,omitempty"`
+	// DedupeWindow is how long after a presubmit was created for a webhook
+	// delivery that trigger skips creating the same presubmit for the same
+	// PR head and delivery again, as happens when GitHub redelivers the
+	// webhook. Disabled when unset.
+	DedupeWindow *metav1.Duration `json:"dedupe_window,omitempty"`
 	// DefaultDecorationConfig are defaults for shared fields for ProwJobs
 	// that request to have their PodSpecs decorated.
 	// This will be deprecated on April 2020, and it will be replaces with DefaultDecorationConfigs['*'] instead.
@@ -396,6 +406,51 @@ type Plank struct {
 	// JobURLPrefixConfig is the host and path prefix under which job details
 	// will be viewable. Use `org/repo`, `org` or `*`as key and an url as value
 	JobURLPrefixConfig map[string]string `json:"job_url_prefix_config,omitempty"`
+
+	// PodSchedulingHints inject pod annotations, node affinity and tolerations
+	// into the pods of jobs matching their label selector. Hints never override
+	// values that are set explicitly in the job's PodSpec, and earlier hints
+	// take precedence over later ones.
+	PodSchedulingHints []PodSchedulingHint `json:"pod_scheduling_hints,omitempty"`
+}
+
+// PodSchedulingHint holds cluster-specific scheduling settings for the pods
+// of all jobs whose labels match the selector.
+type PodSchedulingHint struct {
+	// LabelSelector selects the jobs by their labels. An empty selector
+	// matches all jobs.
+	LabelSelector string `json:"label_selector,omitempty"`
+	// Annotations are added to the pod.
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// Affinity is used for the pod if the PodSpec does not set one.
+	Affinity *v1.Affinity `json:"affinity,omitempty"`
+	// Tolerations are added to the pod.
+	Tolerations []v1.Toleration `json:"tolerations,omitempty"`
+
+	selector labels.Selector
+}
+
+// Matches determines whether the hint applies to a job with the given labels.
+func (h PodSchedulingHint) Matches(jobLabels map[string]string) bool {
+	selector := h.selector
+	if selector == nil {
+		var err error
+		if selector, err = labels.Parse(h.LabelSelector); err != nil {
+			return false
+		}
+	}
+	return selector.Matches(labels.Set(jobLabels))
+}
+
+// PodSchedulingHintsFor returns the hints that apply to a job with the given labels.
+func (p Plank) PodSchedulingHintsFor(jobLabels map[string]string) []PodSchedulingHint {
+	var hints []PodSchedulingHint
+	for _, hint := range p.PodSchedulingHints {
+		if hint.Matches(jobLabels) {
+			hints = append(hints, hint)
+		}
+	}
+	return hints
 }
 
 func (p Plank) GetDefaultDecorationConfigs(repo string) *prowapi.DecorationConfig {
@@ -430,6 +485,10 @@ type Gerrit struct {
 	// RateLimit defines how many changes to query per gerrit API call
 	// default is 5
 	RateLimit int `json:"ratelimit,omitempty"`
+	// SyncConcurrency is how many projects are synced at the same time, so
+	// that a slow project does not delay the others.
+	// default is 1
+	SyncConcurrency int `json:"sync_concurrency,omitempty"`
 }
 
 // JenkinsOperator is config for the jenkins-operator controller.
@@ -455,6 +514,45 @@ type GitHubReporter struct {
 	//
 	// defaults to both presubmit and postsubmit jobs.
 	JobTypesToReport []prowapi.ProwJobType `json:"job_types_to_report,omitempty"`
+
+	// StatusGroups roll up the statuses of related jobs into an aggregate
+	// context that is reported in addition to or instead of the individual
+	// contexts.
+	StatusGroups []StatusGroup `json:"status_groups,omitempty"`
+}
+
+// StatusGroup is an aggregate status context for a group of job contexts.
+// The aggregate context stays pending until all members pass and fails as
+// soon as any member fails.
+type StatusGroup struct {
+	// Context is the context of the aggregate status.
+	Context string `json:"context"`
+	// ContextPrefix adds all contexts with this prefix to the group.
+	ContextPrefix string `json:"context_prefix,omitempty"`
+	// Contexts lists contexts of the group. Listed contexts that have not
+	// been reported yet keep the aggregate status pending.
+	Contexts []string `json:"contexts,omitempty"`
+	// ReplaceContexts reports only the aggregate context instead of the
+	// individual contexts of the members. Tide and branch protection still
+	// wait for the contexts of required presubmits, so groups that replace
+	// contexts may only cover optional or skip_report presubmits.
+	ReplaceContexts bool `json:"replace_contexts,omitempty"`
+}
+
+// Has returns whether the context is a member of the group.
+func (g StatusGroup) Has(context string) bool {
+	if context == g.Context {
+		return false
+	}
+	if g.ContextPrefix != "" && strings.HasPrefix(context, g.ContextPrefix) {
+		return true
+	}
+	for _, c := range g.Contexts {
+		if c == context {
+			return true
+		}
+	}
+	return false
 }
 
 // Sinker is config for the sinker controller.
@@ -468,6 +566,17 @@ type Sinker struct {
 	// MaxPodAge is how old a Pod can be before it is garbage-collected.
 	// Defaults to one day.
 	MaxPodAge *metav1.Duration `json:"max_pod_age,omitempty"`
+	// ArchiveBucket is the name of a GCS bucket that sinker uploads the YAML
+	// of ProwJobs to before garbage-collecting them, under
+	// prowjobs/<start date>/<name>.yaml. ProwJobs are not archived if unset.
+	ArchiveBucket string `json:"archive_bucket,omitempty"`
+}
+
+// Horologium is config for the horologium controller.
+type Horologium struct {
+	// ExtraLabels are added to all ProwJobs created by horologium. Labels of
+	// the periodic itself take precedence.
+	ExtraLabels map[string]string `json:"extra_labels,omitempty"`
 }
 
 // LensConfig names a specific lens, and optionally provides some configuration for it.
@@ -493,6 +602,10 @@ type LensFileConfig struct {
 	OptionalFiles []string `json:"optional_files,omitempty"`
 	// Lens is the lens to use, alongside any lens-specific configuration.
 	Lens LensConfig `json:"lens"`
+	// Fallback makes the lens only appear when no other lens matches the
+	// artifacts of a job, so that users can still browse them. A fallback
+	// lens without required files also appears for jobs without artifacts.
+	Fallback bool `json:"fallback,omitempty"`
 }
 
 // Spyglass holds config for Spyglass
@@ -511,6 +624,9 @@ type Spyglass struct {
 	// expected file size + variance. To include all artifacts with high
 	// probability, use 2*maximum observed artifact size.
 	SizeLimit int64 `json:"size_limit,omitempty"`
+	// RenderConcurrency is the maximum number of artifacts Spyglass fetches in
+	// parallel when rendering a lens. Defaults to 1, which fetches serially.
+	RenderConcurrency int `json:"render_concurrency,omitempty"`
 	// GCSBrowserPrefix is used to generate a link to a human-usable GCS browser.
 	// If left empty, the link will be not be shown. Otherwise, a GCS path (with no
 	// prefix or scheme) will be appended to GCSBrowserPrefix and shown to the user.
@@ -551,6 +667,23 @@ type Deck struct {
 	// accepts a key of: `org/repo`, `org` or `*` (wildcard) to define what GitHub org (or repo) a particular
 	// config applies to and a value of: `RerunAuthConfig` struct to define the users/groups authorized to rerun jobs.
 	RerunAuthConfigs prowapi.RerunAuthConfigs `json:"rerun_auth_configs,omitempty"`
+	// RerunAllowedAgents lists the agents of the jobs that can be rerun from Deck.
+	// Defaults to the kubernetes agent.
+	RerunAllowedAgents []prowapi.ProwJobAgent `json:"rerun_allowed_agents,omitempty"`
+	// MaxJobAge is how long after their start ProwJobs are still listed by
+	// Deck. Older ProwJobs are neither cached nor served, which bounds
+	// Deck's memory usage. Defaults to no limit.
+	MaxJobAge *metav1.Duration `json:"max_job_age,omitempty"`
+}
+
+// RerunAllowsAgent returns whether jobs run by the agent can be rerun from Deck.
+func (d *Deck) RerunAllowsAgent(agent prowapi.ProwJobAgent) bool {
+	for _, allowed := range d.RerunAllowedAgents {
+		if allowed == agent {
+			return true
+		}
+	}
+	return false
 }
 
 // ExternalAgentLog ensures an external agent like Jenkins can expose
@@ -1108,6 +1241,11 @@ func validateJobBase(v JobBase, jobType prowapi.ProwJobType, podNamespace string
 	if err := validateLabels(v.Labels); err != nil {
 		return err
 	}
+	if ttl, ok := v.Annotations[kube.PodTTLAnnotation]; ok {
+		if _, err := time.ParseDuration(ttl); err != nil {
+			return fmt.Errorf("annotation %s: %q is not a valid duration: %v", kube.PodTTLAnnotation, ttl, err)
+		}
+	}
 	if v.Spec == nil || len(v.Spec.Containers) == 0 {
 		return nil // jenkins jobs have no spec
 	}
@@ -1117,11 +1255,11 @@ func validateJobBase(v JobBase, jobType prowapi.ProwJobType, podNamespace string
 	if err := v.UtilityConfig.Validate(); err != nil {
 		return err
 	}
-	return validateDecoration(v.Spec.Containers[0], v.DecorationConfig)
+	return validateDecoration(v.Spec.Containers[0], v.Spec.Volumes, v.DecorationConfig)
 }
 
 // validatePresubmits validates the presubmits for one repo
-func validatePresubmits(presubmits []Presubmit, podNamespace string) error {
+func validatePresubmits(presubmits []Presubmit, podNamespace func(cluster string) string) error {
 	validPresubmits := map[string][]Presubmit{}
 
 	for _, ps := range presubmits {
@@ -1131,7 +1269,7 @@ func validatePresubmits(presubmits []Presubmit, podNamespace string) error {
 				return fmt.Errorf("duplicated presubmit job: %s", ps.Name)
 			}
 		}
-		if err := validateJobBase(ps.JobBase, prowapi.PresubmitJob, podNamespace); err != nil {
+		if err := validateJobBase(ps.JobBase, prowapi.PresubmitJob, podNamespace(ps.Cluster)); err != nil {
 			return fmt.Errorf("invalid presubmit job %s: %v", ps.Name, err)
 		}
 		if err := validateTriggering(ps); err != nil {
@@ -1167,7 +1305,7 @@ func ValidateRefs(repo string, jobBase JobBase) error {
 }
 
 // validatePostsubmits validates the postsubmits for one repo
-func validatePostsubmits(postsubmits []Postsubmit, podNamespace string) error {
+func validatePostsubmits(postsubmits []Postsubmit, podNamespace func(cluster string) string) error {
 	validPostsubmits := map[string][]Postsubmit{}
 
 	for _, ps := range postsubmits {
@@ -1177,7 +1315,7 @@ func validatePostsubmits(postsubmits []Postsubmit, podNamespace string) error {
 				return fmt.Errorf("duplicated postsubmit job: %s", ps.Name)
 			}
 		}
-		if err := validateJobBase(ps.JobBase, prowapi.PostsubmitJob, podNamespace); err != nil {
+		if err := validateJobBase(ps.JobBase, prowapi.PostsubmitJob, podNamespace(ps.Cluster)); err != nil {
 			return fmt.Errorf("invalid postsubmit job %s: %v", ps.Name, err)
 		}
 		validPostsubmits[ps.Name] = append(validPostsubmits[ps.Name], ps)
@@ -1187,7 +1325,7 @@ func validatePostsubmits(postsubmits []Postsubmit, podNamespace string) error {
 }
 
 // validatePeriodics validates a set of periodics
-func validatePeriodics(periodics []Periodic, podNamespace string) error {
+func validatePeriodics(periodics []Periodic, podNamespace func(cluster string) string) error {
 
 	// validate no duplicated periodics
 	validPeriodics := sets.NewString()
@@ -1197,7 +1335,7 @@ func validatePeriodics(periodics []Periodic, podNamespace string) error {
 			return fmt.Errorf("duplicated periodic job : %s", p.Name)
 		}
 		validPeriodics.Insert(p.Name)
-		if err := validateJobBase(p.JobBase, prowapi.PeriodicJob, podNamespace); err != nil {
+		if err := validateJobBase(p.JobBase, prowapi.PeriodicJob, podNamespace(p.Cluster)); err != nil {
 			return fmt.Errorf("invalid periodic job %s: %v", p.Name, err)
 		}
 	}
@@ -1211,19 +1349,38 @@ func (c *Config) validateJobConfig() error {
 
 	// Validate presubmits.
 	for _, jobs := range c.PresubmitsStatic {
-		if err := validatePresubmits(jobs, c.PodNamespace); err != nil {
+		if err := validatePresubmits(jobs, c.PodNamespaceForCluster); err != nil {
 			return err
 		}
 	}
 
+	// Contexts replaced by a status group are never reported, so required
+	// presubmits cannot be members of such a group or their PRs never merge.
+	for _, g := range c.GitHubReporter.StatusGroups {
+		if !g.ReplaceContexts {
+			continue
+		}
+		for _, jobs := range c.PresubmitsStatic {
+			for _, job := range jobs {
+				if job.ContextRequired() && g.Has(job.Context) {
+					return fmt.Errorf("github_reporter.status_groups group %q replaces the context %q of the required presubmit %s, mark the job optional or do not set replace_contexts", g.Context, job.Context, job.Name)
+				}
+			}
+		}
+	}
+
 	// Validate postsubmits.
 	for _, jobs := range c.Postsubmits {
-		if err := validatePostsubmits(jobs, c.PodNamespace); err != nil {
+		if err := validatePostsubmits(jobs, c.PodNamespaceForCluster); err != nil {
 			return err
 		}
 	}
 
-	if err := validatePeriodics(c.Periodics, c.PodNamespace); err != nil {
+	if err := validatePeriodics(c.Periodics, c.PodNamespaceForCluster); err != nil {
+		return err
+	}
+
+	if err := c.validatePodTTLs(); err != nil {
 		return err
 	}
 
@@ -1250,6 +1407,47 @@ func (c *Config) validateJobConfig() error {
 	return nil
 }
 
+// validatePodTTLs rejects pod TTLs longer than sinker's max_prowjob_age. Once
+// sinker garbage-collects a ProwJob its pods are orphaned and deleted, so a
+// longer TTL would have no effect.
+func (c *Config) validatePodTTLs() error {
+	if c.Sinker.MaxProwJobAge == nil {
+		return nil
+	}
+	maxProwJobAge := c.Sinker.MaxProwJobAge.Duration
+	validate := func(base JobBase) error {
+		raw, ok := base.Annotations[kube.PodTTLAnnotation]
+		if !ok {
+			return nil
+		}
+		// The format is checked when validating the job itself.
+		if ttl, err := time.ParseDuration(raw); err == nil && ttl > maxProwJobAge {
+			return fmt.Errorf("job %s: annotation %s: %s exceeds sinker.max_prowjob_age of %s, after which the pods are deleted with the ProwJob", base.Name, kube.PodTTLAnnotation, raw, maxProwJobAge)
+		}
+		return nil
+	}
+	for _, jobs := range c.PresubmitsStatic {
+		for _, job := range jobs {
+			if err := validate(job.JobBase); err != nil {
+				return err
+			}
+		}
+	}
+	for _, jobs := range c.Postsubmits {
+		for _, job := range jobs {
+			if err := validate(job.JobBase); err != nil {
+				return err
+			}
+		}
+	}
+	for _, job := range c.Periodics {
+		if err := validate(job.JobBase); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // DefaultConfigPath will be used if a --config-path is unset
 const DefaultConfigPath = "/etc/config/config.yaml"
 
@@ -1277,6 +1475,14 @@ func parseProwConfig(c *Config) error {
 		c.Plank.PodRunningTimeout = &metav1.Duration{Duration: 48 * time.Hour}
 	}
 
+	for i := range c.Plank.PodSchedulingHints {
+		selector, err := labels.Parse(c.Plank.PodSchedulingHints[i].LabelSelector)
+		if err != nil {
+			return fmt.Errorf("plank pod_scheduling_hints[%d] has an invalid label_selector: %v", i, err)
+		}
+		c.Plank.PodSchedulingHints[i].selector = selector
+	}
+
 	if !c.Plank.AllowCancellations {
 		logrus.Warning("The `plank.allow_cancellations` setting is deprecated. It will be removed and set to always true in March 2020")
 	}
@@ -1289,6 +1495,12 @@ func parseProwConfig(c *Config) error {
 		c.Gerrit.RateLimit = 5
 	}
 
+	if c.Gerrit.SyncConcurrency == 0 {
+		c.Gerrit.SyncConcurrency = 1
+	} else if c.Gerrit.SyncConcurrency < 0 {
+		return fmt.Errorf("gerrit has invalid sync_concurrency (%d), it needs to be a positive number", c.Gerrit.SyncConcurrency)
+	}
+
 	if len(c.GitHubReporter.JobTypesToReport) == 0 {
 		c.GitHubReporter.JobTypesToReport = append(c.GitHubReporter.JobTypesToReport, prowapi.PresubmitJob, prowapi.PostsubmitJob)
 	}
@@ -1301,6 +1513,20 @@ func parseProwConfig(c *Config) error {
 		}
 	}
 
+	groupContexts := sets.NewString()
+	for _, g := range c.GitHubReporter.StatusGroups {
+		if g.Context == "" {
+			return errors.New("github_reporter.status_groups entries must set a context")
+		}
+		if groupContexts.Has(g.Context) {
+			return fmt.Errorf("github_reporter.status_groups context %q is used by more than one group", g.Context)
+		}
+		groupContexts.Insert(g.Context)
+		if g.ContextPrefix == "" && len(g.Contexts) == 0 {
+			return fmt.Errorf("github_reporter.status_groups group %q must set context_prefix or contexts", g.Context)
+		}
+	}
+
 	for i := range c.JenkinsOperators {
 		if err := ValidateController(&c.JenkinsOperators[i].Controller); err != nil {
 			return fmt.Errorf("validating jenkins_operators config: %v", err)
@@ -1342,12 +1568,22 @@ func parseProwConfig(c *Config) error {
 		c.Deck.TideUpdatePeriod = &metav1.Duration{Duration: time.Second * 10}
 	}
 
+	if len(c.Deck.RerunAllowedAgents) == 0 {
+		c.Deck.RerunAllowedAgents = []prowapi.ProwJobAgent{prowapi.KubernetesAgent}
+	}
+
 	if c.Deck.Spyglass.SizeLimit == 0 {
 		c.Deck.Spyglass.SizeLimit = 100e6
 	} else if c.Deck.Spyglass.SizeLimit <= 0 {
 		return fmt.Errorf("invalid value for deck.spyglass.size_limit, must be >=0")
 	}
 
+	if c.Deck.Spyglass.RenderConcurrency == 0 {
+		c.Deck.Spyglass.RenderConcurrency = 1
+	} else if c.Deck.Spyglass.RenderConcurrency < 0 {
+		return fmt.Errorf("invalid value for deck.spyglass.render_concurrency, must be >=0")
+	}
+
 	// Migrate the old `viewers` format to the new `lenses` format.
 	var oldLenses []LensFileConfig
 	for regex, viewers := range c.Deck.Spyglass.Viewers {
@@ -1413,6 +1649,14 @@ func parseProwConfig(c *Config) error {
 		c.Sinker.MaxPodAge = &metav1.Duration{Duration: 24 * time.Hour}
 	}
 
+	if strings.Contains(c.Sinker.ArchiveBucket, "/") {
+		return fmt.Errorf("sinker.archive_bucket must be a bucket name, not a path: %q", c.Sinker.ArchiveBucket)
+	}
+
+	if err := validateLabels(c.Horologium.ExtraLabels); err != nil {
+		return fmt.Errorf("invalid horologium.extra_labels: %v", err)
+	}
+
 	if c.Tide.SyncPeriod == nil {
 		c.Tide.SyncPeriod = &metav1.Duration{Duration: time.Minute}
 	}
@@ -1427,6 +1671,33 @@ func parseProwConfig(c *Config) error {
 	if c.Tide.MaxGoroutines <= 0 {
 		return fmt.Errorf("tide has invalid max_goroutines (%d), it needs to be a positive number", c.Tide.MaxGoroutines)
 	}
+	if c.Tide.MaxPoolSize < 0 {
+		return fmt.Errorf("tide has invalid max_pool_size (%d), it needs to be a non-negative number", c.Tide.MaxPoolSize)
+	}
+	if c.Tide.RetestCooldown != nil && c.Tide.RetestCooldown.Duration < 0 {
+		return fmt.Errorf("tide has invalid retest_cooldown (%v), it needs to be a non-negative duration", c.Tide.RetestCooldown.Duration)
+	}
+	if c.Tide.MinSHAAge != nil && c.Tide.MinSHAAge.Duration < 0 {
+		return fmt.Errorf("tide has invalid min_sha_age (%v), it needs to be a non-negative duration", c.Tide.MinSHAAge.Duration)
+	}
+	for key, max := range c.Tide.MaxBatchesInFlightMap {
+		if max < 0 {
+			return fmt.Errorf("tide has invalid max_batches_in_flight (%d) for %q, it needs to be a non-negative number", max, key)
+		}
+	}
+	if c.Tide.MaxRetestsPerPR < 0 {
+		return fmt.Errorf("tide has invalid max_retests_per_pr (%d), it needs to be a non-negative number", c.Tide.MaxRetestsPerPR)
+	}
+	if c.Tide.MaxQueryResults < 0 {
+		return fmt.Errorf("tide has invalid max_query_results (%d), it needs to be a non-negative number", c.Tide.MaxQueryResults)
+	}
+	switch c.Tide.PoolSortOrder {
+	case "":
+		c.Tide.PoolSortOrder = PoolSortOrderNumber
+	case PoolSortOrderNumber, PoolSortOrderCreated, PoolSortOrderUpdated:
+	default:
+		return fmt.Errorf("tide has invalid pool_sort_order %q, it needs to be one of %q, %q or %q", c.Tide.PoolSortOrder, PoolSortOrderNumber, PoolSortOrderCreated, PoolSortOrderUpdated)
+	}
 
 	for name, method := range c.Tide.MergeType {
 		if method != github.MergeMerge &&
@@ -1460,10 +1731,30 @@ func parseProwConfig(c *Config) error {
 		c.Tide.MergeTemplate[name] = templates
 	}
 
+	if c.Tide.AutoMergeLabel == "" {
+		c.Tide.AutoMergeLabel = DefaultAutoMergeLabel
+	}
+
 	for i, tq := range c.Tide.Queries {
 		if err := tq.Validate(); err != nil {
 			return fmt.Errorf("tide query (index %d) is invalid: %v", i, err)
 		}
+		if tq.AutoMergeLabelRequired && sets.NewString(tq.MissingLabels...).Has(c.Tide.AutoMergeLabel) {
+			return fmt.Errorf("tide query (index %d) is invalid: the auto merge label %q is both required for merge and forbidden", i, c.Tide.AutoMergeLabel)
+		}
+	}
+
+	groupedRepos := sets.NewString()
+	for i, group := range c.Tide.BatchGroups {
+		if err := group.validate(); err != nil {
+			return fmt.Errorf("tide batch group (index %d) is invalid: %v", i, err)
+		}
+		for _, repo := range group.Repos {
+			if groupedRepos.Has(repo) {
+				return fmt.Errorf("tide batch group (index %d) is invalid: repo %q is part of more than one batch group", i, repo)
+			}
+			groupedRepos.Insert(repo)
+		}
 	}
 
 	if c.ProwJobNamespace == "" {
@@ -1582,7 +1873,7 @@ func validateAgent(v JobBase, podNamespace string) error {
 	return nil
 }
 
-func validateDecoration(container v1.Container, config *prowapi.DecorationConfig) error {
+func validateDecoration(container v1.Container, volumes []v1.Volume, config *prowapi.DecorationConfig) error {
 	if config == nil {
 		return nil
 	}
@@ -1595,6 +1886,31 @@ func validateDecoration(container v1.Container, config *prowapi.DecorationConfig
 	if len(args) == 0 || args[0] == "" {
 		return errors.New("decorated job containers must specify command and/or args")
 	}
+	// Decoration overrides the env and command of the container, but
+	// cannot run alongside containers and volumes with its own names.
+	var conflicts []string
+	for _, name := range decorate.ContainerNames() {
+		if container.Name == name {
+			conflicts = append(conflicts, fmt.Sprintf("container name %s is reserved for decoration", name))
+		}
+	}
+	for _, mount := range container.VolumeMounts {
+		for _, name := range decorate.VolumeNames() {
+			if mount.Name == name {
+				conflicts = append(conflicts, fmt.Sprintf("volumeMount name %s is reserved for decoration", name))
+			}
+		}
+	}
+	for _, volume := range volumes {
+		for _, name := range decorate.VolumeNames() {
+			if volume.Name == name {
+				conflicts = append(conflicts, fmt.Sprintf("volume %s is reserved for decoration", name))
+			}
+		}
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("decorated job conflicts with decoration: %s", strings.Join(conflicts, "; "))
+	}
 	return nil
 }
 
@@ -1768,13 +2084,22 @@ func (c *ProwConfig) defaultJobBase(base *JobBase) {
 	if base.Agent == "" { // Use kubernetes by default
 		base.Agent = string(prowapi.KubernetesAgent)
 	}
+	if base.Cluster == "" {
+		base.Cluster = kube.DefaultClusterAlias
+	}
 	if base.Namespace == nil || *base.Namespace == "" {
-		s := c.PodNamespace
+		s := c.PodNamespaceForCluster(base.Cluster)
 		base.Namespace = &s
 	}
-	if base.Cluster == "" {
-		base.Cluster = kube.DefaultClusterAlias
+}
+
+// PodNamespaceForCluster returns the namespace that Pods owned by ProwJobs
+// run in on the given build cluster.
+func (c *ProwConfig) PodNamespaceForCluster(cluster string) string {
+	if namespace, ok := c.BuildClusterPodNamespaces[cluster]; ok && namespace != "" {
+		return namespace
 	}
+	return c.PodNamespace
 }
 
 func (c *ProwConfig) defaultPresubmitFields(js []Presubmit) {
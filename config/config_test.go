@@ -98,6 +98,34 @@ func TestDefaultJobBase(t *testing.T) {
 				j.Namespace = &p
 			},
 		},
+		{
+			name: "nil namespace becomes the pod namespace of the build cluster",
+			config: ProwConfig{
+				PodNamespace:              "pod-namespace",
+				BuildClusterPodNamespaces: map[string]string{"build": "build-pod-namespace"},
+			},
+			base: func(j *JobBase) {
+				j.Namespace = nil
+			},
+			expected: func(j *JobBase) {
+				p := "build-pod-namespace"
+				j.Namespace = &p
+			},
+		},
+		{
+			name: "nil namespace becomes PodNamespace for clusters without a pod namespace",
+			config: ProwConfig{
+				PodNamespace:              "pod-namespace",
+				BuildClusterPodNamespaces: map[string]string{"other": "other-pod-namespace"},
+			},
+			base: func(j *JobBase) {
+				j.Namespace = nil
+			},
+			expected: func(j *JobBase) {
+				p := "pod-namespace"
+				j.Namespace = &p
+			},
+		},
 		{
 			name: "empty cluster becomes DefaultClusterAlias",
 			base: func(j *JobBase) {
@@ -134,6 +162,7 @@ func TestSpyglassConfig(t *testing.T) {
 		expectedViewers      map[string][]string
 		expectedRegexMatches map[string][]string
 		expectedSizeLimit    int64
+		expectedConcurrency  int
 		expectError          bool
 	}{
 		{
@@ -160,8 +189,9 @@ deck:
 				"build-log.txt":              {"build-log.txt"},
 				"artifacts/junit.*\\.xml":    {"artifacts/junit01.xml", "artifacts/junit_runner.xml"},
 			},
-			expectedSizeLimit: 500e6,
-			expectError:       false,
+			expectedSizeLimit:   500e6,
+			expectedConcurrency: 1,
+			expectError:         false,
 		},
 		{
 			name: "Backwards compatibility",
@@ -182,8 +212,9 @@ deck:
 				"build-log.txt":              {"buildlog"},
 				"artifacts/junit.*\\.xml":    {"junit"},
 			},
-			expectedSizeLimit: 500e6,
-			expectError:       false,
+			expectedSizeLimit:   500e6,
+			expectedConcurrency: 1,
+			expectError:         false,
 		},
 		{
 			name: "Invalid spyglass size limit",
@@ -198,6 +229,28 @@ deck:
       - "build-log-viewer"
       "artifacts/junit.*\\.xml":
       - "junit-viewer"
+`,
+			expectError: true,
+		},
+		{
+			name: "Custom render concurrency",
+			spyglassConfig: `
+deck:
+  spyglass:
+    size_limit: 500e+6
+    render_concurrency: 8
+`,
+			expectedSizeLimit:   500e6,
+			expectedConcurrency: 8,
+			expectError:         false,
+		},
+		{
+			name: "Invalid render concurrency",
+			spyglassConfig: `
+deck:
+  spyglass:
+    size_limit: 500e+6
+    render_concurrency: -1
 `,
 			expectError: true,
 		},
@@ -274,6 +327,9 @@ deck:
 		if cfg.Deck.Spyglass.SizeLimit != tc.expectedSizeLimit {
 			t.Errorf("%s expected SizeLimit %d, got %d", tc.name, tc.expectedSizeLimit, cfg.Deck.Spyglass.SizeLimit)
 		}
+		if cfg.Deck.Spyglass.RenderConcurrency != tc.expectedConcurrency {
+			t.Errorf("%s expected RenderConcurrency %d, got %d", tc.name, tc.expectedConcurrency, cfg.Deck.Spyglass.RenderConcurrency)
+		}
 	}
 
 }
@@ -844,6 +900,7 @@ func TestValidateDecoration(t *testing.T) {
 	cases := []struct {
 		name      string
 		container v1.Container
+		volumes   []v1.Volume
 		config    *prowapi.DecorationConfig
 		pass      bool
 	}{
@@ -878,10 +935,52 @@ func TestValidateDecoration(t *testing.T) {
 			name:   "reject container that has no cmd, no args",
 			config: &defCfg,
 		},
+		{
+			name:   "allow container that sets env overridden by decoration",
+			config: &defCfg,
+			container: v1.Container{
+				Command: []string{"hello", "world"},
+				Env:     []v1.EnvVar{{Name: "ARTIFACTS", Value: "/somewhere"}},
+			},
+			pass: true,
+		},
+		{
+			name:   "allow reserved container name without decoration",
+			config: nil,
+			container: v1.Container{
+				Name:    "sidecar",
+				Command: []string{"hello", "world"},
+			},
+			pass: true,
+		},
+		{
+			name:   "reject container name reserved for decoration",
+			config: &defCfg,
+			container: v1.Container{
+				Name:    "sidecar",
+				Command: []string{"hello", "world"},
+			},
+		},
+		{
+			name:   "reject volumeMount name reserved for decoration",
+			config: &defCfg,
+			container: v1.Container{
+				Command:      []string{"hello", "world"},
+				VolumeMounts: []v1.VolumeMount{{Name: "cookiefile", MountPath: "/cookies"}},
+			},
+		},
+		{
+			name:   "reject volume name reserved for decoration",
+			config: &defCfg,
+			container: v1.Container{
+				Command: []string{"hello", "world"},
+			},
+			volumes: []v1.Volume{{Name: "clonerefs-tmp"}},
+		},
 	}
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			switch err := validateDecoration(tc.container, tc.config); {
+			switch err := validateDecoration(tc.container, tc.volumes, tc.config); {
 			case err == nil && !tc.pass:
 				t.Error("validation failed to raise an error")
 			case err != nil && tc.pass:
@@ -997,6 +1096,31 @@ func TestValidateJobBase(t *testing.T) {
 				Namespace: &ns,
 			},
 		},
+		{
+			name: "valid pod ttl",
+			base: JobBase{
+				Name:  "name",
+				Agent: ka,
+				Spec:  &goodSpec,
+				Annotations: map[string]string{
+					kube.PodTTLAnnotation: "72h",
+				},
+				Namespace: &ns,
+			},
+			pass: true,
+		},
+		{
+			name: "invalid pod ttl",
+			base: JobBase{
+				Name:  "name",
+				Agent: ka,
+				Spec:  &goodSpec,
+				Annotations: map[string]string{
+					kube.PodTTLAnnotation: "forever",
+				},
+				Namespace: &ns,
+			},
+		},
 		{
 			name: "invalid labels",
 			base: JobBase{
@@ -1233,6 +1357,99 @@ presubmits:
       - image: alpine`,
 			},
 		},
+		{
+			name: "reject status group replacing the context of a required presubmit",
+			prowConfig: `
+github_reporter:
+  status_groups:
+  - context: unit
+    context_prefix: unit-
+    replace_contexts: true`,
+			jobConfigs: []string{
+				`
+presubmits:
+  foo/bar:
+  - context: unit-bar
+    name: presubmit-bar
+    spec:
+      containers:
+      - image: alpine`,
+			},
+			expectError: true,
+		},
+		{
+			name: "status group may replace the context of an optional presubmit",
+			prowConfig: `
+github_reporter:
+  status_groups:
+  - context: unit
+    context_prefix: unit-
+    replace_contexts: true`,
+			jobConfigs: []string{
+				`
+presubmits:
+  foo/bar:
+  - context: unit-bar
+    name: presubmit-bar
+    optional: true
+    spec:
+      containers:
+      - image: alpine`,
+			},
+		},
+		{
+			name: "status group may group the context of a required presubmit without replacing it",
+			prowConfig: `
+github_reporter:
+  status_groups:
+  - context: unit
+    context_prefix: unit-`,
+			jobConfigs: []string{
+				`
+presubmits:
+  foo/bar:
+  - context: unit-bar
+    name: presubmit-bar
+    spec:
+      containers:
+      - image: alpine`,
+			},
+		},
+		{
+			name: "pod ttl within max_prowjob_age is accepted",
+			prowConfig: `
+sinker:
+  max_prowjob_age: 48h`,
+			jobConfigs: []string{
+				`
+periodics:
+- interval: 10m
+  name: periodic-bar
+  annotations:
+    prow.k8s.io/pod-ttl: 24h
+  spec:
+    containers:
+    - image: alpine`,
+			},
+		},
+		{
+			name: "reject pod ttl longer than max_prowjob_age",
+			prowConfig: `
+sinker:
+  max_prowjob_age: 48h`,
+			jobConfigs: []string{
+				`
+postsubmits:
+  foo/bar:
+  - name: postsubmit-bar
+    annotations:
+      prow.k8s.io/pod-ttl: 72h
+    spec:
+      containers:
+      - image: alpine`,
+			},
+			expectError: true,
+		},
 		{
 			name:       "one presubmit no agent should default",
 			prowConfig: ``,
@@ -2334,6 +2551,87 @@ func TestPlankJobURLPrefix(t *testing.T) {
 	}
 }
 
+func TestPodSchedulingHints(t *testing.T) {
+	testCases := []struct {
+		name          string
+		prowConfig    string
+		jobLabels     map[string]string
+		expectedHints []string
+		expectError   bool
+	}{
+		{
+			name: "hints are selected by job labels",
+			prowConfig: `
+plank:
+  pod_scheduling_hints:
+  - label_selector: "gpu=true"
+    annotations:
+      hint: gpu
+  - annotations:
+      hint: all
+`,
+			jobLabels:     map[string]string{"gpu": "true"},
+			expectedHints: []string{"gpu", "all"},
+		},
+		{
+			name: "non-matching hints are skipped",
+			prowConfig: `
+plank:
+  pod_scheduling_hints:
+  - label_selector: "gpu=true"
+    annotations:
+      hint: gpu
+  - annotations:
+      hint: all
+`,
+			jobLabels:     map[string]string{"gpu": "false"},
+			expectedHints: []string{"all"},
+		},
+		{
+			name: "invalid selector is rejected",
+			prowConfig: `
+plank:
+  pod_scheduling_hints:
+  - label_selector: "==="
+`,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			prowConfigDir, err := ioutil.TempDir("", "prowConfig")
+			if err != nil {
+				t.Fatalf("fail to make tempdir: %v", err)
+			}
+			defer os.RemoveAll(prowConfigDir)
+
+			prowConfig := filepath.Join(prowConfigDir, "config.yaml")
+			if err := ioutil.WriteFile(prowConfig, []byte(tc.prowConfig), 0666); err != nil {
+				t.Fatalf("fail to write prow config: %v", err)
+			}
+
+			cfg, err := Load(prowConfig, "")
+			if tc.expectError {
+				if err == nil {
+					t.Error("expected an error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var hints []string
+			for _, hint := range cfg.Plank.PodSchedulingHintsFor(tc.jobLabels) {
+				hints = append(hints, hint.Annotations["hint"])
+			}
+			if !reflect.DeepEqual(hints, tc.expectedHints) {
+				t.Errorf("expected hints %v, got %v", tc.expectedHints, hints)
+			}
+		})
+	}
+}
+
 func TestValidateComponentConfig(t *testing.T) {
 	testCases := []struct {
 		name        string
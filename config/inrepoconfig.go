@@ -94,16 +94,25 @@ func defaultProwYAMLGetter(
 		return nil, fmt.Errorf("failed to read %q: %v", inRepoConfigFileName, err)
 	}
 
+	prowYAML, err := ParseProwYAML(c, bytes, identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Debugf("Successfully got %d presubmits from %q.", len(prowYAML.Presubmits), inRepoConfigFileName)
+	return prowYAML, nil
+}
+
+// ParseProwYAML decodes the content of a .prow.yaml file for the repo
+// identified by org/repo, then defaults and validates it.
+func ParseProwYAML(c *Config, content []byte, identifier string) (*ProwYAML, error) {
 	prowYAML := &ProwYAML{}
-	if err := yaml.Unmarshal(bytes, prowYAML); err != nil {
+	if err := yaml.Unmarshal(content, prowYAML); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal %q: %v", inRepoConfigFileName, err)
 	}
-
 	if err := defaultAndValidateProwYAML(c, prowYAML, identifier); err != nil {
 		return nil, err
 	}
-
-	log.Debugf("Successfully got %d presubmits from %q.", len(prowYAML.Presubmits), inRepoConfigFileName)
 	return prowYAML, nil
 }
 
@@ -111,7 +120,7 @@ func defaultAndValidateProwYAML(c *Config, p *ProwYAML, identifier string) error
 	if err := defaultPresubmits(p.Presubmits, c, identifier); err != nil {
 		return err
 	}
-	if err := validatePresubmits(append(p.Presubmits, c.PresubmitsStatic[identifier]...), c.PodNamespace); err != nil {
+	if err := validatePresubmits(append(p.Presubmits, c.PresubmitsStatic[identifier]...), c.PodNamespaceForCluster); err != nil {
 		return err
 	}
 
@@ -183,6 +183,9 @@ type Periodic struct {
 	Cron string `json:"cron,omitempty"`
 	// Tags for config entries
 	Tags []string `json:"tags,omitempty"`
+	// StartupPriority orders the periodics horologium triggers in the same
+	// sync, higher priorities are triggered first. Defaults to 0.
+	StartupPriority int `json:"startup_priority,omitempty"`
 
 	interval time.Duration
 }
@@ -0,0 +1,141 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// JobChanges lists the names of the jobs of one kind that were added,
+// removed or modified between two job configs. Presubmits and postsubmits
+// are named org/repo:job.
+type JobChanges struct {
+	Added    []string `json:"added,omitempty"`
+	Removed  []string `json:"removed,omitempty"`
+	Modified []string `json:"modified,omitempty"`
+}
+
+// JobConfigDiff holds the changes between two job configs.
+type JobConfigDiff struct {
+	Presubmits  JobChanges `json:"presubmits"`
+	Postsubmits JobChanges `json:"postsubmits"`
+	Periodics   JobChanges `json:"periodics"`
+}
+
+// ExpandJobConfig defaults and validates the job config jc against the prow
+// config of c the same way Load does, so that it can be compared with the
+// job config of c.
+func (c *Config) ExpandJobConfig(jc JobConfig) (*JobConfig, error) {
+	nc := &Config{
+		ProwConfig: c.ProwConfig,
+		JobConfig:  jc,
+	}
+	// The loaded config already folded the deprecated default decoration
+	// config into DefaultDecorationConfigs.
+	nc.Plank.DefaultDecorationConfig = nil
+	nc.AllRepos = sets.String{}
+	if err := nc.finalizeJobConfig(); err != nil {
+		return nil, err
+	}
+	if err := nc.validateJobConfig(); err != nil {
+		return nil, err
+	}
+	return &nc.JobConfig, nil
+}
+
+// DiffJobConfigs returns the jobs that differ between the old and new job
+// configs. Jobs are matched by name, within the repo for presubmits and
+// postsubmits.
+func DiffJobConfigs(old, new JobConfig) (*JobConfigDiff, error) {
+	var diff JobConfigDiff
+	var err error
+	if diff.Presubmits, err = diffJobs(presubmitsByName(old.PresubmitsStatic), presubmitsByName(new.PresubmitsStatic)); err != nil {
+		return nil, fmt.Errorf("failed to compare presubmits: %v", err)
+	}
+	if diff.Postsubmits, err = diffJobs(postsubmitsByName(old.Postsubmits), postsubmitsByName(new.Postsubmits)); err != nil {
+		return nil, fmt.Errorf("failed to compare postsubmits: %v", err)
+	}
+	if diff.Periodics, err = diffJobs(periodicsByName(old.Periodics), periodicsByName(new.Periodics)); err != nil {
+		return nil, fmt.Errorf("failed to compare periodics: %v", err)
+	}
+	return &diff, nil
+}
+
+func presubmitsByName(presubmits map[string][]Presubmit) map[string]interface{} {
+	jobs := map[string]interface{}{}
+	for repo, ps := range presubmits {
+		for _, p := range ps {
+			jobs[repo+":"+p.Name] = p
+		}
+	}
+	return jobs
+}
+
+func postsubmitsByName(postsubmits map[string][]Postsubmit) map[string]interface{} {
+	jobs := map[string]interface{}{}
+	for repo, ps := range postsubmits {
+		for _, p := range ps {
+			jobs[repo+":"+p.Name] = p
+		}
+	}
+	return jobs
+}
+
+func periodicsByName(periodics []Periodic) map[string]interface{} {
+	jobs := map[string]interface{}{}
+	for _, p := range periodics {
+		jobs[p.Name] = p
+	}
+	return jobs
+}
+
+// diffJobs compares jobs by their serialized form, which leaves out derived
+// fields such as compiled regexes.
+func diffJobs(old, new map[string]interface{}) (JobChanges, error) {
+	var changes JobChanges
+	for name, newJob := range new {
+		oldJob, ok := old[name]
+		if !ok {
+			changes.Added = append(changes.Added, name)
+			continue
+		}
+		oldBytes, err := json.Marshal(oldJob)
+		if err != nil {
+			return JobChanges{}, err
+		}
+		newBytes, err := json.Marshal(newJob)
+		if err != nil {
+			return JobChanges{}, err
+		}
+		if string(oldBytes) != string(newBytes) {
+			changes.Modified = append(changes.Modified, name)
+		}
+	}
+	for name := range old {
+		if _, ok := new[name]; !ok {
+			changes.Removed = append(changes.Removed, name)
+		}
+	}
+	sort.Strings(changes.Added)
+	sort.Strings(changes.Removed)
+	sort.Strings(changes.Modified)
+	return changes, nil
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/diff"
+	"sigs.k8s.io/yaml"
+)
+
+func TestDiffJobConfigs(t *testing.T) {
+	oldConfig := `
+presubmits:
+  org/repo:
+  - name: unchanged
+    always_run: true
+    spec:
+      containers:
+      - image: alpine
+  - name: changed
+    always_run: true
+    spec:
+      containers:
+      - image: alpine
+  - name: removed
+    spec:
+      containers:
+      - image: alpine
+postsubmits:
+  org/repo:
+  - name: post-removed
+    spec:
+      containers:
+      - image: alpine
+periodics:
+- name: periodic
+  interval: 1h
+  spec:
+    containers:
+    - image: alpine
+`
+	newConfig := `
+presubmits:
+  org/repo:
+  - name: unchanged
+    always_run: true
+    spec:
+      containers:
+      - image: alpine
+  - name: changed
+    always_run: false
+    spec:
+      containers:
+      - image: alpine
+  org/other:
+  - name: added
+    spec:
+      containers:
+      - image: alpine
+periodics:
+- name: periodic
+  interval: 2h
+  spec:
+    containers:
+    - image: alpine
+- name: new-periodic
+  cron: "0 * * * *"
+  spec:
+    containers:
+    - image: alpine
+`
+	c := &Config{ProwConfig: ProwConfig{PodNamespace: "default"}}
+	expand := func(raw string) JobConfig {
+		var jc JobConfig
+		if err := yaml.Unmarshal([]byte(raw), &jc); err != nil {
+			t.Fatalf("failed to unmarshal job config: %v", err)
+		}
+		expanded, err := c.ExpandJobConfig(jc)
+		if err != nil {
+			t.Fatalf("failed to expand job config: %v", err)
+		}
+		return *expanded
+	}
+
+	actual, err := DiffJobConfigs(expand(oldConfig), expand(newConfig))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := &JobConfigDiff{
+		Presubmits: JobChanges{
+			Added:    []string{"org/other:added"},
+			Removed:  []string{"org/repo:removed"},
+			Modified: []string{"org/repo:changed"},
+		},
+		Postsubmits: JobChanges{
+			Removed: []string{"org/repo:post-removed"},
+		},
+		Periodics: JobChanges{
+			Added:    []string{"new-periodic"},
+			Modified: []string{"periodic"},
+		},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("unexpected diff: %s", diff.ObjectReflectDiff(expected, actual))
+	}
+
+	same, err := DiffJobConfigs(expand(oldConfig), expand(oldConfig))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(same, &JobConfigDiff{}) {
+		t.Errorf("expected no changes between identical configs, got %#v", same)
+	}
+}
+
+func TestExpandJobConfigInvalid(t *testing.T) {
+	c := &Config{ProwConfig: ProwConfig{PodNamespace: "default"}}
+	jc := JobConfig{Periodics: []Periodic{{JobBase: JobBase{Name: "no-schedule", Spec: nil}}}}
+	if _, err := c.ExpandJobConfig(jc); err == nil {
+		t.Error("expected an error expanding an invalid job config")
+	}
+}
@@ -31,6 +31,10 @@ import (
 	"github.com/clarketm/prow/github"
 )
 
+// DefaultAutoMergeLabel is the default label PRs need to be merged by
+// queries that require the auto merge label.
+const DefaultAutoMergeLabel = "auto-merge"
+
 // TideQueries is a TideQuery slice.
 type TideQueries []TideQuery
 
@@ -57,6 +61,18 @@ type TideRepoContextPolicy struct {
 	Branches map[string]TideContextPolicy `json:"branches,omitempty"`
 }
 
+// TidePoolSortOrder is the order in which Tide considers the PRs of a pool.
+type TidePoolSortOrder string
+
+const (
+	// PoolSortOrderNumber prefers PRs with lower numbers.
+	PoolSortOrderNumber TidePoolSortOrder = "number"
+	// PoolSortOrderCreated prefers PRs that were created earlier.
+	PoolSortOrderCreated TidePoolSortOrder = "created"
+	// PoolSortOrderUpdated prefers PRs that were updated least recently.
+	PoolSortOrderUpdated TidePoolSortOrder = "updated"
+)
+
 // TideContextPolicyOptions holds the default policy, and any org overrides.
 type TideContextPolicyOptions struct {
 	TideContextPolicy
@@ -93,6 +109,12 @@ type Tide struct {
 	// PullRequest struct (prow/github/types.go#PullRequest)
 	MergeTemplate map[string]TideMergeCommitTemplate `json:"merge_commit_template,omitempty"`
 
+	// A key/value pair of an org/repo as the key and a mapping from labels to
+	// merge commit title prefixes as the value, eg `kind/feature: "feat:"`.
+	// The prefix of a label on the PR is prepended to the commit title when
+	// squash merging or when a title template is configured.
+	MergeLabelPrefixes map[string]map[string]string `json:"merge_label_prefixes,omitempty"`
+
 	// URL for tide status contexts.
 	// We can consider allowing this to be set separately for separate repos, or
 	// allowing it to be a template.
@@ -108,6 +130,18 @@ type Tide struct {
 	// Leave this blank to disable this feature and save 1 API token per sync loop.
 	BlockerLabel string `json:"blocker_label,omitempty"`
 
+	// FreezeLabel is an optional label that is used to identify GitHub issues
+	// freezing merges to a repo or branch, eg during a code freeze. Unlike
+	// blockers, tests are still run for PRs in frozen pools.
+	// Leave this blank to disable this feature and save 1 API token per sync loop.
+	FreezeLabel string `json:"freeze_label,omitempty"`
+
+	// PauseLabel is an optional label that is used to identify GitHub issues
+	// pausing Tide on a repo or branch, eg while debugging it. Tide neither
+	// merges PRs nor triggers tests in paused pools, but still reports them.
+	// Leave this blank to disable this feature and save 1 API token per sync loop.
+	PauseLabel string `json:"pause_label,omitempty"`
+
 	// SquashLabel is an optional label that is used to identify PRs that should
 	// always be squash merged.
 	// Leave this blank to disable this feature.
@@ -128,6 +162,26 @@ type Tide struct {
 	// positive number.
 	MaxGoroutines int `json:"max_goroutines,omitempty"`
 
+	// MaxPoolSize is the maximum number of PRs of a single org/repo:branch
+	// pool that are synced per loop. Larger pools are truncated to their
+	// first PRs in PoolSortOrder so that a sync completes in bounded time.
+	// Defaults to 0, which means no limit.
+	MaxPoolSize int `json:"max_pool_size,omitempty"`
+
+	// MaxQueryResults is the maximum number of PRs a single query returns per
+	// sync. Pagination stops once the limit is reached, so that a query that
+	// matches a huge number of PRs can not make a sync unbounded. Queries
+	// without a sort qualifier keep their oldest PRs. Defaults to 0, which
+	// means no limit.
+	MaxQueryResults int `json:"max_query_results,omitempty"`
+
+	// PoolSortOrder determines which PRs of a pool Tide prefers when it picks
+	// a single PR to test or merge and when it selects PRs for a batch.
+	// Valid values are "number" (lowest PR number first), "created" (oldest
+	// PR first) and "updated" (least recently updated PR first). Defaults to
+	// "number".
+	PoolSortOrder TidePoolSortOrder `json:"pool_sort_order,omitempty"`
+
 	// TideContextPolicyOptions defines merge options for context. If not set it will infer
 	// the required and optional contexts from the prow jobs configured and use the github
 	// combined status; otherwise it may apply the branch protection setting or let user
@@ -141,6 +195,77 @@ type Tide struct {
 	//  0 => unlimited batch size
 	// -1 => batch merging disabled :(
 	BatchSizeLimitMap map[string]int `json:"batch_size_limit,omitempty"`
+
+	// MaxBatchesInFlightMap is a key/value pair of an org or org/repo as the
+	// key and the maximum number of batches Tide tests at once as the value.
+	// Batches that run at the same time never share a PR. The "*" key can be
+	// used as a global default.
+	// Defaults to 1. A value of 0 disables batch merging.
+	MaxBatchesInFlightMap map[string]int `json:"max_batches_in_flight,omitempty"`
+
+	// ExplainPoolExit makes Tide comment on PRs that leave the merge pool with
+	// the reason they were excluded. The comment is updated in place if the PR
+	// leaves the pool again. PRs that are only waiting for pending status
+	// contexts are not considered to have left the pool.
+	ExplainPoolExit bool `json:"explain_pool_exit,omitempty"`
+
+	// AbortStaleBatches makes Tide abort pending batch jobs that test a PR
+	// which left the pool or whose head changed, as their results can no
+	// longer be used to merge.
+	AbortStaleBatches bool `json:"abort_stale_batches,omitempty"`
+
+	// RetestCooldown is how long Tide waits after a presubmit of a PR
+	// failed before it triggers the presubmit for the PR again, so that
+	// deterministically failing PRs are not retested on every sync.
+	// Defaults to 0, which retriggers failed presubmits right away.
+	RetestCooldown *metav1.Duration `json:"retest_cooldown,omitempty"`
+
+	// MaxRetestsPerPR is how many times Tide triggers a presubmit for the
+	// head of a PR before it gives up and marks the PR as needing manual
+	// attention in its status context.
+	// Defaults to 0, which retests PRs without limit.
+	MaxRetestsPerPR int `json:"max_retests_per_pr,omitempty"`
+
+	// AutoMergeLabel is the label PRs of the queries that set
+	// autoMergeLabelRequired must have to be merged.
+	// Defaults to "auto-merge".
+	AutoMergeLabel string `json:"auto_merge_label,omitempty"`
+
+	// MinSHAAge is how long ago the head commit of a PR must have been
+	// pushed before Tide merges the PR, giving external CI time to report
+	// pending statuses for it.
+	// Defaults to 0, which merges PRs as soon as their contexts pass.
+	MinSHAAge *metav1.Duration `json:"min_sha_age,omitempty"`
+
+	// RequireBaseGreen makes Tide hold merges into a branch while a status
+	// context of the branch's head commit is failing. PRs are still tested.
+	RequireBaseGreen bool `json:"require_base_green,omitempty"`
+
+	// BatchGroups lists groups of linked repos whose PRs are tested and
+	// merged together in cross-repo batches instead of per-repo batches.
+	BatchGroups []TideBatchGroup `json:"batch_groups,omitempty"`
+}
+
+// TideBatchGroup is a group of repos whose PRs Tide batches together. Only
+// the pools of the group's repos for the same branch are batched together,
+// so the repos have to use the same branch names.
+type TideBatchGroup struct {
+	// Repos are the org/repo names of the repos in the group. The first repo
+	// is the primary repo, whose batch presubmits test the group's batches.
+	// The PRs of the other repos are added to these jobs as extra refs.
+	Repos []string `json:"repos"`
+}
+
+func (g TideBatchGroup) validate() error {
+	if len(g.Repos) < 2 {
+		return fmt.Errorf("needs at least two repos, got %v", g.Repos)
+	}
+	for _, repo := range g.Repos {
+		if parts := strings.Split(repo, "/"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf("repo %q is not of the form org/repo", repo)
+		}
+	}
+	return nil
 }
 
 func (t *Tide) BatchSizeLimit(org, repo string) int {
@@ -153,6 +278,21 @@ func (t *Tide) BatchSizeLimit(org, repo string) int {
 	return t.BatchSizeLimitMap["*"]
 }
 
+// MaxBatchesInFlight returns how many batches Tide tests at once for a repo.
+// The default of 1 is returned when not overridden.
+func (t *Tide) MaxBatchesInFlight(org, repo string) int {
+	if max, ok := t.MaxBatchesInFlightMap[fmt.Sprintf("%s/%s", org, repo)]; ok {
+		return max
+	}
+	if max, ok := t.MaxBatchesInFlightMap[org]; ok {
+		return max
+	}
+	if max, ok := t.MaxBatchesInFlightMap["*"]; ok {
+		return max
+	}
+	return 1
+}
+
 // MergeMethod returns the merge method to use for a repo. The default of merge is
 // returned when not overridden.
 func (t *Tide) MergeMethod(org, repo string) github.PullRequestMergeType {
@@ -182,6 +322,15 @@ func (t *Tide) MergeCommitTemplate(org, repo string) TideMergeCommitTemplate {
 	return v
 }
 
+// MergeLabelPrefixesFor returns the merge commit title prefixes to use for
+// labels on PRs of a repo.
+func (t *Tide) MergeLabelPrefixesFor(org, repo string) map[string]string {
+	if v, ok := t.MergeLabelPrefixes[org+"/"+repo]; ok {
+		return v
+	}
+	return t.MergeLabelPrefixes[org]
+}
+
 // TideQuery is turned into a GitHub search query. See the docs for details:
 // https://help.github.com/articles/searching-issues-and-pull-requests/
 type TideQuery struct {
@@ -194,6 +343,21 @@ type TideQuery struct {
 
 	Labels        []string `json:"labels,omitempty"`
 	MissingLabels []string `json:"missingLabels,omitempty"`
+	// RequiredMergeLabels are labels a PR must have to enter the merge pool.
+	// Unlike Labels, they are not part of the search query, so PRs missing
+	// them are still reported on with the labels they need.
+	RequiredMergeLabels []string `json:"requiredMergeLabels,omitempty"`
+	// AutoMergeLabelRequired makes merging opt-in per PR: only PRs with
+	// Tide's auto merge label enter the merge pool, while all the PRs of the
+	// query are still reported on.
+	AutoMergeLabelRequired bool `json:"autoMergeLabelRequired,omitempty"`
+	// RequiredStatusContexts are status contexts that must pass before Tide
+	// merges a PR into the repos and branches of the query. The contexts of
+	// all queries for a repo and branch apply to all of its PRs, regardless
+	// of which query a PR matches. They are only required for branches whose
+	// required contexts do not come from branch protection, which makes them
+	// useful for repos that cannot use branch protection.
+	RequiredStatusContexts []string `json:"requiredStatusContexts,omitempty"`
 
 	Milestone string `json:"milestone,omitempty"`
 
@@ -267,6 +431,50 @@ func reposInOrg(org string, repos []string) []string {
 	return res
 }
 
+// MergeLabels returns the labels a PR of the query must have to enter the
+// merge pool: the required merge labels and, if the query requires it,
+// Tide's auto merge label.
+func (tq TideQuery) MergeLabels(autoMergeLabel string) []string {
+	if !tq.AutoMergeLabelRequired {
+		return tq.RequiredMergeLabels
+	}
+	if autoMergeLabel == "" {
+		autoMergeLabel = DefaultAutoMergeLabel
+	}
+	return append(append([]string{}, tq.RequiredMergeLabels...), autoMergeLabel)
+}
+
+// MissingRequiredMergeLabels returns the merge labels of the query that are
+// not in labels.
+func (tq TideQuery) MissingRequiredMergeLabels(labels []string, autoMergeLabel string) []string {
+	present := sets.NewString(labels...)
+	var missing []string
+	for _, l := range tq.MergeLabels(autoMergeLabel) {
+		if !present.Has(l) {
+			missing = append(missing, l)
+		}
+	}
+	return missing
+}
+
+// ForBranch returns whether the query covers PRs against the branch.
+func (tq TideQuery) ForBranch(branch string) bool {
+	for _, b := range tq.ExcludedBranches {
+		if b == branch {
+			return false
+		}
+	}
+	if len(tq.IncludedBranches) == 0 {
+		return true
+	}
+	for _, b := range tq.IncludedBranches {
+		if b == branch {
+			return true
+		}
+	}
+	return false
+}
+
 // OrgExceptionsAndRepos determines which orgs and repos a set of queries cover.
 // Output is returned as a mapping from 'included org'->'repos excluded in the org'
 // and a set of included repos.
@@ -416,6 +624,15 @@ func (tq *TideQuery) Validate() error {
 	if err := duplicates("missingLabels", tq.MissingLabels); err != nil {
 		return err
 	}
+	if invalids := sets.NewString(tq.RequiredMergeLabels...).Intersection(sets.NewString(tq.MissingLabels...)); len(invalids) > 0 {
+		return fmt.Errorf("the labels: %q are both required for merge and forbidden", invalids.List())
+	}
+	if err := duplicates("requiredMergeLabels", tq.RequiredMergeLabels); err != nil {
+		return err
+	}
+	if err := duplicates("requiredStatusContexts", tq.RequiredStatusContexts); err != nil {
+		return err
+	}
 
 	if len(tq.ExcludedBranches) > 0 && len(tq.IncludedBranches) > 0 {
 		return errors.New("both 'includedBranches' and 'excludedBranches' are specified ('excludedBranches' have no effect)")
@@ -511,12 +728,23 @@ func (c Config) GetTideContextPolicy(gitClient *git.Client, org, repo, branch st
 	optional.Insert(prowOptional...)
 
 	// Using Branch protection configuration
+	var fromBranchProtection bool
 	if options.FromBranchProtection != nil && *options.FromBranchProtection {
 		bp, err := c.GetBranchProtection(org, repo, branch, presubmits)
 		if err != nil {
 			logrus.WithError(err).Warningf("Error getting branch protection for %s/%s+%s", org, repo, branch)
 		} else if bp != nil && bp.Protect != nil && *bp.Protect && bp.RequiredStatusChecks != nil {
 			required.Insert(bp.RequiredStatusChecks.Contexts...)
+			fromBranchProtection = true
+		}
+	}
+
+	// Without branch protection, the tide queries can require contexts.
+	if !fromBranchProtection {
+		for _, query := range c.Tide.Queries {
+			if query.ForRepo(org, repo) && query.ForBranch(branch) {
+				required.Insert(query.RequiredStatusContexts...)
+			}
 		}
 	}
 
@@ -150,6 +150,52 @@ func TestMergeMethod(t *testing.T) {
 		}
 	}
 }
+func TestMaxBatchesInFlight(t *testing.T) {
+	testCases := []struct {
+		name     string
+		maxMap   map[string]int
+		org      string
+		repo     string
+		expected int
+	}{
+		{
+			name:     "defaults to one batch",
+			org:      "o",
+			repo:     "r",
+			expected: 1,
+		},
+		{
+			name:     "global default",
+			maxMap:   map[string]int{"*": 3},
+			org:      "o",
+			repo:     "r",
+			expected: 3,
+		},
+		{
+			name:     "org overrides global default",
+			maxMap:   map[string]int{"*": 3, "o": 0},
+			org:      "o",
+			repo:     "r",
+			expected: 0,
+		},
+		{
+			name:     "repo overrides org",
+			maxMap:   map[string]int{"*": 3, "o": 0, "o/r": 2},
+			org:      "o",
+			repo:     "r",
+			expected: 2,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ti := &Tide{MaxBatchesInFlightMap: tc.maxMap}
+			if actual := ti.MaxBatchesInFlight(tc.org, tc.repo); actual != tc.expected {
+				t.Errorf("expected %d max batches in flight, got %d", tc.expected, actual)
+			}
+		})
+	}
+}
+
 func TestMergeTemplate(t *testing.T) {
 	ti := &Tide{
 		MergeTemplate: map[string]TideMergeCommitTemplate{
@@ -476,6 +522,71 @@ func TestConfigGetTideContextPolicy(t *testing.T) {
 				OptionalContexts:          []string{},
 			},
 		},
+		{
+			name: "query contexts are required without branch protection",
+			config: Config{
+				ProwConfig: ProwConfig{
+					Tide: Tide{
+						Queries: TideQueries{
+							{
+								Repos:                  []string{"org/repo"},
+								RequiredStatusContexts: []string{"q1"},
+							},
+							{
+								Orgs:                   []string{"org"},
+								ExcludedBranches:       []string{"branch"},
+								RequiredStatusContexts: []string{"q2"},
+							},
+							{
+								Repos:                  []string{"org/other"},
+								RequiredStatusContexts: []string{"q3"},
+							},
+						},
+					},
+				},
+			},
+			expected: TideContextPolicy{
+				RequiredContexts:          []string{"q1"},
+				RequiredIfPresentContexts: []string{},
+				OptionalContexts:          []string{},
+			},
+		},
+		{
+			name: "query contexts are ignored when branch protection requires contexts",
+			config: Config{
+				ProwConfig: ProwConfig{
+					BranchProtection: BranchProtection{
+						Policy: Policy{
+							Protect: &yes,
+							RequiredStatusChecks: &ContextPolicy{
+								Contexts: []string{"r1"},
+							},
+						},
+						Orgs: map[string]Org{
+							"org": {},
+						},
+					},
+					Tide: Tide{
+						ContextOptions: TideContextPolicyOptions{
+							TideContextPolicy: TideContextPolicy{
+								FromBranchProtection: &yes,
+							},
+						},
+						Queries: TideQueries{
+							{
+								Repos:                  []string{"org/repo"},
+								RequiredStatusContexts: []string{"q1"},
+							},
+						},
+					},
+				},
+			},
+			expected: TideContextPolicy{
+				RequiredContexts:          []string{"r1"},
+				RequiredIfPresentContexts: []string{},
+				OptionalContexts:          []string{},
+			},
+		},
 		{
 			name: "manually defined policy",
 			config: Config{
@@ -832,6 +943,32 @@ func TestTideQuery_Validate(t *testing.T) {
 			},
 			expectError: true,
 		},
+		{
+			name: "required merge labels are valid",
+			query: TideQuery{
+				Orgs:                []string{"kuber"},
+				MissingLabels:       []string{"do-not-merge/evil-code"},
+				RequiredMergeLabels: []string{labels.LGTM, labels.Approved},
+			},
+			expectError: false,
+		},
+		{
+			name: "label cannot be required for merge and forbidden",
+			query: TideQuery{
+				Orgs:                []string{"kuber"},
+				MissingLabels:       []string{"do-not-merge/evil-code", labels.LGTM},
+				RequiredMergeLabels: []string{labels.LGTM},
+			},
+			expectError: true,
+		},
+		{
+			name: "duplicate required merge labels are invalid",
+			query: TideQuery{
+				Orgs:                []string{"kuber"},
+				RequiredMergeLabels: []string{labels.LGTM, labels.LGTM},
+			},
+			expectError: true,
+		},
 		{
 			name: "simple excluded branches query is valid",
 			query: TideQuery{
@@ -902,6 +1039,96 @@ func TestTideContextPolicy_Validate(t *testing.T) {
 	}
 }
 
+func TestTideBatchGroups_Validate(t *testing.T) {
+	testCases := []struct {
+		name   string
+		groups []TideBatchGroup
+		failed bool
+	}{
+		{
+			name: "good groups",
+			groups: []TideBatchGroup{
+				{Repos: []string{"o/a", "o/b"}},
+				{Repos: []string{"o/c", "other/d", "other/e"}},
+			},
+		},
+		{
+			name:   "a group needs at least two repos",
+			groups: []TideBatchGroup{{Repos: []string{"o/a"}}},
+			failed: true,
+		},
+		{
+			name:   "repos must be of the form org/repo",
+			groups: []TideBatchGroup{{Repos: []string{"o/a", "o"}}},
+			failed: true,
+		},
+		{
+			name: "a repo can only be part of one group",
+			groups: []TideBatchGroup{
+				{Repos: []string{"o/a", "o/b"}},
+				{Repos: []string{"o/c", "o/a"}},
+			},
+			failed: true,
+		},
+	}
+	for _, tc := range testCases {
+		c := &Config{ProwConfig: ProwConfig{Tide: Tide{BatchGroups: tc.groups}}}
+		err := parseProwConfig(c)
+		failed := err != nil
+		if failed != tc.failed {
+			t.Errorf("%s - expected %v got %v", tc.name, tc.failed, err)
+		}
+	}
+}
+
+func TestTideAutoMergeLabel(t *testing.T) {
+	testCases := []struct {
+		name           string
+		autoMergeLabel string
+		query          TideQuery
+
+		expectedLabels []string
+		failed         bool
+	}{
+		{
+			name:           "auto merge label not required",
+			query:          TideQuery{Orgs: []string{"o"}, RequiredMergeLabels: []string{"lgtm"}},
+			expectedLabels: []string{"lgtm"},
+		},
+		{
+			name:           "default auto merge label",
+			query:          TideQuery{Orgs: []string{"o"}, RequiredMergeLabels: []string{"lgtm"}, AutoMergeLabelRequired: true},
+			expectedLabels: []string{"lgtm", "auto-merge"},
+		},
+		{
+			name:           "configured auto merge label",
+			autoMergeLabel: "ship-it",
+			query:          TideQuery{Orgs: []string{"o"}, AutoMergeLabelRequired: true},
+			expectedLabels: []string{"ship-it"},
+		},
+		{
+			name:   "auto merge label cannot be forbidden",
+			query:  TideQuery{Orgs: []string{"o"}, MissingLabels: []string{"auto-merge"}, AutoMergeLabelRequired: true},
+			failed: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &Config{ProwConfig: ProwConfig{Tide: Tide{AutoMergeLabel: tc.autoMergeLabel, Queries: TideQueries{tc.query}}}}
+			err := parseProwConfig(c)
+			if failed := err != nil; failed != tc.failed {
+				t.Fatalf("expected failure %t, got %v", tc.failed, err)
+			}
+			if tc.failed {
+				return
+			}
+			if labels := c.Tide.Queries[0].MergeLabels(c.Tide.AutoMergeLabel); !reflect.DeepEqual(labels, tc.expectedLabels) {
+				t.Errorf("expected merge labels %v, got %v", tc.expectedLabels, labels)
+			}
+		})
+	}
+}
+
 func TestTideContextPolicy_IsOptional(t *testing.T) {
 	testCases := []struct {
 		name                string
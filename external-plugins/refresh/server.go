@@ -169,6 +169,8 @@ func (s *server) handleIssueComment(l *logrus.Entry, ic github.IssueCommentEvent
 	jenkinsConfig := s.configAgent.Config().JenkinsOperators
 	kubeReport := s.configAgent.Config().Plank.ReportTemplate
 	reportTypes := s.configAgent.Config().GitHubReporter.JobTypesToReport
+	statusGroups := s.configAgent.Config().GitHubReporter.StatusGroups
+	jobs := report.StaticJobLister(presubmits)
 	for _, pj := range pjutil.GetLatestProwJobs(presubmits, prowapi.PresubmitJob) {
 		var reportTemplate *template.Template
 		switch pj.Spec.Agent {
@@ -182,7 +184,7 @@ func (s *server) handleIssueComment(l *logrus.Entry, ic github.IssueCommentEvent
 		}
 
 		s.log.WithFields(l.Data).Infof("Refreshing the status of job %q (pj: %s)", pj.Spec.Job, pj.ObjectMeta.Name)
-		if err := report.Report(s.ghc, reportTemplate, pj, reportTypes); err != nil {
+		if err := report.Report(s.ghc, reportTemplate, pj, reportTypes, statusGroups, jobs); err != nil {
 			s.log.WithError(err).WithFields(l.Data).Info("Failed report.")
 		}
 	}
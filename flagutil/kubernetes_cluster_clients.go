@@ -167,8 +167,10 @@ func (o *KubernetesOptions) InfrastructureClusterClient(dryRun bool) (kubernetes
 	return o.kubernetesClientsByContext[kube.InClusterContext], nil
 }
 
-// BuildClusterClients returns Pod clients for build clusters.
-func (o *KubernetesOptions) BuildClusterClients(namespace string, dryRun bool) (buildClusterClients map[string]corev1.PodInterface, err error) {
+// BuildClusterClients returns Pod clients for build clusters. The client for
+// each build cluster is scoped to the namespace that podNamespace returns for
+// its alias.
+func (o *KubernetesOptions) BuildClusterClients(podNamespace func(cluster string) string, dryRun bool) (buildClusterClients map[string]corev1.PodInterface, err error) {
 	if err := o.resolve(dryRun); err != nil {
 		return nil, err
 	}
@@ -179,7 +181,7 @@ func (o *KubernetesOptions) BuildClusterClients(namespace string, dryRun bool) (
 
 	buildClients := map[string]corev1.PodInterface{}
 	for context, client := range o.kubernetesClientsByContext {
-		buildClients[context] = client.CoreV1().Pods(namespace)
+		buildClients[context] = client.CoreV1().Pods(podNamespace(context))
 	}
 	return buildClients, nil
 }
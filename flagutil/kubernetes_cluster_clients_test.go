@@ -19,6 +19,10 @@ package flagutil
 import (
 	"testing"
 
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
 	"k8s.io/test-infra/pkg/flagutil"
 )
 
@@ -63,3 +67,42 @@ func TestExperimentalKubernetesOptions_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildClusterClients(t *testing.T) {
+	clients := map[string]kubernetes.Interface{
+		"default": fake.NewSimpleClientset(),
+		"trusted": fake.NewSimpleClientset(),
+	}
+	o := &KubernetesOptions{
+		resolved:                   true,
+		kubernetesClientsByContext: clients,
+	}
+	namespaces := map[string]string{"trusted": "trusted-pods"}
+	podNamespace := func(cluster string) string {
+		if namespace, ok := namespaces[cluster]; ok {
+			return namespace
+		}
+		return "test-pods"
+	}
+
+	buildClients, err := o.BuildClusterClients(podNamespace, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]string{
+		"default": "test-pods",
+		"trusted": "trusted-pods",
+	}
+	for cluster, namespace := range expected {
+		if _, err := buildClients[cluster].Create(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod"}}); err != nil {
+			t.Fatalf("%s: failed to create pod: %v", cluster, err)
+		}
+		pods, err := clients[cluster].CoreV1().Pods(namespace).List(metav1.ListOptions{})
+		if err != nil {
+			t.Fatalf("%s: failed to list pods: %v", cluster, err)
+		}
+		if len(pods.Items) != 1 {
+			t.Errorf("%s: expected the pod to be created in namespace %q, found %d pods there", cluster, namespace, len(pods.Items))
+		}
+	}
+}
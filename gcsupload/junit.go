@@ -0,0 +1,103 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcsupload
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"github.com/GoogleCloudPlatform/testgrid/metadata/junit"
+	"github.com/sirupsen/logrus"
+)
+
+// JUnitSummaryName is the name of the merged JUnit summary, uploaded to the
+// root of the job's GCS path. It lives outside of the artifacts directory so
+// that the JUnit lens and TestGrid, which read artifacts/junit*.xml, do not
+// count its tests twice.
+const JUnitSummaryName = "junit_summary.xml"
+
+// junitSummarySuite is the name of the suite holding all merged suites.
+const junitSummarySuite = "summary"
+
+var junitFileRE = regexp.MustCompile(`^junit.*\.xml$`)
+
+// findJUnitFiles returns the JUnit XML files among the items, descending into
+// directories.
+func findJUnitFiles(items []string) []string {
+	var files []string
+	for _, item := range items {
+		filepath.Walk(item, func(fspath string, info os.FileInfo, err error) error {
+			if info == nil || info.IsDir() {
+				return nil
+			}
+			if junitFileRE.MatchString(info.Name()) {
+				files = append(files, fspath)
+			}
+			return nil
+		})
+	}
+	sort.Strings(files)
+	return files
+}
+
+// junitSummary merges the suites of all JUnit files into a single summary
+// suite whose counts cover every merged test case. Files that can not be
+// parsed are skipped.
+func junitSummary(files []string) ([]byte, error) {
+	summary := junit.Suite{Name: junitSummarySuite}
+	for _, file := range files {
+		contents, err := ioutil.ReadFile(file)
+		if err != nil {
+			logrus.WithError(err).Warnf("Could not read JUnit file %s, skipping it in the summary.", file)
+			continue
+		}
+		suites, err := junit.Parse(contents)
+		if err != nil {
+			logrus.WithError(err).Warnf("Could not parse JUnit file %s, skipping it in the summary.", file)
+			continue
+		}
+		for _, suite := range suites.Suites {
+			tests, failures := countResults(suite)
+			summary.Tests += tests
+			summary.Failures += failures
+			summary.Time += suite.Time
+			summary.Suites = append(summary.Suites, suite)
+		}
+	}
+	return xml.MarshalIndent(junit.Suites{Suites: []junit.Suite{summary}}, "", "  ")
+}
+
+// countResults counts the test cases and failures of a suite and all of its
+// nested suites.
+func countResults(suite junit.Suite) (int, int) {
+	tests, failures := len(suite.Results), 0
+	for _, result := range suite.Results {
+		if result.Failure != nil {
+			failures++
+		}
+	}
+	for _, nested := range suite.Suites {
+		nestedTests, nestedFailures := countResults(nested)
+		tests += nestedTests
+		failures += nestedFailures
+	}
+	return tests, failures
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcsupload
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/testgrid/metadata/junit"
+)
+
+func TestJUnitSummary(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "junit")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	files := map[string]string{
+		"artifacts/junit_01.xml": `<testsuite name="unit" time="1.5">
+  <testcase name="passes"/>
+  <testcase name="fails"><failure>boom</failure></testcase>
+</testsuite>`,
+		"artifacts/nested/junit_02.xml": `<testsuites>
+  <testsuite name="e2e" time="10">
+    <testcase name="passes"/>
+    <testcase name="skipped"><skipped/></testcase>
+    <testsuite name="inner">
+      <testcase name="fails"><failure>bang</failure></testcase>
+    </testsuite>
+  </testsuite>
+  <testsuite name="integration" time="2">
+    <testcase name="passes"/>
+  </testsuite>
+</testsuites>`,
+		"artifacts/junit_broken.xml": `not xml`,
+		"artifacts/build.log":        `<testsuite name="not-junit"><testcase name="ignored"/></testsuite>`,
+	}
+	for name, contents := range files {
+		fullPath := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("error creating dir: %v", err)
+		}
+		if err := ioutil.WriteFile(fullPath, []byte(contents), 0644); err != nil {
+			t.Fatalf("error writing file: %v", err)
+		}
+	}
+
+	found := findJUnitFiles([]string{filepath.Join(tmpDir, "artifacts")})
+	expectedFiles := []string{
+		filepath.Join(tmpDir, "artifacts/junit_01.xml"),
+		filepath.Join(tmpDir, "artifacts/junit_broken.xml"),
+		filepath.Join(tmpDir, "artifacts/nested/junit_02.xml"),
+	}
+	if !reflect.DeepEqual(found, expectedFiles) {
+		t.Fatalf("expected JUnit files %v, got %v", expectedFiles, found)
+	}
+
+	raw, err := junitSummary(found)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	summary, err := junit.Parse(raw)
+	if err != nil {
+		t.Fatalf("could not parse the summary: %v", err)
+	}
+	if len(summary.Suites) != 1 {
+		t.Fatalf("expected a single summary suite, got %d", len(summary.Suites))
+	}
+	suite := summary.Suites[0]
+	if suite.Name != junitSummarySuite {
+		t.Errorf("expected summary suite %q, got %q", junitSummarySuite, suite.Name)
+	}
+	if suite.Tests != 6 {
+		t.Errorf("expected 6 tests, got %d", suite.Tests)
+	}
+	if suite.Failures != 2 {
+		t.Errorf("expected 2 failures, got %d", suite.Failures)
+	}
+	if suite.Time != 13.5 {
+		t.Errorf("expected a time of 13.5s, got %v", suite.Time)
+	}
+	var names []string
+	for _, merged := range suite.Suites {
+		names = append(names, merged.Name)
+	}
+	if expected := []string{"unit", "e2e", "integration"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected merged suites %v, got %v", expected, names)
+	}
+}
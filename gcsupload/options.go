@@ -51,6 +51,10 @@ type Options struct {
 	GcsCredentialsFile string `json:"gcs_credentials_file,omitempty"`
 	DryRun             bool   `json:"dry_run"`
 
+	// Concurrency bounds the number of artifacts that are
+	// uploaded in parallel. Zero uploads everything at once.
+	Concurrency int `json:"concurrency,omitempty"`
+
 	// mediaTypes holds additional extension media types to add to Go's
 	// builtin's and the local system's defaults.  Values are
 	// colon-delimited {extension}:{media-type}, for example:
@@ -66,6 +70,9 @@ type Options struct {
 // Validate ensures that the set of options are
 // self-consistent and valid.
 func (o *Options) Validate() error {
+	if o.Concurrency < 0 {
+		return errors.New("upload concurrency cannot be negative")
+	}
 	if o.LocalOutputDir != "" {
 		return nil
 	}
@@ -128,6 +135,8 @@ func (o *Options) AddFlags(fs *flag.FlagSet) {
 	fs.Var(&o.gcsPath, "gcs-path", "GCS path to upload into")
 	fs.StringVar(&o.GcsCredentialsFile, "gcs-credentials-file", "", "file where Google Cloud authentication credentials are stored")
 	fs.BoolVar(&o.DryRun, "dry-run", true, "do not interact with GCS")
+	fs.IntVar(&o.Concurrency, "concurrency", 0, "Maximum number of artifacts to upload in parallel, 0 for no limit")
+	fs.BoolVar(&o.JUnitSummary, "junit-summary", false, "Merge the uploaded junit*.xml files into a single "+JUnitSummaryName+" summary")
 
 	fs.Var(&o.mediaTypes, "media-type", "Optional comma-delimited set of extension media types.  Each entry is colon-delimited {extension}:{media-type}, for example, log:text/plain.")
 
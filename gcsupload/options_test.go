@@ -72,6 +72,17 @@ func TestOptions_Validate(t *testing.T) {
 			},
 			expectedErr: true,
 		},
+		{
+			name: "negative concurrency",
+			input: Options{
+				DryRun:      true,
+				Concurrency: -1,
+				GCSConfiguration: &prowapi.GCSConfiguration{
+					PathStrategy: prowapi.PathStrategyExplicit,
+				},
+			},
+			expectedErr: true,
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -17,7 +17,9 @@ limitations under the License.
 package gcsupload
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"mime"
 	"os"
@@ -61,12 +63,12 @@ func (o Options) Run(spec *downwardapi.JobSpec, extra map[string]gcs.UploadFunc)
 			return fmt.Errorf("could not connect to GCS: %v", err)
 		}
 
-		if err := gcs.Upload(gcsClient.Bucket(o.Bucket), uploadTargets); err != nil {
+		if err := gcs.UploadWithConcurrency(gcsClient.Bucket(o.Bucket), uploadTargets, o.Concurrency); err != nil {
 			return fmt.Errorf("failed to upload to GCS: %v", err)
 		}
 		logrus.Info("Finished upload to GCS")
 	} else {
-		if err := gcs.LocalExport(o.LocalOutputDir, uploadTargets); err != nil {
+		if err := gcs.LocalExportWithConcurrency(o.LocalOutputDir, uploadTargets, o.Concurrency); err != nil {
 			return fmt.Errorf("failed to copy files to %q: %v", o.LocalOutputDir, err)
 		}
 		logrus.Infof("Finished copying files to %q.", o.LocalOutputDir)
@@ -122,6 +124,24 @@ func (o Options) assembleTargets(spec *downwardapi.JobSpec, extra map[string]gcs
 		}
 	}
 
+	if o.GCSConfiguration.JUnitSummary {
+		if files := findJUnitFiles(o.Items); len(files) > 0 {
+			if summary, err := junitSummary(files); err != nil {
+				logrus.WithError(err).Warn("Could not create the JUnit summary.")
+			} else {
+				uploadTargets[path.Join(gcsPath, JUnitSummaryName)] = gcs.DataUpload(bytes.NewReader(summary))
+			}
+		}
+	}
+
+	if class := o.GCSConfiguration.RetentionClass; class != "" {
+		if retention, err := json.Marshal(gcs.Retention{Class: class}); err != nil {
+			logrus.WithError(err).Warn("Could not marshal the retention metadata.")
+		} else {
+			uploadTargets[path.Join(gcsPath, gcs.RetentionMetadataName)] = gcs.DataUpload(bytes.NewReader(retention))
+		}
+	}
+
 	for destination, upload := range extra {
 		uploadTargets[path.Join(gcsPath, destination)] = upload
 	}
@@ -17,6 +17,7 @@ limitations under the License.
 package gcsupload
 
 import (
+	"encoding/json"
 	"io/ioutil"
 	"os"
 	"path"
@@ -153,6 +154,44 @@ func TestOptions_AssembleTargets(t *testing.T) {
 				"pr-logs/pull/org_repo/1/job/latest-build.txt",
 			},
 		},
+		{
+			name:    "junit summary should be uploaded under job dir",
+			jobType: prowapi.PresubmitJob,
+			options: Options{
+				Items: []string{"artifacts"},
+				GCSConfiguration: &prowapi.GCSConfiguration{
+					PathStrategy: prowapi.PathStrategyExplicit,
+					Bucket:       "bucket",
+					JUnitSummary: true,
+				},
+			},
+			paths: []string{"artifacts/", "artifacts/junit_01.xml", "artifacts/build.log"},
+			expected: []string{
+				"pr-logs/pull/org_repo/1/job/build/artifacts/junit_01.xml",
+				"pr-logs/pull/org_repo/1/job/build/artifacts/build.log",
+				"pr-logs/pull/org_repo/1/job/build/junit_summary.xml",
+				"pr-logs/directory/job/build.txt",
+				"pr-logs/directory/job/latest-build.txt",
+				"pr-logs/pull/org_repo/1/job/latest-build.txt",
+			},
+		},
+		{
+			name:    "retention metadata should be uploaded under job dir",
+			jobType: prowapi.PresubmitJob,
+			options: Options{
+				GCSConfiguration: &prowapi.GCSConfiguration{
+					PathStrategy:   prowapi.PathStrategyExplicit,
+					Bucket:         "bucket",
+					RetentionClass: prowapi.RetentionClassShort,
+				},
+			},
+			expected: []string{
+				"pr-logs/pull/org_repo/1/job/build/retention.json",
+				"pr-logs/directory/job/build.txt",
+				"pr-logs/directory/job/latest-build.txt",
+				"pr-logs/pull/org_repo/1/job/latest-build.txt",
+			},
+		},
 		{
 			name:    "only job dir files should be output in local mode",
 			jobType: prowapi.PresubmitJob,
@@ -278,3 +317,39 @@ func TestBuilderForStrategy(t *testing.T) {
 		}
 	}
 }
+
+func TestRunWritesRetentionMetadata(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "retention")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	options := Options{
+		GCSConfiguration: &prowapi.GCSConfiguration{
+			PathStrategy:   prowapi.PathStrategyExplicit,
+			LocalOutputDir: tmpDir,
+			RetentionClass: prowapi.RetentionClassLong,
+		},
+	}
+	spec := &downwardapi.JobSpec{
+		Job:     "job",
+		Type:    prowapi.PeriodicJob,
+		BuildID: "build",
+	}
+	if err := options.Run(spec, nil); err != nil {
+		t.Fatalf("error running upload: %v", err)
+	}
+
+	raw, err := ioutil.ReadFile(path.Join(tmpDir, gcs.RetentionMetadataName))
+	if err != nil {
+		t.Fatalf("error reading retention metadata: %v", err)
+	}
+	var retention gcs.Retention
+	if err := json.Unmarshal(raw, &retention); err != nil {
+		t.Fatalf("error unmarshaling retention metadata: %v", err)
+	}
+	if retention.Class != prowapi.RetentionClassLong {
+		t.Errorf("expected retention class %q, got %q", prowapi.RetentionClassLong, retention.Class)
+	}
+}
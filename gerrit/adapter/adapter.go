@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/andygrunwald/go-gerrit"
@@ -56,6 +57,32 @@ type Controller struct {
 	prowJobClient prowJobClient
 	gc            gerritClient
 	tracker       LastSyncTracker
+
+	// backoffs tracks the projects whose changes failed to process, keyed
+	// by instance and project.
+	backoffLock sync.Mutex
+	backoffs    map[projectKey]projectBackoff
+}
+
+const (
+	// initialProjectBackoff is how long a project is skipped after its
+	// changes first failed to process. The backoff doubles on each
+	// consecutive failure up to maxProjectBackoff.
+	initialProjectBackoff = time.Minute
+	maxProjectBackoff     = 30 * time.Minute
+)
+
+// projectKey identifies a project of a gerrit instance.
+type projectKey struct {
+	instance string
+	project  string
+}
+
+// projectBackoff records the consecutive sync failures of a project and
+// until when the project is skipped.
+type projectBackoff struct {
+	failures int
+	until    time.Time
 }
 
 type LastSyncTracker interface {
@@ -84,29 +111,106 @@ func NewController(lastSyncTracker LastSyncTracker, cookiefilePath string, proje
 }
 
 // Sync looks for newly made gerrit changes
-// and creates prowjobs according to specs
+// and creates prowjobs according to specs.
+// Projects are synced concurrently, up to Gerrit.SyncConcurrency at a time.
+// A project whose changes failed to process is skipped by the following
+// syncs until its backoff expires, leaving its changes for a later sync.
 func (c *Controller) Sync() error {
 	syncTime := c.tracker.Current()
 	latest := syncTime.DeepCopy()
 
+	changesByProject := map[projectKey][]client.ChangeInfo{}
 	for instance, changes := range c.gc.QueryChanges(syncTime, c.config().Gerrit.RateLimit) {
 		for _, change := range changes {
-			if err := c.ProcessChange(instance, change); err != nil {
-				logrus.WithError(err).Errorf("Failed process change %v", change.CurrentRevision)
-			}
-			lastTime, ok := latest[instance][change.Project]
-			if !ok || lastTime.Before(change.Updated.Time) {
-				lastTime = change.Updated.Time
-				latest[instance][change.Project] = lastTime
-			}
+			key := projectKey{instance: instance, project: change.Project}
+			changesByProject[key] = append(changesByProject[key], change)
+		}
+	}
+
+	concurrency := c.config().Gerrit.SyncConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	sema := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	var latestLock sync.Mutex
+	now := time.Now()
+	for key, changes := range changesByProject {
+		log := logrus.WithFields(logrus.Fields{"instance": key.instance, "project": key.project})
+		if until, backingOff := c.backingOff(key, now); backingOff {
+			log.Infof("Skipping %d changes until %v after the project failed to sync", len(changes), until)
+			continue
 		}
+		wg.Add(1)
+		sema <- struct{}{}
+		go func(key projectKey, changes []client.ChangeInfo, log *logrus.Entry) {
+			defer func() {
+				<-sema
+				wg.Done()
+			}()
+			var failed bool
+			var lastTime time.Time
+			for _, change := range changes {
+				if err := c.ProcessChange(key.instance, change); err != nil {
+					log.WithError(err).Errorf("Failed process change %v", change.CurrentRevision)
+					failed = true
+				}
+				if change.Updated.Time.After(lastTime) {
+					lastTime = change.Updated.Time
+				}
+			}
+			c.recordSyncResult(key, failed, time.Now())
 
-		logrus.Infof("Processed %d changes for instance %s", len(changes), instance)
+			latestLock.Lock()
+			defer latestLock.Unlock()
+			if latest[key.instance] == nil {
+				latest[key.instance] = map[string]time.Time{}
+			}
+			if last, ok := latest[key.instance][key.project]; !ok || last.Before(lastTime) {
+				latest[key.instance][key.project] = lastTime
+			}
+			log.Infof("Processed %d changes", len(changes))
+		}(key, changes, log)
 	}
+	wg.Wait()
 
 	return c.tracker.Update(latest)
 }
 
+// backingOff returns whether the project is skipped at now because it
+// recently failed to sync, and until when.
+func (c *Controller) backingOff(key projectKey, now time.Time) (time.Time, bool) {
+	c.backoffLock.Lock()
+	defer c.backoffLock.Unlock()
+	backoff, ok := c.backoffs[key]
+	return backoff.until, ok && now.Before(backoff.until)
+}
+
+// recordSyncResult resets the backoff of the project if it synced and
+// extends it if it failed.
+func (c *Controller) recordSyncResult(key projectKey, failed bool, now time.Time) {
+	c.backoffLock.Lock()
+	defer c.backoffLock.Unlock()
+	if !failed {
+		delete(c.backoffs, key)
+		return
+	}
+	if c.backoffs == nil {
+		c.backoffs = map[projectKey]projectBackoff{}
+	}
+	backoff := c.backoffs[key]
+	backoff.failures++
+	delay := initialProjectBackoff
+	for i := 1; i < backoff.failures && delay < maxProjectBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxProjectBackoff {
+		delay = maxProjectBackoff
+	}
+	backoff.until = now.Add(delay)
+	c.backoffs[key] = backoff
+}
+
 func makeCloneURI(instance, project string) (*url.URL, error) {
 	u, err := url.Parse(instance)
 	if err != nil {
@@ -17,6 +17,7 @@ limitations under the License.
 package adapter
 
 import (
+	"errors"
 	"sync"
 	"testing"
 	"time"
@@ -759,3 +760,176 @@ func TestProcessChange(t *testing.T) {
 		}
 	}
 }
+
+// syncGerritClient serves changes and tracks how many projects are processed
+// at the same time.
+type syncGerritClient struct {
+	fgc
+	changes map[string][]client.ChangeInfo
+	// failing projects fail to get their branch revision.
+	failing map[string]bool
+	// wait is how many concurrent calls GetBranchRevision waits for, up to
+	// a second, before returning.
+	wait int
+
+	lock        sync.Mutex
+	inFlight    int
+	maxInFlight int
+	processed   map[string]int
+}
+
+func (f *syncGerritClient) QueryChanges(lastUpdate client.LastSyncState, rateLimit int) map[string][]client.ChangeInfo {
+	return f.changes
+}
+
+func (f *syncGerritClient) GetBranchRevision(instance, project, branch string) (string, error) {
+	f.lock.Lock()
+	f.inFlight++
+	if f.inFlight > f.maxInFlight {
+		f.maxInFlight = f.inFlight
+	}
+	if f.processed == nil {
+		f.processed = map[string]int{}
+	}
+	f.processed[project]++
+	f.lock.Unlock()
+
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		f.lock.Lock()
+		done := f.maxInFlight >= f.wait
+		f.lock.Unlock()
+		if done {
+			break
+		}
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.inFlight--
+	if f.failing[project] {
+		return "", errors.New("injected error")
+	}
+	return "abc", nil
+}
+
+func syncChanges(instance string, projects ...string) (map[string][]client.ChangeInfo, client.LastSyncState) {
+	var changes []client.ChangeInfo
+	lastSync := client.LastSyncState{instance: map[string]time.Time{}}
+	for i, project := range projects {
+		changes = append(changes, client.ChangeInfo{
+			ID:              project + "~master~1",
+			Number:          i + 1,
+			Project:         project,
+			Branch:          "master",
+			Status:          client.Merged,
+			CurrentRevision: "1",
+			Updated:         stampNow,
+			Revisions: map[string]client.RevisionInfo{
+				"1": {},
+			},
+		})
+		lastSync[instance][project] = timeNow.Add(-time.Hour)
+	}
+	return map[string][]client.ChangeInfo{instance: changes}, lastSync
+}
+
+func TestSyncConcurrency(t *testing.T) {
+	instance := "https://gerrit"
+	projects := []string{"a", "b", "c", "d", "e", "f"}
+	testcases := []struct {
+		name        string
+		concurrency int
+		expectedMax int
+	}{
+		{
+			name:        "projects are synced serially by default",
+			expectedMax: 1,
+		},
+		{
+			name:        "projects are synced up to the concurrency",
+			concurrency: 3,
+			expectedMax: 3,
+		},
+		{
+			name:        "concurrency above the number of projects",
+			concurrency: 10,
+			expectedMax: len(projects),
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &config.Config{ProwConfig: config.ProwConfig{Gerrit: config.Gerrit{SyncConcurrency: tc.concurrency}}}
+			changes, lastSync := syncChanges(instance, projects...)
+			gc := &syncGerritClient{changes: changes, wait: tc.expectedMax}
+			tracker := &fakeSync{val: lastSync}
+			c := &Controller{
+				config:        func() *config.Config { return cfg },
+				prowJobClient: prowfake.NewSimpleClientset().ProwV1().ProwJobs("prowjobs"),
+				gc:            gc,
+				tracker:       tracker,
+			}
+			if err := c.Sync(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if gc.maxInFlight != tc.expectedMax {
+				t.Errorf("expected %d projects to sync at the same time, got %d", tc.expectedMax, gc.maxInFlight)
+			}
+			for _, project := range projects {
+				if gc.processed[project] != 1 {
+					t.Errorf("expected project %s to be processed once, got %d", project, gc.processed[project])
+				}
+				if last := tracker.Current()[instance][project]; !last.Equal(timeNow) {
+					t.Errorf("expected the last sync of project %s to be %v, got %v", project, timeNow, last)
+				}
+			}
+		})
+	}
+}
+
+func TestSyncFailingProjectBackoff(t *testing.T) {
+	instance := "https://gerrit"
+	cfg := &config.Config{ProwConfig: config.ProwConfig{Gerrit: config.Gerrit{SyncConcurrency: 2}}}
+	changes, lastSync := syncChanges(instance, "good", "bad", "other")
+	gc := &syncGerritClient{changes: changes, failing: map[string]bool{"bad": true}}
+	tracker := &fakeSync{val: lastSync}
+	c := &Controller{
+		config:        func() *config.Config { return cfg },
+		prowJobClient: prowfake.NewSimpleClientset().ProwV1().ProwJobs("prowjobs"),
+		gc:            gc,
+		tracker:       tracker,
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := c.Sync(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	expected := map[string]int{"good": 2, "bad": 1, "other": 2}
+	for project, count := range expected {
+		if gc.processed[project] != count {
+			t.Errorf("expected project %s to be processed %d times, got %d", project, count, gc.processed[project])
+		}
+	}
+	if last := tracker.Current()[instance]["good"]; !last.Equal(timeNow) {
+		t.Errorf("expected the failing project not to hold back the last sync of the others, got %v", last)
+	}
+
+	key := projectKey{instance: instance, project: "bad"}
+	if _, backingOff := c.backingOff(key, time.Now().Add(initialProjectBackoff)); backingOff {
+		t.Error("expected the failing project to be retried after the initial backoff")
+	}
+	c.recordSyncResult(key, true, time.Now())
+	if until, backingOff := c.backingOff(key, time.Now().Add(initialProjectBackoff)); !backingOff {
+		t.Errorf("expected the backoff to double after another failure, got %v", until)
+	}
+	for i := 0; i < 10; i++ {
+		c.recordSyncResult(key, true, time.Now())
+	}
+	if _, backingOff := c.backingOff(key, time.Now().Add(maxProjectBackoff)); backingOff {
+		t.Error("expected the backoff to be capped")
+	}
+	c.recordSyncResult(key, false, time.Now())
+	if _, backingOff := c.backingOff(key, time.Now()); backingOff {
+		t.Error("expected a successful sync to reset the backoff")
+	}
+}
@@ -0,0 +1,234 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// appJWTLifetime is how long the JWTs authenticating as the App are
+	// valid. GitHub rejects JWTs that are valid for more than 10 minutes.
+	appJWTLifetime = 9 * time.Minute
+	// appTokenRefreshMargin is how long before their expiry installation
+	// tokens are refreshed, so that requests never use an expired token.
+	appTokenRefreshMargin = 5 * time.Minute
+)
+
+// installationToken is an access token for an installation of a GitHub App.
+// See https://developer.github.com/v3/apps/#create-a-new-installation-token
+type installationToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// tokenMinter mints installation tokens.
+type tokenMinter interface {
+	mintInstallationToken() (installationToken, error)
+}
+
+// appTokenMinter mints installation tokens for an installation of a GitHub
+// App, authenticating as the App with a JWT signed by its private key.
+type appTokenMinter struct {
+	appID          string
+	installationID int64
+	key            *rsa.PrivateKey
+	client         httpClient
+	base           string
+	now            func() time.Time
+}
+
+func (m *appTokenMinter) mintInstallationToken() (installationToken, error) {
+	jwt, err := m.jwt()
+	if err != nil {
+		return installationToken{}, fmt.Errorf("failed to create the JWT: %v", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/app/installations/%d/access_tokens", strings.TrimSuffix(m.base, "/"), m.installationID), nil)
+	if err != nil {
+		return installationToken{}, err
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github.machine-man-preview+json")
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return installationToken{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return installationToken{}, fmt.Errorf("return code not 201: %d %s", resp.StatusCode, resp.Status)
+	}
+	var token installationToken
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return installationToken{}, fmt.Errorf("failed to decode the installation token: %v", err)
+	}
+	return token, nil
+}
+
+// jwt returns a JWT authenticating as the App.
+// See https://developer.github.com/apps/building-github-apps/authenticating-with-github-apps/#authenticating-as-a-github-app
+func (m *appTokenMinter) jwt() (string, error) {
+	now := m.now()
+	claims, err := json.Marshal(map[string]interface{}{
+		// Backdate the token to allow for clock drift.
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(appJWTLifetime).Unix(),
+		"iss": m.appID,
+	})
+	if err != nil {
+		return "", err
+	}
+	unsigned := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`)) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	hash := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, m.key, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// appTokenSource hands out the installation token of a GitHub App and mints
+// a new one shortly before the current one expires.
+type appTokenSource struct {
+	lock   sync.Mutex
+	minter tokenMinter
+	time   timeClient
+	token  installationToken
+	// previous is the token that was replaced by the current one. It stays
+	// valid until it expires, so it is censored as well.
+	previous string
+}
+
+// getToken returns the current installation token, refreshing it first if it
+// is about to expire. If refreshing fails the current token is returned, so
+// that the request fails or succeeds on its own.
+func (s *appTokenSource) getToken() []byte {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.token.Token != "" && s.time.Until(s.token.ExpiresAt) > appTokenRefreshMargin {
+		return []byte(s.token.Token)
+	}
+	token, err := s.minter.mintInstallationToken()
+	if err != nil {
+		logrus.WithError(err).Error("Failed to refresh the GitHub App installation token.")
+		return []byte(s.token.Token)
+	}
+	s.previous = s.token.Token
+	s.token = token
+	return []byte(s.token.Token)
+}
+
+// censor wraps the censor of the client so that it also removes the
+// installation tokens minted by the source, which are not known to it.
+func (s *appTokenSource) censor(censor func([]byte) []byte) func([]byte) []byte {
+	return func(content []byte) []byte {
+		s.lock.Lock()
+		tokens := []string{s.token.Token, s.previous}
+		s.lock.Unlock()
+		for _, token := range tokens {
+			if token != "" {
+				content = bytes.ReplaceAll(content, []byte(token), []byte("CENSORED"))
+			}
+		}
+		if censor != nil {
+			content = censor(content)
+		}
+		return content
+	}
+}
+
+// parseAppPrivateKey parses the PEM encoded private key of a GitHub App.
+func parseAppPrivateKey(privateKey []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(privateKey)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("expected an RSA private key, got %T", key)
+	}
+	return rsaKey, nil
+}
+
+// newAppTokenSource creates the token source for an installation of a GitHub
+// App, minting the tokens from the first base.
+func newAppTokenSource(appID string, installationID int64, privateKey []byte, bases []string) (*appTokenSource, error) {
+	if len(bases) == 0 {
+		return nil, errors.New("at least one base is required")
+	}
+	key, err := parseAppPrivateKey(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the private key of the GitHub App: %v", err)
+	}
+	return &appTokenSource{
+		minter: &appTokenMinter{
+			appID:          appID,
+			installationID: installationID,
+			key:            key,
+			client:         &http.Client{Timeout: maxRequestTime},
+			base:           bases[0],
+			now:            time.Now,
+		},
+		time: &standardTime{},
+	}, nil
+}
+
+// NewAppClientWithFields creates a new fully operational GitHub client that
+// authenticates as an installation of a GitHub App. Installation tokens are
+// minted with the App's PEM encoded private key and refreshed before they
+// expire, so the client can be used for as long as the installation exists.
+// 'bases' is a variadic slice of endpoints to use in order of preference, the
+// installation tokens are minted from the first one.
+func NewAppClientWithFields(fields logrus.Fields, appID string, installationID int64, privateKey []byte, censor func([]byte) []byte, graphqlEndpoint string, bases ...string) (Client, error) {
+	source, err := newAppTokenSource(appID, installationID, privateKey, bases)
+	if err != nil {
+		return nil, err
+	}
+	return newClient(fields, nil, source.getToken, source.censor(censor), graphqlEndpoint, false, bases...), nil
+}
+
+// NewDryRunAppClientWithFields is like NewAppClientWithFields, but creates a
+// client that will not perform mutating actions. See NewDryRunClientWithFields.
+func NewDryRunAppClientWithFields(fields logrus.Fields, appID string, installationID int64, privateKey []byte, censor func([]byte) []byte, graphqlEndpoint string, bases ...string) (Client, error) {
+	source, err := newAppTokenSource(appID, installationID, privateKey, bases)
+	if err != nil {
+		return nil, err
+	}
+	return newClient(fields, nil, source.getToken, source.censor(censor), graphqlEndpoint, true, bases...), nil
+}
@@ -0,0 +1,213 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type fakeTokenMinter struct {
+	tokens []installationToken
+	err    error
+	minted int
+}
+
+func (f *fakeTokenMinter) mintInstallationToken() (installationToken, error) {
+	if f.err != nil {
+		return installationToken{}, f.err
+	}
+	token := f.tokens[f.minted]
+	f.minted++
+	return token, nil
+}
+
+func TestAppTokenSourceRefresh(t *testing.T) {
+	now := time.Now()
+	minter := &fakeTokenMinter{tokens: []installationToken{
+		{Token: "first", ExpiresAt: now.Add(time.Hour)},
+		{Token: "second", ExpiresAt: now.Add(2 * time.Hour)},
+	}}
+	tt := &testTime{now: now}
+	source := &appTokenSource{minter: minter, time: tt}
+
+	if token := string(source.getToken()); token != "first" {
+		t.Errorf("Expected the first token to be minted, got %q", token)
+	}
+	tt.now = now.Add(50 * time.Minute)
+	if token := string(source.getToken()); token != "first" || minter.minted != 1 {
+		t.Errorf("Expected the first token to be reused while it is valid, got %q after %d mints", token, minter.minted)
+	}
+	// The first token expires within the refresh margin.
+	tt.now = now.Add(56 * time.Minute)
+	if token := string(source.getToken()); token != "second" || minter.minted != 2 {
+		t.Errorf("Expected the second token to be minted before the first one expires, got %q after %d mints", token, minter.minted)
+	}
+	// Refreshing fails, so the current token is kept.
+	minter.err = errors.New("injected error")
+	tt.now = now.Add(2 * time.Hour)
+	if token := string(source.getToken()); token != "second" {
+		t.Errorf("Expected the current token when refreshing fails, got %q", token)
+	}
+}
+
+func TestAppTokenSourceCensor(t *testing.T) {
+	now := time.Now()
+	minter := &fakeTokenMinter{tokens: []installationToken{
+		{Token: "first", ExpiresAt: now.Add(time.Hour)},
+		{Token: "second", ExpiresAt: now.Add(2 * time.Hour)},
+	}}
+	tt := &testTime{now: now}
+	source := &appTokenSource{minter: minter, time: tt}
+	censor := source.censor(func(content []byte) []byte {
+		return []byte(strings.Replace(string(content), "secret", "CENSORED", -1))
+	})
+
+	if censored := string(censor([]byte("first secret"))); censored != "first CENSORED" {
+		t.Errorf("Expected only the secret to be censored before a token is minted, got %q", censored)
+	}
+	source.getToken()
+	if censored := string(censor([]byte("first secret"))); censored != "CENSORED CENSORED" {
+		t.Errorf("Expected the minted token to be censored, got %q", censored)
+	}
+	tt.now = now.Add(56 * time.Minute)
+	source.getToken()
+	if censored := string(censor([]byte("first second"))); censored != "CENSORED CENSORED" {
+		t.Errorf("Expected the current and the previous token to be censored, got %q", censored)
+	}
+}
+
+func TestAppTokenMinter(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	now := time.Now()
+	expiry := now.Add(time.Hour).UTC().Truncate(time.Second)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path != "/app/installations/42/access_tokens" {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+		jwt := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		parts := strings.Split(jwt, ".")
+		if len(parts) != 3 {
+			t.Fatalf("Expected a JWT, got Authorization header %q", r.Header.Get("Authorization"))
+		}
+		signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+		if err != nil {
+			t.Fatalf("Failed to decode signature: %v", err)
+		}
+		hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hash[:], signature); err != nil {
+			t.Errorf("Invalid JWT signature: %v", err)
+		}
+		rawClaims, err := base64.RawURLEncoding.DecodeString(parts[1])
+		if err != nil {
+			t.Fatalf("Failed to decode claims: %v", err)
+		}
+		var claims struct {
+			IssuedAt  int64  `json:"iat"`
+			ExpiresAt int64  `json:"exp"`
+			Issuer    string `json:"iss"`
+		}
+		if err := json.Unmarshal(rawClaims, &claims); err != nil {
+			t.Fatalf("Failed to unmarshal claims: %v", err)
+		}
+		if claims.Issuer != "1234" {
+			t.Errorf("Expected issuer 1234, got %q", claims.Issuer)
+		}
+		if lifetime := time.Duration(claims.ExpiresAt-claims.IssuedAt) * time.Second; lifetime > 10*time.Minute {
+			t.Errorf("Expected the JWT to be valid for at most 10 minutes, got %v", lifetime)
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"token": "v1.abc", "expires_at": %q}`, expiry.Format(time.RFC3339))
+	}))
+	defer ts.Close()
+
+	minter := &appTokenMinter{
+		appID:          "1234",
+		installationID: 42,
+		key:            key,
+		client:         &http.Client{},
+		base:           ts.URL,
+		now:            func() time.Time { return now },
+	}
+	token, err := minter.mintInstallationToken()
+	if err != nil {
+		t.Fatalf("Didn't expect error: %v", err)
+	}
+	if token.Token != "v1.abc" || !token.ExpiresAt.Equal(expiry) {
+		t.Errorf("Expected token v1.abc expiring at %v, got %+v", expiry, token)
+	}
+}
+
+func TestNewAppClient(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	privateKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	var mints int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/app/installations/42/access_tokens":
+			mints++
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"token": "v1.abc", "expires_at": %q}`, time.Now().Add(time.Hour).Format(time.RFC3339))
+		case "/user":
+			if auth := r.Header.Get("Authorization"); auth != "Token v1.abc" {
+				t.Errorf("Expected the installation token to be used, got Authorization header %q", auth)
+			}
+			fmt.Fprint(w, `{"login": "my-app[bot]"}`)
+		default:
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+
+	if _, err := NewAppClientWithFields(logrus.Fields{}, "1234", 42, []byte("not a key"), func(b []byte) []byte { return b }, ts.URL, ts.URL); err == nil {
+		t.Error("Expected an error for an invalid private key")
+	}
+	c, err := NewAppClientWithFields(logrus.Fields{}, "1234", 42, privateKey, func(b []byte) []byte { return b }, ts.URL, ts.URL)
+	if err != nil {
+		t.Fatalf("Didn't expect error: %v", err)
+	}
+	if _, err := c.BotName(); err != nil {
+		t.Fatalf("Didn't expect error: %v", err)
+	}
+	if mints != 1 {
+		t.Errorf("Expected the installation token to be minted once, got %d mints", mints)
+	}
+}
@@ -25,9 +25,11 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -62,6 +64,7 @@ type OrganizationClient interface {
 	EditOrg(name string, config Organization) (*Organization, error)
 	ListOrgInvitations(org string) ([]OrgInvitation, error)
 	ListOrgMembers(org, role string) ([]TeamMember, error)
+	ListOrgSecrets(org string) ([]SecretMeta, error)
 	HasPermission(org, repo, user string, roles ...string) (bool, error)
 	GetUserPermission(org, repo, user string) (string, error)
 	UpdateOrgMembership(org, user string, admin bool) (*OrgMembership, error)
@@ -93,6 +96,7 @@ type IssueClient interface {
 	ListIssueComments(org, repo string, number int) ([]IssueComment, error)
 	GetIssueLabels(org, repo string, number int) ([]Label, error)
 	ListIssueEvents(org, repo string, num int) ([]ListedIssueEvent, error)
+	ListIssueTimeline(org, repo string, number int) ([]TimelineEvent, error)
 	AssignIssue(org, repo string, number int, logins []string) error
 	UnassignIssue(org, repo string, number int, logins []string) error
 	CloseIssue(org, repo string, number int) error
@@ -109,18 +113,27 @@ type PullRequestClient interface {
 	GetPullRequest(org, repo string, number int) (*PullRequest, error)
 	EditPullRequest(org, repo string, number int, pr *PullRequest) (*PullRequest, error)
 	GetPullRequestPatch(org, repo string, number int) ([]byte, error)
+	GetPullRequestDiff(org, repo string, number int) ([]byte, error)
 	CreatePullRequest(org, repo, title, body, head, base string, canModify bool) (int, error)
-	UpdatePullRequest(org, repo string, number int, title, body *string, open *bool, branch *string, canModify *bool) error
+	UpdatePullRequest(org, repo string, number int, update PullRequestUpdate) error
 	GetPullRequestChanges(org, repo string, number int) ([]PullRequestChange, error)
+	GetPullRequestChangesLite(org, repo string, number int) ([]PullRequestChange, error)
 	ListPullRequestComments(org, repo string, number int) ([]ReviewComment, error)
 	ListReviews(org, repo string, number int) ([]Review, error)
+	LatestReviewsByUser(org, repo string, number int) (map[string]Review, error)
 	ClosePR(org, repo string, number int) error
 	ReopenPR(org, repo string, number int) error
 	CreateReview(org, repo string, number int, r DraftReview) error
 	RequestReview(org, repo string, number int, logins []string) error
+	RequestTeamReview(org, repo string, number int, teamSlugs []string) error
 	UnrequestReview(org, repo string, number int, logins []string) error
+	ListRequestedReviewers(org, repo string, number int) ([]User, []Team, error)
 	Merge(org, repo string, pr int, details MergeDetails) error
+	EnablePullRequestAutoMerge(prNodeID, mergeMethod string) error
+	DisablePullRequestAutoMerge(prNodeID string) error
+	ListPullRequestClosingIssues(org, repo string, number int) ([]int, error)
 	IsMergeable(org, repo string, number int, SHA string) (bool, error)
+	MergeabilityBatch(prs []PRRef) (map[PRRef]MergeableState, error)
 	ListPRCommits(org, repo string, number int) ([]RepositoryCommit, error)
 }
 
@@ -130,6 +143,10 @@ type CommitClient interface {
 	ListStatuses(org, repo, ref string) ([]Status, error)
 	GetSingleCommit(org, repo, SHA string) (SingleCommit, error)
 	GetCombinedStatus(org, repo, ref string) (*CombinedStatus, error)
+	GetCombinedStatusForApp(org, repo, ref, appSlug string) (*CombinedStatus, error)
+	GetCheckRunsForRef(org, repo, ref string) ([]CheckRun, error)
+	ListCheckRunAnnotations(org, repo string, checkRunID int) ([]CheckRunAnnotation, error)
+	GetStatusRollup(org, repo, ref string) (StatusRollup, error)
 	GetRef(org, repo, ref string) (string, error)
 	DeleteRef(org, repo, ref string) error
 }
@@ -137,8 +154,12 @@ type CommitClient interface {
 // RepositoryClient interface for repository related API actions
 type RepositoryClient interface {
 	GetRepo(owner, name string) (FullRepo, error)
+	GetRepoLanguages(org, repo string) (map[string]int, error)
+	GetRepoTopics(org, repo string) ([]string, error)
 	GetRepos(org string, isUser bool) ([]Repo, error)
 	GetBranches(org, repo string, onlyProtected bool) ([]Branch, error)
+	ListBranchesForCommit(org, repo, sha string) ([]Branch, error)
+	ListTags(org, repo string) ([]Tag, error)
 	GetBranchProtection(org, repo, branch string) (*BranchProtection, error)
 	RemoveBranchProtection(org, repo, branch string) error
 	UpdateBranchProtection(org, repo, branch string, config BranchProtectionRequest) error
@@ -154,7 +175,21 @@ type RepositoryClient interface {
 	CreateFork(owner, repo string) error
 	ListRepoTeams(org, repo string) ([]Team, error)
 	CreateRepo(owner string, isUser bool, repo RepoCreateRequest) (*FullRepo, error)
+	CreateRepoFromTemplate(templateOwner, templateRepo, owner, name string, private bool) (Repo, error)
 	UpdateRepo(owner, name string, repo RepoUpdateRequest) (*FullRepo, error)
+	SetRepoDefaultBranch(org, repo, branch string) error
+	ListWorkflowRuns(org, repo string, opts WorkflowRunOptions) ([]WorkflowRun, error)
+	GetRepoPublicKey(org, repo string) (RepoPublicKey, error)
+	CreateOrUpdateRepoSecret(org, repo, name, encryptedValue, keyID string) error
+	ListEnvironments(org, repo string) ([]Environment, error)
+	ListDeploymentBranchPolicies(org, repo, env string) ([]BranchPolicy, error)
+	ReviewDeploymentProtectionRule(org, repo string, runID int, envName, state, comment string) error
+	ListRepoRulesets(org, repo string) ([]Ruleset, error)
+	CreateRepoRuleset(org, repo string, r Ruleset) (int, error)
+	ListRepoVulnerabilityAlerts(org, repo string) ([]Alert, error)
+	DismissAlert(org, repo string, number int, reason string) error
+	ListDeployKeys(org, repo string) ([]DeployKey, error)
+	CreateDeployKey(org, repo, title, key string, readOnly bool) (int, error)
 }
 
 // TeamClient interface for team related API actions
@@ -179,6 +214,11 @@ type UserClient interface {
 	BotName() (string, error)
 	BotUser() (*User, error)
 	Email() (string, error)
+	ListUserGPGKeys(user string) ([]GPGKey, error)
+	StarRepo(org, repo string) error
+	UnstarRepo(org, repo string) error
+	CreateGist(description string, public bool, files map[string]string) (string, error)
+	ListGists() ([]Gist, error)
 }
 
 // ProjectClient interface for project related API actions
@@ -197,6 +237,7 @@ type ProjectClient interface {
 type MilestoneClient interface {
 	ClearMilestone(org, repo string, num int) error
 	SetMilestone(org, repo string, issueNum, milestoneNum int) error
+	BulkSetMilestone(org, repo string, numbers []int, milestoneNum int) ([]int, error)
 	ListMilestones(org, repo string) ([]Milestone, error)
 }
 
@@ -224,9 +265,13 @@ type Client interface {
 	HookClient
 
 	Throttle(hourlyTokens, burst int)
+	ThrottleOrg(org string, hourlyTokens, burst int)
+	ThrottleStatus() []ThrottleStatus
+	SetOrgBases(org string, bases ...string)
 	Query(ctx context.Context, q interface{}, vars map[string]interface{}) error
 
 	SetMax404Retries(int)
+	SetMaxGraphQLRetries(int)
 
 	WithFields(fields logrus.Fields) Client
 }
@@ -242,10 +287,11 @@ type client struct {
 type delegate struct {
 	time timeClient
 
-	maxRetries    int
-	max404Retries int
-	maxSleepTime  time.Duration
-	initialDelay  time.Duration
+	maxRetries        int
+	max404Retries     int
+	maxGraphQLRetries int
+	maxSleepTime      time.Duration
+	initialDelay      time.Duration
 
 	gqlc     gqlClient
 	client   httpClient
@@ -256,6 +302,12 @@ type delegate struct {
 	getToken func() []byte
 	censor   func([]byte) []byte
 
+	orgThrottleLock sync.RWMutex // protects orgThrottles
+	orgThrottles    map[string]*throttler
+
+	orgBasesLock sync.RWMutex // protects orgBases
+	orgBases     map[string][]string
+
 	mut      sync.Mutex // protects botName and email
 	userData *User
 }
@@ -271,6 +323,8 @@ func (c *client) WithFields(fields logrus.Fields) Client {
 
 var (
 	teamRe = regexp.MustCompile(`^(.*)/(.*)$`)
+	// orgPathRe matches the org (or owner) segment of REST API paths.
+	orgPathRe = regexp.MustCompile(`^/(?:repos|orgs)/([^/?]+)`)
 )
 
 const (
@@ -280,10 +334,11 @@ const (
 	// but will prevent an indefinite stall if GitHub never responds.
 	maxRequestTime = 5 * time.Minute
 
-	defaultMaxRetries    = 8
-	defaultMax404Retries = 2
-	defaultMaxSleepTime  = 2 * time.Minute
-	defaultInitialDelay  = 2 * time.Second
+	defaultMaxRetries        = 8
+	defaultMax404Retries     = 2
+	defaultMaxGraphQLRetries = 3
+	defaultMaxSleepTime      = 2 * time.Minute
+	defaultInitialDelay      = 2 * time.Second
 )
 
 // Force the compiler to check if the TokenSource is implementing correctly.
@@ -302,6 +357,7 @@ type httpClient interface {
 // Interface for how prow interacts with the graphql client, which we may throttle.
 type gqlClient interface {
 	Query(ctx context.Context, q interface{}, vars map[string]interface{}) error
+	Mutate(ctx context.Context, m interface{}, input githubql.Input, vars map[string]interface{}) error
 }
 
 // throttler sets a ceiling on the rate of GitHub requests.
@@ -313,6 +369,34 @@ type throttler struct {
 	graph    gqlClient
 	slow     int32 // Helps log once when requests start/stop being throttled
 	lock     sync.RWMutex
+
+	hourlyTokens int
+	burst        int
+}
+
+// ThrottleStatus is the configuration and current state of a throttle of
+// the client.
+type ThrottleStatus struct {
+	// Org is the org the throttle applies to, empty for the global throttle.
+	Org          string `json:"org,omitempty"`
+	HourlyTokens int    `json:"hourly_tokens"`
+	Burst        int    `json:"burst"`
+	// AvailableTokens is how many requests can be made before the
+	// throttle starts delaying requests.
+	AvailableTokens int `json:"available_tokens"`
+	// Throttled is set once requests had to wait for a token, until the
+	// throttle refilled again.
+	Throttled bool `json:"throttled"`
+}
+
+func (t *throttler) status(org string) ThrottleStatus {
+	return ThrottleStatus{
+		Org:             org,
+		HourlyTokens:    t.hourlyTokens,
+		Burst:           t.burst,
+		AvailableTokens: len(t.throttle),
+		Throttled:       atomic.LoadInt32(&t.slow) == 1,
+	}
 }
 
 func (t *throttler) Wait() {
@@ -378,6 +462,13 @@ func (t *throttler) Query(ctx context.Context, q interface{}, vars map[string]in
 	return t.graph.Query(ctx, q, vars)
 }
 
+func (t *throttler) Mutate(ctx context.Context, m interface{}, input githubql.Input, vars map[string]interface{}) error {
+	t.Wait()
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.graph.Mutate(ctx, m, input, vars)
+}
+
 // Throttle client to a rate of at most hourlyTokens requests per hour,
 // allowing burst tokens.
 func (c *client) Throttle(hourlyTokens, burst int) {
@@ -394,6 +485,44 @@ func (c *client) Throttle(hourlyTokens, burst int) {
 		}
 		return
 	}
+	ticker, throttle := newThrottleChannel(hourlyTokens, burst)
+	if !previouslyThrottled { // Wrap clients if we haven't already
+		c.throttle.http = c.client
+		c.throttle.graph = c.gqlc
+		c.client = &c.throttle
+		c.gqlc = &c.throttle
+	}
+	c.throttle.ticker = ticker
+	c.throttle.throttle = throttle
+	c.throttle.hourlyTokens = hourlyTokens
+	c.throttle.burst = burst
+}
+
+// ThrottleStatus returns the status of the global throttle, if enabled,
+// followed by the statuses of the org throttles sorted by org.
+func (c *client) ThrottleStatus() []ThrottleStatus {
+	var statuses []ThrottleStatus
+	c.throttle.lock.RLock()
+	if c.throttle.ticker != nil {
+		statuses = append(statuses, c.throttle.status(""))
+	}
+	c.throttle.lock.RUnlock()
+	c.orgThrottleLock.RLock()
+	defer c.orgThrottleLock.RUnlock()
+	var orgs []string
+	for org := range c.orgThrottles {
+		orgs = append(orgs, org)
+	}
+	sort.Strings(orgs)
+	for _, org := range orgs {
+		statuses = append(statuses, c.orgThrottles[org].status(org))
+	}
+	return statuses
+}
+
+// newThrottleChannel returns a channel holding burst tokens which is refilled
+// at a rate of hourlyTokens per hour until the returned ticker is stopped.
+func newThrottleChannel(hourlyTokens, burst int) (*time.Ticker, chan time.Time) {
 	rate := time.Hour / time.Duration(hourlyTokens)
 	ticker := time.NewTicker(rate)
 	throttle := make(chan time.Time, burst)
@@ -409,20 +538,111 @@ func (c *client) Throttle(hourlyTokens, burst int) {
 			}
 		}
 	}()
-	if !previouslyThrottled { // Wrap clients if we haven't already
-		c.throttle.http = c.client
-		c.throttle.graph = c.gqlc
-		c.client = &c.throttle
-		c.gqlc = &c.throttle
+	return ticker, throttle
+}
+
+// ThrottleOrg limits REST requests against the org to a rate of at most
+// hourlyTokens requests per hour, allowing burst tokens. Requests for the
+// org are then only subject to this throttle, independently of the global
+// one configured with Throttle(). Requests for other orgs and GraphQL
+// queries keep using the global throttle.
+func (c *client) ThrottleOrg(org string, hourlyTokens, burst int) {
+	c.log("ThrottleOrg", org, hourlyTokens, burst)
+	c.orgThrottleLock.Lock()
+	defer c.orgThrottleLock.Unlock()
+	if previous, ok := c.orgThrottles[org]; ok {
+		previous.ticker.Stop()
+		delete(c.orgThrottles, org)
 	}
-	c.throttle.ticker = ticker
-	c.throttle.throttle = throttle
+	if hourlyTokens <= 0 || burst <= 0 { // Disable throttle
+		return
+	}
+	// Org throttles wrap the underlying client, bypassing the global throttle.
+	underlying := c.client
+	if c.client == &c.throttle {
+		underlying = c.throttle.http
+	}
+	ticker, throttle := newThrottleChannel(hourlyTokens, burst)
+	if c.orgThrottles == nil {
+		c.orgThrottles = map[string]*throttler{}
+	}
+	c.orgThrottles[org] = &throttler{
+		ticker:       ticker,
+		throttle:     throttle,
+		http:         underlying,
+		hourlyTokens: hourlyTokens,
+		burst:        burst,
+	}
+}
+
+// httpClientFor returns the client to use for requests against the org,
+// honoring any throttle configured for it. An empty org gets the default
+// client.
+func (c *client) httpClientFor(org string) httpClient {
+	if org == "" {
+		return c.client
+	}
+	c.orgThrottleLock.RLock()
+	defer c.orgThrottleLock.RUnlock()
+	if t, ok := c.orgThrottles[org]; ok {
+		return t
+	}
+	return c.client
+}
+
+// SetOrgBases overrides the endpoints used for REST requests against the org,
+// for instance to reach orgs hosted on a GitHub Enterprise instance from a
+// client otherwise talking to github.com. The bases are used in order of
+// preference, like the ones the client was created with. Calling this
+// without any bases restores the default endpoints for the org.
+func (c *client) SetOrgBases(org string, bases ...string) {
+	c.log("SetOrgBases", org, bases)
+	c.orgBasesLock.Lock()
+	defer c.orgBasesLock.Unlock()
+	if len(bases) == 0 {
+		delete(c.orgBases, org)
+		return
+	}
+	if c.orgBases == nil {
+		c.orgBases = map[string][]string{}
+	}
+	c.orgBases[org] = bases
+}
+
+// basesFor returns the endpoints to use for requests against the org,
+// honoring any endpoints configured for it. An empty org gets the default
+// endpoints.
+func (c *client) basesFor(org string) []string {
+	if org == "" {
+		return c.bases
+	}
+	c.orgBasesLock.RLock()
+	defer c.orgBasesLock.RUnlock()
+	if bases, ok := c.orgBases[org]; ok {
+		return bases
+	}
+	return c.bases
+}
+
+// orgFromPath extracts the org from an API path such as /repos/org/repo/...
+// or /orgs/org/..., returning an empty string for paths without one.
+func orgFromPath(path string) string {
+	if m := orgPathRe.FindStringSubmatch(path); m != nil {
+		return m[1]
+	}
+	return ""
 }
 
 func (c *client) SetMax404Retries(max int) {
 	c.max404Retries = max
 }
 
+// SetMaxGraphQLRetries sets how many times a GraphQL query failing with a
+// transient error is retried.
+func (c *client) SetMaxGraphQLRetries(max int) {
+	c.maxGraphQLRetries = max
+}
+
 // NewClientWithFields creates a new fully operational GitHub client. With
 // added logging fields.
 // 'getToken' is a generator for the GitHub access token to use.
@@ -431,27 +651,16 @@ func (c *client) SetMax404Retries(max int) {
 //   This should be used when using the ghproxy GitHub proxy cache to allow
 //   this client to bypass the cache if it is temporarily unavailable.
 func NewClientWithFields(fields logrus.Fields, getToken func() []byte, censor func([]byte) []byte, graphqlEndpoint string, bases ...string) Client {
-	return &client{
-		logger: logrus.WithFields(fields).WithField("client", "github"),
-		delegate: &delegate{
-			time: &standardTime{},
-			gqlc: githubql.NewEnterpriseClient(
-				graphqlEndpoint,
-				&http.Client{
-					Timeout:   maxRequestTime,
-					Transport: &oauth2.Transport{Source: newReloadingTokenSource(getToken)},
-				}),
-			client:        &http.Client{Timeout: maxRequestTime},
-			bases:         bases,
-			getToken:      getToken,
-			censor:        censor,
-			dry:           false,
-			maxRetries:    defaultMaxRetries,
-			max404Retries: defaultMax404Retries,
-			initialDelay:  defaultInitialDelay,
-			maxSleepTime:  defaultMaxSleepTime,
-		},
-	}
+	return newClient(fields, nil, getToken, censor, graphqlEndpoint, false, bases...)
+}
+
+// NewClientWithTransport is like NewClientWithFields, but sends all REST and
+// GraphQL requests through the given transport, e.g. to trace them or to
+// collect metrics. The transport sits below the throttler, so throttled
+// requests only reach it once they are allowed to proceed.
+// A nil transport uses http.DefaultTransport.
+func NewClientWithTransport(fields logrus.Fields, transport http.RoundTripper, getToken func() []byte, censor func([]byte) []byte, graphqlEndpoint string, bases ...string) Client {
+	return newClient(fields, transport, getToken, censor, graphqlEndpoint, false, bases...)
 }
 
 // NewClient creates a new fully operational GitHub client.
@@ -468,6 +677,16 @@ func NewClient(getToken func() []byte, censor func([]byte) []byte, graphqlEndpoi
 //   This should be used when using the ghproxy GitHub proxy cache to allow
 //   this client to bypass the cache if it is temporarily unavailable.
 func NewDryRunClientWithFields(fields logrus.Fields, getToken func() []byte, censor func([]byte) []byte, graphqlEndpoint string, bases ...string) Client {
+	return newClient(fields, nil, getToken, censor, graphqlEndpoint, true, bases...)
+}
+
+// NewDryRunClientWithTransport is like NewDryRunClientWithFields, but sends
+// all requests through the given transport. See NewClientWithTransport.
+func NewDryRunClientWithTransport(fields logrus.Fields, transport http.RoundTripper, getToken func() []byte, censor func([]byte) []byte, graphqlEndpoint string, bases ...string) Client {
+	return newClient(fields, transport, getToken, censor, graphqlEndpoint, true, bases...)
+}
+
+func newClient(fields logrus.Fields, transport http.RoundTripper, getToken func() []byte, censor func([]byte) []byte, graphqlEndpoint string, dryRun bool, bases ...string) Client {
 	return &client{
 		logger: logrus.WithFields(fields).WithField("client", "github"),
 		delegate: &delegate{
@@ -476,17 +695,18 @@ func NewDryRunClientWithFields(fields logrus.Fields, getToken func() []byte, cen
 				graphqlEndpoint,
 				&http.Client{
 					Timeout:   maxRequestTime,
-					Transport: &oauth2.Transport{Source: newReloadingTokenSource(getToken)},
+					Transport: &oauth2.Transport{Source: newReloadingTokenSource(getToken), Base: transport},
 				}),
-			client:        &http.Client{Timeout: maxRequestTime},
-			bases:         bases,
-			getToken:      getToken,
-			censor:        censor,
-			dry:           true,
-			maxRetries:    defaultMaxRetries,
-			max404Retries: defaultMax404Retries,
-			initialDelay:  defaultInitialDelay,
-			maxSleepTime:  defaultMaxSleepTime,
+			client:            &http.Client{Timeout: maxRequestTime, Transport: transport},
+			bases:             bases,
+			getToken:          getToken,
+			censor:            censor,
+			dry:               dryRun,
+			maxRetries:        defaultMaxRetries,
+			max404Retries:     defaultMax404Retries,
+			maxGraphQLRetries: defaultMaxGraphQLRetries,
+			initialDelay:      defaultInitialDelay,
+			maxSleepTime:      defaultMaxSleepTime,
 		},
 	}
 }
@@ -628,15 +848,25 @@ func (c *client) requestRaw(r *request) (int, []byte, error) {
 // ratelimit exceeded, and retries 404s a couple times.
 // This function closes the response body iff it also returns an error.
 func (c *client) requestRetry(method, path, accept string, body interface{}) (*http.Response, error) {
+	return c.requestRetryForOrg(orgFromPath(path), method, path, accept, body)
+}
+
+// requestRetryForOrg is like requestRetry, but routes the request through
+// the endpoints and throttle of the given org instead of the org of path.
+// This keeps follow-up requests whose paths carry no org, such as the next
+// pages of paginated results, on the endpoints of the original request.
+func (c *client) requestRetryForOrg(org, method, path, accept string, body interface{}) (*http.Response, error) {
 	var hostIndex int
 	var resp *http.Response
 	var err error
+	bases := c.basesFor(org)
+	httpClient := c.httpClientFor(org)
 	backoff := c.initialDelay
 	for retries := 0; retries < c.maxRetries; retries++ {
 		if retries > 0 && resp != nil {
 			resp.Body.Close()
 		}
-		resp, err = c.doRequest(method, c.bases[hostIndex]+path, accept, body)
+		resp, err = c.doRequest(httpClient, method, bases[hostIndex]+path, accept, body)
 		if err == nil {
 			if resp.StatusCode == 404 && retries < c.max404Retries {
 				// Retry 404s a couple times. Sometimes GitHub is inconsistent in
@@ -707,7 +937,7 @@ func (c *client) requestRetry(method, path, accept string, body interface{}) (*h
 			}
 		} else {
 			// Connection problem. Try a different host.
-			hostIndex = (hostIndex + 1) % len(c.bases)
+			hostIndex = (hostIndex + 1) % len(bases)
 			c.time.Sleep(backoff)
 			backoff *= 2
 		}
@@ -715,7 +945,7 @@ func (c *client) requestRetry(method, path, accept string, body interface{}) (*h
 	return resp, err
 }
 
-func (c *client) doRequest(method, path, accept string, body interface{}) (*http.Response, error) {
+func (c *client) doRequest(client httpClient, method, path, accept string, body interface{}) (*http.Response, error) {
 	var buf io.Reader
 	if body != nil {
 		b, err := json.Marshal(body)
@@ -742,7 +972,7 @@ func (c *client) doRequest(method, path, accept string, body interface{}) (*http
 	// https://go-review.googlesource.com/#/c/3210/ fixed it for GET, but not
 	// for POST.
 	req.Close = true
-	return c.client.Do(req)
+	return client.Do(req)
 }
 
 // Not thread-safe - callers need to hold c.mut.
@@ -806,6 +1036,111 @@ func (c *client) Email() (string, error) {
 	return c.userData.Email, nil
 }
 
+// ListUserGPGKeys returns the public GPG keys of the user.
+//
+// See https://developer.github.com/v3/users/gpg_keys/#list-gpg-keys-for-a-user
+func (c *client) ListUserGPGKeys(user string) ([]GPGKey, error) {
+	c.log("ListUserGPGKeys", user)
+	if c.fake {
+		return nil, nil
+	}
+	path := fmt.Sprintf("/users/%s/gpg_keys", user)
+	var keys []GPGKey
+	err := c.readPaginatedResults(
+		path,
+		acceptNone,
+		func() interface{} {
+			return &[]GPGKey{}
+		},
+		func(obj interface{}) {
+			keys = append(keys, *(obj.(*[]GPGKey))...)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// StarRepo stars org/repo as the authenticated user.
+//
+// See https://developer.github.com/v3/activity/starring/#star-a-repository
+func (c *client) StarRepo(org, repo string) error {
+	c.log("StarRepo", org, repo)
+	_, err := c.request(&request{
+		method:    http.MethodPut,
+		path:      fmt.Sprintf("/user/starred/%s/%s", org, repo),
+		exitCodes: []int{204},
+	}, nil)
+	return err
+}
+
+// UnstarRepo unstars org/repo as the authenticated user.
+//
+// See https://developer.github.com/v3/activity/starring/#unstar-a-repository
+func (c *client) UnstarRepo(org, repo string) error {
+	c.log("UnstarRepo", org, repo)
+	_, err := c.request(&request{
+		method:    http.MethodDelete,
+		path:      fmt.Sprintf("/user/starred/%s/%s", org, repo),
+		exitCodes: []int{204},
+	}, nil)
+	return err
+}
+
+// CreateGist creates a gist holding the files, keyed by file name, as the
+// authenticated user and returns its URL.
+//
+// See https://developer.github.com/v3/gists/#create-a-gist
+func (c *client) CreateGist(description string, public bool, files map[string]string) (string, error) {
+	c.log("CreateGist", description, public)
+	gist := struct {
+		Description string              `json:"description"`
+		Public      bool                `json:"public"`
+		Files       map[string]GistFile `json:"files"`
+	}{
+		Description: description,
+		Public:      public,
+		Files:       map[string]GistFile{},
+	}
+	for name, content := range files {
+		gist.Files[name] = GistFile{Content: content}
+	}
+	var created Gist
+	_, err := c.request(&request{
+		method:      http.MethodPost,
+		path:        "/gists",
+		requestBody: &gist,
+		exitCodes:   []int{201},
+	}, &created)
+	return created.HTMLURL, err
+}
+
+// ListGists lists the gists of the authenticated user.
+//
+// See https://developer.github.com/v3/gists/#list-gists-for-the-authenticated-user
+func (c *client) ListGists() ([]Gist, error) {
+	c.log("ListGists")
+	if c.fake {
+		return nil, nil
+	}
+	var gists []Gist
+	err := c.readPaginatedResults(
+		"/gists",
+		acceptNone,
+		func() interface{} {
+			return &[]Gist{}
+		},
+		func(obj interface{}) {
+			gists = append(gists, *(obj.(*[]Gist))...)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return gists, nil
+}
+
 // IsMember returns whether or not the user is a member of the org.
 //
 // See https://developer.github.com/v3/orgs/members/#check-membership
@@ -981,7 +1316,7 @@ func (c *client) EditOrg(name string, config Organization) (*Organization, error
 	return &retOrg, nil
 }
 
-// ListOrgInvitations lists pending invitations to th org.
+// ListOrgInvitations lists pending invitations to the org.
 //
 // https://developer.github.com/v3/orgs/members/#list-pending-organization-invitations
 func (c *client) ListOrgInvitations(org string) ([]OrgInvitation, error) {
@@ -1041,6 +1376,313 @@ func (c *client) ListOrgMembers(org, role string) ([]TeamMember, error) {
 	return teamMembers, nil
 }
 
+// ListWorkflowRuns lists the GitHub Actions workflow runs of a repo, most recent first.
+//
+// See https://developer.github.com/v3/actions/workflow-runs/#list-repository-workflow-runs
+func (c *client) ListWorkflowRuns(org, repo string, opts WorkflowRunOptions) ([]WorkflowRun, error) {
+	c.log("ListWorkflowRuns", org, repo, opts)
+	if c.fake {
+		return nil, nil
+	}
+	values := url.Values{
+		"per_page": []string{"100"},
+	}
+	if opts.Branch != "" {
+		values.Set("branch", opts.Branch)
+	}
+	if opts.Status != "" {
+		values.Set("status", opts.Status)
+	}
+	type workflowRunsPage struct {
+		WorkflowRuns []WorkflowRun `json:"workflow_runs"`
+	}
+	var runs []WorkflowRun
+	err := c.readPaginatedResultsWithValues(
+		fmt.Sprintf("/repos/%s/%s/actions/runs", org, repo),
+		values,
+		acceptNone,
+		func() interface{} {
+			return &workflowRunsPage{}
+		},
+		func(obj interface{}) {
+			runs = append(runs, obj.(*workflowRunsPage).WorkflowRuns...)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+// GetRepoPublicKey returns the public key that Actions secrets of the repo must be encrypted with.
+//
+// See https://developer.github.com/v3/actions/secrets/#get-your-public-key
+func (c *client) GetRepoPublicKey(org, repo string) (RepoPublicKey, error) {
+	c.log("GetRepoPublicKey", org, repo)
+	var key RepoPublicKey
+	_, err := c.request(&request{
+		method:    http.MethodGet,
+		path:      fmt.Sprintf("/repos/%s/%s/actions/secrets/public-key", org, repo),
+		exitCodes: []int{200},
+	}, &key)
+	return key, err
+}
+
+// CreateOrUpdateRepoSecret sets an Actions secret of the repo. The value must be
+// encrypted with the public key identified by keyID, see GetRepoPublicKey.
+//
+// See https://developer.github.com/v3/actions/secrets/#create-or-update-a-secret-for-a-repository
+func (c *client) CreateOrUpdateRepoSecret(org, repo, name, encryptedValue, keyID string) error {
+	c.log("CreateOrUpdateRepoSecret", org, repo, name)
+	_, err := c.request(&request{
+		method: http.MethodPut,
+		path:   fmt.Sprintf("/repos/%s/%s/actions/secrets/%s", org, repo, name),
+		requestBody: map[string]string{
+			"encrypted_value": encryptedValue,
+			"key_id":          keyID,
+		},
+		exitCodes: []int{201, 204},
+	}, nil)
+	return err
+}
+
+// ListRepoVulnerabilityAlerts lists the Dependabot alerts of the repo.
+//
+// See https://docs.github.com/en/rest/dependabot/alerts#list-dependabot-alerts-for-a-repository
+func (c *client) ListRepoVulnerabilityAlerts(org, repo string) ([]Alert, error) {
+	c.log("ListRepoVulnerabilityAlerts", org, repo)
+	if c.fake {
+		return nil, nil
+	}
+	var alerts []Alert
+	err := c.readPaginatedResults(
+		fmt.Sprintf("/repos/%s/%s/dependabot/alerts", org, repo),
+		acceptNone,
+		func() interface{} {
+			return &[]Alert{}
+		},
+		func(obj interface{}) {
+			alerts = append(alerts, *(obj.(*[]Alert))...)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return alerts, nil
+}
+
+// DismissAlert dismisses a Dependabot alert of the repo. The reason must be
+// one of the AlertDismissedReason constants.
+//
+// See https://docs.github.com/en/rest/dependabot/alerts#update-a-dependabot-alert
+func (c *client) DismissAlert(org, repo string, number int, reason string) error {
+	c.log("DismissAlert", org, repo, number, reason)
+	_, err := c.request(&request{
+		method: http.MethodPatch,
+		path:   fmt.Sprintf("/repos/%s/%s/dependabot/alerts/%d", org, repo, number),
+		requestBody: map[string]string{
+			"state":            AlertStateDismissed,
+			"dismissed_reason": reason,
+		},
+		exitCodes: []int{200},
+	}, nil)
+	return err
+}
+
+// ListEnvironments returns the deployment environments of a repo along with
+// their protection rules.
+//
+// See https://docs.github.com/en/rest/reference/repos#get-all-environments
+func (c *client) ListEnvironments(org, repo string) ([]Environment, error) {
+	c.log("ListEnvironments", org, repo)
+	if c.fake {
+		return nil, nil
+	}
+	type environmentsPage struct {
+		Environments []Environment `json:"environments"`
+	}
+	var environments []Environment
+	err := c.readPaginatedResults(
+		fmt.Sprintf("/repos/%s/%s/environments", org, repo),
+		acceptNone,
+		func() interface{} {
+			return &environmentsPage{}
+		},
+		func(obj interface{}) {
+			environments = append(environments, obj.(*environmentsPage).Environments...)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return environments, nil
+}
+
+// ListDeploymentBranchPolicies returns the branch and tag name patterns that
+// can deploy to an environment of a repo.
+//
+// See https://docs.github.com/en/rest/deployments/branch-policies#list-deployment-branch-policies
+func (c *client) ListDeploymentBranchPolicies(org, repo, env string) ([]BranchPolicy, error) {
+	c.log("ListDeploymentBranchPolicies", org, repo, env)
+	if c.fake {
+		return nil, nil
+	}
+	type branchPoliciesPage struct {
+		BranchPolicies []BranchPolicy `json:"branch_policies"`
+	}
+	var policies []BranchPolicy
+	err := c.readPaginatedResults(
+		fmt.Sprintf("/repos/%s/%s/environments/%s/deployment-branch-policies", org, repo, env),
+		acceptNone,
+		func() interface{} {
+			return &branchPoliciesPage{}
+		},
+		func(obj interface{}) {
+			policies = append(policies, obj.(*branchPoliciesPage).BranchPolicies...)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+// ListOrgSecrets lists the Actions secrets of the org. GitHub never returns
+// the values of secrets, only their metadata.
+//
+// See https://developer.github.com/v3/actions/secrets/#list-organization-secrets
+func (c *client) ListOrgSecrets(org string) ([]SecretMeta, error) {
+	c.log("ListOrgSecrets", org)
+	if c.fake {
+		return nil, nil
+	}
+	type secretsPage struct {
+		Secrets []SecretMeta `json:"secrets"`
+	}
+	var secrets []SecretMeta
+	err := c.readPaginatedResults(
+		fmt.Sprintf("/orgs/%s/actions/secrets", org),
+		acceptNone,
+		func() interface{} {
+			return &secretsPage{}
+		},
+		func(obj interface{}) {
+			secrets = append(secrets, obj.(*secretsPage).Secrets...)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return secrets, nil
+}
+
+// ReviewDeploymentProtectionRule approves or rejects the deployment of a
+// workflow run to an environment that is gated by a custom protection rule.
+// State must be either DeploymentProtectionRuleApproved or
+// DeploymentProtectionRuleRejected.
+//
+// See https://docs.github.com/en/rest/actions/workflow-runs#review-custom-deployment-protection-rules-for-a-workflow-run
+func (c *client) ReviewDeploymentProtectionRule(org, repo string, runID int, envName, state, comment string) error {
+	c.log("ReviewDeploymentProtectionRule", org, repo, runID, envName, state, comment)
+	_, err := c.request(&request{
+		method: http.MethodPost,
+		path:   fmt.Sprintf("/repos/%s/%s/actions/runs/%d/deployment_protection_rule", org, repo, runID),
+		requestBody: map[string]string{
+			"environment_name": envName,
+			"state":            state,
+			"comment":          comment,
+		},
+		exitCodes: []int{204},
+	}, nil)
+	return err
+}
+
+// ListRepoRulesets returns the rulesets of a repo.
+//
+// See https://docs.github.com/en/rest/repos/rules#get-all-repository-rulesets
+func (c *client) ListRepoRulesets(org, repo string) ([]Ruleset, error) {
+	c.log("ListRepoRulesets", org, repo)
+	if c.fake {
+		return nil, nil
+	}
+	var rulesets []Ruleset
+	err := c.readPaginatedResults(
+		fmt.Sprintf("/repos/%s/%s/rulesets", org, repo),
+		acceptNone,
+		func() interface{} {
+			return &[]Ruleset{}
+		},
+		func(obj interface{}) {
+			rulesets = append(rulesets, *(obj.(*[]Ruleset))...)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return rulesets, nil
+}
+
+// CreateRepoRuleset creates a ruleset for a repo and returns its ID.
+//
+// See https://docs.github.com/en/rest/repos/rules#create-a-repository-ruleset
+func (c *client) CreateRepoRuleset(org, repo string, r Ruleset) (int, error) {
+	c.log("CreateRepoRuleset", org, repo, r.Name)
+	var created Ruleset
+	_, err := c.request(&request{
+		method:      http.MethodPost,
+		path:        fmt.Sprintf("/repos/%s/%s/rulesets", org, repo),
+		requestBody: &r,
+		exitCodes:   []int{201},
+	}, &created)
+	return created.ID, err
+}
+
+// ListDeployKeys returns the deploy keys of a repo.
+//
+// See https://developer.github.com/v3/repos/keys/#list-deploy-keys
+func (c *client) ListDeployKeys(org, repo string) ([]DeployKey, error) {
+	c.log("ListDeployKeys", org, repo)
+	if c.fake {
+		return nil, nil
+	}
+	var keys []DeployKey
+	err := c.readPaginatedResults(
+		fmt.Sprintf("/repos/%s/%s/keys", org, repo),
+		acceptNone,
+		func() interface{} {
+			return &[]DeployKey{}
+		},
+		func(obj interface{}) {
+			keys = append(keys, *(obj.(*[]DeployKey))...)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// CreateDeployKey adds a deploy key to a repo and returns its ID. Read only
+// keys can clone the repo but not push to it.
+//
+// See https://developer.github.com/v3/repos/keys/#add-a-new-deploy-key
+func (c *client) CreateDeployKey(org, repo, title, key string, readOnly bool) (int, error) {
+	c.log("CreateDeployKey", org, repo, title, readOnly)
+	type deployKeyRequest struct {
+		Title    string `json:"title"`
+		Key      string `json:"key"`
+		ReadOnly bool   `json:"read_only"`
+	}
+	var created DeployKey
+	_, err := c.request(&request{
+		method:      http.MethodPost,
+		path:        fmt.Sprintf("/repos/%s/%s/keys", org, repo),
+		requestBody: &deployKeyRequest{Title: title, Key: key, ReadOnly: readOnly},
+		exitCodes:   []int{201},
+	}, &created)
+	return created.ID, err
+}
+
 // HasPermission returns true if GetUserPermission() returns any of the roles.
 func (c *client) HasPermission(org, repo, user string, roles ...string) (bool, error) {
 	perm, err := c.GetUserPermission(org, repo, user)
@@ -1233,8 +1875,11 @@ func (c *client) readPaginatedResultsWithValues(path string, values url.Values,
 	if len(values) > 0 {
 		pagedPath += "?" + values.Encode()
 	}
+	// The next links of GitHub may not refer to the org, so all pages are
+	// requested from the endpoints of the first one.
+	org := orgFromPath(path)
 	for {
-		resp, err := c.requestRetry(http.MethodGet, pagedPath, accept, nil)
+		resp, err := c.requestRetryForOrg(org, http.MethodGet, pagedPath, accept, nil)
 		if err != nil {
 			return err
 		}
@@ -1462,6 +2107,20 @@ func (c *client) GetPullRequestPatch(org, repo string, number int) ([]byte, erro
 	return patch, err
 }
 
+// GetPullRequestDiff gets the unified diff of a pull request.
+//
+// See https://developer.github.com/v3/media/#commits-commit-comparison-and-pull-requests
+func (c *client) GetPullRequestDiff(org, repo string, number int) ([]byte, error) {
+	c.log("GetPullRequestDiff", org, repo, number)
+	_, diff, err := c.requestRaw(&request{
+		accept:    "application/vnd.github.VERSION.diff",
+		method:    http.MethodGet,
+		path:      fmt.Sprintf("/repos/%s/%s/pulls/%d", org, repo, number),
+		exitCodes: []int{200},
+	})
+	return diff, err
+}
+
 // CreatePullRequest creates a new pull request and returns its number if
 // the creation is successful, otherwise any error that is encountered.
 //
@@ -1503,48 +2162,72 @@ func (c *client) CreatePullRequest(org, repo, title, body, head, base string, ca
 	return resp.Num, nil
 }
 
-// UpdatePullRequest modifies the title, body, open state
-func (c *client) UpdatePullRequest(org, repo string, number int, title, body *string, open *bool, branch *string, canModify *bool) error {
-	c.log("UpdatePullRequest", org, repo, title)
-	data := struct {
-		State *string `json:"state,omitempty"`
-		Title *string `json:"title,omitempty"`
-		Body  *string `json:"body,omitempty"`
-		Base  *string `json:"base,omitempty"`
-		// MaintainerCanModify allows maintainers of the repo to modify this
-		// pull request, eg. push changes to it before merging.
-		MaintainerCanModify *bool `json:"maintainer_can_modify,omitempty"`
-	}{
-		Title:               title,
-		Body:                body,
-		Base:                branch,
-		MaintainerCanModify: canModify,
+// UpdatePullRequest modifies any subset of the title, body, base branch and
+// state of a pull request in a single call.
+//
+// See https://developer.github.com/v3/pulls/#update-a-pull-request
+func (c *client) UpdatePullRequest(org, repo string, number int, update PullRequestUpdate) error {
+	c.log("UpdatePullRequest", org, repo, number)
+	_, err := c.request(&request{
+		// allow the description and draft fields
+		// https://developer.github.com/changes/2018-02-22-label-description-search-preview/
+		// https://developer.github.com/changes/2019-02-14-draft-pull-requests/
+		accept:      "application/vnd.github.symmetra-preview+json, application/vnd.github.shadow-cat-preview",
+		method:      http.MethodPatch,
+		path:        fmt.Sprintf("/repos/%s/%s/pulls/%d", org, repo, number),
+		requestBody: &update,
+		exitCodes:   []int{200},
+	}, nil)
+	return err
+}
+
+// GetPullRequestChanges gets a list of files modified in a pull request.
+//
+// See https://developer.github.com/v3/pulls/#list-pull-requests-files
+func (c *client) GetPullRequestChanges(org, repo string, number int) ([]PullRequestChange, error) {
+	c.log("GetPullRequestChanges", org, repo, number)
+	if c.fake {
+		return []PullRequestChange{}, nil
 	}
-	if open != nil && *open {
-		op := "open"
-		data.State = &op
-	} else if open != nil {
-		cl := "closed"
-		data.State = &cl
+	path := fmt.Sprintf("/repos/%s/%s/pulls/%d/files", org, repo, number)
+	var changes []PullRequestChange
+	err := c.readPaginatedResults(
+		path,
+		acceptNone,
+		func() interface{} {
+			return &[]PullRequestChange{}
+		},
+		func(obj interface{}) {
+			changes = append(changes, *(obj.(*[]PullRequestChange))...)
+		},
+	)
+	if err != nil {
+		return nil, err
 	}
-	_, err := c.request(&request{
-		// allow the description and draft fields
-		// https://developer.github.com/changes/2018-02-22-label-description-search-preview/
-		// https://developer.github.com/changes/2019-02-14-draft-pull-requests/
-		accept:      "application/vnd.github.symmetra-preview+json, application/vnd.github.shadow-cat-preview",
-		method:      http.MethodPatch,
-		path:        fmt.Sprintf("/repos/%s/%s/pulls/%d", org, repo, number),
-		requestBody: &data,
-		exitCodes:   []int{200},
-	}, nil)
-	return err
+	return changes, nil
 }
 
-// GetPullRequestChanges gets a list of files modified in a pull request.
+// pullRequestChangeLite holds the fields of a PullRequestChange that describe
+// which files changed, but not how they changed.
+type pullRequestChangeLite struct {
+	SHA              string `json:"sha"`
+	Filename         string `json:"filename"`
+	Status           string `json:"status"`
+	Additions        int    `json:"additions"`
+	Deletions        int    `json:"deletions"`
+	Changes          int    `json:"changes"`
+	BlobURL          string `json:"blob_url"`
+	PreviousFilename string `json:"previous_filename"`
+}
+
+// GetPullRequestChangesLite gets a list of files modified in a pull request
+// like GetPullRequestChanges, but drops the patches as each page is read.
+// Use it when only file names and stats are needed to keep memory bounded
+// for huge pull requests.
 //
 // See https://developer.github.com/v3/pulls/#list-pull-requests-files
-func (c *client) GetPullRequestChanges(org, repo string, number int) ([]PullRequestChange, error) {
-	c.log("GetPullRequestChanges", org, repo, number)
+func (c *client) GetPullRequestChangesLite(org, repo string, number int) ([]PullRequestChange, error) {
+	c.log("GetPullRequestChangesLite", org, repo, number)
 	if c.fake {
 		return []PullRequestChange{}, nil
 	}
@@ -1554,10 +2237,21 @@ func (c *client) GetPullRequestChanges(org, repo string, number int) ([]PullRequ
 		path,
 		acceptNone,
 		func() interface{} {
-			return &[]PullRequestChange{}
+			return &[]pullRequestChangeLite{}
 		},
 		func(obj interface{}) {
-			changes = append(changes, *(obj.(*[]PullRequestChange))...)
+			for _, change := range *(obj.(*[]pullRequestChangeLite)) {
+				changes = append(changes, PullRequestChange{
+					SHA:              change.SHA,
+					Filename:         change.Filename,
+					Status:           change.Status,
+					Additions:        change.Additions,
+					Deletions:        change.Deletions,
+					Changes:          change.Changes,
+					BlobURL:          change.BlobURL,
+					PreviousFilename: change.PreviousFilename,
+				})
+			}
 		},
 	)
 	if err != nil {
@@ -1622,6 +2316,32 @@ func (c *client) ListReviews(org, repo string, number int) ([]Review, error) {
 	return reviews, nil
 }
 
+// LatestReviewsByUser returns the most recent approving, change requesting or
+// dismissed review of each reviewer of a pull request, keyed by the
+// normalized login of the reviewer. Comment-only and pending reviews do not
+// change a reviewer's verdict, so they are left out.
+func (c *client) LatestReviewsByUser(org, repo string, number int) (map[string]Review, error) {
+	c.log("LatestReviewsByUser", org, repo, number)
+	reviews, err := c.ListReviews(org, repo, number)
+	if err != nil {
+		return nil, err
+	}
+	latest := map[string]Review{}
+	for _, review := range reviews {
+		switch review.State {
+		case ReviewStateApproved, ReviewStateChangesRequested, ReviewStateDismissed:
+		default:
+			continue
+		}
+		login := NormLogin(review.User.Login)
+		if previous, ok := latest[login]; ok && review.SubmittedAt.Before(previous.SubmittedAt) {
+			continue
+		}
+		latest[login] = review
+	}
+	return latest, nil
+}
+
 // CreateStatus creates or updates the status of a commit.
 //
 // See https://developer.github.com/v3/repos/statuses/#create-a-status
@@ -1638,6 +2358,8 @@ func (c *client) CreateStatus(org, repo, SHA string, s Status) error {
 
 // ListStatuses gets commit statuses for a given ref.
 //
+// This call uses multiple API tokens when results are paginated.
+//
 // See https://developer.github.com/v3/repos/statuses/#list-statuses-for-a-specific-ref
 func (c *client) ListStatuses(org, repo, ref string) ([]Status, error) {
 	c.log("ListStatuses", org, repo, ref)
@@ -1671,6 +2393,40 @@ func (c *client) GetRepo(owner, name string) (FullRepo, error) {
 	return repo, err
 }
 
+// GetRepoLanguages returns the languages of a repo, mapped to the number of
+// bytes of code written in each.
+//
+// See https://developer.github.com/v3/repos/#list-languages
+func (c *client) GetRepoLanguages(org, repo string) (map[string]int, error) {
+	c.log("GetRepoLanguages", org, repo)
+
+	languages := map[string]int{}
+	_, err := c.request(&request{
+		method:    http.MethodGet,
+		path:      fmt.Sprintf("/repos/%s/%s/languages", org, repo),
+		exitCodes: []int{200},
+	}, &languages)
+	return languages, err
+}
+
+// GetRepoTopics returns the topics of a repo.
+//
+// See https://developer.github.com/v3/repos/#list-all-topics-for-a-repository
+func (c *client) GetRepoTopics(org, repo string) ([]string, error) {
+	c.log("GetRepoTopics", org, repo)
+
+	var topics struct {
+		Names []string `json:"names"`
+	}
+	_, err := c.request(&request{
+		method:    http.MethodGet,
+		path:      fmt.Sprintf("/repos/%s/%s/topics", org, repo),
+		accept:    "application/vnd.github.mercy-preview+json",
+		exitCodes: []int{200},
+	}, &topics)
+	return topics.Names, err
+}
+
 // CreateRepo creates a new repository
 // See https://developer.github.com/v3/repos/#create
 func (c *client) CreateRepo(owner string, isUser bool, repo RepoCreateRequest) (*FullRepo, error) {
@@ -1699,6 +2455,32 @@ func (c *client) CreateRepo(owner string, isUser bool, repo RepoCreateRequest) (
 	return &retRepo, err
 }
 
+// CreateRepoFromTemplate creates owner/name from the templateOwner/templateRepo
+// template repository.
+//
+// See https://developer.github.com/v3/repos/#create-repository-using-a-repository-template
+func (c *client) CreateRepoFromTemplate(templateOwner, templateRepo, owner, name string, private bool) (Repo, error) {
+	c.log("CreateRepoFromTemplate", templateOwner, templateRepo, owner, name, private)
+	body := struct {
+		Owner   string `json:"owner"`
+		Name    string `json:"name"`
+		Private bool   `json:"private"`
+	}{
+		Owner:   owner,
+		Name:    name,
+		Private: private,
+	}
+	var repo Repo
+	_, err := c.request(&request{
+		method:      http.MethodPost,
+		path:        fmt.Sprintf("/repos/%s/%s/generate", templateOwner, templateRepo),
+		accept:      "application/vnd.github.baptiste-preview+json",
+		requestBody: &body,
+		exitCodes:   []int{201},
+	}, &repo)
+	return repo, err
+}
+
 // UpdateRepo edits an existing repository
 // See https://developer.github.com/v3/repos/#edit
 func (c *client) UpdateRepo(owner, name string, repo RepoUpdateRequest) (*FullRepo, error) {
@@ -1721,6 +2503,20 @@ func (c *client) UpdateRepo(owner, name string, repo RepoUpdateRequest) (*FullRe
 	return &retRepo, err
 }
 
+// SetRepoDefaultBranch changes the default branch of org/repo.
+//
+// See https://developer.github.com/v3/repos/#update-a-repository
+func (c *client) SetRepoDefaultBranch(org, repo, branch string) error {
+	c.log("SetRepoDefaultBranch", org, repo, branch)
+	_, err := c.request(&request{
+		method:      http.MethodPatch,
+		path:        fmt.Sprintf("/repos/%s/%s", org, repo),
+		requestBody: map[string]string{"default_branch": branch},
+		exitCodes:   []int{200},
+	}, nil)
+	return err
+}
+
 // GetRepos returns all repos in an org.
 //
 // This call uses multiple API tokens when results are paginated.
@@ -1801,6 +2597,48 @@ func (c *client) GetBranches(org, repo string, onlyProtected bool) ([]Branch, er
 	return branches, nil
 }
 
+// ListTags returns all tags in the repo.
+//
+// This call uses multiple API tokens when results are paginated.
+//
+// See https://developer.github.com/v3/repos/#list-tags
+func (c *client) ListTags(org, repo string) ([]Tag, error) {
+	c.log("ListTags", org, repo)
+	var tags []Tag
+	err := c.readPaginatedResults(
+		fmt.Sprintf("/repos/%s/%s/tags", org, repo),
+		acceptNone,
+		func() interface{} {
+			return &[]Tag{}
+		},
+		func(obj interface{}) {
+			tags = append(tags, *(obj.(*[]Tag))...)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// ListBranchesForCommit returns the branches whose head is the given commit.
+//
+// See https://developer.github.com/v3/repos/commits/#list-branches-for-head-commit
+func (c *client) ListBranchesForCommit(org, repo, sha string) ([]Branch, error) {
+	c.log("ListBranchesForCommit", org, repo, sha)
+	var branches []Branch
+	_, err := c.request(&request{
+		method:    http.MethodGet,
+		path:      fmt.Sprintf("/repos/%s/%s/commits/%s/branches-where-head", org, repo, sha),
+		accept:    "application/vnd.github.groot-preview+json",
+		exitCodes: []int{200},
+	}, &branches)
+	if err != nil {
+		return nil, err
+	}
+	return branches, nil
+}
+
 // GetBranchProtection returns current protection object for the branch
 //
 // See https://developer.github.com/v3/repos/branches/#get-branch-protection
@@ -1893,7 +2731,7 @@ func (c *client) AddRepoLabel(org, repo, label, description, color string) error
 //
 // See https://developer.github.com/v3/issues/labels/#update-a-label
 func (c *client) UpdateRepoLabel(org, repo, label, newName, description, color string) error {
-	c.log("UpdateRepoLabel", org, repo, label, newName, color)
+	c.log("UpdateRepoLabel", org, repo, label, newName, description, color)
 	_, err := c.request(&request{
 		method:      http.MethodPatch,
 		path:        fmt.Sprintf("/repos/%s/%s/labels/%s", org, repo, label),
@@ -1940,6 +2778,115 @@ func (c *client) GetCombinedStatus(org, repo, ref string) (*CombinedStatus, erro
 	return &combinedStatus, err
 }
 
+// GetCombinedStatusForApp returns the latest statuses for a given ref that
+// were created by the GitHub App with the given slug, ignoring the statuses
+// of other integrations. GitHub attributes the statuses of an app to its bot
+// user, so the app is identified by its slug rather than its ID. The state is
+// the state of the worst remaining status.
+//
+// See https://developer.github.com/v3/repos/statuses/#list-statuses-for-a-specific-ref
+func (c *client) GetCombinedStatusForApp(org, repo, ref, appSlug string) (*CombinedStatus, error) {
+	c.log("GetCombinedStatusForApp", org, repo, ref, appSlug)
+	combined, err := c.GetCombinedStatus(org, repo, ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get combined status: %v", err)
+	}
+	statuses, err := c.ListStatuses(org, repo, ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list statuses: %v", err)
+	}
+	// Statuses are listed newest first, so the first status of a context is
+	// the one the combined status holds.
+	creators := map[string]string{}
+	for _, status := range statuses {
+		if _, ok := creators[status.Context]; ok {
+			continue
+		}
+		var creator string
+		if status.Creator != nil {
+			creator = status.Creator.Login
+		}
+		creators[status.Context] = creator
+	}
+	filtered := &CombinedStatus{SHA: combined.SHA}
+	for _, status := range combined.Statuses {
+		if creators[status.Context] == appSlug+"[bot]" {
+			filtered.Statuses = append(filtered.Statuses, status)
+		}
+	}
+	filtered.State = NewStatusRollup(filtered.SHA, filtered.Statuses, nil).State
+	return filtered, nil
+}
+
+// GetCheckRunsForRef returns the check runs for a given ref.
+//
+// See https://developer.github.com/v3/checks/runs/#list-check-runs-for-a-specific-ref
+func (c *client) GetCheckRunsForRef(org, repo, ref string) ([]CheckRun, error) {
+	c.log("GetCheckRunsForRef", org, repo, ref)
+	if c.fake {
+		return nil, nil
+	}
+	type checkRunsPage struct {
+		CheckRuns []CheckRun `json:"check_runs"`
+	}
+	var runs []CheckRun
+	err := c.readPaginatedResults(
+		fmt.Sprintf("/repos/%s/%s/commits/%s/check-runs", org, repo, ref),
+		"application/vnd.github.antiope-preview+json", // allow the checks API -- https://developer.github.com/changes/2018-05-07-new-checks-api-public-beta/
+		func() interface{} {
+			return &checkRunsPage{}
+		},
+		func(obj interface{}) {
+			runs = append(runs, obj.(*checkRunsPage).CheckRuns...)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+// ListCheckRunAnnotations returns the annotations a check run reported on
+// the lines of the files of a commit.
+//
+// See https://developer.github.com/v3/checks/runs/#list-annotations-for-a-check-run
+func (c *client) ListCheckRunAnnotations(org, repo string, checkRunID int) ([]CheckRunAnnotation, error) {
+	c.log("ListCheckRunAnnotations", org, repo, checkRunID)
+	if c.fake {
+		return nil, nil
+	}
+	var annotations []CheckRunAnnotation
+	err := c.readPaginatedResults(
+		fmt.Sprintf("/repos/%s/%s/check-runs/%d/annotations", org, repo, checkRunID),
+		"application/vnd.github.antiope-preview+json", // allow the checks API -- https://developer.github.com/changes/2018-05-07-new-checks-api-public-beta/
+		func() interface{} {
+			return &[]CheckRunAnnotation{}
+		},
+		func(obj interface{}) {
+			annotations = append(annotations, *(obj.(*[]CheckRunAnnotation))...)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return annotations, nil
+}
+
+// GetStatusRollup returns the state of every status context and check run
+// of a given ref. See NewStatusRollup for how they are combined.
+func (c *client) GetStatusRollup(org, repo, ref string) (StatusRollup, error) {
+	c.log("GetStatusRollup", org, repo, ref)
+	combined, err := c.GetCombinedStatus(org, repo, ref)
+	if err != nil {
+		return StatusRollup{}, fmt.Errorf("failed to get combined status: %v", err)
+	}
+	runs, err := c.GetCheckRunsForRef(org, repo, ref)
+	if err != nil {
+		return StatusRollup{}, fmt.Errorf("failed to get check runs: %v", err)
+	}
+	return NewStatusRollup(combined.SHA, combined.Statuses, runs), nil
+}
+
 // getLabels is a helper function that retrieves a paginated list of labels from a github URI path.
 func (c *client) getLabels(path string) ([]Label, error) {
 	var labels []Label
@@ -2216,6 +3163,20 @@ func (c *client) RequestReview(org, repo string, number int, logins []string) er
 	return err
 }
 
+// RequestTeamReview tries to add the teams of the org listed in 'teamSlugs' as requested reviewers
+// of the specified PR. Like RequestReview, if the teams cannot all be requested at once we try to
+// request reviews from each team individually and report the teams that could not be requested
+// as "org/slug".
+//
+// See https://developer.github.com/v3/pulls/review_requests/#create-a-review-request
+func (c *client) RequestTeamReview(org, repo string, number int, teamSlugs []string) error {
+	teams := make([]string, 0, len(teamSlugs))
+	for _, slug := range teamSlugs {
+		teams = append(teams, fmt.Sprintf("%s/%s", org, slug))
+	}
+	return c.RequestReview(org, repo, number, teams)
+}
+
 // UnrequestReview tries to remove the users listed in 'logins' from the requested reviewers of the
 // specified PR. The GitHub API treats deletions of review requests differently than creations. Specifically, if
 // 'logins' contains a user that isn't a requested reviewer, other users that are valid are still removed.
@@ -2262,6 +3223,27 @@ func (c *client) UnrequestReview(org, repo string, number int, logins []string)
 	return nil
 }
 
+// ListRequestedReviewers returns the users and teams whose review is requested on the specified PR.
+//
+// See https://developer.github.com/v3/pulls/review_requests/#list-review-requests
+func (c *client) ListRequestedReviewers(org, repo string, number int) ([]User, []Team, error) {
+	c.log("ListRequestedReviewers", org, repo, number)
+	var reviewers struct {
+		Users []User `json:"users"`
+		Teams []Team `json:"teams"`
+	}
+	_, err := c.request(&request{
+		method:    http.MethodGet,
+		path:      fmt.Sprintf("/repos/%s/%s/pulls/%d/requested_reviewers", org, repo, number),
+		accept:    "application/vnd.github.symmetra-preview+json",
+		exitCodes: []int{200},
+	}, &reviewers)
+	if err != nil {
+		return nil, nil, err
+	}
+	return reviewers.Users, reviewers.Teams, nil
+}
+
 // CloseIssue closes the existing, open issue provided
 //
 // See https://developer.github.com/v3/issues/#edit-an-issue
@@ -2453,10 +3435,139 @@ func (c *client) GetFile(org, repo, filepath, commit string) ([]byte, error) {
 }
 
 // Query runs a GraphQL query using shurcooL/githubql's client.
+// Queries failing with a transient error are retried with an exponential
+// backoff, errors reported by GraphQL itself are returned immediately.
 func (c *client) Query(ctx context.Context, q interface{}, vars map[string]interface{}) error {
 	// Don't log query here because Query is typically called multiple times to get all pages.
 	// Instead log once per search and include total search cost.
-	return c.gqlc.Query(ctx, q, vars)
+	backoff := c.initialDelay
+	for retries := 0; ; retries++ {
+		err := c.gqlc.Query(ctx, q, vars)
+		if err == nil || retries >= c.maxGraphQLRetries || ctx.Err() != nil || !isTransientGraphQLError(err) {
+			return err
+		}
+		if c.logger != nil {
+			c.logger.WithError(err).Debugf("Retrying GraphQL query after transient error in %v.", backoff)
+		}
+		c.time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// graphQLServerErrorRe matches the error shurcooL/graphql returns when the
+// GraphQL endpoint responds with a server error.
+var graphQLServerErrorRe = regexp.MustCompile(`^non-200 OK status code: 5\d\d`)
+
+// isTransientGraphQLError determines whether a GraphQL query failed because of
+// a connection problem, a timeout or a server error, which may go away when
+// retrying. Errors in the response, such as validation errors, are permanent.
+func isTransientGraphQLError(err error) bool {
+	if _, ok := err.(net.Error); ok {
+		return true
+	}
+	return graphQLServerErrorRe.MatchString(err.Error())
+}
+
+// EnablePullRequestAutoMergeInput is the input of the enablePullRequestAutoMerge
+// GraphQL mutation. The type name has to match the GraphQL input type.
+type EnablePullRequestAutoMergeInput struct {
+	PullRequestID string `json:"pullRequestId"`
+	// Can be "MERGE", "SQUASH", or "REBASE". Defaults to the repository's default.
+	MergeMethod string `json:"mergeMethod,omitempty"`
+}
+
+// DisablePullRequestAutoMergeInput is the input of the disablePullRequestAutoMerge
+// GraphQL mutation. The type name has to match the GraphQL input type.
+type DisablePullRequestAutoMergeInput struct {
+	PullRequestID string `json:"pullRequestId"`
+}
+
+// EnablePullRequestAutoMerge enables GitHub's auto-merge on the PR with the given
+// GraphQL node ID, merging it with mergeMethod ("merge", "squash" or "rebase")
+// once all its requirements are met.
+//
+// See https://docs.github.com/en/graphql/reference/mutations#enablepullrequestautomerge
+func (c *client) EnablePullRequestAutoMerge(prNodeID, mergeMethod string) error {
+	c.log("EnablePullRequestAutoMerge", prNodeID, mergeMethod)
+	if c.fake || c.dry {
+		return nil
+	}
+	var m struct {
+		EnablePullRequestAutoMerge struct {
+			ClientMutationID githubql.String
+		} `graphql:"enablePullRequestAutoMerge(input: $input)"`
+	}
+	input := EnablePullRequestAutoMergeInput{
+		PullRequestID: prNodeID,
+		MergeMethod:   strings.ToUpper(mergeMethod),
+	}
+	return c.gqlc.Mutate(context.Background(), &m, input, nil)
+}
+
+// DisablePullRequestAutoMerge disables GitHub's auto-merge on the PR with the given
+// GraphQL node ID.
+//
+// See https://docs.github.com/en/graphql/reference/mutations#disablepullrequestautomerge
+func (c *client) DisablePullRequestAutoMerge(prNodeID string) error {
+	c.log("DisablePullRequestAutoMerge", prNodeID)
+	if c.fake || c.dry {
+		return nil
+	}
+	var m struct {
+		DisablePullRequestAutoMerge struct {
+			ClientMutationID githubql.String
+		} `graphql:"disablePullRequestAutoMerge(input: $input)"`
+	}
+	input := DisablePullRequestAutoMergeInput{PullRequestID: prNodeID}
+	return c.gqlc.Mutate(context.Background(), &m, input, nil)
+}
+
+// ListPullRequestClosingIssues returns the numbers of the issues that the PR
+// will close once it merges, whether they are linked in the PR description
+// or manually in the GitHub UI.
+//
+// See https://docs.github.com/en/graphql/reference/objects#pullrequest
+func (c *client) ListPullRequestClosingIssues(org, repo string, number int) ([]int, error) {
+	c.log("ListPullRequestClosingIssues", org, repo, number)
+	if c.fake {
+		return nil, nil
+	}
+	var q struct {
+		Repository struct {
+			PullRequest struct {
+				ClosingIssuesReferences struct {
+					Nodes []struct {
+						Number githubql.Int
+					}
+					PageInfo struct {
+						HasNextPage githubql.Boolean
+						EndCursor   githubql.String
+					}
+				} `graphql:"closingIssuesReferences(first: 100, after: $cursor)"`
+			} `graphql:"pullRequest(number: $number)"`
+		} `graphql:"repository(owner: $org, name: $repo)"`
+	}
+	vars := map[string]interface{}{
+		"org":    githubql.String(org),
+		"repo":   githubql.String(repo),
+		"number": githubql.Int(number),
+		"cursor": (*githubql.String)(nil),
+	}
+	var issues []int
+	for {
+		if err := c.Query(context.Background(), &q, vars); err != nil {
+			return nil, err
+		}
+		refs := q.Repository.PullRequest.ClosingIssuesReferences
+		for _, node := range refs.Nodes {
+			issues = append(issues, int(node.Number))
+		}
+		if !refs.PageInfo.HasNextPage {
+			break
+		}
+		vars["cursor"] = githubql.NewString(refs.PageInfo.EndCursor)
+	}
+	return issues, nil
 }
 
 // CreateTeam adds a team with name to the org, returning a struct with the new ID.
@@ -2684,9 +3795,9 @@ func (c *client) UpdateTeamRepo(id int, org, repo string, permission RepoPermiss
 	}
 
 	data := struct {
-		Permission string `json:"permission"`
+		Permission TeamPermission `json:"permission"`
 	}{
-		Permission: string(permission),
+		Permission: teamPermission(permission),
 	}
 
 	_, err := c.request(&request{
@@ -2698,9 +3809,22 @@ func (c *client) UpdateTeamRepo(id int, org, repo string, permission RepoPermiss
 	return err
 }
 
+// teamPermission converts a repo permission level to the permission the
+// team repository API expects, which names read and write pull and push.
+func teamPermission(permission RepoPermissionLevel) TeamPermission {
+	switch permission {
+	case Read:
+		return RepoPull
+	case Write:
+		return RepoPush
+	default:
+		return TeamPermission(permission)
+	}
+}
+
 // RemoveTeamRepo removes the team from the repo.
 //
-// https://developer.github.com/v3/teams/#add-or-update-team-repository
+// https://developer.github.com/v3/teams/#remove-team-repository
 func (c *client) RemoveTeamRepo(id int, org, repo string) error {
 	c.log("RemoveTeamRepo", id, org, repo)
 	if c.fake || c.dry {
@@ -2947,6 +4071,34 @@ func (c *client) ListIssueEvents(org, repo string, num int) ([]ListedIssueEvent,
 	return events, nil
 }
 
+// ListIssueTimeline gets the timeline of events for the specified issue.
+// Unlike ListIssueEvents, this includes timeline-only events like
+// cross-references from other issues and pull requests.
+//
+// See https://developer.github.com/v3/issues/timeline/
+func (c *client) ListIssueTimeline(org, repo string, number int) ([]TimelineEvent, error) {
+	c.log("ListIssueTimeline", org, repo, number)
+	if c.fake {
+		return nil, nil
+	}
+	path := fmt.Sprintf("/repos/%s/%s/issues/%d/timeline", org, repo, number)
+	var events []TimelineEvent
+	err := c.readPaginatedResults(
+		path,
+		"application/vnd.github.mockingbird-preview",
+		func() interface{} {
+			return &[]TimelineEvent{}
+		},
+		func(obj interface{}) {
+			events = append(events, *(obj.(*[]TimelineEvent))...)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
 // IsMergeable determines if a PR can be merged.
 // Mergeability is calculated by a background job on GitHub and is not immediately available when
 // new commits are added so the PR must be polled until the background job completes.
@@ -2975,6 +4127,65 @@ func (c *client) IsMergeable(org, repo string, number int, SHA string) (bool, er
 	return false, fmt.Errorf("reached maximum number of retries (%d) checking mergeability", maxTries)
 }
 
+// maxNodeIDs is the maximum number of node IDs a GraphQL nodes query accepts.
+const maxNodeIDs = 100
+
+// MergeabilityBatch looks up the mergeable state of several PRs with GraphQL
+// queries over their node IDs, one query per 100 PRs. Unlike IsMergeable it
+// does not poll, so PRs whose mergeability GitHub has yet to calculate are
+// MergeableStateUnknown.
+//
+// See https://docs.github.com/en/graphql/reference/queries#nodes
+func (c *client) MergeabilityBatch(prs []PRRef) (map[PRRef]MergeableState, error) {
+	c.log("MergeabilityBatch", prs)
+	if c.fake || len(prs) == 0 {
+		return nil, nil
+	}
+	ids := make([]githubql.ID, 0, len(prs))
+	for _, pr := range prs {
+		if pr.NodeID == "" {
+			return nil, fmt.Errorf("%s/%s#%d has no node ID", pr.Org, pr.Repo, pr.Number)
+		}
+		ids = append(ids, githubql.ID(pr.NodeID))
+	}
+	states := map[string]MergeableState{}
+	// The nodes query accepts at most maxNodeIDs IDs.
+	for start := 0; start < len(ids); start += maxNodeIDs {
+		end := start + maxNodeIDs
+		if end > len(ids) {
+			end = len(ids)
+		}
+		var q struct {
+			Nodes []struct {
+				PullRequest struct {
+					ID        githubql.ID
+					Mergeable githubql.MergeableState
+				} `graphql:"... on PullRequest"`
+			} `graphql:"nodes(ids: $ids)"`
+		}
+		vars := map[string]interface{}{
+			"ids": ids[start:end],
+		}
+		if err := c.Query(context.Background(), &q, vars); err != nil {
+			return nil, err
+		}
+		for _, node := range q.Nodes {
+			if id, ok := node.PullRequest.ID.(string); ok {
+				states[id] = MergeableState(node.PullRequest.Mergeable)
+			}
+		}
+	}
+	mergeability := make(map[PRRef]MergeableState, len(prs))
+	for _, pr := range prs {
+		state, ok := states[pr.NodeID]
+		if !ok {
+			return nil, fmt.Errorf("no pull request found for %s/%s#%d (node ID %s)", pr.Org, pr.Repo, pr.Number, pr.NodeID)
+		}
+		mergeability[pr] = state
+	}
+	return mergeability, nil
+}
+
 // ClearMilestone clears the milestone from the specified issue
 //
 // See https://developer.github.com/v3/issues/#edit-an-issue
@@ -3014,6 +4225,25 @@ func (c *client) SetMilestone(org, repo string, issueNum, milestoneNum int) erro
 	return err
 }
 
+// BulkSetMilestone sets the milestone of each of the specified issues. An issue
+// that fails to be updated does not stop the others from being updated; the
+// numbers of the issues that failed are returned along with their errors.
+//
+// See https://developer.github.com/v3/issues/#edit-an-issue
+func (c *client) BulkSetMilestone(org, repo string, numbers []int, milestoneNum int) ([]int, error) {
+	c.log("BulkSetMilestone", org, repo, numbers, milestoneNum)
+
+	var failed []int
+	var errs []error
+	for _, number := range numbers {
+		if err := c.SetMilestone(org, repo, number, milestoneNum); err != nil {
+			failed = append(failed, number)
+			errs = append(errs, fmt.Errorf("failed to set the milestone of %s/%s#%d: %v", org, repo, number, err))
+		}
+	}
+	return failed, errorutil.NewAggregate(errs...)
+}
+
 // ListMilestones list all milestones in a repo
 //
 // See https://developer.github.com/v3/issues/milestones/#list-milestones-for-a-repository/
@@ -3260,7 +4490,24 @@ func (c *client) TeamHasMember(teamID int, memberLogin string) (bool, error) {
 	return false, nil
 }
 
-// GetTeamBySlug returns information about that team
+// TeamNotFound happens when github cannot find the team requested by GetTeamBySlug().
+type TeamNotFound struct {
+	Org, Slug string
+}
+
+func (e *TeamNotFound) Error() string {
+	return fmt.Sprintf("team %s not found in org %s", e.Slug, e.Org)
+}
+
+// IsTeamNotFound determines whether the error is a *TeamNotFound.
+func IsTeamNotFound(err error) bool {
+	_, ok := err.(*TeamNotFound)
+	return ok
+}
+
+// GetTeamBySlug returns information about the team with the given slug
+// without listing all teams of the org. A *TeamNotFound error is returned
+// if the org has no such team.
 //
 // See https://developer.github.com/v3/teams/#get-team-by-name
 func (c *client) GetTeamBySlug(slug string, org string) (*Team, error) {
@@ -3269,13 +4516,16 @@ func (c *client) GetTeamBySlug(slug string, org string) (*Team, error) {
 		return &Team{}, nil
 	}
 	var team Team
-	_, err := c.request(&request{
+	code, err := c.request(&request{
 		method:    http.MethodGet,
 		path:      fmt.Sprintf("/orgs/%s/teams/%s", org, slug),
-		exitCodes: []int{200},
+		exitCodes: []int{200, 404},
 	}, &team)
 	if err != nil {
 		return nil, err
 	}
-	return &team, err
+	if code == 404 {
+		return nil, &TeamNotFound{Org: org, Slug: slug}
+	}
+	return &team, nil
 }
@@ -22,10 +22,12 @@ import (
 	"crypto/tls"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"reflect"
 	"strconv"
 	"strings"
@@ -33,6 +35,7 @@ import (
 	"testing"
 	"time"
 
+	githubql "github.com/shurcooL/githubv4"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/utils/diff"
 
@@ -68,11 +71,12 @@ func getClient(url string) *client {
 					TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
 				},
 			},
-			bases:         []string{url},
-			maxRetries:    defaultMaxRetries,
-			max404Retries: defaultMax404Retries,
-			initialDelay:  defaultInitialDelay,
-			maxSleepTime:  defaultMaxSleepTime,
+			bases:             []string{url},
+			maxRetries:        defaultMaxRetries,
+			max404Retries:     defaultMax404Retries,
+			maxGraphQLRetries: defaultMaxGraphQLRetries,
+			initialDelay:      defaultInitialDelay,
+			maxSleepTime:      defaultMaxSleepTime,
 		},
 	}
 }
@@ -334,6 +338,30 @@ func TestGetPullRequest(t *testing.T) {
 	}
 }
 
+func TestGetPullRequestDiff(t *testing.T) {
+	diff := "diff --git a/foo b/foo\n--- a/foo\n+++ b/foo\n@@ -1 +1 @@\n-old\n+new\n"
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path != "/repos/k8s/kuber/pulls/12" {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+		if r.Header.Get("Accept") != "application/vnd.github.VERSION.diff" {
+			t.Errorf("Bad Accept header: %s", r.Header.Get("Accept"))
+		}
+		fmt.Fprint(w, diff)
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	b, err := c.GetPullRequestDiff("k8s", "kuber", 12)
+	if err != nil {
+		t.Errorf("Didn't expect error: %v", err)
+	} else if string(b) != diff {
+		t.Errorf("Wrong diff: %q", string(b))
+	}
+}
+
 func TestGetPullRequestChanges(t *testing.T) {
 	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
@@ -362,6 +390,37 @@ func TestGetPullRequestChanges(t *testing.T) {
 	}
 }
 
+func TestGetPullRequestChangesLite(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path != "/repos/k8s/kuber/pulls/12/files" {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+		changes := []PullRequestChange{
+			{Filename: "foo.txt", Status: "modified", Additions: 2, Deletions: 1, Changes: 3, Patch: "@@ -1 +1,2 @@"},
+		}
+		b, err := json.Marshal(&changes)
+		if err != nil {
+			t.Fatalf("Didn't expect error: %v", err)
+		}
+		fmt.Fprint(w, string(b))
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	cs, err := c.GetPullRequestChangesLite("k8s", "kuber", 12)
+	if err != nil {
+		t.Errorf("Didn't expect error: %v", err)
+	}
+	expected := []PullRequestChange{
+		{Filename: "foo.txt", Status: "modified", Additions: 2, Deletions: 1, Changes: 3},
+	}
+	if !reflect.DeepEqual(cs, expected) {
+		t.Errorf("Wrong result, expected %#v, got %#v", expected, cs)
+	}
+}
+
 func TestGetRef(t *testing.T) {
 	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
@@ -384,145 +443,938 @@ func TestGetRef(t *testing.T) {
 
 func TestDeleteRef(t *testing.T) {
 	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodDelete {
+		if r.Method != http.MethodDelete {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path != "/repos/k8s/kuber/git/refs/heads/my-feature" {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+		http.Error(w, "204 No Content", http.StatusNoContent)
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	if err := c.DeleteRef("k8s", "kuber", "heads/my-feature"); err != nil {
+		t.Errorf("Didn't expect error: %v", err)
+	}
+}
+
+func TestGetSingleCommit(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path != "/repos/octocat/Hello-World/commits/6dcb09b5b57875f334f61aebed695e2e4193db5e" {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+		fmt.Fprint(w, `{
+			"commit": {
+			  "tree": {
+				"sha": "6dcb09b5b57875f334f61aebed695e2e4193db5e"
+			  }
+		        }
+		  }`)
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	commit, err := c.GetSingleCommit("octocat", "Hello-World", "6dcb09b5b57875f334f61aebed695e2e4193db5e")
+	if err != nil {
+		t.Errorf("Didn't expect error: %v", err)
+	} else if commit.Commit.Tree.SHA != "6dcb09b5b57875f334f61aebed695e2e4193db5e" {
+		t.Errorf("Wrong tree-hash: %s", commit.Commit.Tree.SHA)
+	}
+}
+
+func TestCreateStatus(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path != "/repos/k8s/kuber/statuses/abcdef" {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("Could not read request body: %v", err)
+		}
+		var s Status
+		if err := json.Unmarshal(b, &s); err != nil {
+			t.Errorf("Could not unmarshal request: %v", err)
+		} else if s.Context != "c" {
+			t.Errorf("Wrong context: %s", s.Context)
+		}
+		http.Error(w, "201 Created", http.StatusCreated)
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	if err := c.CreateStatus("k8s", "kuber", "abcdef", Status{
+		Context: "c",
+	}); err != nil {
+		t.Errorf("Didn't expect error: %v", err)
+	}
+}
+
+func TestListIssues(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path == "/repos/k8s/kuber/issues" {
+			ics := []Issue{{Number: 1}}
+			b, err := json.Marshal(ics)
+			if err != nil {
+				t.Fatalf("Didn't expect error: %v", err)
+			}
+			w.Header().Set("Link", fmt.Sprintf(`<blorp>; rel="first", <https://%s/someotherpath>; rel="next"`, r.Host))
+			fmt.Fprint(w, string(b))
+		} else if r.URL.Path == "/someotherpath" {
+			ics := []Issue{{Number: 2}}
+			b, err := json.Marshal(ics)
+			if err != nil {
+				t.Fatalf("Didn't expect error: %v", err)
+			}
+			fmt.Fprint(w, string(b))
+		} else {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	ics, err := c.ListOpenIssues("k8s", "kuber")
+	if err != nil {
+		t.Errorf("Didn't expect error: %v", err)
+	} else if len(ics) != 2 {
+		t.Errorf("Expected two issues, found %d: %v", len(ics), ics)
+	} else if ics[0].Number != 1 || ics[1].Number != 2 {
+		t.Errorf("Wrong issue IDs: %v", ics)
+	}
+}
+
+func TestListIssueComments(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path == "/repos/k8s/kuber/issues/15/comments" {
+			ics := []IssueComment{{ID: 1}}
+			b, err := json.Marshal(ics)
+			if err != nil {
+				t.Fatalf("Didn't expect error: %v", err)
+			}
+			w.Header().Set("Link", fmt.Sprintf(`<blorp>; rel="first", <https://%s/someotherpath>; rel="next"`, r.Host))
+			fmt.Fprint(w, string(b))
+		} else if r.URL.Path == "/someotherpath" {
+			ics := []IssueComment{{ID: 2}}
+			b, err := json.Marshal(ics)
+			if err != nil {
+				t.Fatalf("Didn't expect error: %v", err)
+			}
+			fmt.Fprint(w, string(b))
+		} else {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	ics, err := c.ListIssueComments("k8s", "kuber", 15)
+	if err != nil {
+		t.Errorf("Didn't expect error: %v", err)
+	} else if len(ics) != 2 {
+		t.Errorf("Expected two issues, found %d: %v", len(ics), ics)
+	} else if ics[0].ID != 1 || ics[1].ID != 2 {
+		t.Errorf("Wrong issue IDs: %v", ics)
+	}
+}
+
+func TestListWorkflowRuns(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path == "/repos/k8s/kuber/actions/runs" {
+			if branch := r.URL.Query().Get("branch"); branch != "master" {
+				t.Errorf("Bad branch filter: %q", branch)
+			}
+			if status := r.URL.Query().Get("status"); status != "failure" {
+				t.Errorf("Bad status filter: %q", status)
+			}
+			w.Header().Set("Link", fmt.Sprintf(`<blorp>; rel="first", <https://%s/someotherpath>; rel="next"`, r.Host))
+			fmt.Fprint(w, `{"total_count": 3, "workflow_runs": [{"id": 1, "head_branch": "master"}, {"id": 2, "head_branch": "master"}]}`)
+		} else if r.URL.Path == "/someotherpath" {
+			fmt.Fprint(w, `{"total_count": 3, "workflow_runs": [{"id": 3, "head_branch": "master"}]}`)
+		} else {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	runs, err := c.ListWorkflowRuns("k8s", "kuber", WorkflowRunOptions{Branch: "master", Status: "failure"})
+	if err != nil {
+		t.Errorf("Didn't expect error: %v", err)
+	} else if len(runs) != 3 {
+		t.Errorf("Expected three workflow runs, found %d: %v", len(runs), runs)
+	} else if runs[0].ID != 1 || runs[1].ID != 2 || runs[2].ID != 3 {
+		t.Errorf("Wrong workflow run IDs: %v", runs)
+	}
+}
+
+func TestGetStatusRollup(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		switch r.URL.Path {
+		case "/repos/k8s/kuber/commits/abcdef/status":
+			fmt.Fprint(w, `{"sha": "abcdef", "state": "failure", "statuses": [{"context": "build", "state": "success"}, {"context": "lint", "state": "failure"}]}`)
+		case "/repos/k8s/kuber/commits/abcdef/check-runs":
+			w.Header().Set("Link", fmt.Sprintf(`<blorp>; rel="first", <https://%s/someotherpath>; rel="next"`, r.Host))
+			fmt.Fprint(w, `{"total_count": 2, "check_runs": [{"id": 1, "name": "build", "status": "in_progress"}]}`)
+		case "/someotherpath":
+			fmt.Fprint(w, `{"total_count": 2, "check_runs": [{"id": 2, "name": "codeql", "status": "completed", "conclusion": "success"}]}`)
+		default:
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	rollup, err := c.GetStatusRollup("k8s", "kuber", "abcdef")
+	if err != nil {
+		t.Fatalf("Didn't expect error: %v", err)
+	}
+	expected := StatusRollup{
+		SHA:   "abcdef",
+		State: StatusFailure,
+		Contexts: map[string]string{
+			"build":  StatusPending,
+			"lint":   StatusFailure,
+			"codeql": StatusSuccess,
+		},
+	}
+	if !reflect.DeepEqual(rollup, expected) {
+		t.Errorf("Expected rollup %+v, got %+v", expected, rollup)
+	}
+}
+
+func TestListCheckRunAnnotations(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path == "/repos/k8s/kuber/check-runs/42/annotations" {
+			w.Header().Set("Link", fmt.Sprintf(`<blorp>; rel="first", <https://%s/someotherpath>; rel="next"`, r.Host))
+			fmt.Fprint(w, `[{"path": "main.go", "start_line": 10, "end_line": 12, "annotation_level": "failure", "message": "undefined: foo"}]`)
+		} else if r.URL.Path == "/someotherpath" {
+			fmt.Fprint(w, `[{"path": "README.md", "start_line": 3, "end_line": 3, "annotation_level": "warning", "title": "typo", "message": "teh"}]`)
+		} else {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	annotations, err := c.ListCheckRunAnnotations("k8s", "kuber", 42)
+	if err != nil {
+		t.Fatalf("Didn't expect error: %v", err)
+	}
+	expected := []CheckRunAnnotation{
+		{Path: "main.go", StartLine: 10, EndLine: 12, AnnotationLevel: CheckRunAnnotationLevelFailure, Message: "undefined: foo"},
+		{Path: "README.md", StartLine: 3, EndLine: 3, AnnotationLevel: CheckRunAnnotationLevelWarning, Title: "typo", Message: "teh"},
+	}
+	if !reflect.DeepEqual(annotations, expected) {
+		t.Errorf("Expected annotations %+v, got %+v", expected, annotations)
+	}
+}
+
+func TestListOrgSecrets(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path == "/orgs/k8s/actions/secrets" {
+			w.Header().Set("Link", fmt.Sprintf(`<blorp>; rel="first", <https://%s/someotherpath>; rel="next"`, r.Host))
+			fmt.Fprint(w, `{"total_count": 2, "secrets": [{"name": "TOKEN", "created_at": "2019-08-10T14:59:22Z", "updated_at": "2020-01-10T14:59:22Z", "visibility": "all"}]}`)
+		} else if r.URL.Path == "/someotherpath" {
+			fmt.Fprint(w, `{"total_count": 2, "secrets": [{"name": "DEPLOY_KEY", "visibility": "selected", "selected_repositories_url": "https://api.github.com/orgs/k8s/actions/secrets/DEPLOY_KEY/repositories"}]}`)
+		} else {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	secrets, err := c.ListOrgSecrets("k8s")
+	if err != nil {
+		t.Fatalf("Didn't expect error: %v", err)
+	}
+	if len(secrets) != 2 {
+		t.Fatalf("Expected two secrets, found %d: %v", len(secrets), secrets)
+	}
+	if secrets[0].Name != "TOKEN" || secrets[0].Visibility != "all" || secrets[0].UpdatedAt.Year() != 2020 {
+		t.Errorf("Wrong first secret: %+v", secrets[0])
+	}
+	if secrets[1].Name != "DEPLOY_KEY" || secrets[1].SelectedRepositoriesURL == "" {
+		t.Errorf("Wrong second secret: %+v", secrets[1])
+	}
+}
+
+func TestListEnvironments(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path == "/repos/k8s/kuber/environments" {
+			w.Header().Set("Link", fmt.Sprintf(`<blorp>; rel="first", <https://%s/someotherpath>; rel="next"`, r.Host))
+			fmt.Fprint(w, `{"total_count": 2, "environments": [{"id": 1, "name": "production", "protection_rules": [{"id": 10, "type": "wait_timer", "wait_timer": 30}, {"id": 11, "type": "required_reviewers", "reviewers": [{"type": "User", "reviewer": {"id": 100, "login": "alice"}}, {"type": "Team", "reviewer": {"id": 200, "slug": "release"}}]}]}]}`)
+		} else if r.URL.Path == "/someotherpath" {
+			fmt.Fprint(w, `{"total_count": 2, "environments": [{"id": 2, "name": "staging"}]}`)
+		} else {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	envs, err := c.ListEnvironments("k8s", "kuber")
+	if err != nil {
+		t.Fatalf("Didn't expect error: %v", err)
+	}
+	if len(envs) != 2 {
+		t.Fatalf("Expected two environments, found %d: %v", len(envs), envs)
+	}
+	if envs[0].Name != "production" || envs[1].Name != "staging" {
+		t.Errorf("Wrong environments: %v", envs)
+	}
+	if len(envs[1].ProtectionRules) != 0 {
+		t.Errorf("Expected no protection rules for staging, found %v", envs[1].ProtectionRules)
+	}
+	rules := envs[0].ProtectionRules
+	if len(rules) != 2 {
+		t.Fatalf("Expected two protection rules for production, found %d: %v", len(rules), rules)
+	}
+	if rules[0].Type != EnvironmentProtectionRuleWaitTimer || rules[0].WaitTimer != 30 {
+		t.Errorf("Wrong wait timer rule: %+v", rules[0])
+	}
+	if rules[1].Type != EnvironmentProtectionRuleRequiredReviewers || len(rules[1].Reviewers) != 2 {
+		t.Fatalf("Wrong required reviewers rule: %+v", rules[1])
+	}
+	if r := rules[1].Reviewers[0]; r.Type != "User" || r.Reviewer.Login != "alice" {
+		t.Errorf("Wrong user reviewer: %+v", r)
+	}
+	if r := rules[1].Reviewers[1]; r.Type != "Team" || r.Reviewer.Slug != "release" {
+		t.Errorf("Wrong team reviewer: %+v", r)
+	}
+}
+
+func TestListDeploymentBranchPolicies(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path == "/repos/k8s/kuber/environments/production/deployment-branch-policies" {
+			w.Header().Set("Link", fmt.Sprintf(`<blorp>; rel="first", <https://%s/someotherpath>; rel="next"`, r.Host))
+			fmt.Fprint(w, `{"total_count": 3, "branch_policies": [{"id": 1, "name": "release-*", "type": "branch"}, {"id": 2, "name": "main"}]}`)
+		} else if r.URL.Path == "/someotherpath" {
+			fmt.Fprint(w, `{"total_count": 3, "branch_policies": [{"id": 3, "name": "v*", "type": "tag"}]}`)
+		} else {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	policies, err := c.ListDeploymentBranchPolicies("k8s", "kuber", "production")
+	if err != nil {
+		t.Fatalf("Didn't expect error: %v", err)
+	}
+	expected := []BranchPolicy{
+		{ID: 1, Name: "release-*", Type: "branch"},
+		{ID: 2, Name: "main"},
+		{ID: 3, Name: "v*", Type: "tag"},
+	}
+	if !reflect.DeepEqual(policies, expected) {
+		t.Errorf("Expected branch policies %+v, got %+v", expected, policies)
+	}
+}
+
+func TestListBranchesForCommit(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path != "/repos/k8s/kuber/commits/abcdef/branches-where-head" {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+		if accept := r.Header.Get("Accept"); accept != "application/vnd.github.groot-preview+json" {
+			t.Errorf("Bad Accept header: %s", accept)
+		}
+		fmt.Fprint(w, `[{"name": "master", "commit": {"sha": "abcdef"}, "protected": true}, {"name": "release-1.0", "commit": {"sha": "abcdef"}, "protected": false}]`)
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	branches, err := c.ListBranchesForCommit("k8s", "kuber", "abcdef")
+	if err != nil {
+		t.Fatalf("Didn't expect error: %v", err)
+	}
+	expected := []Branch{{Name: "master", Protected: true}, {Name: "release-1.0"}}
+	if !reflect.DeepEqual(branches, expected) {
+		t.Errorf("Expected branches %+v, got %+v", expected, branches)
+	}
+}
+
+func TestListStatuses(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path == "/repos/k8s/kuber/statuses/abcdef" {
+			w.Header().Set("Link", fmt.Sprintf(`<blorp>; rel="first", <https://%s/someotherpath>; rel="next"`, r.Host))
+			fmt.Fprint(w, `[{"context": "unit", "state": "success"}, {"context": "e2e", "state": "pending"}]`)
+		} else if r.URL.Path == "/someotherpath" {
+			fmt.Fprint(w, `[{"context": "lint", "state": "failure"}]`)
+		} else {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	statuses, err := c.ListStatuses("k8s", "kuber", "abcdef")
+	if err != nil {
+		t.Fatalf("Didn't expect error: %v", err)
+	}
+	expected := []Status{
+		{Context: "unit", State: StatusSuccess},
+		{Context: "e2e", State: StatusPending},
+		{Context: "lint", State: StatusFailure},
+	}
+	if !reflect.DeepEqual(statuses, expected) {
+		t.Errorf("Expected statuses %+v, got %+v", expected, statuses)
+	}
+}
+
+func TestGetCombinedStatusForApp(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		switch r.URL.Path {
+		case "/repos/k8s/kuber/commits/abcdef/status":
+			fmt.Fprint(w, `{"sha": "abcdef", "state": "failure", "statuses": [{"context": "unit", "state": "success"}, {"context": "e2e", "state": "pending"}, {"context": "lint", "state": "failure"}]}`)
+		case "/repos/k8s/kuber/statuses/abcdef":
+			fmt.Fprint(w, `[{"context": "unit", "state": "success", "creator": {"login": "ci-app[bot]"}},
+				{"context": "e2e", "state": "pending", "creator": {"login": "ci-app[bot]"}},
+				{"context": "lint", "state": "failure", "creator": {"login": "linter[bot]"}},
+				{"context": "lint", "state": "pending", "creator": {"login": "ci-app[bot]"}}]`)
+		default:
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	combined, err := c.GetCombinedStatusForApp("k8s", "kuber", "abcdef", "ci-app")
+	if err != nil {
+		t.Fatalf("Didn't expect error: %v", err)
+	}
+	expected := &CombinedStatus{
+		SHA:   "abcdef",
+		State: StatusPending,
+		Statuses: []Status{
+			{Context: "unit", State: StatusSuccess},
+			{Context: "e2e", State: StatusPending},
+		},
+	}
+	if !reflect.DeepEqual(combined, expected) {
+		t.Errorf("Expected combined status %+v, got %+v", expected, combined)
+	}
+}
+
+func TestListUserGPGKeys(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path == "/users/alice/gpg_keys" {
+			w.Header().Set("Link", fmt.Sprintf(`<blorp>; rel="first", <https://%s/someotherpath>; rel="next"`, r.Host))
+			fmt.Fprint(w, `[{"id": 1, "key_id": "3262EFF25BA0D270", "can_sign": true, "emails": [{"email": "alice@example.com", "verified": true}]}]`)
+		} else if r.URL.Path == "/someotherpath" {
+			fmt.Fprint(w, `[{"id": 2, "key_id": "4A5D8C91E2B3F607", "can_sign": false}]`)
+		} else {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	keys, err := c.ListUserGPGKeys("alice")
+	if err != nil {
+		t.Fatalf("Didn't expect error: %v", err)
+	}
+	if len(keys) != 2 {
+		t.Fatalf("Expected two keys, found %d: %v", len(keys), keys)
+	}
+	if keys[0].KeyID != "3262EFF25BA0D270" || !keys[0].CanSign || len(keys[0].Emails) != 1 || !keys[0].Emails[0].Verified {
+		t.Errorf("Wrong first key: %+v", keys[0])
+	}
+	if keys[1].KeyID != "4A5D8C91E2B3F607" || keys[1].CanSign {
+		t.Errorf("Wrong second key: %+v", keys[1])
+	}
+}
+
+func TestCreateGist(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path != "/gists" {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("Could not read request body: %v", err)
+		}
+		var body map[string]interface{}
+		if err := json.Unmarshal(b, &body); err != nil {
+			t.Fatalf("Could not unmarshal request: %v", err)
+		}
+		expected := map[string]interface{}{
+			"description": "coverage report",
+			"public":      false,
+			"files": map[string]interface{}{
+				"coverage.txt": map[string]interface{}{"content": "100%"},
+				"summary.md":   map[string]interface{}{"content": "# Summary"},
+			},
+		}
+		if !reflect.DeepEqual(body, expected) {
+			t.Errorf("Wrong request body, expected %v, got %v", expected, body)
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"id": "aa5a315d61ae9438b18d", "html_url": "https://gist.github.com/aa5a315d61ae9438b18d"}`)
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	url, err := c.CreateGist("coverage report", false, map[string]string{"coverage.txt": "100%", "summary.md": "# Summary"})
+	if err != nil {
+		t.Fatalf("Didn't expect error: %v", err)
+	}
+	if url != "https://gist.github.com/aa5a315d61ae9438b18d" {
+		t.Errorf("Wrong gist URL: %s", url)
+	}
+}
+
+func TestListGists(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path == "/gists" {
+			w.Header().Set("Link", fmt.Sprintf(`<blorp>; rel="first", <https://%s/someotherpath>; rel="next"`, r.Host))
+			fmt.Fprint(w, `[{"id": "1", "description": "first", "public": true, "files": {"a.txt": {"filename": "a.txt", "raw_url": "https://gist.githubusercontent.com/a.txt"}}}]`)
+		} else if r.URL.Path == "/someotherpath" {
+			fmt.Fprint(w, `[{"id": "2", "description": "second"}]`)
+		} else {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	gists, err := c.ListGists()
+	if err != nil {
+		t.Fatalf("Didn't expect error: %v", err)
+	}
+	if len(gists) != 2 {
+		t.Fatalf("Expected two gists, found %d: %v", len(gists), gists)
+	}
+	if gists[0].ID != "1" || !gists[0].Public || gists[0].Files["a.txt"].RawURL != "https://gist.githubusercontent.com/a.txt" {
+		t.Errorf("Wrong first gist: %+v", gists[0])
+	}
+	if gists[1].ID != "2" || gists[1].Public {
+		t.Errorf("Wrong second gist: %+v", gists[1])
+	}
+}
+
+func TestStarRepo(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path != "/user/starred/k8s/kuber" {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+		http.Error(w, "204 No Content", http.StatusNoContent)
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	if err := c.StarRepo("k8s", "kuber"); err != nil {
+		t.Errorf("Didn't expect error: %v", err)
+	}
+}
+
+func TestUnstarRepo(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path != "/user/starred/k8s/kuber" {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+		http.Error(w, "204 No Content", http.StatusNoContent)
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	if err := c.UnstarRepo("k8s", "kuber"); err != nil {
+		t.Errorf("Didn't expect error: %v", err)
+	}
+}
+
+func TestStarRepoDryRun(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Unexpected request in dry-run mode: %s %s", r.Method, r.URL.Path)
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	c.dry = true
+	if err := c.StarRepo("k8s", "kuber"); err != nil {
+		t.Errorf("Didn't expect error: %v", err)
+	}
+	if err := c.UnstarRepo("k8s", "kuber"); err != nil {
+		t.Errorf("Didn't expect error: %v", err)
+	}
+}
+
+func TestGetRepoPublicKey(t *testing.T) {
+	ts := simpleTestServer(t, "/repos/k8s/kuber/actions/secrets/public-key", RepoPublicKey{KeyID: "1234", Key: "c2VjcmV0"})
+	defer ts.Close()
+	c := getClient(ts.URL)
+	key, err := c.GetRepoPublicKey("k8s", "kuber")
+	if err != nil {
+		t.Errorf("Didn't expect error: %v", err)
+	} else if key.KeyID != "1234" || key.Key != "c2VjcmV0" {
+		t.Errorf("Wrong public key: %+v", key)
+	}
+}
+
+func TestGetRepoLanguages(t *testing.T) {
+	ts := simpleTestServer(t, "/repos/k8s/kuber/languages", map[string]int{"Go": 12345, "Shell": 678})
+	defer ts.Close()
+	c := getClient(ts.URL)
+	languages, err := c.GetRepoLanguages("k8s", "kuber")
+	if err != nil {
+		t.Errorf("Didn't expect error: %v", err)
+	} else if expected := map[string]int{"Go": 12345, "Shell": 678}; !reflect.DeepEqual(languages, expected) {
+		t.Errorf("Expected languages %v, got %v", expected, languages)
+	}
+}
+
+func TestGetRepoTopics(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path != "/repos/k8s/kuber/topics" {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+		if r.Header.Get("Accept") != "application/vnd.github.mercy-preview+json" {
+			t.Errorf("Bad Accept header: %s", r.Header.Get("Accept"))
+		}
+		fmt.Fprint(w, `{"names": ["kubernetes", "ci"]}`)
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	topics, err := c.GetRepoTopics("k8s", "kuber")
+	if err != nil {
+		t.Errorf("Didn't expect error: %v", err)
+	} else if expected := []string{"kubernetes", "ci"}; !reflect.DeepEqual(topics, expected) {
+		t.Errorf("Expected topics %v, got %v", expected, topics)
+	}
+}
+
+func TestCreateOrUpdateRepoSecret(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path != "/repos/k8s/kuber/actions/secrets/TOKEN" {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("Could not read request body: %v", err)
+		}
+		var ps map[string]string
+		if err := json.Unmarshal(b, &ps); err != nil {
+			t.Errorf("Could not unmarshal request: %v", err)
+		} else if len(ps) != 2 {
+			t.Errorf("Wrong length patch: %v", ps)
+		} else if ps["encrypted_value"] != "ZW5jcnlwdGVk" || ps["key_id"] != "1234" {
+			t.Errorf("Wrong secret: %v", ps)
+		}
+		http.Error(w, "201 Created", http.StatusCreated)
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	if err := c.CreateOrUpdateRepoSecret("k8s", "kuber", "TOKEN", "ZW5jcnlwdGVk", "1234"); err != nil {
+		t.Errorf("Didn't expect error: %v", err)
+	}
+}
+
+func TestListRepoVulnerabilityAlerts(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path == "/repos/k8s/kuber/dependabot/alerts" {
+			w.Header().Set("Link", fmt.Sprintf(`<blorp>; rel="first", <https://%s/someotherpath>; rel="next"`, r.Host))
+			fmt.Fprint(w, `[{"number": 1, "state": "open", "dependency": {"package": {"ecosystem": "go", "name": "golang.org/x/net"}, "manifest_path": "go.mod"}, "security_advisory": {"ghsa_id": "GHSA-1234", "severity": "high"}}]`)
+		} else if r.URL.Path == "/someotherpath" {
+			fmt.Fprint(w, `[{"number": 2, "state": "dismissed", "dismissed_reason": "tolerable_risk"}]`)
+		} else {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	alerts, err := c.ListRepoVulnerabilityAlerts("k8s", "kuber")
+	if err != nil {
+		t.Fatalf("Didn't expect error: %v", err)
+	}
+	if len(alerts) != 2 {
+		t.Fatalf("Expected two alerts, found %d: %v", len(alerts), alerts)
+	}
+	if a := alerts[0]; a.Number != 1 || a.Dependency.Package.Name != "golang.org/x/net" || a.SecurityAdvisory.Severity != "high" || a.DismissedReason != nil {
+		t.Errorf("Wrong first alert: %+v", a)
+	}
+	if a := alerts[1]; a.State != AlertStateDismissed || a.DismissedReason == nil || *a.DismissedReason != AlertDismissedReasonTolerableRisk {
+		t.Errorf("Wrong second alert: %+v", a)
+	}
+}
+
+func TestDismissAlert(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path != "/repos/k8s/kuber/dependabot/alerts/5" {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("Could not read request body: %v", err)
+		}
+		var ps map[string]string
+		if err := json.Unmarshal(b, &ps); err != nil {
+			t.Errorf("Could not unmarshal request: %v", err)
+		} else if ps["state"] != "dismissed" || ps["dismissed_reason"] != "not_used" {
+			t.Errorf("Wrong update: %v", ps)
+		}
+		fmt.Fprint(w, `{"number": 5, "state": "dismissed"}`)
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	if err := c.DismissAlert("k8s", "kuber", 5, AlertDismissedReasonNotUsed); err != nil {
+		t.Errorf("Didn't expect error: %v", err)
+	}
+}
+
+func TestListRepoRulesets(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path == "/repos/k8s/kuber/rulesets" {
+			w.Header().Set("Link", fmt.Sprintf(`<blorp>; rel="first", <https://%s/someotherpath>; rel="next"`, r.Host))
+			fmt.Fprint(w, `[{"id": 1, "name": "protect-main", "target": "branch", "enforcement": "active"}]`)
+		} else if r.URL.Path == "/someotherpath" {
+			fmt.Fprint(w, `[{"id": 2, "name": "protect-tags", "target": "tag", "enforcement": "evaluate"}]`)
+		} else {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	rulesets, err := c.ListRepoRulesets("k8s", "kuber")
+	if err != nil {
+		t.Fatalf("Didn't expect error: %v", err)
+	}
+	expected := []Ruleset{
+		{ID: 1, Name: "protect-main", Target: "branch", Enforcement: RulesetEnforcementActive},
+		{ID: 2, Name: "protect-tags", Target: "tag", Enforcement: RulesetEnforcementEvaluate},
+	}
+	if !reflect.DeepEqual(rulesets, expected) {
+		t.Errorf("Wrong rulesets, expected %+v, got %+v", expected, rulesets)
+	}
+}
+
+func TestCreateRepoRuleset(t *testing.T) {
+	ruleset := Ruleset{
+		Name:        "protect-main",
+		Target:      "branch",
+		Enforcement: RulesetEnforcementActive,
+		BypassActors: []RulesetBypassActor{
+			{ActorID: 5, ActorType: "Team", BypassMode: "always"},
+		},
+		Conditions: &RulesetConditions{
+			RefName: RulesetRefNameCondition{Include: []string{"~DEFAULT_BRANCH"}, Exclude: []string{}},
+		},
+		Rules: []RulesetRule{
+			{Type: "deletion"},
+			{Type: "required_linear_history"},
+		},
+	}
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path != "/repos/k8s/kuber/rulesets" {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("Could not read request body: %v", err)
+		}
+		var actual Ruleset
+		if err := json.Unmarshal(b, &actual); err != nil {
+			t.Errorf("Could not unmarshal request: %v", err)
+		} else if !reflect.DeepEqual(actual, ruleset) {
+			t.Errorf("Wrong ruleset, expected %+v, got %+v", ruleset, actual)
+		}
+		if strings.Contains(string(b), `"id"`) {
+			t.Errorf("Request should not contain an ID: %s", b)
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"id": 42, "name": "protect-main"}`)
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	id, err := c.CreateRepoRuleset("k8s", "kuber", ruleset)
+	if err != nil {
+		t.Fatalf("Didn't expect error: %v", err)
+	}
+	if id != 42 {
+		t.Errorf("Expected ruleset ID 42, got %d", id)
+	}
+}
+
+func TestListDeployKeys(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
 			t.Errorf("Bad method: %s", r.Method)
 		}
-		if r.URL.Path != "/repos/k8s/kuber/git/refs/heads/my-feature" {
+		if r.URL.Path == "/repos/k8s/kuber/keys" {
+			w.Header().Set("Link", fmt.Sprintf(`<blorp>; rel="first", <https://%s/someotherpath>; rel="next"`, r.Host))
+			fmt.Fprint(w, `[{"id": 1, "key": "ssh-rsa AAA", "title": "ci", "verified": true, "read_only": true}]`)
+		} else if r.URL.Path == "/someotherpath" {
+			fmt.Fprint(w, `[{"id": 2, "key": "ssh-rsa BBB", "title": "publisher", "verified": true, "read_only": false}]`)
+		} else {
 			t.Errorf("Bad request path: %s", r.URL.Path)
 		}
-		http.Error(w, "204 No Content", http.StatusNoContent)
 	}))
 	defer ts.Close()
 	c := getClient(ts.URL)
-	if err := c.DeleteRef("k8s", "kuber", "heads/my-feature"); err != nil {
-		t.Errorf("Didn't expect error: %v", err)
+	keys, err := c.ListDeployKeys("k8s", "kuber")
+	if err != nil {
+		t.Fatalf("Didn't expect error: %v", err)
+	}
+	expected := []DeployKey{
+		{ID: 1, Key: "ssh-rsa AAA", Title: "ci", Verified: true, ReadOnly: true},
+		{ID: 2, Key: "ssh-rsa BBB", Title: "publisher", Verified: true},
+	}
+	if !reflect.DeepEqual(keys, expected) {
+		t.Errorf("Expected deploy keys %+v, got %+v", expected, keys)
 	}
 }
 
-func TestGetSingleCommit(t *testing.T) {
+func TestCreateDeployKey(t *testing.T) {
 	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
+		if r.Method != http.MethodPost {
 			t.Errorf("Bad method: %s", r.Method)
 		}
-		if r.URL.Path != "/repos/octocat/Hello-World/commits/6dcb09b5b57875f334f61aebed695e2e4193db5e" {
+		if r.URL.Path != "/repos/k8s/kuber/keys" {
 			t.Errorf("Bad request path: %s", r.URL.Path)
 		}
-		fmt.Fprint(w, `{
-			"commit": {
-			  "tree": {
-				"sha": "6dcb09b5b57875f334f61aebed695e2e4193db5e"
-			  }
-		        }
-		  }`)
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("Could not read request body: %v", err)
+		}
+		var actual map[string]interface{}
+		if err := json.Unmarshal(b, &actual); err != nil {
+			t.Errorf("Could not unmarshal request: %v", err)
+		}
+		expected := map[string]interface{}{"title": "ci", "key": "ssh-rsa AAA", "read_only": true}
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("Wrong deploy key, expected %v, got %v", expected, actual)
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"id": 42, "key": "ssh-rsa AAA", "title": "ci", "read_only": true}`)
 	}))
 	defer ts.Close()
 	c := getClient(ts.URL)
-	commit, err := c.GetSingleCommit("octocat", "Hello-World", "6dcb09b5b57875f334f61aebed695e2e4193db5e")
+	id, err := c.CreateDeployKey("k8s", "kuber", "ci", "ssh-rsa AAA", true)
 	if err != nil {
-		t.Errorf("Didn't expect error: %v", err)
-	} else if commit.Commit.Tree.SHA != "6dcb09b5b57875f334f61aebed695e2e4193db5e" {
-		t.Errorf("Wrong tree-hash: %s", commit.Commit.Tree.SHA)
+		t.Fatalf("Didn't expect error: %v", err)
+	}
+	if id != 42 {
+		t.Errorf("Expected deploy key ID 42, got %d", id)
 	}
 }
 
-func TestCreateStatus(t *testing.T) {
+func TestReviewDeploymentProtectionRule(t *testing.T) {
 	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			t.Errorf("Bad method: %s", r.Method)
 		}
-		if r.URL.Path != "/repos/k8s/kuber/statuses/abcdef" {
+		if r.URL.Path != "/repos/k8s/kuber/actions/runs/42/deployment_protection_rule" {
 			t.Errorf("Bad request path: %s", r.URL.Path)
 		}
 		b, err := ioutil.ReadAll(r.Body)
 		if err != nil {
 			t.Fatalf("Could not read request body: %v", err)
 		}
-		var s Status
-		if err := json.Unmarshal(b, &s); err != nil {
+		var review map[string]string
+		if err := json.Unmarshal(b, &review); err != nil {
 			t.Errorf("Could not unmarshal request: %v", err)
-		} else if s.Context != "c" {
-			t.Errorf("Wrong context: %s", s.Context)
+		} else if len(review) != 3 {
+			t.Errorf("Wrong length review: %v", review)
+		} else if review["environment_name"] != "production" || review["state"] != DeploymentProtectionRuleApproved || review["comment"] != "tests passed" {
+			t.Errorf("Wrong review: %v", review)
 		}
-		http.Error(w, "201 Created", http.StatusCreated)
+		http.Error(w, "204 No Content", http.StatusNoContent)
 	}))
 	defer ts.Close()
 	c := getClient(ts.URL)
-	if err := c.CreateStatus("k8s", "kuber", "abcdef", Status{
-		Context: "c",
-	}); err != nil {
+	if err := c.ReviewDeploymentProtectionRule("k8s", "kuber", 42, "production", DeploymentProtectionRuleApproved, "tests passed"); err != nil {
 		t.Errorf("Didn't expect error: %v", err)
 	}
 }
 
-func TestListIssues(t *testing.T) {
+func TestSetRepoDefaultBranch(t *testing.T) {
 	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
+		if r.Method != http.MethodPatch {
 			t.Errorf("Bad method: %s", r.Method)
 		}
-		if r.URL.Path == "/repos/k8s/kuber/issues" {
-			ics := []Issue{{Number: 1}}
-			b, err := json.Marshal(ics)
-			if err != nil {
-				t.Fatalf("Didn't expect error: %v", err)
-			}
-			w.Header().Set("Link", fmt.Sprintf(`<blorp>; rel="first", <https://%s/someotherpath>; rel="next"`, r.Host))
-			fmt.Fprint(w, string(b))
-		} else if r.URL.Path == "/someotherpath" {
-			ics := []Issue{{Number: 2}}
-			b, err := json.Marshal(ics)
-			if err != nil {
-				t.Fatalf("Didn't expect error: %v", err)
-			}
-			fmt.Fprint(w, string(b))
-		} else {
+		if r.URL.Path != "/repos/k8s/kuber" {
 			t.Errorf("Bad request path: %s", r.URL.Path)
 		}
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("Could not read request body: %v", err)
+		}
+		var ps map[string]string
+		if err := json.Unmarshal(b, &ps); err != nil {
+			t.Errorf("Could not unmarshal request: %v", err)
+		} else if len(ps) != 1 || ps["default_branch"] != "main" {
+			t.Errorf("Wrong body: %v", ps)
+		}
+		http.Error(w, "200 OK", http.StatusOK)
 	}))
 	defer ts.Close()
 	c := getClient(ts.URL)
-	ics, err := c.ListOpenIssues("k8s", "kuber")
-	if err != nil {
+	if err := c.SetRepoDefaultBranch("k8s", "kuber", "main"); err != nil {
 		t.Errorf("Didn't expect error: %v", err)
-	} else if len(ics) != 2 {
-		t.Errorf("Expected two issues, found %d: %v", len(ics), ics)
-	} else if ics[0].Number != 1 || ics[1].Number != 2 {
-		t.Errorf("Wrong issue IDs: %v", ics)
 	}
 }
 
-func TestListIssueComments(t *testing.T) {
+func TestSetRepoDefaultBranchDryRun(t *testing.T) {
 	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
-			t.Errorf("Bad method: %s", r.Method)
-		}
-		if r.URL.Path == "/repos/k8s/kuber/issues/15/comments" {
-			ics := []IssueComment{{ID: 1}}
-			b, err := json.Marshal(ics)
-			if err != nil {
-				t.Fatalf("Didn't expect error: %v", err)
-			}
-			w.Header().Set("Link", fmt.Sprintf(`<blorp>; rel="first", <https://%s/someotherpath>; rel="next"`, r.Host))
-			fmt.Fprint(w, string(b))
-		} else if r.URL.Path == "/someotherpath" {
-			ics := []IssueComment{{ID: 2}}
-			b, err := json.Marshal(ics)
-			if err != nil {
-				t.Fatalf("Didn't expect error: %v", err)
-			}
-			fmt.Fprint(w, string(b))
-		} else {
-			t.Errorf("Bad request path: %s", r.URL.Path)
-		}
+		t.Errorf("Unexpected request in dry-run mode: %s %s", r.Method, r.URL.Path)
 	}))
 	defer ts.Close()
 	c := getClient(ts.URL)
-	ics, err := c.ListIssueComments("k8s", "kuber", 15)
-	if err != nil {
+	c.dry = true
+	if err := c.SetRepoDefaultBranch("k8s", "kuber", "main"); err != nil {
 		t.Errorf("Didn't expect error: %v", err)
-	} else if len(ics) != 2 {
-		t.Errorf("Expected two issues, found %d: %v", len(ics), ics)
-	} else if ics[0].ID != 1 || ics[1].ID != 2 {
-		t.Errorf("Wrong issue IDs: %v", ics)
 	}
 }
 
@@ -784,6 +1636,40 @@ func TestListPullRequestComments(t *testing.T) {
 	}
 }
 
+func TestLatestReviewsByUser(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path != "/repos/k8s/kuber/pulls/15/reviews" {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+		fmt.Fprint(w, `[
+			{"id": 1, "user": {"login": "Alice"}, "state": "CHANGES_REQUESTED", "submitted_at": "2020-01-01T00:00:00Z"},
+			{"id": 2, "user": {"login": "bob"}, "state": "APPROVED", "submitted_at": "2020-01-01T01:00:00Z"},
+			{"id": 3, "user": {"login": "alice"}, "state": "APPROVED", "submitted_at": "2020-01-02T00:00:00Z"},
+			{"id": 4, "user": {"login": "alice"}, "state": "COMMENTED", "submitted_at": "2020-01-03T00:00:00Z"},
+			{"id": 5, "user": {"login": "bob"}, "state": "CHANGES_REQUESTED", "submitted_at": "2020-01-02T01:00:00Z"},
+			{"id": 6, "user": {"login": "carol"}, "state": "COMMENTED", "submitted_at": "2020-01-02T02:00:00Z"}
+		]`)
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	reviews, err := c.LatestReviewsByUser("k8s", "kuber", 15)
+	if err != nil {
+		t.Fatalf("Didn't expect error: %v", err)
+	}
+	expected := map[string]int{"alice": 3, "bob": 5}
+	if len(reviews) != len(expected) {
+		t.Errorf("Expected reviews by %v, got %v", expected, reviews)
+	}
+	for login, id := range expected {
+		if review, ok := reviews[login]; !ok || review.ID != id {
+			t.Errorf("Expected review %d by %s, got %+v", id, login, review)
+		}
+	}
+}
+
 func TestListReviews(t *testing.T) {
 	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
@@ -932,6 +1818,59 @@ func TestRequestReview(t *testing.T) {
 	}
 }
 
+func TestRequestTeamReview(t *testing.T) {
+	var requests [][]string
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path != "/repos/k8s/kuber/pulls/5/requested_reviewers" {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("Could not read request body: %v", err)
+		}
+		var ps map[string][]string
+		if err := json.Unmarshal(b, &ps); err != nil {
+			t.Fatalf("Could not unmarshal request: %v", err)
+		}
+		if len(ps) != 1 || len(ps["team_reviewers"]) == 0 {
+			t.Fatalf("Expected only team reviewers, got %v", ps)
+		}
+		requests = append(requests, ps["team_reviewers"])
+		if sets.NewString(ps["team_reviewers"]...).Has("no-access") {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(PullRequest{})
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+
+	if err := c.RequestTeamReview("k8s", "kuber", 5, []string{"team1", "team2"}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if expected := [][]string{{"team1", "team2"}}; !reflect.DeepEqual(requests, expected) {
+		t.Errorf("Expected the teams to be requested at once %v, got %v", expected, requests)
+	}
+
+	requests = nil
+	if err := c.RequestTeamReview("k8s", "kuber", 5, []string{"team1", "no-access", "team2"}); err == nil {
+		t.Errorf("Expected an error")
+	} else if merr, ok := err.(MissingUsers); ok {
+		if len(merr.Users) != 1 || merr.Users[0] != "k8s/no-access" {
+			t.Errorf("Expected [k8s/no-access], not %v", merr.Users)
+		}
+	} else {
+		t.Errorf("Expected MissingUsers error, got %v", err)
+	}
+	if expected := [][]string{{"team1", "no-access", "team2"}, {"team1"}, {"no-access"}, {"team2"}}; !reflect.DeepEqual(requests, expected) {
+		t.Errorf("Expected the teams to be requested individually after the failure %v, got %v", expected, requests)
+	}
+}
+
 func TestUnrequestReview(t *testing.T) {
 	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodDelete {
@@ -980,6 +1919,30 @@ func TestUnrequestReview(t *testing.T) {
 	}
 }
 
+func TestListRequestedReviewers(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path != "/repos/k8s/kuber/pulls/5/requested_reviewers" {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+		fmt.Fprint(w, `{"users": [{"login": "octocat"}, {"login": "hubot"}], "teams": [{"id": 1, "name": "Justice League", "slug": "justice-league"}]}`)
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	users, teams, err := c.ListRequestedReviewers("k8s", "kuber", 5)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(users) != 2 || users[0].Login != "octocat" || users[1].Login != "hubot" {
+		t.Errorf("Wrong users: %+v", users)
+	}
+	if len(teams) != 1 || teams[0].ID != 1 || teams[0].Slug != "justice-league" {
+		t.Errorf("Wrong teams: %+v", teams)
+	}
+}
+
 func TestCloseIssue(t *testing.T) {
 	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPatch {
@@ -1064,6 +2027,65 @@ func TestClosePR(t *testing.T) {
 	}
 }
 
+func TestUpdatePullRequest(t *testing.T) {
+	title, body, base, state, canModify := "new title", "new body", "release-1.0", "closed", false
+	testCases := []struct {
+		name         string
+		update       PullRequestUpdate
+		expectedBody map[string]interface{}
+	}{
+		{
+			name:         "only base is set",
+			update:       PullRequestUpdate{Base: &base},
+			expectedBody: map[string]interface{}{"base": "release-1.0"},
+		},
+		{
+			name: "all fields are set",
+			update: PullRequestUpdate{
+				Title:               &title,
+				Body:                &body,
+				Base:                &base,
+				State:               &state,
+				MaintainerCanModify: &canModify,
+			},
+			expectedBody: map[string]interface{}{
+				"title":                 "new title",
+				"body":                  "new body",
+				"base":                  "release-1.0",
+				"state":                 "closed",
+				"maintainer_can_modify": false,
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPatch {
+					t.Errorf("Bad method: %s", r.Method)
+				}
+				if r.URL.Path != "/repos/k8s/kuber/pulls/5" {
+					t.Errorf("Bad request path: %s", r.URL.Path)
+				}
+				b, err := ioutil.ReadAll(r.Body)
+				if err != nil {
+					t.Fatalf("Could not read request body: %v", err)
+				}
+				var actual map[string]interface{}
+				if err := json.Unmarshal(b, &actual); err != nil {
+					t.Errorf("Could not unmarshal request: %v", err)
+				} else if !reflect.DeepEqual(actual, tc.expectedBody) {
+					t.Errorf("Wrong patch: %s", diff.ObjectReflectDiff(tc.expectedBody, actual))
+				}
+			}))
+			defer ts.Close()
+			c := getClient(ts.URL)
+			if err := c.UpdatePullRequest("k8s", "kuber", 5, tc.update); err != nil {
+				t.Errorf("Didn't expect error: %v", err)
+			}
+		})
+	}
+}
+
 func TestReopenPR(t *testing.T) {
 	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPatch {
@@ -1181,43 +2203,97 @@ func TestGetFile(t *testing.T) {
 		if err != nil {
 			t.Fatalf("Didn't expect error: %v", err)
 		}
-		fmt.Fprint(w, string(b))
+		fmt.Fprint(w, string(b))
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	if content, err := c.GetFile("k8s", "kuber", "foo.txt", ""); err != nil {
+		t.Errorf("Didn't expect error: %v", err)
+	} else if string(content) != "abcde" {
+		t.Errorf("Wrong content -- expect: abcde, got: %s", string(content))
+	}
+}
+
+func TestGetFileRef(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path != "/repos/k8s/kuber/contents/foo/bar.txt" {
+			t.Errorf("Bad request path: %s", r.URL)
+		}
+		if r.URL.RawQuery != "ref=12345" {
+			t.Errorf("Bad request query: %s", r.URL.RawQuery)
+		}
+		c := &Content{
+			Content: base64.StdEncoding.EncodeToString([]byte("abcde")),
+		}
+		b, err := json.Marshal(&c)
+		if err != nil {
+			t.Fatalf("Didn't expect error: %v", err)
+		}
+		fmt.Fprint(w, string(b))
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	if content, err := c.GetFile("k8s", "kuber", "foo/bar.txt", "12345"); err != nil {
+		t.Errorf("Didn't expect error: %v", err)
+	} else if string(content) != "abcde" {
+		t.Errorf("Wrong content -- expect: abcde, got: %s", string(content))
+	}
+}
+
+func TestAddRepoLabel(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path != "/repos/k8s/kuber/labels" {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("Could not read request body: %v", err)
+		}
+		var label Label
+		if err := json.Unmarshal(b, &label); err != nil {
+			t.Errorf("Could not unmarshal request: %v", err)
+		} else if label.Name != "kind/bug" || label.Description != "Categorizes issue as a bug." || label.Color != "e11d21" {
+			t.Errorf("Wrong label: %+v", label)
+		}
+		http.Error(w, "201 Created", http.StatusCreated)
 	}))
 	defer ts.Close()
 	c := getClient(ts.URL)
-	if content, err := c.GetFile("k8s", "kuber", "foo.txt", ""); err != nil {
+	if err := c.AddRepoLabel("k8s", "kuber", "kind/bug", "Categorizes issue as a bug.", "e11d21"); err != nil {
 		t.Errorf("Didn't expect error: %v", err)
-	} else if string(content) != "abcde" {
-		t.Errorf("Wrong content -- expect: abcde, got: %s", string(content))
 	}
 }
 
-func TestGetFileRef(t *testing.T) {
+func TestUpdateRepoLabel(t *testing.T) {
 	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
+		if r.Method != http.MethodPatch {
 			t.Errorf("Bad method: %s", r.Method)
 		}
-		if r.URL.Path != "/repos/k8s/kuber/contents/foo/bar.txt" {
-			t.Errorf("Bad request path: %s", r.URL)
-		}
-		if r.URL.RawQuery != "ref=12345" {
-			t.Errorf("Bad request query: %s", r.URL.RawQuery)
-		}
-		c := &Content{
-			Content: base64.StdEncoding.EncodeToString([]byte("abcde")),
+		if r.URL.Path != "/repos/k8s/kuber/labels/bug" {
+			t.Errorf("Bad request path: %s", r.URL.Path)
 		}
-		b, err := json.Marshal(&c)
+		b, err := ioutil.ReadAll(r.Body)
 		if err != nil {
-			t.Fatalf("Didn't expect error: %v", err)
+			t.Fatalf("Could not read request body: %v", err)
 		}
-		fmt.Fprint(w, string(b))
+		var label Label
+		if err := json.Unmarshal(b, &label); err != nil {
+			t.Errorf("Could not unmarshal request: %v", err)
+		} else if label.Name != "kind/bug" || label.Description != "Categorizes issue as a bug." || label.Color != "e11d21" {
+			t.Errorf("Wrong label: %+v", label)
+		}
+		http.Error(w, "200 OK", http.StatusOK)
 	}))
 	defer ts.Close()
 	c := getClient(ts.URL)
-	if content, err := c.GetFile("k8s", "kuber", "foo/bar.txt", "12345"); err != nil {
+	if err := c.UpdateRepoLabel("k8s", "kuber", "bug", "kind/bug", "Categorizes issue as a bug.", "e11d21"); err != nil {
 		t.Errorf("Didn't expect error: %v", err)
-	} else if string(content) != "abcde" {
-		t.Errorf("Wrong content -- expect: abcde, got: %s", string(content))
 	}
 }
 
@@ -1296,6 +2372,40 @@ func TestListTeams(t *testing.T) {
 	}
 }
 
+func TestGetTeamBySlug(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		switch r.URL.Path {
+		case "/orgs/foo/teams/leads":
+			fmt.Fprint(w, `{"id": 42, "name": "Leads", "slug": "leads"}`)
+		case "/orgs/foo/teams/missing":
+			http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+		default:
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+
+	team, err := c.GetTeamBySlug("leads", "foo")
+	if err != nil {
+		t.Fatalf("Didn't expect error: %v", err)
+	}
+	if team.ID != 42 || team.Name != "Leads" || team.Slug != "leads" {
+		t.Errorf("Wrong team: %+v", team)
+	}
+
+	team, err = c.GetTeamBySlug("missing", "foo")
+	if !IsTeamNotFound(err) {
+		t.Errorf("Expected a TeamNotFound error, got %v", err)
+	}
+	if team != nil {
+		t.Errorf("Expected no team, got %+v", team)
+	}
+}
+
 func TestCreateTeam(t *testing.T) {
 	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
@@ -1390,6 +2500,62 @@ func TestEditTeam(t *testing.T) {
 	}
 }
 
+func TestUpdateTeamRepo(t *testing.T) {
+	testCases := []struct {
+		permission RepoPermissionLevel
+		expected   string
+	}{
+		{permission: Read, expected: "pull"},
+		{permission: Write, expected: "push"},
+		{permission: Admin, expected: "admin"},
+	}
+	for _, tc := range testCases {
+		t.Run(string(tc.permission), func(t *testing.T) {
+			ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPut {
+					t.Errorf("Bad method: %s", r.Method)
+				}
+				if r.URL.Path != "/teams/63/repos/k8s/kuber" {
+					t.Errorf("Bad request path: %s", r.URL.Path)
+				}
+				b, err := ioutil.ReadAll(r.Body)
+				if err != nil {
+					t.Fatalf("Could not read request body: %v", err)
+				}
+				var ps map[string]string
+				if err := json.Unmarshal(b, &ps); err != nil {
+					t.Errorf("Could not unmarshal request: %v", err)
+				} else if ps["permission"] != tc.expected {
+					t.Errorf("Expected permission %q, got %q", tc.expected, ps["permission"])
+				}
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer ts.Close()
+			c := getClient(ts.URL)
+			if err := c.UpdateTeamRepo(63, "k8s", "kuber", tc.permission); err != nil {
+				t.Errorf("Didn't expect error: %v", err)
+			}
+		})
+	}
+}
+
+func TestRemoveTeamRepo(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path != "/teams/63/repos/k8s/kuber" {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	if err := c.RemoveTeamRepo(63, "k8s", "kuber"); err != nil {
+		t.Errorf("Didn't expect error: %v", err)
+	}
+}
+
 func TestListTeamMembers(t *testing.T) {
 	ts := simpleTestServer(t, "/teams/1/members", []TeamMember{{Login: "foo"}})
 	defer ts.Close()
@@ -1404,6 +2570,42 @@ func TestListTeamMembers(t *testing.T) {
 	}
 }
 
+func TestListOrgInvitations(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path == "/orgs/k8s/invitations" {
+			invitations := []OrgInvitation{{TeamMember: TeamMember{Login: "foo"}, Inviter: TeamMember{Login: "admin"}}}
+			b, err := json.Marshal(invitations)
+			if err != nil {
+				t.Fatalf("Didn't expect error: %v", err)
+			}
+			w.Header().Set("Link", fmt.Sprintf(`<blorp>; rel="first", <https://%s/someotherpath>; rel="next"`, r.Host))
+			fmt.Fprint(w, string(b))
+		} else if r.URL.Path == "/someotherpath" {
+			invitations := []OrgInvitation{{Email: "bar@example.com", Inviter: TeamMember{Login: "admin"}}}
+			b, err := json.Marshal(invitations)
+			if err != nil {
+				t.Fatalf("Didn't expect error: %v", err)
+			}
+			fmt.Fprint(w, string(b))
+		} else {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	invitations, err := c.ListOrgInvitations("k8s")
+	if err != nil {
+		t.Errorf("Didn't expect error: %v", err)
+	} else if len(invitations) != 2 {
+		t.Errorf("Expected two invitations, found %d: %v", len(invitations), invitations)
+	} else if invitations[0].Login != "foo" || invitations[1].Email != "bar@example.com" {
+		t.Errorf("Wrong invitations: %v", invitations)
+	}
+}
+
 func TestIsCollaborator(t *testing.T) {
 	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
@@ -1493,89 +2695,331 @@ func TestListIssueEvents(t *testing.T) {
 	if events[0].Event != IssueActionLabeled {
 		t.Errorf("Wrong event for index 0: %v", events[0])
 	}
-	if events[1].Event != IssueActionClosed {
-		t.Errorf("Wrong event for index 1: %v", events[1])
+	if events[1].Event != IssueActionClosed {
+		t.Errorf("Wrong event for index 1: %v", events[1])
+	}
+}
+
+func TestListIssueTimeline(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.Header.Get("Accept") != "application/vnd.github.mockingbird-preview" {
+			t.Errorf("Bad Accept header: %s", r.Header.Get("Accept"))
+		}
+		if r.URL.Path == "/repos/org/repo/issues/1/timeline" {
+			w.Header().Set("Link", fmt.Sprintf(`<https://%s/someotherpath>; rel="next"`, r.Host))
+			fmt.Fprint(w, `[{"event": "cross_referenced", "actor": {"login": "alice"}, "source": {"type": "issue", "issue": {"number": 2, "pull_request": {"url": "https://api.github.com/repos/org/repo/pulls/2"}}}}]`)
+		} else if r.URL.Path == "/someotherpath" {
+			fmt.Fprint(w, `[{"event": "mentioned", "actor": {"login": "bob"}}]`)
+		} else {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	events, err := c.ListIssueTimeline("org", "repo", 1)
+	if err != nil {
+		t.Fatalf("Didn't expect error: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("Expected two events, found %d: %v", len(events), events)
+	}
+	if events[0].Event != TimelineEventCrossReferenced || events[0].Actor.Login != "alice" {
+		t.Errorf("Wrong event for index 0: %v", events[0])
+	}
+	if events[0].Source == nil || events[0].Source.Issue.Number != 2 || !events[0].Source.Issue.IsPullRequest() {
+		t.Errorf("Expected cross-reference from PR #2, got %v", events[0].Source)
+	}
+	if events[1].Event != "mentioned" || events[1].Source != nil {
+		t.Errorf("Wrong event for index 1: %v", events[1])
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/repos/org/repo/issues/1/events" {
+			b, err := json.Marshal([]ListedIssueEvent{{Event: IssueActionClosed}})
+			if err != nil {
+				t.Fatalf("Didn't expect error: %v", err)
+			}
+			fmt.Fprint(w, string(b))
+		} else if r.URL.Path == "/repos/org/repo/issues/2/events" {
+			w.Header().Set(ghcache.CacheModeHeader, string(ghcache.ModeRevalidated))
+			b, err := json.Marshal([]ListedIssueEvent{{Event: IssueActionOpened}})
+			if err != nil {
+				t.Fatalf("Didn't expect error: %v", err)
+			}
+			fmt.Fprint(w, string(b))
+		} else {
+			t.Fatalf("Bad request path: %s", r.URL.Path)
+		}
+	}))
+	c := getClient(ts.URL)
+	c.Throttle(1, 2)
+	if c.client != &c.throttle {
+		t.Errorf("Bad client %v, expecting %v", c.client, &c.throttle)
+	}
+	if len(c.throttle.throttle) != 2 {
+		t.Fatalf("Expected two items in throttle channel, found %d", len(c.throttle.throttle))
+	}
+	if cap(c.throttle.throttle) != 2 {
+		t.Fatalf("Expected throttle channel capacity of two, found %d", cap(c.throttle.throttle))
+	}
+	check := func(events []ListedIssueEvent, err error, expectedAction IssueEventAction) {
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		if len(events) != 1 || events[0].Event != expectedAction {
+			t.Errorf("Expected one %q event, found: %v", string(expectedAction), events)
+		}
+		if len(c.throttle.throttle) != 1 {
+			t.Errorf("Expected one item in throttle channel, found %d", len(c.throttle.throttle))
+		}
+	}
+	events, err := c.ListIssueEvents("org", "repo", 1)
+	check(events, err, IssueActionClosed)
+	// The following 2 calls should be refunded.
+	events, err = c.ListIssueEvents("org", "repo", 2)
+	check(events, err, IssueActionOpened)
+	events, err = c.ListIssueEvents("org", "repo", 2)
+	check(events, err, IssueActionOpened)
+
+	// Check that calls are delayed while throttled.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	go func() {
+		if _, err := c.ListIssueEvents("org", "repo", 1); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		if _, err := c.ListIssueEvents("org", "repo", 1); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		cancel()
+	}()
+	slowed := false
+	for ctx.Err() == nil {
+		// Wait for the client to get throttled
+		if atomic.LoadInt32(&c.throttle.slow) == 0 {
+			continue
+		}
+		// Throttled, now add to the channel
+		slowed = true
+		select {
+		case c.throttle.throttle <- time.Now(): // Add items to the channel
+		case <-ctx.Done():
+		}
+	}
+	if !slowed {
+		t.Errorf("Never throttled")
+	}
+	if err := ctx.Err(); err != context.Canceled {
+		t.Errorf("Expected context cancellation did not happen: %v", err)
+	}
+}
+
+func TestSetOrgBases(t *testing.T) {
+	handler := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			b, err := json.Marshal([]ListedIssueEvent{{Event: IssueActionClosed, Actor: User{Login: name}}})
+			if err != nil {
+				t.Fatalf("Didn't expect error: %v", err)
+			}
+			fmt.Fprint(w, string(b))
+		}
+	}
+	dotcom := httptest.NewTLSServer(handler("dotcom"))
+	defer dotcom.Close()
+	enterprise := httptest.NewTLSServer(handler("enterprise"))
+	defer enterprise.Close()
+
+	c := getClient(dotcom.URL)
+	c.SetOrgBases("internal", enterprise.URL)
+
+	served := func(org string) string {
+		events, err := c.ListIssueEvents(org, "repo", 1)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(events) != 1 {
+			t.Fatalf("Expected one event, got %v", events)
+		}
+		return events[0].Actor.Login
+	}
+	if got := served("org"); got != "dotcom" {
+		t.Errorf("Expected requests for org to hit the default base, hit %s", got)
+	}
+	if got := served("internal"); got != "enterprise" {
+		t.Errorf("Expected requests for internal to hit the org base, hit %s", got)
+	}
+
+	c.SetOrgBases("internal")
+	if got := served("internal"); got != "dotcom" {
+		t.Errorf("Expected requests for internal to hit the default base after reset, hit %s", got)
+	}
+}
+
+func TestSetOrgBasesPagination(t *testing.T) {
+	dotcom := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Unexpected request to the default base: %s", r.URL.Path)
+		http.Error(w, "wrong base", http.StatusNotFound)
+	}))
+	defer dotcom.Close()
+	enterprise := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/internal/repo/issues/1/events":
+			// Like GitHub, link to the next page by repository ID.
+			w.Header().Set("Link", fmt.Sprintf(`<https://%s/repositories/42/issues/1/events?page=2>; rel="next"`, r.Host))
+			fmt.Fprint(w, `[{"event": "closed"}]`)
+		case "/repositories/42/issues/1/events":
+			fmt.Fprint(w, `[{"event": "reopened"}]`)
+		default:
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+	}))
+	defer enterprise.Close()
+
+	c := getClient(dotcom.URL)
+	c.SetOrgBases("internal", enterprise.URL)
+	events, err := c.ListIssueEvents("internal", "repo", 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(events) != 2 || events[0].Event != IssueActionClosed || events[1].Event != IssueActionReopened {
+		t.Errorf("Expected the events of both pages, got %v", events)
+	}
+}
+
+func TestThrottleStatus(t *testing.T) {
+	c := getClient("")
+	if statuses := c.ThrottleStatus(); len(statuses) != 0 {
+		t.Errorf("Expected no throttle statuses for an unthrottled client, got %+v", statuses)
+	}
+
+	c.Throttle(100, 2)
+	c.ThrottleOrg("other", 10, 1)
+	c.ThrottleOrg("another", 20, 3)
+	expected := []ThrottleStatus{
+		{HourlyTokens: 100, Burst: 2, AvailableTokens: 2},
+		{Org: "another", HourlyTokens: 20, Burst: 3, AvailableTokens: 3},
+		{Org: "other", HourlyTokens: 10, Burst: 1, AvailableTokens: 1},
+	}
+	if statuses := c.ThrottleStatus(); !reflect.DeepEqual(statuses, expected) {
+		t.Errorf("Expected throttle statuses %+v, got %+v", expected, statuses)
+	}
+
+	// Drain the global throttle so that the next request has to wait.
+	c.throttle.Wait()
+	c.throttle.Wait()
+	done := make(chan struct{})
+	go func() {
+		c.throttle.Wait()
+		close(done)
+	}()
+	for !c.ThrottleStatus()[0].Throttled {
+		time.Sleep(time.Millisecond)
+	}
+	c.throttle.Refund()
+	<-done
+	if status := c.ThrottleStatus()[0]; !status.Throttled || status.AvailableTokens != 0 {
+		t.Errorf("Expected the global throttle to be throttled without tokens, got %+v", status)
+	}
+
+	c.Throttle(0, 0)
+	c.ThrottleOrg("another", 0, 0)
+	expected = []ThrottleStatus{{Org: "other", HourlyTokens: 10, Burst: 1, AvailableTokens: 1}}
+	if statuses := c.ThrottleStatus(); !reflect.DeepEqual(statuses, expected) {
+		t.Errorf("Expected throttle statuses %+v after disabling throttles, got %+v", expected, statuses)
 	}
 }
 
-func TestThrottle(t *testing.T) {
+func TestThrottleOrg(t *testing.T) {
 	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path == "/repos/org/repo/issues/1/events" {
+		if r.URL.Path == "/repos/org/repo/issues/1/events" || r.URL.Path == "/repos/other/repo/issues/1/events" {
 			b, err := json.Marshal([]ListedIssueEvent{{Event: IssueActionClosed}})
 			if err != nil {
 				t.Fatalf("Didn't expect error: %v", err)
 			}
 			fmt.Fprint(w, string(b))
-		} else if r.URL.Path == "/repos/org/repo/issues/2/events" {
-			w.Header().Set(ghcache.CacheModeHeader, string(ghcache.ModeRevalidated))
-			b, err := json.Marshal([]ListedIssueEvent{{Event: IssueActionOpened}})
-			if err != nil {
-				t.Fatalf("Didn't expect error: %v", err)
-			}
-			fmt.Fprint(w, string(b))
 		} else {
 			t.Fatalf("Bad request path: %s", r.URL.Path)
 		}
 	}))
+	defer ts.Close()
 	c := getClient(ts.URL)
 	c.Throttle(1, 2)
-	if c.client != &c.throttle {
-		t.Errorf("Bad client %v, expecting %v", c.client, &c.throttle)
+	c.ThrottleOrg("other", 1, 1)
+	other, ok := c.orgThrottles["other"]
+	if !ok {
+		t.Fatal("Expected a throttle for org other")
+	}
+	if other.http == &c.throttle {
+		t.Error("Expected the org throttle to bypass the global throttle")
+	}
+	if _, err := c.ListIssueEvents("other", "repo", 1); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(other.throttle) != 0 {
+		t.Errorf("Expected no items in org throttle channel, found %d", len(other.throttle))
 	}
 	if len(c.throttle.throttle) != 2 {
-		t.Fatalf("Expected two items in throttle channel, found %d", len(c.throttle.throttle))
+		t.Errorf("Expected global throttle channel to be untouched, found %d items", len(c.throttle.throttle))
 	}
-	if cap(c.throttle.throttle) != 2 {
-		t.Fatalf("Expected throttle channel capacity of two, found %d", cap(c.throttle.throttle))
+	if _, err := c.ListIssueEvents("org", "repo", 1); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
 	}
-	check := func(events []ListedIssueEvent, err error, expectedAction IssueEventAction) {
-		if err != nil {
-			t.Errorf("Unexpected error: %v", err)
-		}
-		if len(events) != 1 || events[0].Event != expectedAction {
-			t.Errorf("Expected one %q event, found: %v", string(expectedAction), events)
-		}
-		if len(c.throttle.throttle) != 1 {
-			t.Errorf("Expected one item in throttle channel, found %d", len(c.throttle.throttle))
-		}
+	if len(c.throttle.throttle) != 1 {
+		t.Errorf("Expected one item in global throttle channel, found %d", len(c.throttle.throttle))
+	}
+	if len(other.throttle) != 0 {
+		t.Errorf("Expected org throttle channel to be untouched, found %d items", len(other.throttle))
 	}
-	events, err := c.ListIssueEvents("org", "repo", 1)
-	check(events, err, IssueActionClosed)
-	// The following 2 calls should be refunded.
-	events, err = c.ListIssueEvents("org", "repo", 2)
-	check(events, err, IssueActionOpened)
-	events, err = c.ListIssueEvents("org", "repo", 2)
-	check(events, err, IssueActionOpened)
 
-	// Check that calls are delayed while throttled.
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	// The org is throttled even though the global throttle has tokens left.
+	done := make(chan struct{})
 	go func() {
-		if _, err := c.ListIssueEvents("org", "repo", 1); err != nil {
-			t.Errorf("Unexpected error: %v", err)
-		}
-		if _, err := c.ListIssueEvents("org", "repo", 1); err != nil {
+		if _, err := c.ListIssueEvents("other", "repo", 1); err != nil {
 			t.Errorf("Unexpected error: %v", err)
 		}
-		cancel()
+		close(done)
 	}()
-	slowed := false
-	for ctx.Err() == nil {
-		// Wait for the client to get throttled
-		if atomic.LoadInt32(&c.throttle.slow) == 0 {
-			continue
-		}
-		// Throttled, now add to the channel
-		slowed = true
-		select {
-		case c.throttle.throttle <- time.Now(): // Add items to the channel
-		case <-ctx.Done():
-		}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for atomic.LoadInt32(&other.slow) == 0 && ctx.Err() == nil {
 	}
-	if !slowed {
-		t.Errorf("Never throttled")
+	if ctx.Err() != nil {
+		t.Fatal("Org requests were never throttled")
 	}
-	if err := ctx.Err(); err != context.Canceled {
-		t.Errorf("Expected context cancellation did not happen: %v", err)
+	if atomic.LoadInt32(&c.throttle.slow) != 0 {
+		t.Error("Expected global throttle not to be slowed")
+	}
+	other.throttle <- time.Now()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		t.Fatal("Throttled org request never completed")
+	}
+
+	c.ThrottleOrg("other", 0, 0)
+	if _, ok := c.orgThrottles["other"]; ok {
+		t.Error("Expected org throttle to be removed")
+	}
+	if c.httpClientFor("other") != &c.throttle {
+		t.Error("Expected org to fall back to the global throttle")
+	}
+}
+
+func TestOrgFromPath(t *testing.T) {
+	for path, expected := range map[string]string{
+		"/repos/org/repo/pulls/1": "org",
+		"/orgs/org/members":       "org",
+		"/orgs/org?per_page=100":  "org",
+		"/user":                   "",
+		"/teams/1/members":        "",
+	} {
+		if actual := orgFromPath(path); actual != expected {
+			t.Errorf("orgFromPath(%q): expected %q, got %q", path, expected, actual)
+		}
 	}
 }
 
@@ -1603,6 +3047,35 @@ func TestGetBranches(t *testing.T) {
 	}
 }
 
+func TestListTags(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path == "/repos/org/repo/tags" {
+			w.Header().Set("Link", fmt.Sprintf(`<blorp>; rel="first", <https://%s/someotherpath>; rel="next"`, r.Host))
+			fmt.Fprint(w, `[{"name": "v1.1.0", "commit": {"sha": "abc", "url": "https://api.github.com/repos/org/repo/commits/abc"}}]`)
+		} else if r.URL.Path == "/someotherpath" {
+			fmt.Fprint(w, `[{"name": "v1.0.0", "commit": {"sha": "def", "url": "https://api.github.com/repos/org/repo/commits/def"}}]`)
+		} else {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	tags, err := c.ListTags("org", "repo")
+	if err != nil {
+		t.Fatalf("Didn't expect error: %v", err)
+	}
+	expected := []Tag{
+		{Name: "v1.1.0", Commit: TagCommit{SHA: "abc"}},
+		{Name: "v1.0.0", Commit: TagCommit{SHA: "def"}},
+	}
+	if !reflect.DeepEqual(tags, expected) {
+		t.Errorf("Expected tags %+v, got %+v", expected, tags)
+	}
+}
+
 func TestGetBranchProtection(t *testing.T) {
 	contexts := []string{"foo-pr-test", "other"}
 	pushers := []Team{{Slug: "movers"}, {Slug: "awesome-team"}, {Slug: "shakers"}}
@@ -1896,6 +3369,75 @@ func TestSetMilestone(t *testing.T) {
 	}
 }
 
+func TestBulkSetMilestone(t *testing.T) {
+	newMilestone := 42
+	testCases := []struct {
+		name     string
+		numbers  []int
+		failing  sets.Int
+		expected []int
+	}{
+		{
+			name:    "all issues updated",
+			numbers: []int{1, 2, 3},
+		},
+		{
+			name:     "failures do not stop the other issues from being updated",
+			numbers:  []int{1, 2, 3, 4},
+			failing:  sets.NewInt(1, 3),
+			expected: []int{1, 3},
+		},
+		{
+			name:     "all issues fail",
+			numbers:  []int{1, 2},
+			failing:  sets.NewInt(1, 2),
+			expected: []int{1, 2},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			updated := sets.NewInt()
+			ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPatch {
+					t.Errorf("Bad method: %s", r.Method)
+				}
+				var number int
+				if _, err := fmt.Sscanf(r.URL.Path, "/repos/k8s/kuber/issues/%d", &number); err != nil {
+					t.Fatalf("Bad request path: %s", r.URL.Path)
+				}
+				var issue struct {
+					Milestone int `json:"milestone"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&issue); err != nil {
+					t.Fatalf("Could not unmarshal request: %v", err)
+				}
+				if issue.Milestone != newMilestone {
+					t.Errorf("Expected milestone to be set to %d, but got %d.", newMilestone, issue.Milestone)
+				}
+				if tc.failing.Has(number) {
+					http.Error(w, "422 Unprocessable Entity", http.StatusUnprocessableEntity)
+					return
+				}
+				updated.Insert(number)
+			}))
+			defer ts.Close()
+			c := getClient(ts.URL)
+			failed, err := c.BulkSetMilestone("k8s", "kuber", tc.numbers, newMilestone)
+			if len(tc.expected) == 0 && err != nil {
+				t.Errorf("Didn't expect error: %v", err)
+			} else if len(tc.expected) > 0 && err == nil {
+				t.Error("Expected an error for the failed issues")
+			}
+			if !reflect.DeepEqual(failed, tc.expected) {
+				t.Errorf("Expected issues %v to fail, got %v", tc.expected, failed)
+			}
+			if expected := sets.NewInt(tc.numbers...).Difference(tc.failing); !updated.Equal(expected) {
+				t.Errorf("Expected issues %v to be updated, got %v", expected.List(), updated.List())
+			}
+		})
+	}
+}
+
 func TestListMilestones(t *testing.T) {
 	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
@@ -1973,6 +3515,42 @@ func TestCombinedStatus(t *testing.T) {
 	}
 }
 
+func TestCreateRepoFromTemplate(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("Bad method: %s", r.Method)
+		}
+		if r.URL.Path != "/repos/k8s/template/generate" {
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+		if accept := r.Header.Get("Accept"); accept != "application/vnd.github.baptiste-preview+json" {
+			t.Errorf("Bad Accept header: %s", accept)
+		}
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("Could not read request body: %v", err)
+		}
+		var body map[string]interface{}
+		if err := json.Unmarshal(b, &body); err != nil {
+			t.Errorf("Could not unmarshal request: %v", err)
+		} else if expected := map[string]interface{}{"owner": "kubernetes", "name": "kuber", "private": true}; !reflect.DeepEqual(body, expected) {
+			t.Errorf("Expected body %v, got %v", expected, body)
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"name": "kuber", "full_name": "kubernetes/kuber", "private": true, "owner": {"login": "kubernetes"}}`)
+	}))
+	defer ts.Close()
+	c := getClient(ts.URL)
+	repo, err := c.CreateRepoFromTemplate("k8s", "template", "kubernetes", "kuber", true)
+	if err != nil {
+		t.Fatalf("Didn't expect error: %v", err)
+	}
+	expected := Repo{Owner: User{Login: "kubernetes"}, Name: "kuber", FullName: "kubernetes/kuber", Private: true}
+	if !reflect.DeepEqual(repo, expected) {
+		t.Errorf("Expected repo %+v, got %+v", expected, repo)
+	}
+}
+
 func TestCreateRepo(t *testing.T) {
 	org := "org"
 	usersRepoName := "users-repository"
@@ -2161,3 +3739,326 @@ func TestUpdateRepo(t *testing.T) {
 		})
 	}
 }
+
+type recordingGraphQLClient struct {
+	inputs []githubql.Input
+}
+
+func (r *recordingGraphQLClient) Query(ctx context.Context, q interface{}, vars map[string]interface{}) error {
+	return nil
+}
+
+func (r *recordingGraphQLClient) Mutate(ctx context.Context, m interface{}, input githubql.Input, vars map[string]interface{}) error {
+	r.inputs = append(r.inputs, input)
+	return nil
+}
+
+// countingTransport counts the requests per path before passing them on.
+type countingTransport struct {
+	base     http.RoundTripper
+	requests map[string]int
+}
+
+func (c *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.requests[req.URL.Path]++
+	return c.base.RoundTrip(req)
+}
+
+func TestNewClientWithTransport(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/k8s/kuber":
+			fmt.Fprint(w, `{"name": "kuber"}`)
+		case "/graphql":
+			if auth := r.Header.Get("Authorization"); auth != "Bearer token" {
+				t.Errorf("Expected the GraphQL request to be authenticated, got Authorization header %q", auth)
+			}
+			fmt.Fprint(w, `{"data": {}}`)
+		default:
+			t.Errorf("Bad request path: %s", r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+	transport := &countingTransport{
+		base:     &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+		requests: map[string]int{},
+	}
+	getToken := func() []byte { return []byte("token") }
+	censor := func(content []byte) []byte { return content }
+	c := NewClientWithTransport(nil, transport, getToken, censor, ts.URL+"/graphql", ts.URL)
+	c.Throttle(3600, 10)
+
+	if _, err := c.GetRepo("k8s", "kuber"); err != nil {
+		t.Fatalf("Didn't expect error getting repo: %v", err)
+	}
+	var q struct {
+		Viewer struct {
+			Login githubql.String
+		}
+	}
+	if err := c.Query(context.Background(), &q, nil); err != nil {
+		t.Fatalf("Didn't expect error querying: %v", err)
+	}
+	if expected := map[string]int{"/repos/k8s/kuber": 1, "/graphql": 1}; !reflect.DeepEqual(transport.requests, expected) {
+		t.Errorf("Expected requests %v to go through the transport, got %v", expected, transport.requests)
+	}
+}
+
+// flakyGraphQLClient fails queries with the given errors, in order, and
+// succeeds once it runs out of errors.
+type flakyGraphQLClient struct {
+	errs  []error
+	calls int
+}
+
+func (f *flakyGraphQLClient) Query(ctx context.Context, q interface{}, vars map[string]interface{}) error {
+	f.calls++
+	if f.calls <= len(f.errs) {
+		return f.errs[f.calls-1]
+	}
+	return nil
+}
+
+func (f *flakyGraphQLClient) Mutate(ctx context.Context, m interface{}, input githubql.Input, vars map[string]interface{}) error {
+	return nil
+}
+
+func TestQueryRetries(t *testing.T) {
+	serverError := errors.New(`non-200 OK status code: 502 Bad Gateway body: ""`)
+	connError := &url.Error{Op: "Post", URL: "https://api.github.com/graphql", Err: errors.New("connection reset by peer")}
+	validationError := errors.New("Field 'foo' doesn't exist on type 'Query'")
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	testCases := []struct {
+		name          string
+		ctx           context.Context
+		errs          []error
+		expectedCalls int
+		expectErr     bool
+	}{
+		{
+			name:          "success is not retried",
+			expectedCalls: 1,
+		},
+		{
+			name:          "server errors are retried until the query succeeds",
+			errs:          []error{serverError, serverError},
+			expectedCalls: 3,
+		},
+		{
+			name:          "connection errors are retried",
+			errs:          []error{connError},
+			expectedCalls: 2,
+		},
+		{
+			name:          "GraphQL errors are not retried",
+			errs:          []error{validationError},
+			expectedCalls: 1,
+			expectErr:     true,
+		},
+		{
+			name:          "transient errors are retried a bounded number of times",
+			errs:          []error{serverError, serverError, serverError, serverError, serverError},
+			expectedCalls: defaultMaxGraphQLRetries + 1,
+			expectErr:     true,
+		},
+		{
+			name:          "canceled queries are not retried",
+			ctx:           canceled,
+			errs:          []error{connError},
+			expectedCalls: 1,
+			expectErr:     true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			gqlc := &flakyGraphQLClient{errs: tc.errs}
+			c := getClient("")
+			c.gqlc = gqlc
+			ctx := tc.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			err := c.Query(ctx, &struct{}{}, nil)
+			if err != nil && !tc.expectErr {
+				t.Errorf("Unexpected error: %v", err)
+			} else if err == nil && tc.expectErr {
+				t.Error("Expected an error, got none")
+			}
+			if gqlc.calls != tc.expectedCalls {
+				t.Errorf("Expected %d queries, got %d", tc.expectedCalls, gqlc.calls)
+			}
+		})
+	}
+}
+
+func TestPullRequestAutoMerge(t *testing.T) {
+	gqlc := &recordingGraphQLClient{}
+	c := getClient("")
+	c.gqlc = gqlc
+
+	if err := c.EnablePullRequestAutoMerge("MDExOlB1bGxSZXF1ZXN0MQ==", "squash"); err != nil {
+		t.Fatalf("Didn't expect error enabling auto-merge: %v", err)
+	}
+	if err := c.DisablePullRequestAutoMerge("MDExOlB1bGxSZXF1ZXN0MQ=="); err != nil {
+		t.Fatalf("Didn't expect error disabling auto-merge: %v", err)
+	}
+	expected := []githubql.Input{
+		EnablePullRequestAutoMergeInput{PullRequestID: "MDExOlB1bGxSZXF1ZXN0MQ==", MergeMethod: "SQUASH"},
+		DisablePullRequestAutoMergeInput{PullRequestID: "MDExOlB1bGxSZXF1ZXN0MQ=="},
+	}
+	if !reflect.DeepEqual(expected, gqlc.inputs) {
+		t.Errorf("Wrong mutation inputs: %s", diff.ObjectReflectDiff(expected, gqlc.inputs))
+	}
+
+	c.dry = true
+	if err := c.EnablePullRequestAutoMerge("MDExOlB1bGxSZXF1ZXN0MQ==", ""); err != nil {
+		t.Fatalf("Didn't expect error enabling auto-merge in dry-run: %v", err)
+	}
+	if len(gqlc.inputs) != len(expected) {
+		t.Errorf("Expected no mutation in dry-run mode, got %v", gqlc.inputs[len(expected):])
+	}
+}
+
+// pagedGraphQLClient answers queries with the JSON page keyed by the value of
+// the cursor variable, the empty string being the first page.
+type pagedGraphQLClient struct {
+	pages map[string]string
+	vars  []map[string]interface{}
+}
+
+func (p *pagedGraphQLClient) Query(ctx context.Context, q interface{}, vars map[string]interface{}) error {
+	recorded := map[string]interface{}{}
+	for k, v := range vars {
+		recorded[k] = v
+	}
+	p.vars = append(p.vars, recorded)
+	cursor := ""
+	if c, ok := vars["cursor"].(*githubql.String); ok && c != nil {
+		cursor = string(*c)
+	}
+	page, ok := p.pages[cursor]
+	if !ok {
+		return fmt.Errorf("unexpected cursor %q", cursor)
+	}
+	return json.Unmarshal([]byte(page), q)
+}
+
+func (p *pagedGraphQLClient) Mutate(ctx context.Context, m interface{}, input githubql.Input, vars map[string]interface{}) error {
+	return nil
+}
+
+func TestListPullRequestClosingIssues(t *testing.T) {
+	gqlc := &pagedGraphQLClient{pages: map[string]string{
+		"": `{"repository": {"pullRequest": {"closingIssuesReferences": {
+			"nodes": [{"number": 3}, {"number": 5}],
+			"pageInfo": {"hasNextPage": true, "endCursor": "abc"}}}}}`,
+		"abc": `{"repository": {"pullRequest": {"closingIssuesReferences": {
+			"nodes": [{"number": 8}],
+			"pageInfo": {"hasNextPage": false, "endCursor": "def"}}}}}`,
+	}}
+	c := getClient("")
+	c.gqlc = gqlc
+
+	issues, err := c.ListPullRequestClosingIssues("k8s", "kuber", 12)
+	if err != nil {
+		t.Fatalf("Didn't expect error: %v", err)
+	}
+	if expected := []int{3, 5, 8}; !reflect.DeepEqual(issues, expected) {
+		t.Errorf("Expected issues %v, got %v", expected, issues)
+	}
+	if len(gqlc.vars) != 2 {
+		t.Fatalf("Expected 2 queries, got %d", len(gqlc.vars))
+	}
+	for _, vars := range gqlc.vars {
+		if vars["org"] != githubql.String("k8s") || vars["repo"] != githubql.String("kuber") || vars["number"] != githubql.Int(12) {
+			t.Errorf("Wrong query variables: %v", vars)
+		}
+	}
+}
+
+func TestMergeabilityBatch(t *testing.T) {
+	// The GraphQL client flattens inline fragments when decoding, plain JSON
+	// decoding needs the fragment spelled out as a field.
+	gqlc := &pagedGraphQLClient{pages: map[string]string{
+		"": `{"nodes": [
+			{"PullRequest": {"id": "PR_1", "mergeable": "MERGEABLE"}},
+			{"PullRequest": {"id": "PR_2", "mergeable": "CONFLICTING"}},
+			{"PullRequest": {"id": "PR_3", "mergeable": "UNKNOWN"}}]}`,
+	}}
+	c := getClient("")
+	c.gqlc = gqlc
+
+	prs := []PRRef{
+		{Org: "k8s", Repo: "kuber", Number: 1, NodeID: "PR_1"},
+		{Org: "k8s", Repo: "kuber", Number: 2, NodeID: "PR_2"},
+		{Org: "k8s", Repo: "test-infra", Number: 3, NodeID: "PR_3"},
+	}
+	mergeability, err := c.MergeabilityBatch(prs)
+	if err != nil {
+		t.Fatalf("Didn't expect error: %v", err)
+	}
+	expected := map[PRRef]MergeableState{
+		prs[0]: MergeableStateMergeable,
+		prs[1]: MergeableStateConflicting,
+		prs[2]: MergeableStateUnknown,
+	}
+	if !reflect.DeepEqual(mergeability, expected) {
+		t.Errorf("Expected mergeability %v, got %v", expected, mergeability)
+	}
+	if len(gqlc.vars) != 1 {
+		t.Fatalf("Expected 1 query, got %d", len(gqlc.vars))
+	}
+	if ids := gqlc.vars[0]["ids"]; !reflect.DeepEqual(ids, []githubql.ID{"PR_1", "PR_2", "PR_3"}) {
+		t.Errorf("Wrong ids queried: %v", ids)
+	}
+
+	if _, err := c.MergeabilityBatch(append(prs, PRRef{Org: "k8s", Repo: "kuber", Number: 4, NodeID: "PR_4"})); err == nil {
+		t.Error("Expected an error for a PR missing from the response")
+	}
+}
+
+// nodesGraphQLClient answers nodes queries with a mergeable PR for every ID.
+type nodesGraphQLClient struct {
+	queries [][]githubql.ID
+}
+
+func (n *nodesGraphQLClient) Query(ctx context.Context, q interface{}, vars map[string]interface{}) error {
+	ids := vars["ids"].([]githubql.ID)
+	n.queries = append(n.queries, ids)
+	var nodes []string
+	for _, id := range ids {
+		nodes = append(nodes, fmt.Sprintf(`{"PullRequest": {"id": %q, "mergeable": "MERGEABLE"}}`, id))
+	}
+	return json.Unmarshal([]byte(fmt.Sprintf(`{"nodes": [%s]}`, strings.Join(nodes, ","))), q)
+}
+
+func (n *nodesGraphQLClient) Mutate(ctx context.Context, m interface{}, input githubql.Input, vars map[string]interface{}) error {
+	return nil
+}
+
+func TestMergeabilityBatchChunks(t *testing.T) {
+	gqlc := &nodesGraphQLClient{}
+	c := getClient("")
+	c.gqlc = gqlc
+
+	var prs []PRRef
+	for i := 1; i <= 250; i++ {
+		prs = append(prs, PRRef{Org: "k8s", Repo: "kuber", Number: i, NodeID: fmt.Sprintf("PR_%d", i)})
+	}
+	mergeability, err := c.MergeabilityBatch(prs)
+	if err != nil {
+		t.Fatalf("Didn't expect error: %v", err)
+	}
+	if len(mergeability) != len(prs) {
+		t.Errorf("Expected the mergeability of %d PRs, got %d", len(prs), len(mergeability))
+	}
+	var sizes []int
+	for _, ids := range gqlc.queries {
+		sizes = append(sizes, len(ids))
+	}
+	if expected := []int{100, 100, 50}; !reflect.DeepEqual(sizes, expected) {
+		t.Errorf("Expected queries for %v IDs, got %v", expected, sizes)
+	}
+}
@@ -46,10 +46,14 @@ type FakeClient struct {
 	ReviewID            int
 	Reviews             map[int][]github.Review
 	CombinedStatuses    map[string]*github.CombinedStatus
+	CheckRuns           map[string][]github.CheckRun
 	CreatedStatuses     map[string][]github.Status
 	IssueEvents         map[int][]github.ListedIssueEvent
 	Commits             map[string]github.SingleCommit
 
+	// Maps PR number to the numbers of the issues it closes
+	PullRequestClosingIssues map[int][]int
+
 	//All Labels That Exist In The Repo
 	RepoLabelsExisting []string
 	// org/repo#number:label
@@ -84,6 +88,9 @@ type FakeClient struct {
 	// A list of refs that got deleted via DeleteRef
 	RefsDeleted []struct{ Org, Repo, Ref string }
 
+	// Maps org/repo to the tags of the repo
+	Tags map[string][]github.Tag
+
 	// A map of repo names to projects
 	RepoProjects map[string][]github.Project
 
@@ -253,6 +260,21 @@ func (f *FakeClient) GetPullRequestChanges(org, repo string, number int) ([]gith
 	return f.PullRequestChanges[number], nil
 }
 
+// GetPullRequestChangesLite returns the file modifications in a PR without their patches.
+func (f *FakeClient) GetPullRequestChangesLite(org, repo string, number int) ([]github.PullRequestChange, error) {
+	var changes []github.PullRequestChange
+	for _, change := range f.PullRequestChanges[number] {
+		change.Patch = ""
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// ListPullRequestClosingIssues returns the numbers of the issues a PR closes.
+func (f *FakeClient) ListPullRequestClosingIssues(org, repo string, number int) ([]int, error) {
+	return f.PullRequestClosingIssues[number], nil
+}
+
 // GetRef returns the hash of a ref.
 func (f *FakeClient) GetRef(owner, repo, ref string) (string, error) {
 	return TestRef, nil
@@ -264,6 +286,11 @@ func (f *FakeClient) DeleteRef(owner, repo, ref string) error {
 	return nil
 }
 
+// ListTags returns the tags of a repo.
+func (f *FakeClient) ListTags(org, repo string) ([]github.Tag, error) {
+	return f.Tags[org+"/"+repo], nil
+}
+
 // GetSingleCommit returns a single commit.
 func (f *FakeClient) GetSingleCommit(org, repo, SHA string) (github.SingleCommit, error) {
 	return f.Commits[SHA], nil
@@ -299,6 +326,20 @@ func (f *FakeClient) GetCombinedStatus(owner, repo, ref string) (*github.Combine
 	return f.CombinedStatuses[ref], nil
 }
 
+// GetCheckRunsForRef returns the check runs of a commit.
+func (f *FakeClient) GetCheckRunsForRef(owner, repo, ref string) ([]github.CheckRun, error) {
+	return f.CheckRuns[ref], nil
+}
+
+// GetStatusRollup combines the statuses and check runs of a commit.
+func (f *FakeClient) GetStatusRollup(owner, repo, ref string) (github.StatusRollup, error) {
+	var statuses []github.Status
+	if combined := f.CombinedStatuses[ref]; combined != nil {
+		statuses = combined.Statuses
+	}
+	return github.NewStatusRollup(ref, statuses, f.CheckRuns[ref]), nil
+}
+
 // GetRepoLabels gets labels in a repo.
 func (f *FakeClient) GetRepoLabels(owner, repo string) ([]github.Label, error) {
 	la := []github.Label{}
@@ -659,6 +700,7 @@ func (f *FakeClient) TeamHasMember(teamID int, memberLogin string) (bool, error)
 	return false, nil
 }
 
+// GetTeamBySlug returns the team with the given slug or a *github.TeamNotFound error.
 func (f *FakeClient) GetTeamBySlug(slug string, org string) (*github.Team, error) {
 	teams, _ := f.ListTeams(org)
 	for _, team := range teams {
@@ -666,5 +708,5 @@ func (f *FakeClient) GetTeamBySlug(slug string, org string) (*github.Team, error
 			return &team, nil
 		}
 	}
-	return &github.Team{}, nil
+	return nil, &github.TeamNotFound{Org: org, Slug: slug}
 }
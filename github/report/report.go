@@ -21,10 +21,12 @@ package report
 import (
 	"bytes"
 	"fmt"
+	"sort"
 	"strings"
 	"text/template"
 
 	prowapi "github.com/clarketm/prow/apis/prowjobs/v1"
+	"github.com/clarketm/prow/config"
 	"github.com/clarketm/prow/github"
 	"github.com/clarketm/prow/plugins"
 )
@@ -81,30 +83,162 @@ func truncate(in string) string {
 	return in[:half] + elide + in[len(in)-half:]
 }
 
+// JobLister lists the ProwJobs of a repo. The states of status groups are
+// computed from the ProwJobs it returns.
+type JobLister func(org, repo string) ([]prowapi.ProwJob, error)
+
+// StaticJobLister returns a JobLister for a fixed list of ProwJobs. The
+// reported jobs replace the listed jobs of the same name, so that the states
+// of status groups reflect all reports of a sync.
+func StaticJobLister(jobs []prowapi.ProwJob, reported ...prowapi.ProwJob) JobLister {
+	updated := map[string]prowapi.ProwJob{}
+	for _, pj := range reported {
+		updated[pj.Name] = pj
+	}
+	all := make([]prowapi.ProwJob, 0, len(jobs))
+	for _, pj := range jobs {
+		if u, ok := updated[pj.Name]; ok {
+			pj = u
+		}
+		all = append(all, pj)
+	}
+	return func(_, _ string) ([]prowapi.ProwJob, error) {
+		return all, nil
+	}
+}
+
+// reportedSHA returns the commit that the status of a job with the refs is
+// reported on.
+func reportedSHA(refs *prowapi.Refs) string {
+	if len(refs.Pulls) > 0 {
+		return refs.Pulls[0].SHA
+	}
+	return refs.BaseSHA
+}
+
 // reportStatus should be called on any prowjob status changes
-func reportStatus(ghc GitHubClient, pj prowapi.ProwJob) error {
+func reportStatus(ghc GitHubClient, pj prowapi.ProwJob, statusGroups []config.StatusGroup, jobs JobLister) error {
 	refs := pj.Spec.Refs
 	if pj.Spec.Report {
 		contextState, err := prowjobStateToGitHubStatus(pj.Status.State)
 		if err != nil {
 			return err
 		}
-		sha := refs.BaseSHA
-		if len(refs.Pulls) > 0 {
-			sha = refs.Pulls[0].SHA
+		sha := reportedSHA(refs)
+		var groups []config.StatusGroup
+		var replaced bool
+		for _, g := range statusGroups {
+			if g.Has(pj.Spec.Context) {
+				groups = append(groups, g)
+				replaced = replaced || g.ReplaceContexts
+			}
+		}
+		if !replaced {
+			status := github.Status{
+				State:       contextState,
+				Description: truncate(pj.Status.Description),
+				Context:     pj.Spec.Context, // consider truncating this too
+				TargetURL:   pj.Status.URL,
+			}
+			if err := ghc.CreateStatus(refs.Org, refs.Repo, sha, status); err != nil {
+				return err
+			}
+		}
+		if len(groups) == 0 {
+			return nil
 		}
-		if err := ghc.CreateStatus(refs.Org, refs.Repo, sha, github.Status{
-			State:       contextState,
-			Description: truncate(pj.Status.Description),
-			Context:     pj.Spec.Context, // consider truncating this too
-			TargetURL:   pj.Status.URL,
-		}); err != nil {
-			return err
+		statuses, err := jobStatuses(pj, jobs)
+		if err != nil {
+			return fmt.Errorf("error listing the jobs of the status group: %v", err)
+		}
+		for _, g := range groups {
+			if err := ghc.CreateStatus(refs.Org, refs.Repo, sha, groupStatus(g, statuses)); err != nil {
+				return fmt.Errorf("error setting status group: %v", err)
+			}
 		}
 	}
 	return nil
 }
 
+// jobStatuses returns the statuses of the latest reported jobs for each
+// context of the commit the job reports on. The statuses are computed from
+// the ProwJobs rather than read back from GitHub so that jobs finishing at
+// the same time can not leave a group with a stale state.
+func jobStatuses(pj prowapi.ProwJob, jobs JobLister) ([]github.Status, error) {
+	refs := pj.Spec.Refs
+	latest := map[string]prowapi.ProwJob{pj.Spec.Context: pj}
+	if jobs != nil {
+		all, err := jobs(refs.Org, refs.Repo)
+		if err != nil {
+			return nil, err
+		}
+		sha := reportedSHA(refs)
+		for _, job := range all {
+			switch {
+			case !job.Spec.Report || job.Spec.Refs == nil || len(job.Spec.Refs.Pulls) > 1:
+				continue
+			case job.Spec.Context == pj.Spec.Context:
+				// The reported job is the latest state of its context.
+				continue
+			case job.Spec.Refs.Org != refs.Org || job.Spec.Refs.Repo != refs.Repo || reportedSHA(job.Spec.Refs) != sha:
+				continue
+			}
+			if previous, ok := latest[job.Spec.Context]; ok && !previous.CreationTimestamp.Before(&job.CreationTimestamp) {
+				continue
+			}
+			latest[job.Spec.Context] = job
+		}
+	}
+	var statuses []github.Status
+	for context, job := range latest {
+		state, err := prowjobStateToGitHubStatus(job.Status.State)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, github.Status{Context: context, State: state})
+	}
+	return statuses, nil
+}
+
+// groupStatus computes the aggregate status of the group from the latest
+// statuses of the commit. The group fails if any member failed, is pending
+// until all members passed and succeeds otherwise.
+func groupStatus(group config.StatusGroup, statuses []github.Status) github.Status {
+	states := map[string]string{}
+	for _, c := range group.Contexts {
+		states[c] = github.StatusPending
+	}
+	for _, s := range statuses {
+		if group.Has(s.Context) {
+			states[s.Context] = s.State
+		}
+	}
+	var failed []string
+	var passed int
+	for context, state := range states {
+		switch state {
+		case github.StatusSuccess:
+			passed++
+		case github.StatusFailure, github.StatusError:
+			failed = append(failed, context)
+		}
+	}
+	status := github.Status{Context: group.Context}
+	switch {
+	case len(failed) > 0:
+		sort.Strings(failed)
+		status.State = github.StatusFailure
+		status.Description = truncate(fmt.Sprintf("Failed: %s", strings.Join(failed, ", ")))
+	case passed < len(states):
+		status.State = github.StatusPending
+		status.Description = fmt.Sprintf("%d/%d contexts passed.", passed, len(states))
+	default:
+		status.State = github.StatusSuccess
+		status.Description = fmt.Sprintf("All %d contexts passed.", len(states))
+	}
+	return status
+}
+
 // TODO(krzyzacy):
 // Move this logic into github/reporter, once we unify all reporting logic to crier
 func ShouldReport(pj prowapi.ProwJob, validTypes []prowapi.ProwJobType) bool {
@@ -127,8 +261,9 @@ func ShouldReport(pj prowapi.ProwJob, validTypes []prowapi.ProwJobType) bool {
 }
 
 // Report is creating/updating/removing reports in GitHub based on the state of
-// the provided ProwJob.
-func Report(ghc GitHubClient, reportTemplate *template.Template, pj prowapi.ProwJob, validTypes []prowapi.ProwJobType) error {
+// the provided ProwJob. The states of its status groups are computed from the
+// ProwJobs listed by jobs.
+func Report(ghc GitHubClient, reportTemplate *template.Template, pj prowapi.ProwJob, validTypes []prowapi.ProwJobType, statusGroups []config.StatusGroup, jobs JobLister) error {
 	if ghc == nil {
 		return fmt.Errorf("trying to report pj %s, but found empty github client", pj.ObjectMeta.Name)
 	}
@@ -143,7 +278,7 @@ func Report(ghc GitHubClient, reportTemplate *template.Template, pj prowapi.Prow
 		return nil
 	}
 
-	if err := reportStatus(ghc, pj); err != nil {
+	if err := reportStatus(ghc, pj, statusGroups, jobs); err != nil {
 		return fmt.Errorf("error setting status: %v", err)
 	}
 
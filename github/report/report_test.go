@@ -21,7 +21,10 @@ import (
 	"strings"
 	"testing"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
 	prowapi "github.com/clarketm/prow/apis/prowjobs/v1"
+	"github.com/clarketm/prow/config"
 	"github.com/clarketm/prow/github"
 )
 
@@ -257,6 +260,22 @@ func shout(i int) string {
 }
 
 func TestReportStatus(t *testing.T) {
+	job := func(name, context, sha string, state prowapi.ProwJobState, created int64) prowapi.ProwJob {
+		return prowapi.ProwJob{
+			ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.Unix(created, 0)},
+			Spec: prowapi.ProwJobSpec{
+				Type:    prowapi.PresubmitJob,
+				Context: context,
+				Report:  true,
+				Refs: &prowapi.Refs{
+					Org:   "k8s",
+					Repo:  "test-infra",
+					Pulls: []prowapi.Pull{{Number: 1, SHA: sha}},
+				},
+			},
+			Status: prowapi.ProwJobStatus{State: state},
+		}
+	}
 	const (
 		defMsg = "default-message"
 	)
@@ -267,6 +286,8 @@ func TestReportStatus(t *testing.T) {
 		report           bool
 		desc             string // override default msg
 		pjType           prowapi.ProwJobType
+		statusGroups     []config.StatusGroup
+		jobs             []prowapi.ProwJob
 		expectedStatuses []string
 		expectedDesc     string
 	}{
@@ -328,6 +349,70 @@ func TestReportStatus(t *testing.T) {
 
 			expectedStatuses: []string{"success"},
 		},
+		{
+			name: "Status group member also sets the pending group status",
+
+			state:            prowapi.SuccessState,
+			report:           true,
+			pjType:           prowapi.PresubmitJob,
+			statusGroups:     []config.StatusGroup{{Context: "all", Contexts: []string{"parent", "other"}}},
+			expectedStatuses: []string{"success", "pending"},
+		},
+		{
+			name: "Status group succeeds once all members passed",
+
+			state:        prowapi.SuccessState,
+			report:       true,
+			pjType:       prowapi.PresubmitJob,
+			statusGroups: []config.StatusGroup{{Context: "all", Contexts: []string{"parent", "other"}}},
+			jobs: []prowapi.ProwJob{
+				job("old-parent", "parent", "abcdef", prowapi.PendingState, 1),
+				job("other", "other", "abcdef", prowapi.SuccessState, 1),
+			},
+			expectedStatuses: []string{"success", "success"},
+		},
+		{
+			name: "Status group uses the latest job of each context",
+
+			state:        prowapi.SuccessState,
+			report:       true,
+			pjType:       prowapi.PresubmitJob,
+			statusGroups: []config.StatusGroup{{Context: "all", Contexts: []string{"parent", "other"}}},
+			jobs: []prowapi.ProwJob{
+				job("other-old", "other", "abcdef", prowapi.SuccessState, 1),
+				job("other-new", "other", "abcdef", prowapi.PendingState, 2),
+			},
+			expectedStatuses: []string{"success", "pending"},
+		},
+		{
+			name: "Status group ignores jobs of other commits",
+
+			state:            prowapi.SuccessState,
+			report:           true,
+			pjType:           prowapi.PresubmitJob,
+			statusGroups:     []config.StatusGroup{{Context: "all", Contexts: []string{"parent", "other"}}},
+			jobs:             []prowapi.ProwJob{job("other", "other", "123456", prowapi.SuccessState, 1)},
+			expectedStatuses: []string{"success", "pending"},
+		},
+		{
+			name: "Status group replacing its contexts only sets the group status",
+
+			state:            prowapi.SuccessState,
+			report:           true,
+			pjType:           prowapi.PresubmitJob,
+			statusGroups:     []config.StatusGroup{{Context: "all", Contexts: []string{"parent", "other"}, ReplaceContexts: true}},
+			expectedStatuses: []string{"pending"},
+			expectedDesc:     "1/2 contexts passed.",
+		},
+		{
+			name: "Status group not containing the context is not set",
+
+			state:            prowapi.SuccessState,
+			report:           true,
+			pjType:           prowapi.PresubmitJob,
+			statusGroups:     []config.StatusGroup{{Context: "unit", ContextPrefix: "unit-"}},
+			expectedStatuses: []string{"success"},
+		},
 	}
 
 	for _, tc := range tests {
@@ -364,7 +449,7 @@ func TestReportStatus(t *testing.T) {
 				},
 			}
 			// Run
-			if err := reportStatus(ghc, pj); err != nil {
+			if err := reportStatus(ghc, pj, tc.statusGroups, StaticJobLister(tc.jobs)); err != nil {
 				t.Error(err)
 			}
 			// Check
@@ -477,6 +562,56 @@ func TestTruncate(t *testing.T) {
 	}
 }
 
+func TestGroupStatus(t *testing.T) {
+	group := config.StatusGroup{Context: "unit", ContextPrefix: "unit-", Contexts: []string{"lint"}}
+	testCases := []struct {
+		name     string
+		statuses []github.Status
+		expected github.Status
+	}{
+		{
+			name:     "unreported listed context is pending",
+			statuses: []github.Status{{Context: "unit-foo", State: github.StatusSuccess}},
+			expected: github.Status{Context: "unit", State: github.StatusPending, Description: "1/2 contexts passed."},
+		},
+		{
+			name: "pending member keeps the group pending",
+			statuses: []github.Status{
+				{Context: "unit-foo", State: github.StatusSuccess},
+				{Context: "unit-bar", State: github.StatusPending},
+				{Context: "lint", State: github.StatusSuccess},
+			},
+			expected: github.Status{Context: "unit", State: github.StatusPending, Description: "2/3 contexts passed."},
+		},
+		{
+			name: "failed members fail the group",
+			statuses: []github.Status{
+				{Context: "unit-foo", State: github.StatusFailure},
+				{Context: "unit-bar", State: github.StatusPending},
+				{Context: "lint", State: github.StatusError},
+			},
+			expected: github.Status{Context: "unit", State: github.StatusFailure, Description: "Failed: lint, unit-foo"},
+		},
+		{
+			name: "all members passed, ignoring other contexts",
+			statuses: []github.Status{
+				{Context: "unit-foo", State: github.StatusSuccess},
+				{Context: "lint", State: github.StatusSuccess},
+				{Context: "e2e", State: github.StatusFailure},
+				{Context: "unit", State: github.StatusPending},
+			},
+			expected: github.Status{Context: "unit", State: github.StatusSuccess, Description: "All 2 contexts passed."},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := groupStatus(group, tc.statuses); actual != tc.expected {
+				t.Errorf("expected status %+v, got %+v", tc.expected, actual)
+			}
+		})
+	}
+}
+
 func TestShouldReport(t *testing.T) {
 	var testcases = []struct {
 		name       string
@@ -19,10 +19,14 @@ limitations under the License.
 package reporter
 
 import (
+	"k8s.io/apimachinery/pkg/labels"
+
 	"github.com/clarketm/prow/apis/prowjobs/v1"
+	pjlister "github.com/clarketm/prow/client/listers/prowjobs/v1"
 	"github.com/clarketm/prow/config"
 	"github.com/clarketm/prow/gerrit/client"
 	"github.com/clarketm/prow/github/report"
+	"github.com/clarketm/prow/kube"
 )
 
 const (
@@ -35,14 +39,16 @@ type Client struct {
 	gc          report.GitHubClient
 	config      config.Getter
 	reportAgent v1.ProwJobAgent
+	lister      pjlister.ProwJobLister
 }
 
 // NewReporter returns a reporter client
-func NewReporter(gc report.GitHubClient, cfg config.Getter, reportAgent v1.ProwJobAgent) *Client {
+func NewReporter(gc report.GitHubClient, cfg config.Getter, reportAgent v1.ProwJobAgent, lister pjlister.ProwJobLister) *Client {
 	return &Client{
 		gc:          gc,
 		config:      cfg,
 		reportAgent: reportAgent,
+		lister:      lister,
 	}
 }
 
@@ -71,5 +77,23 @@ func (c *Client) ShouldReport(pj *v1.ProwJob) bool {
 // Report will report via reportlib
 func (c *Client) Report(pj *v1.ProwJob) ([]*v1.ProwJob, error) {
 	// TODO(krzyzacy): ditch ReportTemplate, and we can drop reference to config.Getter
-	return []*v1.ProwJob{pj}, report.Report(c.gc, c.config().Plank.ReportTemplate, *pj, c.config().GitHubReporter.JobTypesToReport)
+	cfg := c.config()
+	return []*v1.ProwJob{pj}, report.Report(c.gc, cfg.Plank.ReportTemplate, *pj, cfg.GitHubReporter.JobTypesToReport, cfg.GitHubReporter.StatusGroups, c.listJobs)
+}
+
+// listJobs lists the ProwJobs of the repo from the informer cache.
+func (c *Client) listJobs(org, repo string) ([]v1.ProwJob, error) {
+	selector := labels.Set{
+		kube.OrgLabel:  org,
+		kube.RepoLabel: repo,
+	}
+	pjs, err := c.lister.List(selector.AsSelector())
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]v1.ProwJob, 0, len(pjs))
+	for _, pj := range pjs {
+		jobs = append(jobs, *pj)
+	}
+	return jobs, nil
 }
@@ -100,7 +100,7 @@ func TestShouldReport(t *testing.T) {
 
 	for _, tc := range testcases {
 		t.Run(tc.name, func(t *testing.T) {
-			c := NewReporter(nil, nil, tc.reportAgent)
+			c := NewReporter(nil, nil, tc.reportAgent, nil)
 			if r := c.ShouldReport(&tc.pj); r == tc.report {
 				return
 			}
@@ -145,6 +145,9 @@ type Status struct {
 	TargetURL   string `json:"target_url,omitempty"`
 	Description string `json:"description,omitempty"`
 	Context     string `json:"context,omitempty"`
+	// Creator is the user that created the status. It is only set on
+	// statuses listed by ListStatuses.
+	Creator *User `json:"creator,omitempty"`
 }
 
 // CombinedStatus is the latest statuses for a ref.
@@ -154,6 +157,294 @@ type CombinedStatus struct {
 	State    string   `json:"state"`
 }
 
+// CheckRun is the result of a check, e.g. reported by a GitHub App, on a commit.
+type CheckRun struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	HeadSHA    string `json:"head_sha"`
+	Status     string `json:"status"`
+	Conclusion string `json:"conclusion"`
+	DetailsURL string `json:"details_url"`
+}
+
+// Possible check run statuses and conclusions. The conclusion is only set
+// once the status is completed.
+const (
+	CheckRunStatusQueued     = "queued"
+	CheckRunStatusInProgress = "in_progress"
+	CheckRunStatusCompleted  = "completed"
+
+	CheckRunConclusionSuccess        = "success"
+	CheckRunConclusionFailure        = "failure"
+	CheckRunConclusionNeutral        = "neutral"
+	CheckRunConclusionCancelled      = "cancelled"
+	CheckRunConclusionSkipped        = "skipped"
+	CheckRunConclusionTimedOut       = "timed_out"
+	CheckRunConclusionActionRequired = "action_required"
+)
+
+// CheckRunAnnotation is a comment a check run made on lines of a file.
+type CheckRunAnnotation struct {
+	Path            string `json:"path"`
+	StartLine       int    `json:"start_line"`
+	EndLine         int    `json:"end_line"`
+	AnnotationLevel string `json:"annotation_level"`
+	Title           string `json:"title,omitempty"`
+	Message         string `json:"message"`
+}
+
+// Possible check run annotation levels.
+const (
+	CheckRunAnnotationLevelNotice  = "notice"
+	CheckRunAnnotationLevelWarning = "warning"
+	CheckRunAnnotationLevelFailure = "failure"
+)
+
+// StatusRollup is the state of all statuses and check runs of a ref.
+type StatusRollup struct {
+	SHA string `json:"sha"`
+	// State is the state of the worst context, one of StatusError, StatusFailure,
+	// StatusPending and StatusSuccess. It is StatusSuccess without any context.
+	State string `json:"state"`
+	// Contexts maps status contexts and check run names to their state.
+	Contexts map[string]string `json:"contexts"`
+}
+
+// statusPrecedence ranks the states of a StatusRollup, the worst first.
+var statusPrecedence = map[string]int{
+	StatusError:   0,
+	StatusFailure: 1,
+	StatusPending: 2,
+	StatusSuccess: 3,
+}
+
+// checkRunState maps a check run to the equivalent status state. Neutral and
+// skipped checks do not block, so they count as successful.
+func checkRunState(run CheckRun) string {
+	if run.Status != CheckRunStatusCompleted {
+		return StatusPending
+	}
+	switch run.Conclusion {
+	case CheckRunConclusionSuccess, CheckRunConclusionNeutral, CheckRunConclusionSkipped:
+		return StatusSuccess
+	default:
+		return StatusFailure
+	}
+}
+
+// NewStatusRollup combines the statuses and check runs of a ref. When a
+// status and a check run share a name, the worse state of the two is kept.
+func NewStatusRollup(sha string, statuses []Status, runs []CheckRun) StatusRollup {
+	rollup := StatusRollup{SHA: sha, State: StatusSuccess, Contexts: map[string]string{}}
+	set := func(name, state string) {
+		if _, known := statusPrecedence[state]; !known {
+			state = StatusError
+		}
+		if existing, ok := rollup.Contexts[name]; !ok || statusPrecedence[state] < statusPrecedence[existing] {
+			rollup.Contexts[name] = state
+		}
+		if statusPrecedence[state] < statusPrecedence[rollup.State] {
+			rollup.State = state
+		}
+	}
+	for _, status := range statuses {
+		set(status.Context, status.State)
+	}
+	for _, run := range runs {
+		set(run.Name, checkRunState(run))
+	}
+	return rollup
+}
+
+// WorkflowRun is a run of a GitHub Actions workflow.
+type WorkflowRun struct {
+	ID         int       `json:"id"`
+	Name       string    `json:"name"`
+	WorkflowID int       `json:"workflow_id"`
+	HeadBranch string    `json:"head_branch"`
+	HeadSHA    string    `json:"head_sha"`
+	Event      string    `json:"event"`
+	Status     string    `json:"status"`
+	Conclusion string    `json:"conclusion"`
+	HTMLURL    string    `json:"html_url"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// WorkflowRunOptions filters the workflow runs returned by ListWorkflowRuns.
+// Empty fields do not filter.
+type WorkflowRunOptions struct {
+	Branch string
+	// Status can be a run status like "in_progress" or a conclusion like "failure".
+	Status string
+}
+
+// RepoPublicKey is the public key used to encrypt the Actions secrets of a repo.
+type RepoPublicKey struct {
+	KeyID string `json:"key_id"`
+	Key   string `json:"key"`
+}
+
+// SecretMeta is the metadata of an Actions secret. The value of a secret can
+// not be read back.
+type SecretMeta struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// Visibility of an org secret, one of all, private or selected.
+	Visibility string `json:"visibility,omitempty"`
+	// SelectedRepositoriesURL lists the repos that can access an org secret
+	// with selected visibility.
+	SelectedRepositoriesURL string `json:"selected_repositories_url,omitempty"`
+}
+
+// Alert is a Dependabot alert about a vulnerable dependency of a repo.
+type Alert struct {
+	Number           int                   `json:"number"`
+	State            string                `json:"state"`
+	Dependency       AlertDependency       `json:"dependency"`
+	SecurityAdvisory AlertSecurityAdvisory `json:"security_advisory"`
+	HTMLURL          string                `json:"html_url"`
+	CreatedAt        time.Time             `json:"created_at"`
+	DismissedReason  *string               `json:"dismissed_reason"`
+}
+
+// AlertDependency is the vulnerable dependency of a Dependabot alert.
+type AlertDependency struct {
+	Package struct {
+		Ecosystem string `json:"ecosystem"`
+		Name      string `json:"name"`
+	} `json:"package"`
+	ManifestPath string `json:"manifest_path"`
+}
+
+// AlertSecurityAdvisory is the advisory a Dependabot alert was raised for.
+type AlertSecurityAdvisory struct {
+	GHSAID   string `json:"ghsa_id"`
+	CVEID    string `json:"cve_id"`
+	Summary  string `json:"summary"`
+	Severity string `json:"severity"`
+}
+
+// Possible states of a Dependabot alert.
+const (
+	AlertStateOpen      = "open"
+	AlertStateDismissed = "dismissed"
+	AlertStateFixed     = "fixed"
+)
+
+// Possible reasons for dismissing a Dependabot alert.
+const (
+	AlertDismissedReasonFixStarted    = "fix_started"
+	AlertDismissedReasonInaccurate    = "inaccurate"
+	AlertDismissedReasonNoBandwidth   = "no_bandwidth"
+	AlertDismissedReasonNotUsed       = "not_used"
+	AlertDismissedReasonTolerableRisk = "tolerable_risk"
+)
+
+// Environment is a deployment environment of a repo.
+type Environment struct {
+	ID              int                         `json:"id"`
+	Name            string                      `json:"name"`
+	HTMLURL         string                      `json:"html_url"`
+	CreatedAt       time.Time                   `json:"created_at"`
+	UpdatedAt       time.Time                   `json:"updated_at"`
+	ProtectionRules []EnvironmentProtectionRule `json:"protection_rules,omitempty"`
+}
+
+// Possible types of environment protection rules.
+const (
+	EnvironmentProtectionRuleWaitTimer         = "wait_timer"
+	EnvironmentProtectionRuleRequiredReviewers = "required_reviewers"
+	EnvironmentProtectionRuleBranchPolicy      = "branch_policy"
+)
+
+// EnvironmentProtectionRule gates deployments to an environment.
+type EnvironmentProtectionRule struct {
+	ID   int    `json:"id"`
+	Type string `json:"type"`
+	// WaitTimer is the number of minutes to wait before deploying, set
+	// for wait_timer rules.
+	WaitTimer int `json:"wait_timer,omitempty"`
+	// Reviewers must approve deployments, set for required_reviewers rules.
+	Reviewers []EnvironmentReviewer `json:"reviewers,omitempty"`
+}
+
+// EnvironmentReviewer is a user or team that can approve deployments.
+type EnvironmentReviewer struct {
+	// Type is either "User" or "Team".
+	Type     string `json:"type"`
+	Reviewer struct {
+		ID int `json:"id"`
+		// Login is set for users.
+		Login string `json:"login,omitempty"`
+		// Slug is set for teams.
+		Slug string `json:"slug,omitempty"`
+	} `json:"reviewer"`
+}
+
+// BranchPolicy is a name pattern of the branches or tags that can deploy to
+// an environment with custom deployment branch policies.
+type BranchPolicy struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	// Type is either "branch" or "tag".
+	Type string `json:"type,omitempty"`
+}
+
+// Possible states when reviewing a deployment protection rule.
+const (
+	DeploymentProtectionRuleApproved = "approved"
+	DeploymentProtectionRuleRejected = "rejected"
+)
+
+// Possible enforcement levels of a ruleset.
+const (
+	RulesetEnforcementDisabled = "disabled"
+	RulesetEnforcementActive   = "active"
+	RulesetEnforcementEvaluate = "evaluate"
+)
+
+// Ruleset is a named list of rules that applies to the branches or tags of a repo.
+type Ruleset struct {
+	ID   int    `json:"id,omitempty"`
+	Name string `json:"name"`
+	// Target is either "branch" or "tag".
+	Target       string               `json:"target,omitempty"`
+	Enforcement  string               `json:"enforcement"`
+	BypassActors []RulesetBypassActor `json:"bypass_actors,omitempty"`
+	Conditions   *RulesetConditions   `json:"conditions,omitempty"`
+	Rules        []RulesetRule        `json:"rules,omitempty"`
+}
+
+// RulesetBypassActor is an actor that can bypass the rules of a ruleset.
+type RulesetBypassActor struct {
+	ActorID int `json:"actor_id"`
+	// ActorType is one of "RepositoryRole", "Team", "Integration" or "OrganizationAdmin".
+	ActorType string `json:"actor_type"`
+	// BypassMode is either "always" or "pull_request".
+	BypassMode string `json:"bypass_mode,omitempty"`
+}
+
+// RulesetConditions select the refs a ruleset applies to.
+type RulesetConditions struct {
+	RefName RulesetRefNameCondition `json:"ref_name"`
+}
+
+// RulesetRefNameCondition selects refs by name. Patterns may use fnmatch
+// syntax and the special values "~DEFAULT_BRANCH" and "~ALL".
+type RulesetRefNameCondition struct {
+	Include []string `json:"include"`
+	Exclude []string `json:"exclude"`
+}
+
+// RulesetRule is a single rule of a ruleset, e.g. "deletion" or
+// "required_status_checks". The parameters depend on the type of the rule.
+type RulesetRule struct {
+	Type       string                 `json:"type"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+}
+
 // User is a GitHub user account.
 type User struct {
 	Login       string          `json:"login"`
@@ -172,6 +463,54 @@ const (
 	UserTypeBot = "Bot"
 )
 
+// GPGKey is a GPG key a user can sign commits with.
+// See https://developer.github.com/v3/users/gpg_keys/
+type GPGKey struct {
+	ID        int           `json:"id"`
+	KeyID     string        `json:"key_id"`
+	PublicKey string        `json:"public_key"`
+	Emails    []GPGKeyEmail `json:"emails"`
+	CanSign   bool          `json:"can_sign"`
+	CreatedAt time.Time     `json:"created_at"`
+	ExpiresAt *time.Time    `json:"expires_at,omitempty"`
+}
+
+// GPGKeyEmail is an email address associated with a GPG key.
+type GPGKeyEmail struct {
+	Email    string `json:"email"`
+	Verified bool   `json:"verified"`
+}
+
+// DeployKey is an SSH key that grants access to a single repo.
+// See https://developer.github.com/v3/repos/keys/
+type DeployKey struct {
+	ID        int       `json:"id"`
+	Key       string    `json:"key"`
+	Title     string    `json:"title"`
+	Verified  bool      `json:"verified"`
+	ReadOnly  bool      `json:"read_only"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Gist is a collection of files shared by a user.
+// See https://developer.github.com/v3/gists/
+type Gist struct {
+	ID          string              `json:"id"`
+	Description string              `json:"description"`
+	Public      bool                `json:"public"`
+	HTMLURL     string              `json:"html_url"`
+	Files       map[string]GistFile `json:"files"`
+	CreatedAt   time.Time           `json:"created_at"`
+}
+
+// GistFile is a file of a gist. Content is only set when the gist is
+// fetched on its own or created.
+type GistFile struct {
+	Filename string `json:"filename,omitempty"`
+	Content  string `json:"content,omitempty"`
+	RawURL   string `json:"raw_url,omitempty"`
+}
+
 // NormLogin normalizes GitHub login strings
 func NormLogin(login string) string {
 	return strings.TrimPrefix(strings.ToLower(login), "@")
@@ -229,6 +568,7 @@ type PullRequestEvent struct {
 // PullRequest contains information about a PullRequest.
 type PullRequest struct {
 	ID                 int               `json:"id"`
+	NodeID             string            `json:"node_id"`
 	Number             int               `json:"number"`
 	HTMLURL            string            `json:"html_url"`
 	User               User              `json:"user"`
@@ -258,6 +598,42 @@ type PullRequest struct {
 	Milestone *Milestone `json:"milestone,omitempty"`
 }
 
+// PRRef identifies a pull request. NodeID is the GraphQL node ID of the PR.
+type PRRef struct {
+	Org    string
+	Repo   string
+	Number int
+	NodeID string
+}
+
+// MergeableState is whether GitHub can merge a pull request.
+type MergeableState string
+
+// Possible mergeable states of a pull request.
+const (
+	// MergeableStateMergeable means the PR can be merged.
+	MergeableStateMergeable MergeableState = "MERGEABLE"
+	// MergeableStateConflicting means the PR cannot be merged due to merge conflicts.
+	MergeableStateConflicting MergeableState = "CONFLICTING"
+	// MergeableStateUnknown means GitHub is still calculating the mergeability of the PR.
+	MergeableStateUnknown MergeableState = "UNKNOWN"
+)
+
+// PullRequestUpdate contains the fields of a pull request to update. Nil
+// fields are left unchanged.
+// See also: https://developer.github.com/v3/pulls/#update-a-pull-request
+type PullRequestUpdate struct {
+	Title *string `json:"title,omitempty"`
+	Body  *string `json:"body,omitempty"`
+	// Base is the name of the branch the changes should be pulled into.
+	Base *string `json:"base,omitempty"`
+	// State is either "open" or "closed".
+	State *string `json:"state,omitempty"`
+	// MaintainerCanModify allows maintainers of the repo to modify this
+	// pull request, eg. push changes to it before merging.
+	MaintainerCanModify *bool `json:"maintainer_can_modify,omitempty"`
+}
+
 // PullRequestBranch contains information about a particular branch in a PR.
 type PullRequestBranch struct {
 	Ref  string `json:"ref"`
@@ -489,6 +865,18 @@ type Branch struct {
 	// TODO(fejta): consider including undocumented protection key
 }
 
+// Tag contains general tag information.
+// See https://developer.github.com/v3/repos/#list-tags
+type Tag struct {
+	Name   string    `json:"name"`
+	Commit TagCommit `json:"commit"`
+}
+
+// TagCommit is the commit a tag points to.
+type TagCommit struct {
+	SHA string `json:"sha"`
+}
+
 // BranchProtection represents protections
 // currently in place for a branch
 // See also: https://developer.github.com/v3/repos/branches/#get-branch-protection
@@ -656,6 +1044,27 @@ type ListedIssueEvent struct {
 	CreatedAt time.Time        `json:"created_at"`
 }
 
+// TimelineEvent represents an event from an issue's timeline. The timeline
+// includes events like cross-references that the events API omits.
+// https://developer.github.com/v3/issues/timeline/
+type TimelineEvent struct {
+	Event     string               `json:"event"`
+	Actor     User                 `json:"actor"`
+	CreatedAt time.Time            `json:"created_at"`
+	Source    *TimelineEventSource `json:"source,omitempty"`
+}
+
+// TimelineEventCrossReferenced is the timeline event for an issue or pull
+// request that references another issue.
+const TimelineEventCrossReferenced = "cross_referenced"
+
+// TimelineEventSource is the issue or pull request that triggered a
+// cross_referenced timeline event.
+type TimelineEventSource struct {
+	Type  string `json:"type"`
+	Issue Issue  `json:"issue"`
+}
+
 // IssueCommentEventAction enumerates the triggers for this
 // webhook payload type. See also:
 // https://developer.github.com/v3/activity/events/types/#issuecommentevent
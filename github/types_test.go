@@ -17,6 +17,7 @@ limitations under the License.
 package github
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -107,3 +108,79 @@ func TestUnmarshalClientError(t *testing.T) {
 		}
 	}
 }
+
+func TestNewStatusRollup(t *testing.T) {
+	testCases := []struct {
+		name             string
+		statuses         []Status
+		runs             []CheckRun
+		expectedState    string
+		expectedContexts map[string]string
+	}{
+		{
+			name:             "nothing reported is successful",
+			expectedState:    StatusSuccess,
+			expectedContexts: map[string]string{},
+		},
+		{
+			name: "check runs are normalized to status states",
+			runs: []CheckRun{
+				{Name: "queued", Status: CheckRunStatusQueued},
+				{Name: "passed", Status: CheckRunStatusCompleted, Conclusion: CheckRunConclusionSuccess},
+				{Name: "neutral", Status: CheckRunStatusCompleted, Conclusion: CheckRunConclusionNeutral},
+				{Name: "timed-out", Status: CheckRunStatusCompleted, Conclusion: CheckRunConclusionTimedOut},
+			},
+			expectedState: StatusFailure,
+			expectedContexts: map[string]string{
+				"queued":    StatusPending,
+				"passed":    StatusSuccess,
+				"neutral":   StatusSuccess,
+				"timed-out": StatusFailure,
+			},
+		},
+		{
+			name: "the worse state wins when a status and a check run share a name",
+			statuses: []Status{
+				{Context: "build", State: StatusSuccess},
+				{Context: "lint", State: StatusFailure},
+				{Context: "test", State: StatusPending},
+			},
+			runs: []CheckRun{
+				{Name: "build", Status: CheckRunStatusInProgress},
+				{Name: "lint", Status: CheckRunStatusCompleted, Conclusion: CheckRunConclusionSuccess},
+				{Name: "test", Status: CheckRunStatusCompleted, Conclusion: CheckRunConclusionSuccess},
+			},
+			expectedState: StatusFailure,
+			expectedContexts: map[string]string{
+				"build": StatusPending,
+				"lint":  StatusFailure,
+				"test":  StatusPending,
+			},
+		},
+		{
+			name: "errors take precedence over failures",
+			statuses: []Status{
+				{Context: "build", State: StatusError},
+			},
+			runs: []CheckRun{
+				{Name: "build", Status: CheckRunStatusCompleted, Conclusion: CheckRunConclusionFailure},
+			},
+			expectedState:    StatusError,
+			expectedContexts: map[string]string{"build": StatusError},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rollup := NewStatusRollup("sha", tc.statuses, tc.runs)
+			if rollup.SHA != "sha" {
+				t.Errorf("expected SHA %q, got %q", "sha", rollup.SHA)
+			}
+			if rollup.State != tc.expectedState {
+				t.Errorf("expected state %q, got %q", tc.expectedState, rollup.State)
+			}
+			if !reflect.DeepEqual(rollup.Contexts, tc.expectedContexts) {
+				t.Errorf("expected contexts %v, got %v", tc.expectedContexts, rollup.Contexts)
+			}
+		})
+	}
+}
@@ -67,21 +67,21 @@ func (s *Server) handleReviewEvent(l *logrus.Entry, re github.ReviewEvent) {
 		"url":               re.Review.HTMLURL,
 	})
 	l.Infof("Review %s.", re.Action)
+	work := map[string]func() error{}
 	for p, h := range s.Plugins.ReviewEventHandlers(re.PullRequest.Base.Repo.Owner.Login, re.PullRequest.Base.Repo.Name) {
-		s.wg.Add(1)
-		go func(p string, h plugins.ReviewEventHandler) {
-			defer s.wg.Done()
+		p, h := p, h
+		work[p] = func() error {
 			agent := plugins.NewAgent(s.ConfigAgent, s.Plugins, s.ClientAgent, s.Metrics.Metrics, l.WithField("plugin", p))
+			agent.DeliveryID = re.GUID
 			agent.InitializeCommentPruner(
 				re.Repo.Owner.Login,
 				re.Repo.Name,
 				re.PullRequest.Number,
 			)
-			if err := h(agent, re); err != nil {
-				agent.Logger.WithError(err).Error("Error handling ReviewEvent.")
-			}
-		}(p, h)
+			return h(agent, re)
+		}
 	}
+	s.dispatchPlugins(l, "ReviewEvent", work)
 	action := genericCommentAction(string(re.Action))
 	if action == "" {
 		l.Errorf(failedCommentCoerceFmt, "pull_request_review", string(re.Action))
@@ -118,21 +118,21 @@ func (s *Server) handleReviewCommentEvent(l *logrus.Entry, rce github.ReviewComm
 		"url":               rce.Comment.HTMLURL,
 	})
 	l.Infof("Review comment %s.", rce.Action)
+	work := map[string]func() error{}
 	for p, h := range s.Plugins.ReviewCommentEventHandlers(rce.PullRequest.Base.Repo.Owner.Login, rce.PullRequest.Base.Repo.Name) {
-		s.wg.Add(1)
-		go func(p string, h plugins.ReviewCommentEventHandler) {
-			defer s.wg.Done()
+		p, h := p, h
+		work[p] = func() error {
 			agent := plugins.NewAgent(s.ConfigAgent, s.Plugins, s.ClientAgent, s.Metrics.Metrics, l.WithField("plugin", p))
+			agent.DeliveryID = rce.GUID
 			agent.InitializeCommentPruner(
 				rce.Repo.Owner.Login,
 				rce.Repo.Name,
 				rce.PullRequest.Number,
 			)
-			if err := h(agent, rce); err != nil {
-				agent.Logger.WithError(err).Error("Error handling ReviewCommentEvent.")
-			}
-		}(p, h)
+			return h(agent, rce)
+		}
 	}
+	s.dispatchPlugins(l, "ReviewCommentEvent", work)
 	action := genericCommentAction(string(rce.Action))
 	if action == "" {
 		l.Errorf(failedCommentCoerceFmt, "pull_request_review_comment", string(rce.Action))
@@ -168,21 +168,21 @@ func (s *Server) handlePullRequestEvent(l *logrus.Entry, pr github.PullRequestEv
 		"url":               pr.PullRequest.HTMLURL,
 	})
 	l.Infof("Pull request %s.", pr.Action)
+	work := map[string]func() error{}
 	for p, h := range s.Plugins.PullRequestHandlers(pr.PullRequest.Base.Repo.Owner.Login, pr.PullRequest.Base.Repo.Name) {
-		s.wg.Add(1)
-		go func(p string, h plugins.PullRequestHandler) {
-			defer s.wg.Done()
+		p, h := p, h
+		work[p] = func() error {
 			agent := plugins.NewAgent(s.ConfigAgent, s.Plugins, s.ClientAgent, s.Metrics.Metrics, l.WithField("plugin", p))
+			agent.DeliveryID = pr.GUID
 			agent.InitializeCommentPruner(
 				pr.Repo.Owner.Login,
 				pr.Repo.Name,
 				pr.PullRequest.Number,
 			)
-			if err := h(agent, pr); err != nil {
-				agent.Logger.WithError(err).Error("Error handling PullRequestEvent.")
-			}
-		}(p, h)
+			return h(agent, pr)
+		}
 	}
+	s.dispatchPlugins(l, "PullRequestEvent", work)
 	action := genericCommentAction(string(pr.Action))
 	if action == "" {
 		if !nonCommentPullRequestActions[pr.Action] {
@@ -220,16 +220,16 @@ func (s *Server) handlePushEvent(l *logrus.Entry, pe github.PushEvent) {
 		"head":              pe.After,
 	})
 	l.Info("Push event.")
+	work := map[string]func() error{}
 	for p, h := range s.Plugins.PushEventHandlers(pe.Repo.Owner.Name, pe.Repo.Name) {
-		s.wg.Add(1)
-		go func(p string, h plugins.PushEventHandler) {
-			defer s.wg.Done()
+		p, h := p, h
+		work[p] = func() error {
 			agent := plugins.NewAgent(s.ConfigAgent, s.Plugins, s.ClientAgent, s.Metrics.Metrics, l.WithField("plugin", p))
-			if err := h(agent, pe); err != nil {
-				agent.Logger.WithError(err).Error("Error handling PushEvent.")
-			}
-		}(p, h)
+			agent.DeliveryID = pe.GUID
+			return h(agent, pe)
+		}
 	}
+	s.dispatchPlugins(l, "PushEvent", work)
 }
 
 func (s *Server) handleIssueEvent(l *logrus.Entry, i github.IssueEvent) {
@@ -242,21 +242,21 @@ func (s *Server) handleIssueEvent(l *logrus.Entry, i github.IssueEvent) {
 		"url":               i.Issue.HTMLURL,
 	})
 	l.Infof("Issue %s.", i.Action)
+	work := map[string]func() error{}
 	for p, h := range s.Plugins.IssueHandlers(i.Repo.Owner.Login, i.Repo.Name) {
-		s.wg.Add(1)
-		go func(p string, h plugins.IssueHandler) {
-			defer s.wg.Done()
+		p, h := p, h
+		work[p] = func() error {
 			agent := plugins.NewAgent(s.ConfigAgent, s.Plugins, s.ClientAgent, s.Metrics.Metrics, l.WithField("plugin", p))
+			agent.DeliveryID = i.GUID
 			agent.InitializeCommentPruner(
 				i.Repo.Owner.Login,
 				i.Repo.Name,
 				i.Issue.Number,
 			)
-			if err := h(agent, i); err != nil {
-				agent.Logger.WithError(err).Error("Error handling IssueEvent.")
-			}
-		}(p, h)
+			return h(agent, i)
+		}
 	}
+	s.dispatchPlugins(l, "IssueEvent", work)
 	action := genericCommentAction(string(i.Action))
 	if action == "" {
 		if !nonCommentIssueActions[i.Action] {
@@ -295,21 +295,21 @@ func (s *Server) handleIssueCommentEvent(l *logrus.Entry, ic github.IssueComment
 		"url":               ic.Comment.HTMLURL,
 	})
 	l.Infof("Issue comment %s.", ic.Action)
+	work := map[string]func() error{}
 	for p, h := range s.Plugins.IssueCommentHandlers(ic.Repo.Owner.Login, ic.Repo.Name) {
-		s.wg.Add(1)
-		go func(p string, h plugins.IssueCommentHandler) {
-			defer s.wg.Done()
+		p, h := p, h
+		work[p] = func() error {
 			agent := plugins.NewAgent(s.ConfigAgent, s.Plugins, s.ClientAgent, s.Metrics.Metrics, l.WithField("plugin", p))
+			agent.DeliveryID = ic.GUID
 			agent.InitializeCommentPruner(
 				ic.Repo.Owner.Login,
 				ic.Repo.Name,
 				ic.Issue.Number,
 			)
-			if err := h(agent, ic); err != nil {
-				agent.Logger.WithError(err).Error("Error handling IssueCommentEvent.")
-			}
-		}(p, h)
+			return h(agent, ic)
+		}
 	}
+	s.dispatchPlugins(l, "IssueCommentEvent", work)
 	action := genericCommentAction(string(ic.Action))
 	if action == "" {
 		l.Errorf(failedCommentCoerceFmt, "issue_comment", string(ic.Action))
@@ -347,16 +347,16 @@ func (s *Server) handleStatusEvent(l *logrus.Entry, se github.StatusEvent) {
 		"id":                se.ID,
 	})
 	l.Infof("Status description %s.", se.Description)
+	work := map[string]func() error{}
 	for p, h := range s.Plugins.StatusEventHandlers(se.Repo.Owner.Login, se.Repo.Name) {
-		s.wg.Add(1)
-		go func(p string, h plugins.StatusEventHandler) {
-			defer s.wg.Done()
+		p, h := p, h
+		work[p] = func() error {
 			agent := plugins.NewAgent(s.ConfigAgent, s.Plugins, s.ClientAgent, s.Metrics.Metrics, l.WithField("plugin", p))
-			if err := h(agent, se); err != nil {
-				agent.Logger.WithError(err).Error("Error handling StatusEvent.")
-			}
-		}(p, h)
+			agent.DeliveryID = se.GUID
+			return h(agent, se)
+		}
 	}
+	s.dispatchPlugins(l, "StatusEvent", work)
 }
 
 // genericCommentAction normalizes the action string to a GenericCommentEventAction or returns ""
@@ -375,19 +375,19 @@ func genericCommentAction(action string) github.GenericCommentEventAction {
 }
 
 func (s *Server) handleGenericComment(l *logrus.Entry, ce *github.GenericCommentEvent) {
+	work := map[string]func() error{}
 	for p, h := range s.Plugins.GenericCommentHandlers(ce.Repo.Owner.Login, ce.Repo.Name) {
-		s.wg.Add(1)
-		go func(p string, h plugins.GenericCommentHandler) {
-			defer s.wg.Done()
+		p, h := p, h
+		work[p] = func() error {
 			agent := plugins.NewAgent(s.ConfigAgent, s.Plugins, s.ClientAgent, s.Metrics.Metrics, l.WithField("plugin", p))
+			agent.DeliveryID = ce.GUID
 			agent.InitializeCommentPruner(
 				ce.Repo.Owner.Login,
 				ce.Repo.Name,
 				ce.Number,
 			)
-			if err := h(agent, *ce); err != nil {
-				agent.Logger.WithError(err).Error("Error handling GenericCommentEvent.")
-			}
-		}(p, h)
+			return h(agent, *ce)
+		}
 	}
+	s.dispatchPlugins(l, "GenericCommentEvent", work)
 }
@@ -43,6 +43,9 @@ type Server struct {
 	ConfigAgent    *config.Agent
 	TokenGenerator func() []byte
 	Metrics        *Metrics
+	// PluginConcurrency bounds how many plugins may handle a single
+	// event in parallel. Zero means no bound.
+	PluginConcurrency int
 
 	// c is an http client used for dispatching events
 	// to external plugin services.
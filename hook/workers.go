@@ -0,0 +1,103 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hook
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/clarketm/prow/plugins"
+)
+
+// dispatchPlugins runs the handlers of the plugins subscribed to an event in the
+// background and logs the errors they return.
+func (s *Server) dispatchPlugins(l *logrus.Entry, event string, work map[string]func() error) {
+	if len(work) == 0 {
+		return
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if err := runPlugins(work, s.PluginConcurrency, plugins.SerialPlugins()); err != nil {
+			l.WithError(err).Errorf("Error handling %s.", event)
+		}
+	}()
+}
+
+// runPlugins runs the handlers of the plugins handling an event, keyed by
+// plugin name, and blocks until they are all done. At most concurrency
+// handlers run in parallel, or all of them if concurrency is not positive.
+// Handlers of the serial plugins run one after the other, in name order,
+// alongside the others. The errors of all handlers are aggregated.
+func runPlugins(work map[string]func() error, concurrency int, serial sets.String) error {
+	var parallel, ordered []string
+	for name := range work {
+		if serial.Has(name) {
+			ordered = append(ordered, name)
+		} else {
+			parallel = append(parallel, name)
+		}
+	}
+	sort.Strings(ordered)
+	if concurrency <= 0 || concurrency > len(parallel) {
+		concurrency = len(parallel)
+	}
+
+	var errLock sync.Mutex
+	var errs []error
+	run := func(name string) {
+		if err := work[name](); err != nil {
+			errLock.Lock()
+			errs = append(errs, fmt.Errorf("plugin %s: %v", name, err))
+			errLock.Unlock()
+		}
+	}
+
+	var wg sync.WaitGroup
+	queue := make(chan string, len(parallel))
+	for _, name := range parallel {
+		queue <- name
+	}
+	close(queue)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for name := range queue {
+				run(name)
+			}
+		}()
+	}
+	if len(ordered) > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, name := range ordered {
+				run(name)
+			}
+		}()
+	}
+	wg.Wait()
+
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+	return utilerrors.NewAggregate(errs)
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hook
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// tracker records how many fake plugins run at the same time.
+type tracker struct {
+	lock    sync.Mutex
+	running int
+	max     int
+	order   []string
+}
+
+func (t *tracker) plugin(name string, err error) func() error {
+	return func() error {
+		t.lock.Lock()
+		t.running++
+		if t.running > t.max {
+			t.max = t.running
+		}
+		t.order = append(t.order, name)
+		t.lock.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		t.lock.Lock()
+		t.running--
+		t.lock.Unlock()
+		return err
+	}
+}
+
+func TestRunPluginsConcurrency(t *testing.T) {
+	testCases := []struct {
+		name        string
+		plugins     int
+		concurrency int
+		expectedMax int
+	}{
+		{
+			name:        "concurrency bounds parallel plugins",
+			plugins:     10,
+			concurrency: 3,
+			expectedMax: 3,
+		},
+		{
+			name:        "single worker runs plugins one at a time",
+			plugins:     5,
+			concurrency: 1,
+			expectedMax: 1,
+		},
+		{
+			name:        "no bound runs all plugins in parallel",
+			plugins:     5,
+			concurrency: 0,
+			expectedMax: 5,
+		},
+		{
+			name:        "bound larger than the plugin count runs all plugins in parallel",
+			plugins:     2,
+			concurrency: 10,
+			expectedMax: 2,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := &tracker{}
+			work := map[string]func() error{}
+			for i := 0; i < tc.plugins; i++ {
+				name := fmt.Sprintf("plugin-%d", i)
+				work[name] = tr.plugin(name, nil)
+			}
+			if err := runPlugins(work, tc.concurrency, sets.NewString()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(tr.order) != tc.plugins {
+				t.Errorf("expected %d plugins to run, got %d", tc.plugins, len(tr.order))
+			}
+			if tr.max > tc.expectedMax {
+				t.Errorf("expected at most %d plugins running at once, got %d", tc.expectedMax, tr.max)
+			}
+			if tc.concurrency == 0 && tr.max != tc.expectedMax {
+				t.Errorf("expected all %d plugins to run at once, got %d", tc.expectedMax, tr.max)
+			}
+		})
+	}
+}
+
+func TestRunPluginsSerial(t *testing.T) {
+	tr := &tracker{}
+	work := map[string]func() error{
+		"c": tr.plugin("c", nil),
+		"a": tr.plugin("a", nil),
+		"b": tr.plugin("b", nil),
+	}
+	if err := runPlugins(work, 0, sets.NewString("a", "b", "c")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tr.max != 1 {
+		t.Errorf("expected serial plugins to run one at a time, got %d at once", tr.max)
+	}
+	if got := strings.Join(tr.order, ","); got != "a,b,c" {
+		t.Errorf("expected serial plugins to run in name order, got %s", got)
+	}
+}
+
+func TestRunPluginsErrors(t *testing.T) {
+	tr := &tracker{}
+	work := map[string]func() error{
+		"ok":      tr.plugin("ok", nil),
+		"broken":  tr.plugin("broken", errors.New("oops")),
+		"ordered": tr.plugin("ordered", errors.New("also oops")),
+	}
+	err := runPlugins(work, 2, sets.NewString("ordered"))
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	expected := "[plugin broken: oops, plugin ordered: also oops]"
+	if err.Error() != expected {
+		t.Errorf("expected aggregated error %q, got %q", expected, err.Error())
+	}
+	if len(tr.order) != 3 {
+		t.Errorf("expected every plugin to run despite errors, got %v", tr.order)
+	}
+}
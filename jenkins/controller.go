@@ -219,8 +219,14 @@ func (c *Controller) Sync() error {
 	var reportErrs []error
 	reportTemplate := c.config().ReportTemplate
 	reportTypes := c.cfg().GitHubReporter.JobTypesToReport
+	statusGroups := c.cfg().GitHubReporter.StatusGroups
+	var reports []prowapi.ProwJob
 	for report := range reportCh {
-		if err := reportlib.Report(c.ghc, reportTemplate, report, reportTypes); err != nil {
+		reports = append(reports, report)
+	}
+	jobs := reportlib.StaticJobLister(pjs.Items, reports...)
+	for _, report := range reports {
+		if err := reportlib.Report(c.ghc, reportTemplate, report, reportTypes, statusGroups, jobs); err != nil {
 			reportErrs = append(reportErrs, err)
 			c.log.WithFields(pjutil.ProwJobFields(&report)).WithError(err).Warn("Failed to report ProwJob status")
 		}
@@ -50,4 +50,16 @@ const (
 	// PullLabel is added in resources created by prow and
 	// carries the PR number associated with the job, eg 321.
 	PullLabel = "prow.k8s.io/refs.pull"
+	// DeliveryIDAnnotation is added to ProwJobs created in response to a
+	// GitHub webhook and carries the X-GitHub-Delivery ID of that webhook.
+	DeliveryIDAnnotation = "prow.k8s.io/github-delivery-id"
+	// PodTTLAnnotation can be set on a job to override how long sinker
+	// keeps its completed pods around, eg 72h. It is propagated to the
+	// pods through the ProwJob annotations. It cannot exceed sinker's
+	// max_prowjob_age, as pods are deleted along with their ProwJob.
+	PodTTLAnnotation = "prow.k8s.io/pod-ttl"
+	// TideRetestCountAnnotation is added to the presubmits Tide triggers for
+	// a single PR and counts how many times Tide triggered the job for the
+	// head of the PR, including this run.
+	TideRetestCountAnnotation = "prow.k8s.io/tide-retest-count"
 )
@@ -19,6 +19,8 @@ package logrusutil
 
 import (
 	"bytes"
+	"flag"
+	"fmt"
 	"strings"
 
 	"github.com/sirupsen/logrus"
@@ -58,6 +60,41 @@ func ComponentInit(component string) {
 	)
 }
 
+// LogLevelOptions holds the --log-level flag shared by components.
+// An empty level leaves the level to the log_level of the Prow config.
+type LogLevelOptions struct {
+	LogLevel string
+}
+
+// AddFlags injects the --log-level flag into the given FlagSet.
+func (o *LogLevelOptions) AddFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.LogLevel, "log-level", "", fmt.Sprintf("Logging level, one of %v. Overrides the log_level of the Prow config if set.", logrus.AllLevels))
+}
+
+// Validate validates that the log level is known to logrus.
+func (o *LogLevelOptions) Validate() error {
+	if o.LogLevel == "" {
+		return nil
+	}
+	if _, err := logrus.ParseLevel(o.LogLevel); err != nil {
+		return fmt.Errorf("--log-level invalid: %v", err)
+	}
+	return nil
+}
+
+// Apply sets the level of the standard logger if a level is set.
+func (o *LogLevelOptions) Apply() error {
+	if o.LogLevel == "" {
+		return nil
+	}
+	level, err := logrus.ParseLevel(o.LogLevel)
+	if err != nil {
+		return fmt.Errorf("--log-level invalid: %v", err)
+	}
+	logrus.SetLevel(level)
+	return nil
+}
+
 // Format implements logrus.Formatter's Format. We allocate a new Fields
 // map in order to not modify the caller's Entry, as that is not a thread
 // safe operation.
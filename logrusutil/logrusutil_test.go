@@ -17,6 +17,7 @@ limitations under the License.
 package logrusutil
 
 import (
+	"flag"
 	"fmt"
 	"testing"
 
@@ -127,3 +128,56 @@ func TestCensoringFormatterWithCornerCases(t *testing.T) {
 		})
 	}
 }
+
+func TestLogLevelOptions(t *testing.T) {
+	testCases := []struct {
+		name          string
+		args          []string
+		expectedLevel logrus.Level
+		expectedErr   bool
+	}{
+		{
+			name:          "unset level leaves the level alone",
+			expectedLevel: logrus.PanicLevel,
+		},
+		{
+			name:          "explicit level is applied",
+			args:          []string{"--log-level=debug"},
+			expectedLevel: logrus.DebugLevel,
+		},
+		{
+			name:        "unknown level is rejected",
+			args:        []string{"--log-level=chatty"},
+			expectedErr: true,
+		},
+	}
+
+	originalLevel := logrus.GetLevel()
+	defer logrus.SetLevel(originalLevel)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			logrus.SetLevel(logrus.PanicLevel)
+			var o LogLevelOptions
+			fs := flag.NewFlagSet("fake-flags", flag.PanicOnError)
+			o.AddFlags(fs)
+			if err := fs.Parse(tc.args); err != nil {
+				t.Fatalf("failed to parse flags: %v", err)
+			}
+			if err := o.Validate(); err != nil {
+				if !tc.expectedErr {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if tc.expectedErr {
+				t.Fatal("expected an error, got none")
+			}
+			if err := o.Apply(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if level := logrus.GetLevel(); level != tc.expectedLevel {
+				t.Errorf("expected level %v, got %v", tc.expectedLevel, level)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pjutil
+
+import (
+	"sort"
+	"sync"
+
+	prowapi "github.com/clarketm/prow/apis/prowjobs/v1"
+)
+
+// SpecDefaulter sets instance specific defaults on new ProwJobs, e.g. a
+// label every job should have or the cluster jobs run in by default.
+type SpecDefaulter interface {
+	// Default mutates the new ProwJob in place.
+	Default(pj *prowapi.ProwJob)
+}
+
+var (
+	specDefaultersLock sync.RWMutex
+	specDefaulters     = map[string]SpecDefaulter{}
+)
+
+// RegisterSpecDefaulter registers a SpecDefaulter that NewProwJob invokes on
+// every ProwJob it creates. Defaulters are invoked in the order of their names
+// and registering a defaulter under an existing name replaces it.
+func RegisterSpecDefaulter(name string, defaulter SpecDefaulter) {
+	specDefaultersLock.Lock()
+	defer specDefaultersLock.Unlock()
+	specDefaulters[name] = defaulter
+}
+
+// UnregisterSpecDefaulter removes the SpecDefaulter registered under the name.
+func UnregisterSpecDefaulter(name string) {
+	specDefaultersLock.Lock()
+	defer specDefaultersLock.Unlock()
+	delete(specDefaulters, name)
+}
+
+func applySpecDefaulters(pj *prowapi.ProwJob) {
+	specDefaultersLock.RLock()
+	defer specDefaultersLock.RUnlock()
+	var names []string
+	for name := range specDefaulters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		specDefaulters[name].Default(pj)
+	}
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pjutil
+
+import (
+	"testing"
+
+	prowapi "github.com/clarketm/prow/apis/prowjobs/v1"
+)
+
+type fakeSpecDefaulter struct {
+	label, cluster string
+	calls          *[]string
+}
+
+func (f fakeSpecDefaulter) Default(pj *prowapi.ProwJob) {
+	*f.calls = append(*f.calls, f.label)
+	pj.Labels[f.label] = "true"
+	if pj.Spec.Cluster == "" {
+		pj.Spec.Cluster = f.cluster
+	}
+}
+
+func TestNewProwJobSpecDefaulters(t *testing.T) {
+	var calls []string
+	RegisterSpecDefaulter("b", fakeSpecDefaulter{label: "second", cluster: "ignored", calls: &calls})
+	RegisterSpecDefaulter("a", fakeSpecDefaulter{label: "first", cluster: "build", calls: &calls})
+	defer UnregisterSpecDefaulter("a")
+	defer UnregisterSpecDefaulter("b")
+
+	spec := prowapi.ProwJobSpec{Job: "job", Type: prowapi.PeriodicJob}
+	pj := NewProwJob(spec, nil, nil)
+	if pj.Spec.Cluster != "build" {
+		t.Errorf("expected the first defaulter to set the cluster to %q, got %q", "build", pj.Spec.Cluster)
+	}
+	for _, label := range []string{"first", "second"} {
+		if pj.Labels[label] != "true" {
+			t.Errorf("expected label %q to be set, got labels %v", label, pj.Labels)
+		}
+	}
+	if len(calls) != 2 || calls[0] != "first" || calls[1] != "second" {
+		t.Errorf("expected defaulters to be invoked in the order of their names, got %v", calls)
+	}
+	if spec.Cluster != "" {
+		t.Errorf("expected the spec passed to NewProwJob to be left alone, got cluster %q", spec.Cluster)
+	}
+
+	UnregisterSpecDefaulter("a")
+	UnregisterSpecDefaulter("b")
+	calls = nil
+	pj = NewProwJob(spec, nil, nil)
+	if len(calls) != 0 || pj.Spec.Cluster != "" {
+		t.Errorf("expected unregistered defaulters not to be invoked, got calls %v and cluster %q", calls, pj.Spec.Cluster)
+	}
+}
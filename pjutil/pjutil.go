@@ -36,11 +36,12 @@ import (
 	"github.com/clarketm/prow/pod-utils/downwardapi"
 )
 
-// NewProwJob initializes a ProwJob out of a ProwJobSpec.
+// NewProwJob initializes a ProwJob out of a ProwJobSpec. The registered
+// SpecDefaulters are applied to the new ProwJob.
 func NewProwJob(spec prowapi.ProwJobSpec, extraLabels, extraAnnotations map[string]string) prowapi.ProwJob {
 	labels, annotations := decorate.LabelsAndAnnotationsForSpec(spec, extraLabels, extraAnnotations)
 
-	return prowapi.ProwJob{
+	pj := prowapi.ProwJob{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "prow.k8s.io/v1",
 			Kind:       "ProwJob",
@@ -56,6 +57,8 @@ func NewProwJob(spec prowapi.ProwJobSpec, extraLabels, extraAnnotations map[stri
 			State:     prowapi.TriggeredState,
 		},
 	}
+	applySpecDefaulters(&pj)
+	return pj
 }
 
 func createRefs(pr github.PullRequest, baseSHA string) prowapi.Refs {
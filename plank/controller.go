@@ -276,8 +276,14 @@ func (c *Controller) Sync() error {
 	if !c.skipReport {
 		reportTemplate := c.config().Plank.ReportTemplate
 		reportTypes := c.config().GitHubReporter.JobTypesToReport
+		statusGroups := c.config().GitHubReporter.StatusGroups
+		var reports []prowapi.ProwJob
 		for report := range reportCh {
-			if err := reportlib.Report(c.ghc, reportTemplate, report, reportTypes); err != nil {
+			reports = append(reports, report)
+		}
+		jobs := reportlib.StaticJobLister(pjs.Items, reports...)
+		for _, report := range reports {
+			if err := reportlib.Report(c.ghc, reportTemplate, report, reportTypes, statusGroups, jobs); err != nil {
 				reportErrs = append(reportErrs, err)
 				c.log.WithFields(pjutil.ProwJobFields(&report)).WithError(err).Warn("Failed to report ProwJob status")
 			}
@@ -554,6 +560,9 @@ func (c *Controller) startPod(pj prowapi.ProwJob) (string, string, error) {
 	if err != nil {
 		return "", "", err
 	}
+	for _, hint := range c.config().Plank.PodSchedulingHintsFor(pj.Labels) {
+		decorate.ApplySchedulingHints(pod, hint.Annotations, hint.Affinity, hint.Tolerations)
+	}
 
 	client, ok := c.buildClients[pj.ClusterAlias()]
 	if !ok {
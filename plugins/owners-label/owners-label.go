@@ -51,7 +51,7 @@ type githubClient interface {
 	AddLabel(org, repo string, number int, label string) error
 	GetIssueLabels(org, repo string, number int) ([]github.Label, error)
 	GetRepoLabels(owner, repo string) ([]github.Label, error)
-	GetPullRequestChanges(org, repo string, number int) ([]github.PullRequestChange, error)
+	GetPullRequestChangesLite(org, repo string, number int) ([]github.PullRequestChange, error)
 }
 
 func handlePullRequest(pc plugins.Agent, pre github.PullRequestEvent) error {
@@ -73,7 +73,7 @@ func handle(ghc githubClient, oc ownersClient, log *logrus.Entry, pre *github.Pu
 	number := pre.Number
 
 	// First see if there are any labels requested based on the files changed.
-	changes, err := ghc.GetPullRequestChanges(org, repo, number)
+	changes, err := ghc.GetPullRequestChangesLite(org, repo, number)
 	if err != nil {
 		return fmt.Errorf("error getting PR changes: %v", err)
 	}
@@ -26,6 +26,7 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/kubernetes"
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"sigs.k8s.io/yaml"
@@ -51,6 +52,7 @@ var (
 	reviewEventHandlers        = map[string]ReviewEventHandler{}
 	reviewCommentEventHandlers = map[string]ReviewCommentEventHandler{}
 	statusEventHandlers        = map[string]StatusEventHandler{}
+	serialPlugins              = sets.NewString()
 	CommentMap                 = genyaml.NewCommentMap("prow/plugins/config.go")
 )
 
@@ -135,6 +137,17 @@ func RegisterGenericCommentHandler(name string, fn GenericCommentHandler, help H
 	genericCommentHandlers[name] = fn
 }
 
+// RegisterSerialPlugin marks a plugin as sensitive to the order in which it handles
+// events, opting it out of handling an event concurrently with other serial plugins.
+func RegisterSerialPlugin(name string) {
+	serialPlugins.Insert(name)
+}
+
+// SerialPlugins returns the names of the plugins registered with RegisterSerialPlugin.
+func SerialPlugins() sets.String {
+	return sets.NewString(serialPlugins.UnsortedList()...)
+}
+
 // Agent may be used concurrently, so each entry must be thread-safe.
 type Agent struct {
 	GitHubClient              github.Client
@@ -158,6 +171,9 @@ type Agent struct {
 
 	Logger *logrus.Entry
 
+	// DeliveryID is the X-GitHub-Delivery ID of the webhook being handled.
+	DeliveryID string
+
 	// may be nil if not initialized
 	commentPruner *commentpruner.EventClient
 }
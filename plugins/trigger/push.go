@@ -73,6 +73,7 @@ func handlePE(c Client, pe github.PushEvent) error {
 		}
 		labels[github.EventGUID] = pe.GUID
 		pj := pjutil.NewProwJob(pjutil.PostsubmitSpec(j, refs), labels, j.Annotations)
+		annotateDeliveryID(&pj, c.DeliveryID)
 		c.Logger.WithFields(pjutil.ProwJobFields(&pj)).Info("Creating a new prowjob.")
 		if _, err := c.ProwJobClient.Create(&pj); err != nil {
 			return err
@@ -21,12 +21,15 @@ import (
 	"strings"
 
 	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/sets"
 	prowapi "github.com/clarketm/prow/apis/prowjobs/v1"
 	"github.com/clarketm/prow/config"
 	"github.com/clarketm/prow/errorutil"
 	"github.com/clarketm/prow/git"
 	"github.com/clarketm/prow/github"
+	"github.com/clarketm/prow/kube"
 	"github.com/clarketm/prow/pjutil"
 	"github.com/clarketm/prow/pluginhelp"
 	"github.com/clarketm/prow/plugins"
@@ -117,6 +120,7 @@ type trustedPullRequestClient interface {
 
 type prowJobClient interface {
 	Create(*prowapi.ProwJob) (*prowapi.ProwJob, error)
+	List(opts metav1.ListOptions) (*prowapi.ProwJobList, error)
 }
 
 // Client holds the necessary structures to work with prow via logging, github, kubernetes and its configuration.
@@ -128,6 +132,8 @@ type Client struct {
 	Config        *config.Config
 	Logger        *logrus.Entry
 	GitClient     *git.Client
+	// DeliveryID is the ID of the webhook delivery being handled, if any.
+	DeliveryID string
 }
 
 // trustedUserClient is used to check is user member and repo collaborator
@@ -143,6 +149,7 @@ func getClient(pc plugins.Agent) Client {
 		ProwJobClient: pc.ProwJobClient,
 		Logger:        pc.Logger,
 		GitClient:     pc.GitClient,
+		DeliveryID:    pc.DeliveryID,
 	}
 }
 
@@ -240,6 +247,15 @@ func runRequested(c Client, pr *github.PullRequest, baseSHA string, requestedJob
 	for _, job := range requestedJobs {
 		c.Logger.Infof("Starting %s build.", job.Name)
 		pj := pjutil.NewPresubmit(*pr, baseSHA, job, eventGUID)
+		annotateDeliveryID(&pj, c.DeliveryID)
+		if duplicate, err := isDuplicate(c, &pj); err != nil {
+			c.Logger.WithError(err).Error("Failed to check for a duplicate prowjob.")
+			errors = append(errors, err)
+			continue
+		} else if duplicate {
+			c.Logger.WithFields(pjutil.ProwJobFields(&pj)).Info("Skipping a duplicate prowjob.")
+			continue
+		}
 		c.Logger.WithFields(pjutil.ProwJobFields(&pj)).Info("Creating a new prowjob.")
 		if _, err := c.ProwJobClient.Create(&pj); err != nil {
 			c.Logger.WithError(err).Error("Failed to create prowjob.")
@@ -249,6 +265,56 @@ func runRequested(c Client, pr *github.PullRequest, baseSHA string, requestedJob
 	return errorutil.NewAggregate(errors...)
 }
 
+// isDuplicate determines whether the same presubmit was already created for
+// the same PR head and webhook delivery within the configured dedupe window.
+func isDuplicate(c Client, pj *prowapi.ProwJob) (bool, error) {
+	if c.Config == nil {
+		return false, nil
+	}
+	window := c.Config.Plank.DedupeWindow
+	if window == nil || window.Duration <= 0 || pj.Spec.Refs == nil || len(pj.Spec.Refs.Pulls) == 0 {
+		return false, nil
+	}
+	guid := pj.Labels[github.EventGUID]
+	if guid == "" {
+		return false, nil
+	}
+	selector := labels.Set{github.EventGUID: guid}
+	for _, label := range []string{kube.ProwJobTypeLabel, kube.OrgLabel, kube.RepoLabel, kube.PullLabel} {
+		if value, ok := pj.Labels[label]; ok {
+			selector[label] = value
+		}
+	}
+	existing, err := c.ProwJobClient.List(metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return false, fmt.Errorf("failed to list prowjobs: %v", err)
+	}
+	for _, other := range existing.Items {
+		if other.Spec.Job != pj.Spec.Job || other.Spec.Refs == nil || len(other.Spec.Refs.Pulls) == 0 {
+			continue
+		}
+		if other.Spec.Refs.Pulls[0].SHA != pj.Spec.Refs.Pulls[0].SHA {
+			continue
+		}
+		if pj.Status.StartTime.Sub(other.Status.StartTime.Time) < window.Duration {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// annotateDeliveryID records the ID of the webhook delivery that caused the
+// ProwJob to be created, so the job can be correlated back to the webhook.
+func annotateDeliveryID(pj *prowapi.ProwJob, deliveryID string) {
+	if deliveryID == "" {
+		return
+	}
+	if pj.Annotations == nil {
+		pj.Annotations = map[string]string{}
+	}
+	pj.Annotations[kube.DeliveryIDAnnotation] = deliveryID
+}
+
 // skipRequested posts skipped statuses for the config.Presubmits that are requested
 func skipRequested(c Client, pr *github.PullRequest, skippedJobs []config.Presubmit) error {
 	var errors []error
@@ -19,6 +19,7 @@ package trigger
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -34,6 +35,8 @@ import (
 	"github.com/clarketm/prow/git"
 	"github.com/clarketm/prow/github"
 	"github.com/clarketm/prow/github/fakegithub"
+	"github.com/clarketm/prow/kube"
+	"github.com/clarketm/prow/pjutil"
 	"github.com/clarketm/prow/plugins"
 )
 
@@ -466,6 +469,181 @@ func TestRunRequested(t *testing.T) {
 	}
 }
 
+func TestRunRequestedAnnotatesDeliveryID(t *testing.T) {
+	var testCases = []struct {
+		name       string
+		deliveryID string
+		expected   map[string]string
+	}{
+		{
+			name:       "delivery ID is recorded as an annotation",
+			deliveryID: "72d3162e-cc78-11e3-81ab-4c9367dc0958",
+			expected:   map[string]string{kube.DeliveryIDAnnotation: "72d3162e-cc78-11e3-81ab-4c9367dc0958"},
+		},
+		{
+			name: "no annotation without a delivery ID",
+		},
+	}
+
+	pr := &github.PullRequest{
+		Base: github.PullRequestBranch{
+			Repo: github.Repo{
+				Owner: github.User{
+					Login: "org",
+				},
+				Name: "repo",
+			},
+			Ref: "branch",
+		},
+		Head: github.PullRequestBranch{
+			SHA: "foobar1",
+		},
+	}
+	requestedJobs := []config.Presubmit{{
+		JobBase: config.JobBase{
+			Name: "first",
+		},
+		Reporter: config.Reporter{Context: "first-context"},
+	}}
+
+	for _, testCase := range testCases {
+		fakeProwJobClient := fake.NewSimpleClientset()
+		client := getClient(plugins.Agent{
+			ProwJobClient: fakeProwJobClient.ProwV1().ProwJobs("prowjobs"),
+			Logger:        logrus.WithField("testcase", testCase.name),
+			DeliveryID:    testCase.deliveryID,
+		})
+		client.GitHubClient = &fakegithub.FakeClient{}
+
+		if err := runRequested(client, pr, fakegithub.TestRef, requestedJobs, "event-guid"); err != nil {
+			t.Fatalf("%s: expected no error but got one: %v", testCase.name, err)
+		}
+		existingProwJobs, err := fakeProwJobClient.ProwV1().ProwJobs("prowjobs").List(metav1.ListOptions{})
+		if err != nil {
+			t.Fatalf("%s: could not list current state of prow jobs: %v", testCase.name, err)
+		}
+		if len(existingProwJobs.Items) != 1 {
+			t.Fatalf("%s: expected one ProwJob, got %d", testCase.name, len(existingProwJobs.Items))
+		}
+		annotation, ok := existingProwJobs.Items[0].Annotations[kube.DeliveryIDAnnotation]
+		if expected, shouldExist := testCase.expected[kube.DeliveryIDAnnotation]; ok != shouldExist || annotation != expected {
+			t.Errorf("%s: expected delivery ID annotation %q, got %q", testCase.name, expected, annotation)
+		}
+	}
+}
+
+func TestRunRequestedDedupe(t *testing.T) {
+	pr := &github.PullRequest{
+		Base: github.PullRequestBranch{
+			Repo: github.Repo{
+				Owner: github.User{
+					Login: "org",
+				},
+				Name: "repo",
+			},
+			Ref: "branch",
+		},
+		Head: github.PullRequestBranch{
+			SHA: "foobar1",
+		},
+	}
+	job := config.Presubmit{
+		JobBase: config.JobBase{
+			Name: "first",
+		},
+		Reporter: config.Reporter{Context: "first-context"},
+	}
+
+	var testCases = []struct {
+		name     string
+		window   *metav1.Duration
+		existing func() prowapi.ProwJob
+		created  bool
+	}{
+		{
+			name:   "duplicate within the window is suppressed",
+			window: &metav1.Duration{Duration: time.Hour},
+			existing: func() prowapi.ProwJob {
+				return pjutil.NewPresubmit(*pr, fakegithub.TestRef, job, "event-guid")
+			},
+		},
+		{
+			name: "duplicate is created without a window",
+			existing: func() prowapi.ProwJob {
+				return pjutil.NewPresubmit(*pr, fakegithub.TestRef, job, "event-guid")
+			},
+			created: true,
+		},
+		{
+			name:   "duplicate outside of the window is created",
+			window: &metav1.Duration{Duration: time.Hour},
+			existing: func() prowapi.ProwJob {
+				pj := pjutil.NewPresubmit(*pr, fakegithub.TestRef, job, "event-guid")
+				pj.Status.StartTime = metav1.NewTime(pj.Status.StartTime.Add(-2 * time.Hour))
+				return pj
+			},
+			created: true,
+		},
+		{
+			name:   "job for another delivery is created",
+			window: &metav1.Duration{Duration: time.Hour},
+			existing: func() prowapi.ProwJob {
+				return pjutil.NewPresubmit(*pr, fakegithub.TestRef, job, "other-event-guid")
+			},
+			created: true,
+		},
+		{
+			name:   "job for another head is created",
+			window: &metav1.Duration{Duration: time.Hour},
+			existing: func() prowapi.ProwJob {
+				pj := pjutil.NewPresubmit(*pr, fakegithub.TestRef, job, "event-guid")
+				pj.Spec.Refs.Pulls[0].SHA = "foobar0"
+				return pj
+			},
+			created: true,
+		},
+		{
+			name:   "another job is created",
+			window: &metav1.Duration{Duration: time.Hour},
+			existing: func() prowapi.ProwJob {
+				other := job
+				other.Name = "second"
+				return pjutil.NewPresubmit(*pr, fakegithub.TestRef, other, "event-guid")
+			},
+			created: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			existing := testCase.existing()
+			existing.Namespace = "prowjobs"
+			fakeProwJobClient := fake.NewSimpleClientset(&existing)
+			client := Client{
+				GitHubClient:  &fakegithub.FakeClient{},
+				ProwJobClient: fakeProwJobClient.ProwV1().ProwJobs("prowjobs"),
+				Config:        &config.Config{ProwConfig: config.ProwConfig{Plank: config.Plank{DedupeWindow: testCase.window}}},
+				Logger:        logrus.WithField("testcase", testCase.name),
+			}
+
+			if err := runRequested(client, pr, fakegithub.TestRef, []config.Presubmit{job}, "event-guid"); err != nil {
+				t.Fatalf("expected no error but got one: %v", err)
+			}
+			prowJobs, err := fakeProwJobClient.ProwV1().ProwJobs("prowjobs").List(metav1.ListOptions{})
+			if err != nil {
+				t.Fatalf("could not list current state of prow jobs: %v", err)
+			}
+			expected := 1
+			if testCase.created {
+				expected = 2
+			}
+			if len(prowJobs.Items) != expected {
+				t.Errorf("expected %d ProwJobs, got %d", expected, len(prowJobs.Items))
+			}
+		})
+	}
+}
+
 func TestValidateContextOverlap(t *testing.T) {
 	var testCases = []struct {
 		name          string
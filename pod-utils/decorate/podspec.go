@@ -57,6 +57,11 @@ const (
 	outputMountName         = "output"
 	outputMountPath         = "/output"
 	oauthTokenFilename      = "oauth-token"
+	placeEntrypointName     = "place-entrypoint"
+	initUploadName          = "initupload"
+	sidecarName             = "sidecar"
+	cloneRefsTmpName        = "clonerefs-tmp"
+	cookiefileName          = "cookiefile"
 )
 
 // Labels returns a string slice with label consts from kube.
@@ -74,6 +79,17 @@ func VolumeMountPaths() []string {
 	return []string{logMountPath, codeMountPath, toolsMountPath, gcsCredentialsMountPath}
 }
 
+// ContainerNames returns a string slice with the names of the containers decoration adds.
+func ContainerNames() []string {
+	return []string{cloneRefsName, placeEntrypointName, initUploadName, sidecarName}
+}
+
+// VolumeNames returns a string slice with the names of the volumes decoration may add
+// in addition to the ones of VolumeMounts().
+func VolumeNames() []string {
+	return []string{cloneRefsTmpName, cookiefileName}
+}
+
 // LabelsAndAnnotationsForSpec returns a minimal set of labels to add to prowjobs or its owned resources.
 //
 // User-provided extraLabels and extraAnnotations values will take precedence over auto-provided values.
@@ -199,6 +215,49 @@ func ProwJobToPodLocal(pj prowapi.ProwJob, buildID string, outputDir string) (*c
 	}, nil
 }
 
+// ApplySchedulingHints merges scheduling hints into the pod without overriding
+// explicit values: annotations are only added for new keys, each kind of
+// affinity is only used if the pod does not set it and tolerations are only
+// added for key and effect pairs the pod does not tolerate yet.
+func ApplySchedulingHints(pod *coreapi.Pod, annotations map[string]string, affinity *coreapi.Affinity, tolerations []coreapi.Toleration) {
+	for key, value := range annotations {
+		if pod.ObjectMeta.Annotations == nil {
+			pod.ObjectMeta.Annotations = map[string]string{}
+		}
+		if _, exists := pod.ObjectMeta.Annotations[key]; !exists {
+			pod.ObjectMeta.Annotations[key] = value
+		}
+	}
+
+	if affinity != nil {
+		if pod.Spec.Affinity == nil {
+			pod.Spec.Affinity = &coreapi.Affinity{}
+		}
+		if pod.Spec.Affinity.NodeAffinity == nil && affinity.NodeAffinity != nil {
+			pod.Spec.Affinity.NodeAffinity = affinity.NodeAffinity.DeepCopy()
+		}
+		if pod.Spec.Affinity.PodAffinity == nil && affinity.PodAffinity != nil {
+			pod.Spec.Affinity.PodAffinity = affinity.PodAffinity.DeepCopy()
+		}
+		if pod.Spec.Affinity.PodAntiAffinity == nil && affinity.PodAntiAffinity != nil {
+			pod.Spec.Affinity.PodAntiAffinity = affinity.PodAntiAffinity.DeepCopy()
+		}
+	}
+
+	for _, toleration := range tolerations {
+		tolerated := false
+		for _, existing := range pod.Spec.Tolerations {
+			if existing.Key == toleration.Key && existing.Effect == toleration.Effect {
+				tolerated = true
+				break
+			}
+		}
+		if !tolerated {
+			pod.Spec.Tolerations = append(pod.Spec.Tolerations, toleration)
+		}
+	}
+}
+
 const cloneLogPath = "clone.json"
 
 // CloneLogPath returns the path to the clone log file in the volume mount.
@@ -308,7 +367,7 @@ func cookiefileVolume(secret string) (coreapi.Volume, coreapi.VolumeMount, strin
 	}
 	var cookiefileMode int32 = 0400 // u+r
 	vol := coreapi.Volume{
-		Name: "cookiefile",
+		Name: cookiefileName,
 		VolumeSource: coreapi.VolumeSource{
 			Secret: &coreapi.SecretVolumeSource{
 				SecretName:  cookieSecret,
@@ -373,7 +432,7 @@ func CloneRefs(pj prowapi.ProwJob, codeMount, logMount coreapi.VolumeMount) (*co
 		cloneVolumes = append(cloneVolumes, oauthVolume)
 	}
 
-	volume, mount := tmpVolume("clonerefs-tmp")
+	volume, mount := tmpVolume(cloneRefsTmpName)
 	cloneMounts = append(cloneMounts, mount)
 	cloneVolumes = append(cloneVolumes, volume)
 
@@ -475,7 +534,7 @@ func InjectEntrypoint(c *coreapi.Container, timeout, gracePeriod time.Duration,
 // PlaceEntrypoint will copy entrypoint from the entrypoint image to the tools volume
 func PlaceEntrypoint(image string, toolsMount coreapi.VolumeMount) coreapi.Container {
 	return coreapi.Container{
-		Name:         "place-entrypoint",
+		Name:         placeEntrypointName,
 		Image:        image,
 		Command:      []string{"/bin/cp"},
 		Args:         []string{"/entrypoint", entrypointLocation(toolsMount)},
@@ -534,7 +593,7 @@ func InitUpload(image string, opt gcsupload.Options, creds *coreapi.VolumeMount,
 		return nil, fmt.Errorf("could not encode initupload configuration as JSON: %v", err)
 	}
 	return &coreapi.Container{
-		Name:    "initupload",
+		Name:    initUploadName,
 		Image:   image,
 		Command: []string{"/initupload"}, // TODO(fejta): remove this, use image's entrypoint and delete /initupload symlink
 		Env: KubeEnv(map[string]string{
@@ -695,7 +754,7 @@ func Sidecar(image string, gcsOptions gcsupload.Options, gcsMount *coreapi.Volum
 	}
 
 	return &coreapi.Container{
-		Name:    "sidecar",
+		Name:    sidecarName,
 		Image:   image,
 		Command: []string{"/sidecar"}, // TODO(fejta): remove, use image's entrypoint
 		Env: KubeEnv(map[string]string{
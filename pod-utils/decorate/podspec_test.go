@@ -30,6 +30,7 @@ import (
 	prowapi "github.com/clarketm/prow/apis/prowjobs/v1"
 	"github.com/clarketm/prow/clonerefs"
 	"github.com/clarketm/prow/entrypoint"
+	"github.com/clarketm/prow/gcsupload"
 	"github.com/clarketm/prow/initupload"
 	"github.com/clarketm/prow/kube"
 	"github.com/clarketm/prow/sidecar"
@@ -1923,3 +1924,208 @@ func TestProwJobToPod(t *testing.T) {
 		})
 	}
 }
+
+func TestProwJobToPodJobTimeout(t *testing.T) {
+	defaults := &prowapi.DecorationConfig{
+		Timeout:     &prowapi.Duration{Duration: 2 * time.Hour},
+		GracePeriod: &prowapi.Duration{Duration: 15 * time.Second},
+		UtilityImages: &prowapi.UtilityImages{
+			CloneRefs:  "clonerefs:tag",
+			InitUpload: "initupload:tag",
+			Entrypoint: "entrypoint:tag",
+			Sidecar:    "sidecar:tag",
+		},
+		GCSConfiguration: &prowapi.GCSConfiguration{
+			Bucket:       "my-bucket",
+			PathStrategy: "legacy",
+			DefaultOrg:   "kubernetes",
+			DefaultRepo:  "kubernetes",
+		},
+		GCSCredentialsSecret: "secret-name",
+	}
+	testCases := []struct {
+		name             string
+		decorationConfig *prowapi.DecorationConfig
+
+		expectedTimeout     time.Duration
+		expectedGracePeriod time.Duration
+	}{
+		{
+			name:                "job without timeout uses the default",
+			decorationConfig:    &prowapi.DecorationConfig{},
+			expectedTimeout:     2 * time.Hour,
+			expectedGracePeriod: 15 * time.Second,
+		},
+		{
+			name: "job timeout overrides the default",
+			decorationConfig: &prowapi.DecorationConfig{
+				Timeout:     &prowapi.Duration{Duration: 10 * time.Minute},
+				GracePeriod: &prowapi.Duration{Duration: time.Minute},
+			},
+			expectedTimeout:     10 * time.Minute,
+			expectedGracePeriod: time.Minute,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pj := prowapi.ProwJob{
+				ObjectMeta: metav1.ObjectMeta{Name: "pod"},
+				Spec: prowapi.ProwJobSpec{
+					Type:             prowapi.PeriodicJob,
+					Job:              "job-name",
+					Agent:            prowapi.KubernetesAgent,
+					DecorationConfig: tc.decorationConfig.ApplyDefault(defaults),
+					PodSpec: &coreapi.PodSpec{
+						Containers: []coreapi.Container{{Image: "tester", Command: []string{"/bin/thing"}}},
+					},
+				},
+			}
+			pod, err := ProwJobToPod(pj, "build")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var opts *entrypoint.Options
+			for _, c := range pod.Spec.Containers {
+				if c.Name != "test" {
+					continue
+				}
+				for _, env := range c.Env {
+					if env.Name == entrypoint.JSONConfigEnvVar {
+						opts = entrypoint.NewOptions()
+						if err := opts.LoadConfig(env.Value); err != nil {
+							t.Fatalf("failed to load entrypoint options: %v", err)
+						}
+					}
+				}
+			}
+			if opts == nil {
+				t.Fatal("test container has no entrypoint options")
+			}
+			if opts.Timeout != tc.expectedTimeout {
+				t.Errorf("expected timeout %v, got %v", tc.expectedTimeout, opts.Timeout)
+			}
+			if opts.GracePeriod != tc.expectedGracePeriod {
+				t.Errorf("expected grace period %v, got %v", tc.expectedGracePeriod, opts.GracePeriod)
+			}
+		})
+	}
+}
+
+func TestApplySchedulingHints(t *testing.T) {
+	nodeAffinity := func(pool string) *coreapi.NodeAffinity {
+		return &coreapi.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &coreapi.NodeSelector{
+				NodeSelectorTerms: []coreapi.NodeSelectorTerm{{
+					MatchExpressions: []coreapi.NodeSelectorRequirement{{
+						Key:      "pool",
+						Operator: coreapi.NodeSelectorOpIn,
+						Values:   []string{pool},
+					}},
+				}},
+			},
+		}
+	}
+	hintAffinity := &coreapi.Affinity{
+		NodeAffinity:    nodeAffinity("hinted"),
+		PodAntiAffinity: &coreapi.PodAntiAffinity{},
+	}
+	hintTolerations := []coreapi.Toleration{
+		{Key: "dedicated", Operator: coreapi.TolerationOpEqual, Value: "hinted", Effect: coreapi.TaintEffectNoSchedule},
+		{Key: "gpu", Operator: coreapi.TolerationOpExists, Effect: coreapi.TaintEffectNoSchedule},
+	}
+
+	testCases := []struct {
+		name                string
+		annotations         map[string]string
+		podSpec             coreapi.PodSpec
+		expectedAffinity    *coreapi.Affinity
+		expectedTolerations []coreapi.Toleration
+		expectedAnnotation  string
+	}{
+		{
+			name:                "hints are merged into a pod without scheduling settings",
+			podSpec:             coreapi.PodSpec{Containers: []coreapi.Container{{Image: "tester"}}},
+			expectedAffinity:    hintAffinity,
+			expectedTolerations: hintTolerations,
+			expectedAnnotation:  "hinted",
+		},
+		{
+			name:        "hints do not override explicit spec values",
+			annotations: map[string]string{"scheduling.example.com/hint": "explicit"},
+			podSpec: coreapi.PodSpec{
+				Containers: []coreapi.Container{{Image: "tester"}},
+				Affinity:   &coreapi.Affinity{NodeAffinity: nodeAffinity("explicit")},
+				Tolerations: []coreapi.Toleration{
+					{Key: "dedicated", Operator: coreapi.TolerationOpEqual, Value: "explicit", Effect: coreapi.TaintEffectNoSchedule},
+				},
+			},
+			expectedAffinity: &coreapi.Affinity{
+				NodeAffinity:    nodeAffinity("explicit"),
+				PodAntiAffinity: &coreapi.PodAntiAffinity{},
+			},
+			expectedTolerations: []coreapi.Toleration{
+				{Key: "dedicated", Operator: coreapi.TolerationOpEqual, Value: "explicit", Effect: coreapi.TaintEffectNoSchedule},
+				{Key: "gpu", Operator: coreapi.TolerationOpExists, Effect: coreapi.TaintEffectNoSchedule},
+			},
+			expectedAnnotation: "explicit",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pj := prowapi.ProwJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "pod",
+					Annotations: tc.annotations,
+				},
+				Spec: prowapi.ProwJobSpec{
+					Type:    prowapi.PeriodicJob,
+					Job:     "job",
+					PodSpec: tc.podSpec.DeepCopy(),
+				},
+			}
+			pod, err := ProwJobToPod(pj, "1")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			ApplySchedulingHints(pod, map[string]string{"scheduling.example.com/hint": "hinted"}, hintAffinity, hintTolerations)
+			if !equality.Semantic.DeepEqual(pod.Spec.Affinity, tc.expectedAffinity) {
+				t.Errorf("unexpected affinity diff:\n%s", diff.ObjectReflectDiff(tc.expectedAffinity, pod.Spec.Affinity))
+			}
+			if !equality.Semantic.DeepEqual(pod.Spec.Tolerations, tc.expectedTolerations) {
+				t.Errorf("unexpected tolerations diff:\n%s", diff.ObjectReflectDiff(tc.expectedTolerations, pod.Spec.Tolerations))
+			}
+			if actual := pod.ObjectMeta.Annotations["scheduling.example.com/hint"]; actual != tc.expectedAnnotation {
+				t.Errorf("expected annotation %q, got %q", tc.expectedAnnotation, actual)
+			}
+			if hintAffinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions[0].Values[0] != "hinted" {
+				t.Error("the hint itself must not be modified")
+			}
+		})
+	}
+}
+
+func TestGCSOptionsJUnitSummary(t *testing.T) {
+	for _, junitSummary := range []bool{false, true} {
+		dc := prowapi.DecorationConfig{
+			GCSConfiguration: &prowapi.GCSConfiguration{
+				Bucket:       "bucket",
+				PathStrategy: prowapi.PathStrategyExplicit,
+				JUnitSummary: junitSummary,
+			},
+			GCSCredentialsSecret: "secret",
+		}
+		_, _, opt := GCSOptions(dc, false)
+		encoded, err := gcsupload.Encode(opt)
+		if err != nil {
+			t.Fatalf("unexpected error encoding the options: %v", err)
+		}
+		decoded := gcsupload.NewOptions()
+		if err := decoded.LoadConfig(encoded); err != nil {
+			t.Fatalf("unexpected error decoding the options: %v", err)
+		}
+		if decoded.JUnitSummary != junitSummary {
+			t.Errorf("expected the upload options to have junit_summary %t, got %t", junitSummary, decoded.JUnitSummary)
+		}
+	}
+}
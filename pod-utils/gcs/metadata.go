@@ -33,6 +33,16 @@ type Started = metadata.Started
 // Finished holds finished.json data
 type Finished = metadata.Finished
 
+// RetentionMetadataName is the name of the object that holds the
+// retention class of a job run.
+const RetentionMetadataName = "retention.json"
+
+// Retention holds retention.json data
+type Retention struct {
+	// Class is the retention class of the run's artifacts, short or long.
+	Class string `json:"class"`
+}
+
 // AttributesFromFileName guesses file attributes from the filename
 // and returns the attributes and a simplifed filename.  For example,
 // build-log.txt.gz would be:
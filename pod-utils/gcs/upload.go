@@ -38,38 +38,67 @@ type destToWriter func(dest string) dataWriter
 // uploadTargets map to GCS in parallel. The map is
 // keyed on GCS path under the bucket
 func Upload(bucket *storage.BucketHandle, uploadTargets map[string]UploadFunc) error {
+	return UploadWithConcurrency(bucket, uploadTargets, 0)
+}
+
+// UploadWithConcurrency uploads all of the data in the uploadTargets map
+// to GCS with at most concurrency uploads in flight. A non-positive
+// concurrency uploads every target in parallel.
+func UploadWithConcurrency(bucket *storage.BucketHandle, uploadTargets map[string]UploadFunc, concurrency int) error {
 	dtw := func(dest string) dataWriter {
 		return gcsObjectWriter{bucket.Object(dest).NewWriter(context.Background())}
 	}
-	return upload(dtw, uploadTargets)
+	return upload(dtw, uploadTargets, concurrency)
 }
 
 // LocalExport copies all of the data in the uploadTargets map to local files in parallel. The map
 // is keyed on file path under the exportDir.
 func LocalExport(exportDir string, uploadTargets map[string]UploadFunc) error {
+	return LocalExportWithConcurrency(exportDir, uploadTargets, 0)
+}
+
+// LocalExportWithConcurrency copies all of the data in the uploadTargets map to local
+// files with at most concurrency copies in flight. A non-positive concurrency copies
+// every target in parallel.
+func LocalExportWithConcurrency(exportDir string, uploadTargets map[string]UploadFunc, concurrency int) error {
 	dtw := func(dest string) dataWriter {
 		return &localFileWriter{
 			filePath: path.Join(exportDir, dest),
 		}
 	}
-	return upload(dtw, uploadTargets)
+	return upload(dtw, uploadTargets, concurrency)
+}
+
+type uploadTarget struct {
+	dest   string
+	upload UploadFunc
 }
 
-func upload(dtw destToWriter, uploadTargets map[string]UploadFunc) error {
+func upload(dtw destToWriter, uploadTargets map[string]UploadFunc, concurrency int) error {
+	if concurrency <= 0 || concurrency > len(uploadTargets) {
+		concurrency = len(uploadTargets)
+	}
+	queue := make(chan uploadTarget, len(uploadTargets))
+	for dest, upload := range uploadTargets {
+		logrus.WithField("dest", dest).Info("Queued for upload")
+		queue <- uploadTarget{dest: dest, upload: upload}
+	}
+	close(queue)
+
 	errCh := make(chan error, len(uploadTargets))
 	group := &sync.WaitGroup{}
-	group.Add(len(uploadTargets))
-	for dest, upload := range uploadTargets {
-		log := logrus.WithField("dest", dest)
-		log.Info("Queued for upload")
-		go func(f UploadFunc, writer dataWriter, log *logrus.Entry) {
+	group.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
 			defer group.Done()
-			if err := f(writer); err != nil {
-				errCh <- err
-			} else {
-				log.Info("Finished upload")
+			for target := range queue {
+				if err := target.upload(dtw(target.dest)); err != nil {
+					errCh <- err
+				} else {
+					logrus.WithField("dest", target.dest).Info("Finished upload")
+				}
 			}
-		}(upload, dtw(dest), log)
+		}()
 	}
 	group.Wait()
 	close(errCh)
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"sync"
 	"testing"
+	"time"
 
 	"cloud.google.com/go/storage"
 )
@@ -100,3 +101,68 @@ func TestUploadToGcs(t *testing.T) {
 		}
 	}
 }
+
+func TestUploadConcurrency(t *testing.T) {
+	var testCases = []struct {
+		name        string
+		targets     int
+		concurrency int
+		expectedMax int
+	}{
+		{
+			name:        "bounded",
+			targets:     20,
+			concurrency: 3,
+			expectedMax: 3,
+		},
+		{
+			name:        "serial",
+			targets:     5,
+			concurrency: 1,
+			expectedMax: 1,
+		},
+		{
+			name:        "more workers than targets",
+			targets:     2,
+			concurrency: 10,
+			expectedMax: 2,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			lock := sync.Mutex{}
+			running, maxRunning, count := 0, 0, 0
+
+			instrumented := func(_ dataWriter) error {
+				lock.Lock()
+				running++
+				count++
+				if running > maxRunning {
+					maxRunning = running
+				}
+				lock.Unlock()
+				time.Sleep(10 * time.Millisecond)
+				lock.Lock()
+				running--
+				lock.Unlock()
+				return nil
+			}
+
+			targets := map[string]UploadFunc{}
+			for i := 0; i < testCase.targets; i++ {
+				targets[fmt.Sprintf("target-%d", i)] = instrumented
+			}
+
+			if err := UploadWithConcurrency(&storage.BucketHandle{}, targets, testCase.concurrency); err != nil {
+				t.Fatalf("expected no error but got %v", err)
+			}
+			if count != testCase.targets {
+				t.Errorf("expected %d targets to be uploaded, but %d were", testCase.targets, count)
+			}
+			if maxRunning > testCase.expectedMax {
+				t.Errorf("expected at most %d concurrent uploads, saw %d", testCase.expectedMax, maxRunning)
+			}
+		})
+	}
+}
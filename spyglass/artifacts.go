@@ -19,6 +19,7 @@ package spyglass
 import (
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -114,8 +115,8 @@ func (s *Spyglass) FetchArtifacts(src string, podName string, sizeLimit int64, a
 		return nil, fmt.Errorf("invalid src: %v", src)
 	}
 
-	podLogNeeded := false
-	for _, name := range artifactNames {
+	concurrency := s.config().Deck.Spyglass.RenderConcurrency
+	fetched, errs := fetchConcurrently(artifactNames, concurrency, func(name string) (lenses.Artifact, error) {
 		art, err := s.GCSArtifactFetcher.artifact(gcsKey, name, sizeLimit)
 		if err == nil {
 			// Actually try making a request, because calling GCSArtifactFetcher.artifact does no I/O.
@@ -123,13 +124,18 @@ func (s *Spyglass) FetchArtifacts(src string, podName string, sizeLimit int64, a
 			// the extra network I/O should not be too problematic).
 			_, err = art.Size()
 		}
-		if err != nil {
+		return art, err
+	})
+
+	podLogNeeded := false
+	for i, name := range artifactNames {
+		if errs[i] != nil {
 			if name == "build-log.txt" {
 				podLogNeeded = true
 			}
 			continue
 		}
-		arts = append(arts, art)
+		arts = append(arts, fetched[i])
 	}
 
 	if podLogNeeded {
@@ -145,6 +151,38 @@ func (s *Spyglass) FetchArtifacts(src string, podName string, sizeLimit int64, a
 	return arts, nil
 }
 
+// fetchConcurrently calls fetch for every name, running at most concurrency
+// fetches at a time. Results and errors are returned in the order of names.
+// A concurrency below one fetches serially.
+func fetchConcurrently(names []string, concurrency int, fetch func(name string) (lenses.Artifact, error)) ([]lenses.Artifact, []error) {
+	arts := make([]lenses.Artifact, len(names))
+	errs := make([]error, len(names))
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if concurrency > len(names) {
+		concurrency = len(names)
+	}
+
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				arts[i], errs[i] = fetch(names[i])
+			}
+		}()
+	}
+	for i := range names {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+	return arts, errs
+}
+
 func splitSrc(src string) (keyType, key string, err error) {
 	split := strings.SplitN(src, "/", 2)
 	if len(split) < 2 {
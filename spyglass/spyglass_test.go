@@ -24,7 +24,9 @@ import (
 	"reflect"
 	"sort"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/clarketm/prow/gcsupload"
 	"github.com/clarketm/prow/pod-utils/downwardapi"
@@ -1259,6 +1261,71 @@ func TestFetchArtifactsPodLog(t *testing.T) {
 	}
 }
 
+func TestFetchConcurrently(t *testing.T) {
+	var names []string
+	for i := 0; i < 20; i++ {
+		names = append(names, fmt.Sprintf("artifact-%d", i))
+	}
+	testCases := []struct {
+		name        string
+		concurrency int
+		expectedMax int32
+	}{
+		{
+			name:        "unset concurrency fetches serially",
+			concurrency: 0,
+			expectedMax: 1,
+		},
+		{
+			name:        "serial",
+			concurrency: 1,
+			expectedMax: 1,
+		},
+		{
+			name:        "bounded",
+			concurrency: 4,
+			expectedMax: 4,
+		},
+		{
+			name:        "concurrency above artifact count",
+			concurrency: 100,
+			expectedMax: int32(len(names)),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var inFlight, maxInFlight int32
+			fetch := func(name string) (lenses.Artifact, error) {
+				current := atomic.AddInt32(&inFlight, 1)
+				defer atomic.AddInt32(&inFlight, -1)
+				for {
+					max := atomic.LoadInt32(&maxInFlight)
+					if current <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, current) {
+						break
+					}
+				}
+				time.Sleep(10 * time.Millisecond)
+				return nil, fmt.Errorf("%s", name)
+			}
+			_, errs := fetchConcurrently(names, tc.concurrency, fetch)
+			if len(errs) != len(names) {
+				t.Fatalf("Expected %d results, got %d", len(names), len(errs))
+			}
+			for i, name := range names {
+				if errs[i] == nil || errs[i].Error() != name {
+					t.Errorf("Expected result %d to belong to %s, got %v", i, name, errs[i])
+				}
+			}
+			if max := atomic.LoadInt32(&maxInFlight); max > tc.expectedMax {
+				t.Errorf("Expected at most %d concurrent fetches, got %d", tc.expectedMax, max)
+			}
+			if tc.expectedMax > 1 && atomic.LoadInt32(&maxInFlight) < 2 {
+				t.Errorf("Expected fetches to run concurrently, but at most one was in flight")
+			}
+		})
+	}
+}
+
 func TestKeyToJob(t *testing.T) {
 	testCases := []struct {
 		name      string
@@ -0,0 +1,312 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tide
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	prowapi "github.com/clarketm/prow/apis/prowjobs/v1"
+	"github.com/clarketm/prow/config"
+	"github.com/clarketm/prow/tide/blockers"
+)
+
+// batchGroup holds the subpools of the repos of a batch group that share a
+// branch. The subpool of the primary repo comes first.
+type batchGroup struct {
+	subpools []*subpool
+}
+
+func (g batchGroup) primary() *subpool {
+	return g.subpools[0]
+}
+
+// batchGroups collects the subpools of the configured batch groups. A group
+// is only formed for a branch if the primary repo and at least one other repo
+// of the group have PRs in the pool for that branch.
+func batchGroups(groups []config.TideBatchGroup, sps map[string]*subpool) []batchGroup {
+	var res []batchGroup
+	for _, group := range groups {
+		byBranch := map[string][]*subpool{}
+		for i, repo := range group.Repos {
+			for _, sp := range sps {
+				if sp.org+"/"+sp.repo != repo {
+					continue
+				}
+				// Only branches the primary repo has PRs for form a group.
+				if i > 0 && len(byBranch[sp.branch]) == 0 {
+					continue
+				}
+				byBranch[sp.branch] = append(byBranch[sp.branch], sp)
+			}
+		}
+		for _, members := range byBranch {
+			if len(members) < 2 {
+				continue
+			}
+			res = append(res, batchGroup{subpools: members})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		a, b := res[i].primary(), res[j].primary()
+		return poolKey(a.org, a.repo, a.branch) < poolKey(b.org, b.repo, b.branch)
+	})
+	return res
+}
+
+// isGroupBatch determines whether a batch job tests the PRs of a batch group,
+// which are added to the job as extra refs.
+func isGroupBatch(pj prowapi.ProwJob) bool {
+	for _, ref := range pj.Spec.ExtraRefs {
+		if len(ref.Pulls) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// syncBatchGroups merges the passing batches of the batch groups or triggers
+// new group batches. The resulting actions are stored in the subpools of the
+// groups and reported by syncSubpool.
+func (c *Controller) syncBatchGroups(sps map[string]*subpool, blocks, freezes, pauses blockers.Blockers) {
+	for _, group := range batchGroups(c.config().Tide.BatchGroups, sps) {
+		primary := group.primary()
+		primary.groupMembers = map[string]*subpool{}
+		for _, sp := range group.subpools {
+			primary.groupMembers[sp.org+"/"+sp.repo] = sp
+		}
+		var blocked, frozen bool
+		for _, sp := range group.subpools {
+			blocked = blocked || len(blocks.GetApplicable(sp.org, sp.repo, sp.branch)) > 0
+			blocked = blocked || len(pauses.GetApplicable(sp.org, sp.repo, sp.branch)) > 0
+			frozen = frozen || len(freezes.GetApplicable(sp.org, sp.repo, sp.branch)) > 0
+		}
+		if blocked {
+			continue
+		}
+		c.syncBatchGroup(group, frozen)
+	}
+}
+
+func (c *Controller) syncBatchGroup(group batchGroup, frozen bool) {
+	primary := group.primary()
+	successBatch, pendingBatch := c.accumulateGroupBatch(group)
+	switch {
+	case len(successBatch) > 0:
+		if minAge := c.config().Tide.MinSHAAge; minAge != nil && minAge.Duration > 0 && !frozen {
+			// Hold the whole group until all heads of the batch are old
+			// enough to merge.
+			frozen = groupHasYoungHeads(group, successBatch, minAge.Duration, time.Now())
+		}
+		if c.config().Tide.RequireBaseGreen && !frozen {
+			// Hold the whole group if the base of any of its repos is failing.
+			frozen = c.groupBaseIsFailing(group)
+		}
+		for _, sp := range group.subpools {
+			sp.grouped = true
+			prs := successBatch[poolKey(sp.org, sp.repo, sp.branch)]
+			if len(prs) == 0 {
+				continue
+			}
+			if frozen {
+				// Hold on to the passing batch until it may be merged.
+				sp.groupAction = Wait
+				sp.groupBatchPending = prs
+				continue
+			}
+			sp.groupAction, sp.groupTargets, sp.groupErr = MergeBatch, prs, c.mergePRs(*sp, prs)
+		}
+		return
+	case len(pendingBatch) > 0:
+		for _, sp := range group.subpools {
+			sp.grouped = true
+			sp.groupBatchPending = pendingBatch[poolKey(sp.org, sp.repo, sp.branch)]
+		}
+		return
+	}
+
+	batches := map[string][]PullRequest{}
+	for _, sp := range group.subpools {
+		prs, _, err := c.pickBatch(*sp, sp.cc)
+		if err != nil {
+			sp.log.WithError(err).Error("Error picking PRs for the group batch.")
+			return
+		}
+		if len(prs) > 0 {
+			batches[poolKey(sp.org, sp.repo, sp.branch)] = prs
+		}
+	}
+	if len(batches) < 2 {
+		// Without PRs of several repos, the repos are batched on their own.
+		primary.log.Debugf("Only %d repos of the batch group have PRs to batch, not triggering a group batch.", len(batches))
+		return
+	}
+
+	primaryPRs := batches[poolKey(primary.org, primary.repo, primary.branch)]
+	var extraRefs []prowapi.Refs
+	for _, sp := range group.subpools[1:] {
+		if prs := batches[poolKey(sp.org, sp.repo, sp.branch)]; len(prs) > 0 {
+			extraRefs = append(extraRefs, prowapi.Refs{
+				Org:     sp.org,
+				Repo:    sp.repo,
+				BaseRef: sp.branch,
+				BaseSHA: sp.sha,
+				Pulls:   prMeta(prs...),
+			})
+		}
+	}
+	presubmits, err := c.presubmitsForBatch(primaryPRs, primary.org, primary.repo, primary.sha, primary.branch)
+	if err == nil {
+		err = c.trigger(*primary, presubmits, primaryPRs, extraRefs...)
+	}
+	for _, sp := range group.subpools {
+		sp.grouped = true
+		if prs := batches[poolKey(sp.org, sp.repo, sp.branch)]; len(prs) > 0 {
+			sp.groupAction, sp.groupTargets, sp.groupErr = TriggerBatch, prs, err
+		}
+	}
+}
+
+// groupHasYoungHeads reports whether the head of any PR of the group batch
+// was pushed less than minAge ago.
+func groupHasYoungHeads(group batchGroup, batch map[string][]PullRequest, minAge time.Duration, now time.Time) bool {
+	for _, sp := range group.subpools {
+		prs := batch[poolKey(sp.org, sp.repo, sp.branch)]
+		if old := withoutYoungHeads(*sp, prs, minAge, now); len(old) < len(prs) {
+			return true
+		}
+	}
+	return false
+}
+
+// groupBaseIsFailing reports whether the base of any subpool of the group is
+// failing. If a base status can not be determined, the group is treated as
+// failing so that its merges are held.
+func (c *Controller) groupBaseIsFailing(group batchGroup) bool {
+	for _, sp := range group.subpools {
+		failing, err := c.baseIsFailing(*sp)
+		if err != nil {
+			sp.log.WithError(err).Error("Error checking the base of the batch group.")
+			return true
+		}
+		if failing {
+			sp.log.Info("Base is failing, holding the merges of the batch group.")
+			return true
+		}
+	}
+	return false
+}
+
+// accumulateGroupBatch looks at the group batch jobs of the primary subpool
+// and returns the PRs of a successful and of a pending group batch, keyed by
+// the pool keys of their subpools. Batches are only valid if all their PRs
+// still point to the heads of PRs in the pool and all their base SHAs match.
+func (c *Controller) accumulateGroupBatch(group batchGroup) (successBatch, pendingBatch map[string][]PullRequest) {
+	primary := group.primary()
+	members := map[string]*subpool{}
+	for _, sp := range group.subpools {
+		members[sp.org+"/"+sp.repo] = sp
+	}
+	type accState struct {
+		prs        map[string][]PullRequest
+		jobStates  map[string]simpleState
+		validPulls bool
+	}
+	states := map[string]*accState{}
+	for _, pj := range primary.pjs {
+		if pj.Spec.Type != prowapi.BatchJob || !isGroupBatch(pj) {
+			continue
+		}
+		refs := []string{pj.Spec.Refs.String()}
+		for _, ref := range pj.Spec.ExtraRefs {
+			refs = append(refs, ref.String())
+		}
+		key := strings.Join(refs, ";")
+		if _, ok := states[key]; !ok {
+			prs, valid := groupBatchPRs(primary.log.WithField("batch", key), members, pj)
+			states[key] = &accState{prs: prs, jobStates: map[string]simpleState{}, validPulls: valid}
+		}
+		if !states[key].validPulls {
+			continue
+		}
+		context := pj.Spec.Context
+		jobState := toSimpleState(pj.Status.State)
+		// Store the best result for this batch+context.
+		if s, ok := states[key].jobStates[context]; !ok || s == failureState || jobState == successState {
+			states[key].jobStates[context] = jobState
+		}
+	}
+	for key, state := range states {
+		if !state.validPulls {
+			continue
+		}
+		primaryPRs := state.prs[poolKey(primary.org, primary.repo, primary.branch)]
+		requiredPresubmits, err := c.presubmitsForBatch(primaryPRs, primary.org, primary.repo, primary.sha, primary.branch)
+		if err != nil {
+			primary.log.WithError(err).Error("Error getting presubmits for group batch")
+			continue
+		}
+		switch overallBatchState(requiredPresubmits, state.jobStates, primary.log.WithField("batch", key)) {
+		// Like for batches of a single repo, only one pending and one
+		// successful group batch are considered at a time.
+		case pendingState:
+			pendingBatch = state.prs
+		case successState:
+			successBatch = state.prs
+		}
+	}
+	return successBatch, pendingBatch
+}
+
+// groupBatchPRs returns the PRs tested by a group batch job, keyed by the
+// pool keys of their subpools, and whether they are still valid.
+func groupBatchPRs(log *logrus.Entry, members map[string]*subpool, pj prowapi.ProwJob) (map[string][]PullRequest, bool) {
+	prs := map[string][]PullRequest{}
+	refs := append([]prowapi.Refs{*pj.Spec.Refs}, pj.Spec.ExtraRefs...)
+	for i, ref := range refs {
+		// The job's own extra refs do not test any PRs.
+		if i > 0 && len(ref.Pulls) == 0 {
+			continue
+		}
+		sp, ok := members[ref.Org+"/"+ref.Repo]
+		if !ok {
+			log.Debugf("group batch invalid, %s/%s has no PRs in the pool", ref.Org, ref.Repo)
+			return nil, false
+		}
+		if ref.BaseSHA != sp.sha {
+			log.Debugf("group batch invalid, base of %s/%s changed", ref.Org, ref.Repo)
+			return nil, false
+		}
+		heads := map[int]PullRequest{}
+		for _, pr := range sp.prs {
+			heads[int(pr.Number)] = pr
+		}
+		key := poolKey(sp.org, sp.repo, sp.branch)
+		for _, pull := range ref.Pulls {
+			pr, ok := heads[pull.Number]
+			if !ok || string(pr.HeadRefOID) != pull.SHA {
+				log.Debugf("group batch invalid, %s/%s#%d left the pool or its HEAD changed", ref.Org, ref.Repo, pull.Number)
+				return nil, false
+			}
+			prs[key] = append(prs[key], pr)
+		}
+	}
+	return prs, true
+}
@@ -0,0 +1,331 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tide
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	githubql "github.com/shurcooL/githubv4"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	prowapi "github.com/clarketm/prow/apis/prowjobs/v1"
+	"github.com/clarketm/prow/config"
+	"github.com/clarketm/prow/git/localgit"
+	"github.com/clarketm/prow/github"
+	"github.com/clarketm/prow/tide/blockers"
+)
+
+func TestBatchGroups(t *testing.T) {
+	newSubpool := func(repo, branch string) *subpool {
+		return &subpool{org: "o", repo: repo, branch: branch}
+	}
+	sps := map[string]*subpool{
+		"o/a:master":  newSubpool("a", "master"),
+		"o/b:master":  newSubpool("b", "master"),
+		"o/c:master":  newSubpool("c", "master"),
+		"o/a:release": newSubpool("a", "release"),
+		"o/c:release": newSubpool("c", "release"),
+		"o/b:feature": newSubpool("b", "feature"),
+		"o/d:feature": newSubpool("d", "feature"),
+	}
+	groups := []config.TideBatchGroup{
+		{Repos: []string{"o/a", "o/b", "o/c"}},
+		// The primary repo has no PRs in the pool.
+		{Repos: []string{"o/e", "o/d"}},
+	}
+
+	var actual [][]string
+	for _, group := range batchGroups(groups, sps) {
+		var keys []string
+		for _, sp := range group.subpools {
+			keys = append(keys, poolKey(sp.org, sp.repo, sp.branch))
+		}
+		actual = append(actual, keys)
+	}
+	expected := [][]string{
+		{"o/a:master", "o/b:master", "o/c:master"},
+		{"o/a:release", "o/c:release"},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected batch groups %v, got %v", expected, actual)
+	}
+}
+
+func TestAccumulateGroupBatch(t *testing.T) {
+	newPR := func(number int) PullRequest {
+		var pr PullRequest
+		pr.Number = githubql.Int(number)
+		pr.HeadRefOID = githubql.String(fmt.Sprintf("sha-%d", number))
+		return pr
+	}
+	newBatch := func(context string, state prowapi.ProwJobState, bSHA string, bPulls []prowapi.Pull) prowapi.ProwJob {
+		return prowapi.ProwJob{
+			Spec: prowapi.ProwJobSpec{
+				Type:      prowapi.BatchJob,
+				Context:   context,
+				Refs:      &prowapi.Refs{Org: "o", Repo: "a", BaseRef: "master", BaseSHA: "a-sha", Pulls: []prowapi.Pull{{Number: 1, SHA: "sha-1"}}},
+				ExtraRefs: []prowapi.Refs{{Org: "o", Repo: "b", BaseRef: "master", BaseSHA: bSHA, Pulls: bPulls}},
+			},
+			Status: prowapi.ProwJobStatus{State: state},
+		}
+	}
+	validPulls := []prowapi.Pull{{Number: 2, SHA: "sha-2"}}
+	testcases := []struct {
+		name string
+		pjs  []prowapi.ProwJob
+
+		success bool
+		pending bool
+	}{
+		{
+			name: "all required jobs passed",
+			pjs: []prowapi.ProwJob{
+				newBatch("job-a", prowapi.SuccessState, "b-sha", validPulls),
+				newBatch("job-b", prowapi.SuccessState, "b-sha", validPulls),
+			},
+			success: true,
+		},
+		{
+			name: "a required job is pending",
+			pjs: []prowapi.ProwJob{
+				newBatch("job-a", prowapi.SuccessState, "b-sha", validPulls),
+				newBatch("job-b", prowapi.PendingState, "b-sha", validPulls),
+			},
+			pending: true,
+		},
+		{
+			name: "a required job is missing",
+			pjs: []prowapi.ProwJob{
+				newBatch("job-a", prowapi.SuccessState, "b-sha", validPulls),
+			},
+		},
+		{
+			name: "the base of the other repo moved",
+			pjs: []prowapi.ProwJob{
+				newBatch("job-a", prowapi.SuccessState, "old-b-sha", validPulls),
+				newBatch("job-b", prowapi.SuccessState, "old-b-sha", validPulls),
+			},
+		},
+		{
+			name: "the head of a PR of the other repo changed",
+			pjs: []prowapi.ProwJob{
+				newBatch("job-a", prowapi.SuccessState, "b-sha", []prowapi.Pull{{Number: 2, SHA: "old"}}),
+				newBatch("job-b", prowapi.SuccessState, "b-sha", []prowapi.Pull{{Number: 2, SHA: "old"}}),
+			},
+		},
+	}
+
+	presubmits := []config.Presubmit{
+		{JobBase: config.JobBase{Name: "job-a"}, Reporter: config.Reporter{Context: "job-a"}, AlwaysRun: true},
+		{JobBase: config.JobBase{Name: "job-b"}, Reporter: config.Reporter{Context: "job-b"}, AlwaysRun: true},
+	}
+	cfg := &config.Config{JobConfig: config.JobConfig{PresubmitsStatic: map[string][]config.Presubmit{"o/a": presubmits}}}
+	c := &Controller{
+		logger: logrus.WithField("controller", "tide"),
+		config: func() *config.Config { return cfg },
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			primary := &subpool{log: logrus.WithField("test", tc.name), org: "o", repo: "a", branch: "master", sha: "a-sha", prs: []PullRequest{newPR(1)}, pjs: tc.pjs}
+			other := &subpool{log: logrus.WithField("test", tc.name), org: "o", repo: "b", branch: "master", sha: "b-sha", prs: []PullRequest{newPR(2)}}
+			successBatch, pendingBatch := c.accumulateGroupBatch(batchGroup{subpools: []*subpool{primary, other}})
+			// The primary subpool does not consider group batches on its own.
+			if success, pending, _ := c.accumulateBatch(*primary); len(success) > 0 || len(pending) > 0 {
+				t.Errorf("expected accumulateBatch to ignore group batches, got success %v and pending %v", prNumbers(success), prNumbers(pending))
+			}
+			expected := map[string][]PullRequest{
+				"o/a:master": {newPR(1)},
+				"o/b:master": {newPR(2)},
+			}
+			if tc.success != (len(successBatch) > 0) {
+				t.Errorf("expected success batch: %t, got %v", tc.success, successBatch)
+			} else if tc.success && !reflect.DeepEqual(successBatch, expected) {
+				t.Errorf("expected success batch %v, got %v", expected, successBatch)
+			}
+			if tc.pending != (len(pendingBatch) > 0) {
+				t.Errorf("expected pending batch: %t, got %v", tc.pending, pendingBatch)
+			} else if tc.pending && !reflect.DeepEqual(pendingBatch, expected) {
+				t.Errorf("expected pending batch %v, got %v", expected, pendingBatch)
+			}
+		})
+	}
+}
+
+func TestSyncBatchGroups(t *testing.T) {
+	sleep = func(time.Duration) {}
+	defer func() { sleep = time.Sleep }()
+
+	lg, gc, err := localgit.New()
+	if err != nil {
+		t.Fatalf("Error making local git: %v", err)
+	}
+	defer gc.Clean()
+	defer lg.Clean()
+	newSubpool := func(repo string, numbers ...int) *subpool {
+		if err := lg.MakeFakeRepo("o", repo); err != nil {
+			t.Fatalf("Error making fake repo: %v", err)
+		}
+		if err := lg.AddCommit("o", repo, map[string][]byte{"foo": []byte("foo")}); err != nil {
+			t.Fatalf("Adding initial commit: %v", err)
+		}
+		sp := &subpool{
+			log:    logrus.WithField("repo", repo),
+			org:    "o",
+			repo:   repo,
+			branch: "master",
+			sha:    "master",
+			cc:     map[int]contextChecker{},
+		}
+		for _, number := range numbers {
+			if err := lg.CheckoutNewBranch("o", repo, fmt.Sprintf("pr-%d", number)); err != nil {
+				t.Fatalf("Error checking out new branch: %v", err)
+			}
+			if err := lg.AddCommit("o", repo, map[string][]byte{fmt.Sprintf("%d", number): []byte("ok")}); err != nil {
+				t.Fatalf("Error adding commit: %v", err)
+			}
+			if err := lg.Checkout("o", repo, "master"); err != nil {
+				t.Fatalf("Error checking out master: %v", err)
+			}
+			oid := githubql.String(fmt.Sprintf("origin/pr-%d", number))
+			var pr PullRequest
+			pr.Number = githubql.Int(number)
+			pr.HeadRefOID = oid
+			pr.Commits.Nodes = []struct {
+				Commit Commit
+			}{{Commit: Commit{OID: oid}}}
+			sp.prs = append(sp.prs, pr)
+			sp.cc[number] = &config.TideContextPolicy{}
+		}
+		return sp
+	}
+	primary, other := newSubpool("a", 1, 2), newSubpool("b", 3)
+	sps := map[string]*subpool{"o/a:master": primary, "o/b:master": other}
+
+	cfg := &config.Config{
+		ProwConfig: config.ProwConfig{
+			ProwJobNamespace: "prowjobs",
+			Tide: config.Tide{
+				BatchGroups: []config.TideBatchGroup{{Repos: []string{"o/a", "o/b"}}},
+			},
+		},
+		JobConfig: config.JobConfig{
+			PresubmitsStatic: map[string][]config.Presubmit{
+				"o/a": {{
+					AlwaysRun: true,
+					JobBase:   config.JobBase{Name: "group-job"},
+					Reporter:  config.Reporter{Context: "group-job"},
+				}},
+			},
+		},
+	}
+	fgc := &fgc{}
+	client := fakectrlruntimeclient.NewFakeClient()
+	c := &Controller{
+		ctx:           context.Background(),
+		logger:        logrus.WithField("controller", "tide"),
+		gc:            gc,
+		config:        func() *config.Config { return cfg },
+		ghc:           fgc,
+		prowJobClient: client,
+	}
+
+	// Without a group batch, one is triggered for the PRs of both repos.
+	c.syncBatchGroups(sps, blockers.Blockers{}, blockers.Blockers{}, blockers.Blockers{})
+	prowJobs := &prowapi.ProwJobList{}
+	if err := client.List(context.Background(), prowJobs); err != nil {
+		t.Fatalf("failed to list ProwJobs: %v", err)
+	}
+	if len(prowJobs.Items) != 1 {
+		t.Fatalf("expected one group batch job to be triggered, got %d jobs", len(prowJobs.Items))
+	}
+	pj := prowJobs.Items[0]
+	if pj.Spec.Type != prowapi.BatchJob || !isGroupBatch(pj) {
+		t.Errorf("expected a group batch job, got a %s job with extra refs %v", pj.Spec.Type, pj.Spec.ExtraRefs)
+	}
+	if expected := prMeta(primary.prs...); !reflect.DeepEqual(pj.Spec.Refs.Pulls, expected) {
+		t.Errorf("expected the job to test the PRs of the primary repo %v, got %v", expected, pj.Spec.Refs.Pulls)
+	}
+	expectedExtraRefs := []prowapi.Refs{{Org: "o", Repo: "b", BaseRef: "master", BaseSHA: "master", Pulls: prMeta(other.prs...)}}
+	if !reflect.DeepEqual(pj.Spec.ExtraRefs, expectedExtraRefs) {
+		t.Errorf("expected extra refs %v, got %v", expectedExtraRefs, pj.Spec.ExtraRefs)
+	}
+	for _, sp := range sps {
+		if !sp.grouped || sp.groupAction != TriggerBatch || sp.groupErr != nil {
+			t.Errorf("expected %s/%s to trigger the group batch, got action %q and error %v", sp.org, sp.repo, sp.groupAction, sp.groupErr)
+		}
+	}
+
+	// While the base of a repo of the group is failing, the passing group
+	// batch is held.
+	pj.Status = prowapi.ProwJobStatus{State: prowapi.SuccessState, StartTime: metav1.Now()}
+	primary.pjs = []prowapi.ProwJob{pj}
+	cfg.Tide.RequireBaseGreen = true
+	fgc.expectedSHA = "master"
+	fgc.combinedStatus = map[string]string{"build": github.StatusFailure}
+	for _, sp := range sps {
+		sp.grouped, sp.groupAction, sp.groupTargets, sp.groupErr = false, "", nil, nil
+	}
+	c.syncBatchGroups(sps, blockers.Blockers{}, blockers.Blockers{}, blockers.Blockers{})
+	if fgc.merged != 0 {
+		t.Errorf("expected the group batch to be held while the base is failing, got %d merges", fgc.merged)
+	}
+	for _, sp := range sps {
+		if sp.groupAction != Wait || len(sp.groupBatchPending) != len(sp.prs) {
+			t.Errorf("expected %s/%s to hold %d PRs, got action %q with pending batch %v", sp.org, sp.repo, len(sp.prs), sp.groupAction, prNumbers(sp.groupBatchPending))
+		}
+	}
+
+	// While a head of the group batch is younger than the minimum SHA age,
+	// the passing group batch is held as well.
+	fgc.combinedStatus = map[string]string{"build": github.StatusSuccess}
+	cfg.Tide.MinSHAAge = &metav1.Duration{Duration: time.Hour}
+	other.prs[0].Commits.Nodes[0].Commit.PushedDate = &githubql.DateTime{Time: time.Now()}
+	for _, sp := range sps {
+		sp.grouped, sp.groupAction, sp.groupTargets, sp.groupErr, sp.groupBatchPending = false, "", nil, nil, nil
+	}
+	c.syncBatchGroups(sps, blockers.Blockers{}, blockers.Blockers{}, blockers.Blockers{})
+	if fgc.merged != 0 {
+		t.Errorf("expected the group batch to be held while a head is young, got %d merges", fgc.merged)
+	}
+	for _, sp := range sps {
+		if sp.groupAction != Wait {
+			t.Errorf("expected %s/%s to hold the group batch, got action %q", sp.org, sp.repo, sp.groupAction)
+		}
+	}
+
+	// Once the group batch passed, the base is green and all heads are old
+	// enough, the PRs of both repos are merged.
+	other.prs[0].Commits.Nodes[0].Commit.PushedDate = &githubql.DateTime{Time: time.Now().Add(-2 * time.Hour)}
+	for _, sp := range sps {
+		sp.grouped, sp.groupAction, sp.groupTargets, sp.groupErr, sp.groupBatchPending = false, "", nil, nil, nil
+	}
+	c.syncBatchGroups(sps, blockers.Blockers{}, blockers.Blockers{}, blockers.Blockers{})
+	if fgc.merged != 3 {
+		t.Errorf("expected all 3 PRs of the group batch to be merged, got %d merges", fgc.merged)
+	}
+	for _, sp := range sps {
+		if sp.groupAction != MergeBatch || len(sp.groupTargets) != len(sp.prs) {
+			t.Errorf("expected %s/%s to merge %d PRs, got action %q with targets %v", sp.org, sp.repo, len(sp.prs), sp.groupAction, prNumbers(sp.groupTargets))
+		}
+	}
+}
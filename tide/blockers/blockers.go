@@ -32,6 +32,10 @@ var (
 	branchRE = regexp.MustCompile(`(?im)\bbranch:[^\w-]*([\w-./]+)\b`)
 )
 
+// branchLabelPrefix marks issue labels that scope a blocker to a branch,
+// e.g. "branch:release-1.2".
+const branchLabelPrefix = "branch:"
+
 type githubClient interface {
 	Query(context.Context, interface{}, map[string]interface{}) error
 }
@@ -95,7 +99,9 @@ func fromIssues(issues []Issue, log *logrus.Entry) Blockers {
 			Title:  strippedTitle,
 			URL:    string(issue.URL),
 		}
-		if branches := parseBranches(string(issue.Title)); len(branches) > 0 {
+		branches := parseBranches(string(issue.Title))
+		branches = append(branches, branchesFromLabels(issue)...)
+		if len(branches) > 0 {
 			for _, branch := range branches {
 				key := OrgRepoBranch{
 					Org:    string(issue.Repository.Owner.Login),
@@ -135,6 +141,22 @@ func parseBranches(str string) []string {
 	return res
 }
 
+// branchesFromLabels returns the branches selected by the issue's
+// branch-scoping labels.
+func branchesFromLabels(issue Issue) []string {
+	var res []string
+	for _, label := range issue.Labels.Nodes {
+		name := string(label.Name)
+		if !strings.HasPrefix(name, branchLabelPrefix) {
+			continue
+		}
+		if branch := strings.TrimSpace(strings.TrimPrefix(name, branchLabelPrefix)); branch != "" {
+			res = append(res, branch)
+		}
+	}
+	return res
+}
+
 func search(ctx context.Context, ghc githubClient, log *logrus.Entry, q string) ([]Issue, error) {
 	requestStart := time.Now()
 	var ret []Issue
@@ -176,6 +198,12 @@ type Issue struct {
 			Login githubql.String
 		}
 	}
+	// Labels may scope the blocker to branches, see branchLabelPrefix.
+	Labels struct {
+		Nodes []struct {
+			Name githubql.String
+		}
+	} `graphql:"labels(first: 100)"`
 }
 
 type searchQuery struct {
@@ -135,6 +135,13 @@ func TestBlockerQuery(t *testing.T) {
 	}
 }
 
+func withLabels(issue Issue, labels ...string) Issue {
+	for _, label := range labels {
+		issue.Labels.Nodes = append(issue.Labels.Nodes, struct{ Name githubql.String }{Name: githubql.String(label)})
+	}
+	return issue
+}
+
 func testIssue(number int, title, org, repo string) Issue {
 	return Issue{
 		Number: githubql.Int(number),
@@ -407,6 +414,52 @@ func TestBlockers(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "1 repo blocker for a branch selected by label",
+			issues: []Issue{
+				withLabels(testIssue(7, "FREEZE THE RELEASE BRANCH!", "k", "t-i"), "blocker", "branch:release-1.2"),
+			},
+			checks: []check{
+				{
+					org:      "k",
+					repo:     "t-i",
+					branch:   "release-1.2",
+					blockers: sets.NewInt(7),
+				},
+				{
+					org:      "k",
+					repo:     "t-i",
+					branch:   "master",
+					blockers: sets.NewInt(),
+				},
+			},
+		},
+		{
+			name: "branches selected by title and label are combined",
+			issues: []Issue{
+				withLabels(testIssue(8, "BLOCK THE feature BRANCH! branch:feature", "k", "t-i"), "branch:release-1.2"),
+			},
+			checks: []check{
+				{
+					org:      "k",
+					repo:     "t-i",
+					branch:   "feature",
+					blockers: sets.NewInt(8),
+				},
+				{
+					org:      "k",
+					repo:     "t-i",
+					branch:   "release-1.2",
+					blockers: sets.NewInt(8),
+				},
+				{
+					org:      "k",
+					repo:     "t-i",
+					branch:   "master",
+					blockers: sets.NewInt(),
+				},
+			},
+		},
 	}
 
 	for _, tc := range tcs {
@@ -19,6 +19,7 @@ package tide
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/clarketm/prow/github"
@@ -40,7 +41,15 @@ func floor(t time.Time) time.Time {
 	return t
 }
 
-func search(query querier, log *logrus.Entry, q string, start, end time.Time) ([]PullRequest, error) {
+// search returns the PRs matching the query that were updated between start
+// and end. If maxResults is positive, at most that many PRs are returned, the
+// oldest first unless the query is sorted otherwise, and the returned bool
+// indicates whether any PRs were cut off.
+func search(query querier, log *logrus.Entry, q string, start, end time.Time, maxResults int) ([]PullRequest, bool, error) {
+	if maxResults > 0 && !strings.Contains(q, "sort:") {
+		// Without a sort order the results that get cut off are arbitrary.
+		q += " sort:created-asc"
+	}
 	start = floor(start)
 	end = floor(end)
 	log = log.WithFields(logrus.Fields{
@@ -57,6 +66,7 @@ func search(query querier, log *logrus.Entry, q string, start, end time.Time) ([
 
 	var totalCost, remaining int
 	var ret []PullRequest
+	var truncated bool
 	var sq searchQuery
 	ctx := context.Background()
 	for {
@@ -65,13 +75,21 @@ func search(query querier, log *logrus.Entry, q string, start, end time.Time) ([
 			if cursor != nil {
 				err = fmt.Errorf("cursor: %q, err: %v", *cursor, err)
 			}
-			return ret, err
+			return ret, false, err
 		}
 		totalCost += int(sq.RateLimit.Cost)
 		remaining = int(sq.RateLimit.Remaining)
 		for _, n := range sq.Search.Nodes {
 			ret = append(ret, n.PullRequest)
 		}
+		if maxResults > 0 && len(ret) >= maxResults {
+			if len(ret) > maxResults || sq.Search.PageInfo.HasNextPage {
+				log.Warnf("Query matched more than %d PRs, truncating the results.", maxResults)
+				truncated = true
+			}
+			ret = ret[:maxResults]
+			break
+		}
 		if !sq.Search.PageInfo.HasNextPage {
 			break
 		}
@@ -80,7 +98,7 @@ func search(query querier, log *logrus.Entry, q string, start, end time.Time) ([
 		log = log.WithField("searchCursor", *cursor)
 	}
 	log.WithField("duration", time.Since(requestStart).String()).Debugf("Query returned %d PRs and cost %d point(s). %d remaining.", len(ret), totalCost, remaining)
-	return ret, nil
+	return ret, truncated, nil
 }
 
 // dateToken generates a GitHub search query token for the specified date range.
@@ -51,15 +51,18 @@ func TestSearch(t *testing.T) {
 	}
 
 	cases := []struct {
-		name     string
-		start    time.Time
-		end      time.Time
-		q        string
-		cursors  []*githubql.String
-		sqs      []searchQuery
-		errs     []error
-		expected []PullRequest
-		err      bool
+		name      string
+		start     time.Time
+		end       time.Time
+		input     string
+		q         string
+		cursors   []*githubql.String
+		sqs       []searchQuery
+		errs      []error
+		max       int
+		expected  []PullRequest
+		truncated bool
+		err       bool
 	}{
 		{
 			name:    "single page works",
@@ -115,6 +118,52 @@ func TestSearch(t *testing.T) {
 			errs:     []error{nil, nil, nil},
 			expected: makePRs(1, 2, 3, 4, 5, 6),
 		},
+		{
+			name:  "stop paginating at the maximum number of results",
+			start: earlier,
+			end:   now,
+			q:     datedQuery(q+" sort:created-asc", earlier, now),
+			cursors: []*githubql.String{
+				nil,
+				githubql.NewString("first"),
+			},
+			sqs: []searchQuery{
+				makeQuery(true, "first", 1, 2),
+				makeQuery(true, "second", 3, 4),
+			},
+			errs:      []error{nil, nil},
+			max:       3,
+			expected:  makePRs(1, 2, 3),
+			truncated: true,
+		},
+		{
+			name:    "keep the sort order of the query when truncating",
+			start:   earlier,
+			end:     now,
+			input:   q + " sort:updated-asc",
+			q:       datedQuery(q+" sort:updated-asc", earlier, now),
+			cursors: []*githubql.String{nil},
+			sqs: []searchQuery{
+				makeQuery(true, "first", 1, 2),
+			},
+			errs:      []error{nil},
+			max:       2,
+			expected:  makePRs(1, 2),
+			truncated: true,
+		},
+		{
+			name:    "no truncation when the results fit",
+			start:   earlier,
+			end:     now,
+			q:       datedQuery(q+" sort:created-asc", earlier, now),
+			cursors: []*githubql.String{nil},
+			sqs: []searchQuery{
+				makeQuery(false, "", 1, 2),
+			},
+			errs:     []error{nil},
+			max:      2,
+			expected: makePRs(1, 2),
+		},
 		{
 			name:  "return partial results on later page failure",
 			start: earlier,
@@ -155,7 +204,11 @@ func TestSearch(t *testing.T) {
 				*ret = sq
 				return nil
 			}
-			prs, err := search(querier, logrus.WithField("test", tc.name), q, tc.start, tc.end)
+			input := tc.input
+			if input == "" {
+				input = q
+			}
+			prs, truncated, err := search(querier, logrus.WithField("test", tc.name), input, tc.start, tc.end, tc.max)
 			switch {
 			case err != nil:
 				if !tc.err {
@@ -164,6 +217,12 @@ func TestSearch(t *testing.T) {
 			case tc.err:
 				t.Errorf("failed to receive expected error")
 			}
+			if i != len(tc.sqs) {
+				t.Errorf("expected %d queries, got %d", len(tc.sqs), i)
+			}
+			if truncated != tc.truncated {
+				t.Errorf("expected truncated %t, got %t", tc.truncated, truncated)
+			}
 			// Always check prs because we might return some results on error
 			if !reflect.DeepEqual(tc.expected, prs) {
 				t.Errorf("prs do not match:\n%s", diff.ObjectReflectDiff(tc.expected, prs))
@@ -46,11 +46,18 @@ import (
 const (
 	statusContext string = "tide"
 	statusInPool         = "In merge pool."
+	// statusPoolTruncated is used when a PR meets the merge requirements but
+	// was not synced because its pool or query exceeded its limit.
+	statusPoolTruncated = "Waiting for room in the merge pool."
 	// statusNotInPool is a format string used when a PR is not in a tide pool.
 	// The '%s' field is populated with the reason why the PR is not in a
 	// tide pool or the empty string if the reason is unknown. See requirementDiff.
 	statusNotInPool = "Not mergeable.%s"
 
+	// poolExitMarker identifies the comment explaining why a PR left the pool
+	// so that it can be updated instead of posting a new one.
+	poolExitMarker = "<!-- tide pool exit -->"
+
 	maxStatusDescriptionLength = 140
 )
 
@@ -81,15 +88,49 @@ type statusController struct {
 
 	sync.Mutex
 	poolPRs          map[string]PullRequest
+	truncated        truncation
 	requiredContexts map[string][]string
+	retests          map[string]map[string]int
 	blocks           blockers.Blockers
 	baseSHAs         map[string]string
 
+	// queriedPRs holds the PRs found by the Tide queries during the last
+	// sync, so that PRs that left the pool are processed even if the
+	// incremental search does not return them.
+	queriedPRs map[string]PullRequest
+
+	// pooled holds the keys of the PRs that entered the pool and have not
+	// been explained to have left it yet. It is only accessed from the sync
+	// loop.
+	pooled sets.String
+
 	storedState
 	opener io.Opener
 	path   string
 }
 
+// truncation records the PRs that Tide may have left out of the pool because
+// a pool or a query exceeded its limit.
+type truncation struct {
+	// prs holds the keys of the PRs that were dropped from their pool.
+	prs sets.String
+	// queries holds the queries whose results were cut off, so any PR of
+	// their repos may be missing.
+	queries config.TideQueries
+}
+
+func (t truncation) has(pr *PullRequest) bool {
+	if t.prs.Has(prKey(pr)) {
+		return true
+	}
+	for _, q := range t.queries {
+		if q.ForRepo(string(pr.Repository.Owner.Login), string(pr.Repository.Name)) {
+			return true
+		}
+	}
+	return false
+}
+
 func (sc *statusController) shutdown() {
 	close(sc.newPoolPending)
 	<-sc.shutDown
@@ -107,7 +148,7 @@ func (sc *statusController) shutdown() {
 // Note: an empty diff can be returned if the reason that the PR does not match
 // the TideQuery is unknown. This can happen if this function's logic
 // does not match GitHub's and does not indicate that the PR matches the query.
-func requirementDiff(pr *PullRequest, q *config.TideQuery, cc contextChecker) (string, int) {
+func requirementDiff(pr *PullRequest, q *config.TideQuery, autoMergeLabel string, cc contextChecker) (string, int) {
 	const maxLabelChars = 50
 	var desc string
 	var diff int
@@ -159,7 +200,8 @@ func requirementDiff(pr *PullRequest, q *config.TideQuery, cc contextChecker) (s
 
 	// Weight incorrect labels and statues with low (normal) diff values.
 	var missingLabels []string
-	for _, l1 := range q.Labels {
+	requiredLabels := append(append([]string{}, q.Labels...), q.MergeLabels(autoMergeLabel)...)
+	for _, l1 := range requiredLabels {
 		var found bool
 		for _, l2 := range pr.Labels.Nodes {
 			if string(l2.Name) == l1 {
@@ -233,7 +275,7 @@ func requirementDiff(pr *PullRequest, q *config.TideQuery, cc contextChecker) (s
 // in order to generate a diff for the status description. We choose the query
 // for the repo that the PR is closest to meeting (as determined by the number
 // of unmet/violated requirements).
-func (sc *statusController) expectedStatus(log *logrus.Entry, queryMap *config.QueryMap, pr *PullRequest, pool map[string]PullRequest, cc contextChecker, blocks blockers.Blockers, baseSHA string) (string, string) {
+func (sc *statusController) expectedStatus(log *logrus.Entry, queryMap *config.QueryMap, pr *PullRequest, pool map[string]PullRequest, truncated truncation, cc contextChecker, blocks blockers.Blockers, baseSHA string, retests map[string]int) (string, string) {
 	org := string(pr.Repository.Owner.Login)
 	repo := string(pr.Repository.Name)
 	if _, ok := pool[prKey(pr)]; !ok {
@@ -252,13 +294,17 @@ func (sc *statusController) expectedStatus(log *logrus.Entry, queryMap *config.Q
 		}
 		minDiffCount := -1
 		var minDiff string
+		autoMergeLabel := sc.config().Tide.AutoMergeLabel
 		for _, q := range queryMap.ForRepo(org, repo) {
-			diff, diffCount := requirementDiff(pr, &q, cc)
+			diff, diffCount := requirementDiff(pr, &q, autoMergeLabel, cc)
 			if minDiffCount == -1 || diffCount < minDiffCount {
 				minDiffCount = diffCount
 				minDiff = diff
 			}
 		}
+		if minDiffCount == 0 && truncated.has(pr) {
+			return github.StatusPending, statusPoolTruncated
+		}
 		return github.StatusPending, fmt.Sprintf(statusNotInPool, minDiff)
 	}
 
@@ -275,6 +321,9 @@ func (sc *statusController) expectedStatus(log *logrus.Entry, queryMap *config.Q
 		passingUpToDateContexts = append(passingUpToDateContexts, pj.Spec.Context)
 	}
 	if diff := cc.MissingRequiredContexts(passingUpToDateContexts); len(diff) > 0 {
+		if exhausted := sc.exhaustedRetests(retests, diff); len(exhausted) > 0 {
+			return github.StatusError, retestLimitStatus(exhausted)
+		}
 		return github.StatePending, retestingStatus(diff)
 	}
 	return github.StatusSuccess, statusInPool
@@ -293,6 +342,35 @@ func retestingStatus(retested []string) string {
 	return all
 }
 
+// exhaustedRetests returns the missing contexts that Tide stopped retesting
+// for the head of the PR because they reached the retest limit.
+func (sc *statusController) exhaustedRetests(retests map[string]int, missing []string) []string {
+	maxRetests := sc.config().Tide.MaxRetestsPerPR
+	if maxRetests == 0 {
+		return nil
+	}
+	var exhausted []string
+	for _, name := range missing {
+		if retests[name] >= maxRetests {
+			exhausted = append(exhausted, name)
+		}
+	}
+	return exhausted
+}
+
+func retestLimitStatus(exhausted []string) string {
+	sort.Strings(exhausted)
+	all := fmt.Sprintf(statusNotInPool, fmt.Sprintf(" Retest limit reached, needs manual attention: %s", strings.Join(exhausted, " ")))
+	if len(all) > maxStatusDescriptionLength {
+		s := ""
+		if len(exhausted) > 1 {
+			s = "s"
+		}
+		return fmt.Sprintf(statusNotInPool, fmt.Sprintf(" Retest limit reached for %d job%s, needs manual attention.", len(exhausted), s))
+	}
+	return all
+}
+
 // targetURL determines the URL used for more details in the status
 // context on GitHub. If no PR dashboard is configured, we will use
 // the administrative Prow overview.
@@ -315,11 +393,14 @@ func targetURL(c config.Getter, pr *PullRequest, log *logrus.Entry) string {
 	return link
 }
 
-func (sc *statusController) setStatuses(all []PullRequest, pool map[string]PullRequest, blocks blockers.Blockers, baseSHAs map[string]string, requiredContexts map[string][]string) {
+func (sc *statusController) setStatuses(all []PullRequest, pool, queried map[string]PullRequest, truncated truncation, blocks blockers.Blockers, baseSHAs map[string]string, requiredContexts map[string][]string, retests map[string]map[string]int) {
 	// queryMap caches which queries match a repo.
 	// Make a new one each sync loop as queries will change.
 	queryMap := sc.config().Tide.Queries.QueryMap()
 	processed := sets.NewString()
+	if sc.pooled == nil {
+		sc.pooled = sets.NewString()
+	}
 
 	process := func(pr *PullRequest) {
 		processed.Insert(prKey(pr))
@@ -345,7 +426,16 @@ func (sc *statusController) setStatuses(all []PullRequest, pool map[string]PullR
 			return
 		}
 
-		wantState, wantDesc := sc.expectedStatus(log, queryMap, pr, pool, cr, blocks, baseSHA)
+		wantState, wantDesc := sc.expectedStatus(log, queryMap, pr, pool, truncated, cr, blocks, baseSHA, retests[prKey(pr)])
+		// PRs that were only truncated from their pool did not really leave it.
+		if _, inPool := pool[prKey(pr)]; !inPool && wantDesc != statusPoolTruncated && sc.pooled.Has(prKey(pr)) {
+			if reason, left := sc.poolExitReason(log, queryMap, pr, cr, blocks); left {
+				if sc.config().Tide.ExplainPoolExit {
+					sc.explainPoolExit(log, pr, reason)
+				}
+				sc.pooled.Delete(prKey(pr))
+			}
+		}
 		var actualState githubql.StatusState
 		var actualDesc string
 		for _, ctx := range contexts {
@@ -397,6 +487,77 @@ func (sc *statusController) setStatuses(all []PullRequest, pool map[string]PullR
 			process(&poolPR)
 		}
 	}
+	// PRs that left the pool without being updated are not returned by the
+	// incremental search, so process them with their state from the last
+	// sync. PRs that no longer match any query were merged or closed.
+	for _, key := range sc.pooled.List() {
+		if processed.Has(key) {
+			continue
+		}
+		if _, inPool := pool[key]; inPool {
+			continue
+		}
+		if queriedPR, ok := queried[key]; ok {
+			process(&queriedPR)
+		} else {
+			sc.pooled.Delete(key)
+		}
+	}
+	sc.pooled.Insert(sets.StringKeySet(pool).UnsortedList()...)
+}
+
+// poolExitReason determines from the current state of a PR that is not in the
+// pool why it cannot be merged. It returns false if the PR is only waiting for
+// pending status contexts, as such PRs move in and out of the pool until their
+// contexts finish and did not leave it for good.
+func (sc *statusController) poolExitReason(log *logrus.Entry, queryMap *config.QueryMap, pr *PullRequest, cc contextChecker, blocks blockers.Blockers) (string, bool) {
+	settled := *pr
+	settled.Commits.Nodes = make([]struct{ Commit Commit }, len(pr.Commits.Nodes))
+	for i, node := range pr.Commits.Nodes {
+		var contexts []Context
+		for _, ctx := range node.Commit.Status.Contexts {
+			if ctx.State == githubql.StatusStatePending {
+				ctx.State = githubql.StatusStateSuccess
+			}
+			contexts = append(contexts, ctx)
+		}
+		node.Commit.Status.Contexts = contexts
+		settled.Commits.Nodes[i] = node
+	}
+	_, desc := sc.expectedStatus(log, queryMap, &settled, nil, truncation{}, cc, blocks, "", nil)
+	if reason := strings.TrimSpace(strings.TrimPrefix(desc, fmt.Sprintf(statusNotInPool, ""))); reason != "" {
+		return reason, true
+	}
+	if pr.Mergeable == githubql.MergeableStateConflicting {
+		return "It has merge conflicts.", true
+	}
+	return "", false
+}
+
+// explainPoolExit comments on a PR that just left the pool with the reason it
+// is no longer mergeable, updating the previous explanation if there is one.
+func (sc *statusController) explainPoolExit(log *logrus.Entry, pr *PullRequest, reason string) {
+	comment := fmt.Sprintf("%s\nThis PR was removed from the merge pool. %s\n\nIt will be added back automatically once it meets the merge requirements again.", poolExitMarker, reason)
+
+	org := string(pr.Repository.Owner.Login)
+	repo := string(pr.Repository.Name)
+	number := int(pr.Number)
+	comments, err := sc.ghc.ListIssueComments(org, repo, number)
+	if err != nil {
+		log.WithError(err).Error("Failed to list comments to explain the pool exit.")
+		return
+	}
+	for _, c := range comments {
+		if strings.Contains(c.Body, poolExitMarker) {
+			if err := sc.ghc.EditComment(org, repo, c.ID, comment); err != nil {
+				log.WithError(err).Error("Failed to update the pool exit comment.")
+			}
+			return
+		}
+	}
+	if err := sc.ghc.CreateComment(org, repo, number, comment); err != nil {
+		log.WithError(err).Error("Failed to comment about the pool exit.")
+	}
 }
 
 func (sc *statusController) load() {
@@ -483,11 +644,14 @@ func (sc *statusController) waitSync() {
 		case <-wait:
 			sc.Lock()
 			pool := sc.poolPRs
+			queried := sc.queriedPRs
+			truncated := sc.truncated
 			blocks := sc.blocks
 			baseSHAs := sc.baseSHAs
 			requiredContexts := sc.requiredContexts
+			retests := sc.retests
 			sc.Unlock()
-			sc.sync(pool, blocks, baseSHAs, requiredContexts)
+			sc.sync(pool, queried, truncated, blocks, baseSHAs, requiredContexts, retests)
 			return
 		case more := <-sc.newPoolPending:
 			if !more {
@@ -497,7 +661,7 @@ func (sc *statusController) waitSync() {
 	}
 }
 
-func (sc *statusController) sync(pool map[string]PullRequest, blocks blockers.Blockers, baseSHAs map[string]string, requiredContexts map[string][]string) {
+func (sc *statusController) sync(pool, queried map[string]PullRequest, truncated truncation, blocks blockers.Blockers, baseSHAs map[string]string, requiredContexts map[string][]string, retests map[string]map[string]int) {
 	sc.lastSyncStart = time.Now()
 	defer func() {
 		duration := time.Since(sc.lastSyncStart)
@@ -506,7 +670,7 @@ func (sc *statusController) sync(pool map[string]PullRequest, blocks blockers.Bl
 		tideMetrics.syncHeartbeat.WithLabelValues("status-update").Inc()
 	}()
 
-	sc.setStatuses(sc.search(), pool, blocks, baseSHAs, requiredContexts)
+	sc.setStatuses(sc.search(), pool, queried, truncated, blocks, baseSHAs, requiredContexts, retests)
 }
 
 func (sc *statusController) search() []PullRequest {
@@ -527,7 +691,7 @@ func (sc *statusController) search() []PullRequest {
 		sc.PreviousQuery = query
 	}
 
-	prs, err := search(sc.ghc.Query, sc.logger, query, sc.LatestPR.Time, now)
+	prs, _, err := search(sc.ghc.Query, sc.logger, query, sc.LatestPR.Time, now, 0)
 	log.WithField("duration", time.Since(now).String()).Debugf("Found %d open PRs.", len(prs))
 	if err != nil {
 		log := log.WithError(err)
@@ -49,9 +49,12 @@ func TestExpectedStatus(t *testing.T) {
 		milestone        string
 		contexts         []Context
 		inPool           bool
+		truncated        truncation
 		blocks           []int
 		prowJobs         []runtime.Object
 		requiredContexts []string
+		maxRetests       int
+		retests          map[string]int
 
 		state string
 		desc  string
@@ -193,6 +196,39 @@ func TestExpectedStatus(t *testing.T) {
 			state: github.StatusPending,
 			desc:  fmt.Sprintf(statusNotInPool, ""),
 		},
+		{
+			name:      "truncated from the pool",
+			labels:    neededLabels,
+			milestone: "v1.0",
+			contexts:  []Context{{Context: githubql.String("job-name"), State: githubql.StatusStateSuccess}},
+			inPool:    false,
+			truncated: truncation{prs: sets.NewString("#0")},
+
+			state: github.StatusPending,
+			desc:  statusPoolTruncated,
+		},
+		{
+			name:      "repo of a truncated query",
+			labels:    neededLabels,
+			milestone: "v1.0",
+			contexts:  []Context{{Context: githubql.String("job-name"), State: githubql.StatusStateSuccess}},
+			inPool:    false,
+			truncated: truncation{queries: config.TideQueries{{Orgs: []string{""}}}},
+
+			state: github.StatusPending,
+			desc:  statusPoolTruncated,
+		},
+		{
+			name:      "truncated from the pool but missing requirements",
+			labels:    neededLabels,
+			milestone: "v1.1",
+			contexts:  []Context{{Context: githubql.String("job-name"), State: githubql.StatusStateSuccess}},
+			inPool:    false,
+			truncated: truncation{prs: sets.NewString("#0")},
+
+			state: github.StatusPending,
+			desc:  fmt.Sprintf(statusNotInPool, " Must be in milestone v1.0."),
+		},
 		{
 			name:      "check that min diff query is used",
 			labels:    []string{"3", "4", "5", "6", "7"},
@@ -399,6 +435,60 @@ func TestExpectedStatus(t *testing.T) {
 			state: github.StatusPending,
 			desc:  "Not mergeable. Retesting 2 jobs.",
 		},
+		{
+			name:             "missing context below the retest limit is retested",
+			inPool:           true,
+			baseref:          "baseref",
+			requiredContexts: []string{"foo", "bar"},
+			maxRetests:       2,
+			prowJobs: []runtime.Object{
+				&prowapi.ProwJob{
+					ObjectMeta: metav1.ObjectMeta{Name: "123"},
+					Spec: prowapi.ProwJobSpec{
+						Context: "foo",
+						Refs: &prowapi.Refs{
+							BaseSHA: "baseref",
+							Pulls:   []prowapi.Pull{{SHA: "head"}},
+						},
+						Type: prowapi.PresubmitJob,
+					},
+					Status: prowapi.ProwJobStatus{
+						State: prowapi.SuccessState,
+					},
+				},
+			},
+			retests: map[string]int{"bar": 1},
+
+			state: github.StatusPending,
+			desc:  "Not mergeable. Retesting: bar",
+		},
+		{
+			name:             "missing context that reached the retest limit needs manual attention",
+			inPool:           true,
+			baseref:          "baseref",
+			requiredContexts: []string{"foo", "bar"},
+			maxRetests:       2,
+			prowJobs: []runtime.Object{
+				&prowapi.ProwJob{
+					ObjectMeta: metav1.ObjectMeta{Name: "123"},
+					Spec: prowapi.ProwJobSpec{
+						Context: "foo",
+						Refs: &prowapi.Refs{
+							BaseSHA: "baseref",
+							Pulls:   []prowapi.Pull{{SHA: "head"}},
+						},
+						Type: prowapi.PresubmitJob,
+					},
+					Status: prowapi.ProwJobStatus{
+						State: prowapi.SuccessState,
+					},
+				},
+			},
+			retests: map[string]int{"bar": 2},
+
+			state: github.StatusError,
+			desc:  "Not mergeable. Retest limit reached, needs manual attention: bar",
+		},
 	}
 
 	for _, tc := range testcases {
@@ -468,12 +558,14 @@ func TestExpectedStatus(t *testing.T) {
 			}
 			blocks.Repo[blockers.OrgRepo{Org: "", Repo: ""}] = items
 
-			sc, err := newStatusController(logrus.NewEntry(logrus.StandardLogger()), nil, newFakeManager(tc.prowJobs...), nil, nil, nil, "")
+			cfg := &config.Config{}
+			cfg.Tide.MaxRetestsPerPR = tc.maxRetests
+			sc, err := newStatusController(logrus.NewEntry(logrus.StandardLogger()), nil, newFakeManager(tc.prowJobs...), nil, func() *config.Config { return cfg }, nil, "")
 			if err != nil {
 				t.Fatalf("failed to get statusController: %v", err)
 			}
 			cc := &config.TideContextPolicy{RequiredContexts: tc.requiredContexts}
-			state, desc := sc.expectedStatus(sc.logger, queriesByRepo, &pr, pool, cc, blocks, tc.baseref)
+			state, desc := sc.expectedStatus(sc.logger, queriesByRepo, &pr, pool, tc.truncated, cc, blocks, tc.baseref, tc.retests)
 			if state != tc.state {
 				t.Errorf("Expected status state %q, but got %q.", string(tc.state), string(state))
 			}
@@ -484,6 +576,52 @@ func TestExpectedStatus(t *testing.T) {
 	}
 }
 
+func TestRequirementDiffRequiredMergeLabels(t *testing.T) {
+	query := &config.TideQuery{
+		Orgs:                []string{"org"},
+		Labels:              []string{"ok-to-merge"},
+		RequiredMergeLabels: []string{"lgtm", "approved"},
+	}
+	testCases := []struct {
+		name         string
+		labels       []string
+		expectedDesc string
+		expectedDiff int
+	}{
+		{
+			name:   "all labels present",
+			labels: []string{"ok-to-merge", "lgtm", "approved"},
+		},
+		{
+			name:         "one required merge label missing",
+			labels:       []string{"ok-to-merge", "lgtm"},
+			expectedDesc: " Needs approved label.",
+			expectedDiff: 1,
+		},
+		{
+			name:         "required merge labels and query label missing",
+			labels:       []string{"lgtm"},
+			expectedDesc: " Needs approved, ok-to-merge labels.",
+			expectedDiff: 2,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var pr PullRequest
+			for _, label := range tc.labels {
+				pr.Labels.Nodes = append(pr.Labels.Nodes, struct{ Name githubql.String }{Name: githubql.String(label)})
+			}
+			desc, diff := requirementDiff(&pr, query, "", &config.TideContextPolicy{})
+			if desc != tc.expectedDesc {
+				t.Errorf("Expected description %q, got %q.", tc.expectedDesc, desc)
+			}
+			if diff != tc.expectedDiff {
+				t.Errorf("Expected diff %d, got %d.", tc.expectedDiff, diff)
+			}
+		})
+	}
+}
+
 func TestSetStatuses(t *testing.T) {
 	statusNotInPoolEmpty := fmt.Sprintf(statusNotInPool, "")
 	testcases := []struct {
@@ -596,7 +734,7 @@ func TestSetStatuses(t *testing.T) {
 		if err != nil {
 			t.Fatalf("failed to get statusController: %v", err)
 		}
-		sc.setStatuses([]PullRequest{pr}, pool, blockers.Blockers{}, nil, nil)
+		sc.setStatuses([]PullRequest{pr}, pool, nil, truncation{}, blockers.Blockers{}, nil, nil, nil)
 		if str, err := log.String(); err != nil {
 			t.Fatalf("For case %s: failed to get log output: %v", tc.name, err)
 		} else if str != initialLog {
@@ -754,7 +892,7 @@ func TestSetStatusRespectsRequiredContexts(t *testing.T) {
 		pjClient: fakectrlruntimeclient.NewFakeClient(),
 	}
 	pool := map[string]PullRequest{prKey(&pr): pr}
-	sc.setStatuses([]PullRequest{pr}, pool, blockers.Blockers{}, nil, requiredContexts)
+	sc.setStatuses([]PullRequest{pr}, pool, nil, truncation{}, blockers.Blockers{}, nil, requiredContexts, nil)
 	if str, err := log.String(); err != nil {
 		t.Fatalf("Failed to get log output: %v", err)
 	} else if str != initialLog {
@@ -774,3 +912,180 @@ func TestSetStatusRespectsRequiredContexts(t *testing.T) {
 		t.Errorf("Expected description to be %q, was %q", expectedDescription, val.Description)
 	}
 }
+
+func TestExplainPoolExit(t *testing.T) {
+	var pr PullRequest
+	pr.Commits.Nodes = []struct{ Commit Commit }{{}}
+	pr.Repository.Owner.Login = githubql.String("org")
+	pr.Repository.Name = githubql.String("repo")
+	pr.Repository.NameWithOwner = githubql.String("org/repo")
+	pr.Number = githubql.Int(2)
+
+	testCases := []struct {
+		name            string
+		explainPoolExit bool
+		truncated       bool
+		expectedComment string
+	}{
+		{
+			name: "disabled",
+		},
+		{
+			name:            "enabled",
+			explainPoolExit: true,
+			expectedComment: poolExitMarker + "\nThis PR was removed from the merge pool. Needs lgtm label.\n\nIt will be added back automatically once it meets the merge requirements again.",
+		},
+		{
+			name:            "truncated PRs did not leave the pool",
+			explainPoolExit: true,
+			truncated:       true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fghc := &fgc{}
+			sc := &statusController{
+				logger: logrus.WithField("component", "tide"),
+				ghc:    fghc,
+				config: func() *config.Config {
+					return &config.Config{
+						ProwConfig: config.ProwConfig{
+							Tide: config.Tide{
+								Queries:         config.TideQueries{{Repos: []string{"org/repo"}, Labels: []string{"lgtm"}}},
+								ExplainPoolExit: tc.explainPoolExit,
+							},
+						},
+					}
+				},
+				pjClient: fakectrlruntimeclient.NewFakeClient(),
+			}
+			pooled := map[string]PullRequest{prKey(&pr): pr}
+			pr := pr
+			var truncated truncation
+			if tc.truncated {
+				// Truncation only applies to PRs that meet the merge requirements.
+				pr.Labels.Nodes = []struct{ Name githubql.String }{{Name: githubql.String("lgtm")}}
+				truncated = truncation{prs: sets.NewString(prKey(&pr))}
+			}
+
+			// The PR enters the pool, leaves it, enters it again and leaves it
+			// again. Only a single comment should be kept up to date.
+			for i := 0; i < 2; i++ {
+				sc.setStatuses([]PullRequest{pr}, pooled, nil, truncation{}, blockers.Blockers{}, nil, nil, nil)
+				sc.setStatuses([]PullRequest{pr}, map[string]PullRequest{}, nil, truncated, blockers.Blockers{}, nil, nil, nil)
+			}
+			// Staying out of the pool does not comment again.
+			sc.setStatuses([]PullRequest{pr}, map[string]PullRequest{}, nil, truncated, blockers.Blockers{}, nil, nil, nil)
+
+			if tc.expectedComment == "" {
+				if n := len(fghc.comments[2]); n != 0 {
+					t.Errorf("expected no comments, got %d", n)
+				}
+				return
+			}
+			if n := len(fghc.comments[2]); n != 1 {
+				t.Fatalf("expected exactly one comment, got %d", n)
+			}
+			if body := fghc.comments[2][0].Body; body != tc.expectedComment {
+				t.Errorf("expected comment %q, got %q", tc.expectedComment, body)
+			}
+		})
+	}
+}
+
+func TestPoolExitFromCurrentState(t *testing.T) {
+	newPR := func(state githubql.StatusState) PullRequest {
+		var pr PullRequest
+		pr.Repository.Owner.Login = githubql.String("org")
+		pr.Repository.Name = githubql.String("repo")
+		pr.Repository.NameWithOwner = githubql.String("org/repo")
+		pr.Number = githubql.Int(2)
+		pr.HeadRefOID = githubql.String("sha")
+		pr.Labels.Nodes = []struct{ Name githubql.String }{{Name: githubql.String("lgtm")}}
+		var commit Commit
+		commit.OID = githubql.String("sha")
+		commit.Status.Contexts = []Context{{Context: githubql.String("ci"), State: state}}
+		pr.Commits.Nodes = []struct{ Commit Commit }{{Commit: commit}}
+		return pr
+	}
+	expectedComment := poolExitMarker + "\nThis PR was removed from the merge pool. Job ci has not succeeded.\n\nIt will be added back automatically once it meets the merge requirements again."
+
+	testCases := []struct {
+		name            string
+		sync            func(sc *statusController, pr PullRequest)
+		expectedComment string
+	}{
+		{
+			name: "pending contexts are not a pool exit",
+			sync: func(sc *statusController, pr PullRequest) {
+				pending := newPR(githubql.StatusStatePending)
+				for i := 0; i < 2; i++ {
+					sc.setStatuses([]PullRequest{pr}, map[string]PullRequest{prKey(&pr): pr}, nil, truncation{}, blockers.Blockers{}, nil, nil, nil)
+					sc.setStatuses([]PullRequest{pending}, map[string]PullRequest{}, nil, truncation{}, blockers.Blockers{}, nil, nil, nil)
+				}
+			},
+		},
+		{
+			name: "failure after pending contexts is a pool exit",
+			sync: func(sc *statusController, pr PullRequest) {
+				pending, failed := newPR(githubql.StatusStatePending), newPR(githubql.StatusStateFailure)
+				sc.setStatuses([]PullRequest{pr}, map[string]PullRequest{prKey(&pr): pr}, nil, truncation{}, blockers.Blockers{}, nil, nil, nil)
+				sc.setStatuses([]PullRequest{pending}, map[string]PullRequest{}, nil, truncation{}, blockers.Blockers{}, nil, nil, nil)
+				sc.setStatuses([]PullRequest{failed}, map[string]PullRequest{}, nil, truncation{}, blockers.Blockers{}, nil, nil, nil)
+			},
+			expectedComment: expectedComment,
+		},
+		{
+			name: "PR that left the pool outside of the incremental search",
+			sync: func(sc *statusController, pr PullRequest) {
+				failed := newPR(githubql.StatusStateFailure)
+				sc.setStatuses([]PullRequest{pr}, map[string]PullRequest{prKey(&pr): pr}, nil, truncation{}, blockers.Blockers{}, nil, nil, nil)
+				sc.setStatuses(nil, map[string]PullRequest{}, map[string]PullRequest{prKey(&failed): failed}, truncation{}, blockers.Blockers{}, nil, nil, nil)
+			},
+			expectedComment: expectedComment,
+		},
+		{
+			name: "PR that no longer matches any query is forgotten",
+			sync: func(sc *statusController, pr PullRequest) {
+				failed := newPR(githubql.StatusStateFailure)
+				sc.setStatuses([]PullRequest{pr}, map[string]PullRequest{prKey(&pr): pr}, nil, truncation{}, blockers.Blockers{}, nil, nil, nil)
+				sc.setStatuses(nil, map[string]PullRequest{}, nil, truncation{}, blockers.Blockers{}, nil, nil, nil)
+				sc.setStatuses([]PullRequest{failed}, map[string]PullRequest{}, nil, truncation{}, blockers.Blockers{}, nil, nil, nil)
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fghc := &fgc{}
+			sc := &statusController{
+				logger: logrus.WithField("component", "tide"),
+				ghc:    fghc,
+				config: func() *config.Config {
+					return &config.Config{
+						ProwConfig: config.ProwConfig{
+							Tide: config.Tide{
+								Queries:         config.TideQueries{{Repos: []string{"org/repo"}, Labels: []string{"lgtm"}}},
+								ExplainPoolExit: true,
+							},
+						},
+					}
+				},
+				pjClient: fakectrlruntimeclient.NewFakeClient(),
+			}
+			tc.sync(sc, newPR(githubql.StatusStateSuccess))
+
+			if tc.expectedComment == "" {
+				if n := len(fghc.comments[2]); n != 0 {
+					t.Errorf("expected no comments, got %d: %v", n, fghc.comments[2])
+				}
+				return
+			}
+			if n := len(fghc.comments[2]); n != 1 {
+				t.Fatalf("expected exactly one comment, got %d", n)
+			}
+			if body := fghc.comments[2][0].Body; body != tc.expectedComment {
+				t.Errorf("expected comment %q, got %q", tc.expectedComment, body)
+			}
+		})
+	}
+}
@@ -26,6 +26,7 @@ import (
 	"fmt"
 	"net/http"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -43,6 +44,7 @@ import (
 	"github.com/clarketm/prow/errorutil"
 	"github.com/clarketm/prow/git"
 	"github.com/clarketm/prow/github"
+	"github.com/clarketm/prow/kube"
 	"github.com/clarketm/prow/pjutil"
 	"github.com/clarketm/prow/tide/blockers"
 	"github.com/clarketm/prow/tide/history"
@@ -52,10 +54,13 @@ import (
 var sleep = time.Sleep
 
 type githubClient interface {
+	CreateComment(org, repo string, number int, comment string) error
 	CreateStatus(string, string, string, github.Status) error
+	EditComment(org, repo string, id int, comment string) error
 	GetCombinedStatus(org, repo, ref string) (*github.CombinedStatus, error)
 	GetPullRequestChanges(org, repo string, number int) ([]github.PullRequestChange, error)
 	GetRef(string, string, string) (string, error)
+	ListIssueComments(org, repo string, number int) ([]github.IssueComment, error)
 	Merge(string, string, int, github.MergeDetails) error
 	Query(context.Context, interface{}, map[string]interface{}) error
 }
@@ -86,6 +91,42 @@ type Controller struct {
 	changedFiles *changedFilesAgent
 
 	History *history.History
+
+	// dryRun makes the controller report the merges and ProwJob changes it
+	// would make instead of performing them.
+	dryRun bool
+	// plannedLock guards planned, the dry-run actions of the ongoing sync.
+	plannedLock sync.Mutex
+	planned     []DryRunAction
+	// dryRunReport holds the dry-run actions of the last sync. Guarded by m.
+	dryRunReport DryRunReport
+}
+
+// Kinds of actions reported in dry-run mode.
+const (
+	DryRunMerge           = "merge"
+	DryRunCreateProwJob   = "create-prowjob"
+	DryRunAbortStaleBatch = "abort-stale-batch"
+)
+
+// DryRunAction is a mutation that the controller skipped in dry-run mode.
+type DryRunAction struct {
+	Org    string `json:"org"`
+	Repo   string `json:"repo"`
+	Branch string `json:"branch"`
+	// Kind is one of DryRunMerge, DryRunCreateProwJob and DryRunAbortStaleBatch.
+	Kind string `json:"kind"`
+	PRs  []int  `json:"prs"`
+	// MergeMethod is set for merges.
+	MergeMethod github.PullRequestMergeType `json:"merge_method,omitempty"`
+	// Job is set for ProwJob creations and aborts.
+	Job string `json:"job,omitempty"`
+}
+
+// DryRunReport lists the actions skipped during the last sync in dry-run mode.
+type DryRunReport struct {
+	Time    time.Time      `json:"time"`
+	Actions []DryRunAction `json:"actions"`
 }
 
 // Action represents what actions the controller can take. It will take
@@ -100,6 +141,7 @@ const (
 	Merge               = "MERGE"
 	MergeBatch          = "MERGE_BATCH"
 	PoolBlocked         = "BLOCKED"
+	PoolPaused          = "PAUSED"
 )
 
 // recordableActions is the subset of actions that we keep historical record of.
@@ -132,7 +174,11 @@ type Pool struct {
 	Action   Action
 	Target   []PullRequest
 	Blockers []blockers.Blocker
-	Error    string
+	// Freezes are the issues that freeze merges into the pool's branch.
+	Freezes []blockers.Blocker
+	// Pauses are the issues that pause Tide on the pool's branch.
+	Pauses []blockers.Blocker
+	Error  string
 }
 
 // Prometheus Metrics
@@ -224,7 +270,11 @@ type manager interface {
 }
 
 // NewController makes a Controller out of the given clients.
-func NewController(ghcSync, ghcStatus github.Client, mgr manager, cfg config.Getter, gc *git.Client, maxRecordsPerPool int, opener io.Opener, historyURI, statusURI string, logger *logrus.Entry) (*Controller, error) {
+//
+// In dry-run mode the controller neither merges PRs nor creates or aborts
+// ProwJobs, but reports what it would have done through ServeDryRunReport.
+// The GitHub clients are expected to be dry-run clients in that case.
+func NewController(ghcSync, ghcStatus github.Client, mgr manager, cfg config.Getter, gc *git.Client, maxRecordsPerPool int, opener io.Opener, historyURI, statusURI string, dryRun bool, logger *logrus.Entry) (*Controller, error) {
 	if logger == nil {
 		logger = logrus.NewEntry(logrus.StandardLogger())
 	}
@@ -239,7 +289,7 @@ func NewController(ghcSync, ghcStatus github.Client, mgr manager, cfg config.Get
 	}
 	go sc.run()
 
-	return newSyncController(logger, ghcSync, mgr, cfg, gc, sc, hist)
+	return newSyncController(logger, ghcSync, mgr, cfg, gc, sc, hist, dryRun)
 }
 
 func newStatusController(logger *logrus.Entry, ghc githubClient, mgr manager, gc *git.Client, cfg config.Getter, opener io.Opener, statusURI string) (*statusController, error) {
@@ -267,6 +317,7 @@ func newSyncController(
 	gc *git.Client,
 	sc *statusController,
 	hist *history.History,
+	dryRun bool,
 ) (*Controller, error) {
 	if err := mgr.GetFieldIndexer().IndexField(
 		&prowapi.ProwJob{},
@@ -288,6 +339,7 @@ func newSyncController(
 			nextChangeCache: make(map[changeCacheKey][]string),
 		},
 		History: hist,
+		dryRun:  dryRun,
 	}, nil
 }
 
@@ -345,12 +397,16 @@ func (c *Controller) Sync() error {
 
 	c.logger.Debug("Building tide pool.")
 	prs := make(map[string]PullRequest)
+	var truncatedQueries config.TideQueries
 	for _, query := range c.config().Tide.Queries {
 		q := query.Query()
-		results, err := search(c.ghc.Query, c.logger, q, time.Time{}, time.Now())
+		results, truncated, err := search(c.ghc.Query, c.logger, q, time.Time{}, time.Now(), c.config().Tide.MaxQueryResults)
 		if err != nil && len(results) == 0 {
 			return fmt.Errorf("query %q, err: %v", q, err)
 		}
+		if truncated {
+			truncatedQueries = append(truncatedQueries, query)
+		}
 		if err != nil {
 			c.logger.WithError(err).WithField("query", q).Warning("found partial results")
 		}
@@ -362,18 +418,26 @@ func (c *Controller) Sync() error {
 		"duration", time.Since(start).String(),
 	).Debugf("Found %d (unfiltered) pool PRs.", len(prs))
 
-	var blocks blockers.Blockers
+	var blocks, freezes, pauses blockers.Blockers
 	var err error
 	if len(prs) > 0 {
 		if label := c.config().Tide.BlockerLabel; label != "" {
 			c.logger.Debugf("Searching for blocking issues (label %q).", label)
-			orgExcepts, repos := c.config().Tide.Queries.OrgExceptionsAndRepos()
-			orgs := make([]string, 0, len(orgExcepts))
-			for org := range orgExcepts {
-				orgs = append(orgs, org)
+			blocks, err = blockers.FindAll(c.ghc, c.logger, label, c.orgRepoQuery())
+			if err != nil {
+				return err
 			}
-			orgRepoQuery := orgRepoQueryString(orgs, repos.UnsortedList(), orgExcepts)
-			blocks, err = blockers.FindAll(c.ghc, c.logger, label, orgRepoQuery)
+		}
+		if label := c.config().Tide.FreezeLabel; label != "" {
+			c.logger.Debugf("Searching for freeze issues (label %q).", label)
+			freezes, err = blockers.FindAll(c.ghc, c.logger, label, c.orgRepoQuery())
+			if err != nil {
+				return err
+			}
+		}
+		if label := c.config().Tide.PauseLabel; label != "" {
+			c.logger.Debugf("Searching for pausing issues (label %q).", label)
+			pauses, err = blockers.FindAll(c.ghc, c.logger, label, c.orgRepoQuery())
 			if err != nil {
 				return err
 			}
@@ -384,14 +448,19 @@ func (c *Controller) Sync() error {
 	if err != nil {
 		return err
 	}
-	filteredPools := c.filterSubpools(c.config().Tide.MaxGoroutines, rawPools)
+	filteredPools, truncatedPRs := c.filterSubpools(c.config().Tide.MaxGoroutines, rawPools)
+	// Batch groups span several subpools, so they are synced beforehand.
+	c.syncBatchGroups(filteredPools, blocks, freezes, pauses)
 
 	// Notify statusController about the new pool.
 	c.sc.Lock()
 	c.sc.blocks = blocks
 	c.sc.poolPRs = poolPRMap(filteredPools)
+	c.sc.queriedPRs = prs
+	c.sc.truncated = truncation{prs: truncatedPRs, queries: truncatedQueries}
 	c.sc.baseSHAs = baseSHAMap(filteredPools)
 	c.sc.requiredContexts = requiredContextsMap(filteredPools)
+	c.sc.retests = retestsMap(filteredPools)
 	select {
 	case c.sc.newPoolPending <- true:
 	default:
@@ -404,7 +473,7 @@ func (c *Controller) Sync() error {
 		c.config().Tide.MaxGoroutines,
 		filteredPools,
 		func(sp *subpool) {
-			pool, err := c.syncSubpool(*sp, blocks.GetApplicable(sp.org, sp.repo, sp.branch))
+			pool, err := c.syncSubpool(*sp, blocks.GetApplicable(sp.org, sp.repo, sp.branch), freezes.GetApplicable(sp.org, sp.repo, sp.branch), pauses.GetApplicable(sp.org, sp.repo, sp.branch))
 			if err != nil {
 				tideMetrics.poolErrors.WithLabelValues(sp.org, sp.repo, sp.branch).Inc()
 				sp.log.WithError(err).Errorf("Error syncing subpool.")
@@ -423,10 +492,24 @@ func (c *Controller) Sync() error {
 	c.pools = pools
 	c.m.Unlock()
 
+	if c.dryRun {
+		c.publishDryRunReport()
+	}
+
 	c.History.Flush()
 	return nil
 }
 
+// orgRepoQuery returns the search query tokens matching the repos Tide is configured for.
+func (c *Controller) orgRepoQuery() string {
+	orgExcepts, repos := c.config().Tide.Queries.OrgExceptionsAndRepos()
+	orgs := make([]string, 0, len(orgExcepts))
+	for org := range orgExcepts {
+		orgs = append(orgs, org)
+	}
+	return orgRepoQueryString(orgs, repos.UnsortedList(), orgExcepts)
+}
+
 func (c *Controller) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	c.m.Lock()
 	defer c.m.Unlock()
@@ -440,6 +523,59 @@ func (c *Controller) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// ServeDryRunReport serves the actions that were skipped during the last sync
+// because the controller runs in dry-run mode.
+func (c *Controller) ServeDryRunReport(w http.ResponseWriter, r *http.Request) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	b, err := json.Marshal(c.dryRunReport)
+	if err != nil {
+		c.logger.WithError(err).Error("Encoding JSON.")
+		b = []byte("{}")
+	}
+	if _, err = w.Write(b); err != nil {
+		c.logger.WithError(err).Error("Writing JSON response.")
+	}
+}
+
+// planDryRunAction records an action that was skipped in dry-run mode.
+func (c *Controller) planDryRunAction(action DryRunAction) {
+	c.plannedLock.Lock()
+	defer c.plannedLock.Unlock()
+	c.planned = append(c.planned, action)
+}
+
+// publishDryRunReport replaces the dry-run report with the actions planned
+// during the sync that just completed.
+func (c *Controller) publishDryRunReport() {
+	c.plannedLock.Lock()
+	actions := c.planned
+	c.planned = nil
+	c.plannedLock.Unlock()
+
+	sort.SliceStable(actions, func(i, j int) bool {
+		if key, other := poolKey(actions[i].Org, actions[i].Repo, actions[i].Branch), poolKey(actions[j].Org, actions[j].Repo, actions[j].Branch); key != other {
+			return key < other
+		}
+		return actions[i].Kind < actions[j].Kind
+	})
+	for _, action := range actions {
+		c.logger.WithFields(logrus.Fields{
+			"org":          action.Org,
+			"repo":         action.Repo,
+			"branch":       action.Branch,
+			"kind":         action.Kind,
+			"prs":          action.PRs,
+			"merge-method": action.MergeMethod,
+			"job":          action.Job,
+		}).Info("Dry-run: skipped action.")
+	}
+
+	c.m.Lock()
+	c.dryRunReport = DryRunReport{Time: time.Now(), Actions: actions}
+	c.m.Unlock()
+}
+
 func subpoolsInParallel(goroutines int, sps map[string]*subpool, process func(*subpool)) {
 	// Load the subpools into a channel for use as a work queue.
 	queue := make(chan *subpool, len(sps))
@@ -465,16 +601,25 @@ func subpoolsInParallel(goroutines int, sps map[string]*subpool, process func(*s
 }
 
 // filterSubpools filters non-pool PRs out of the initially identified subpools,
-// deleting any pools that become empty.
+// deleting any pools that become empty. It also returns the keys of the PRs
+// that were not synced because their subpool exceeded the max pool size.
 // See filterSubpool for filtering details.
-func (c *Controller) filterSubpools(goroutines int, raw map[string]*subpool) map[string]*subpool {
+func (c *Controller) filterSubpools(goroutines int, raw map[string]*subpool) (map[string]*subpool, sets.String) {
 	filtered := make(map[string]*subpool)
+	truncated := sets.NewString()
 	var lock sync.Mutex
 
 	subpoolsInParallel(
 		goroutines,
 		raw,
 		func(sp *subpool) {
+			if dropped := truncateSubpool(sp, c.config().Tide.MaxPoolSize, c.config().Tide.PoolSortOrder); len(dropped) > 0 {
+				lock.Lock()
+				for _, pr := range dropped {
+					truncated.Insert(prKey(&pr))
+				}
+				lock.Unlock()
+			}
 			if err := c.initSubpoolData(sp); err != nil {
 				sp.log.WithError(err).Error("Error initializing subpool.")
 				return
@@ -491,7 +636,21 @@ func (c *Controller) filterSubpools(goroutines int, raw map[string]*subpool) map
 			}
 		},
 	)
-	return filtered
+	return filtered, truncated
+}
+
+// truncateSubpool limits the subpool to the first max PRs in the given sort
+// order and returns the PRs it dropped. A non-positive max disables the limit.
+func truncateSubpool(sp *subpool, max int, order config.TidePoolSortOrder) []PullRequest {
+	if max <= 0 || len(sp.prs) <= max {
+		return nil
+	}
+	less := prLess(order)
+	sort.Slice(sp.prs, func(i, j int) bool { return less(sp.prs[i], sp.prs[j]) })
+	dropped := sp.prs[max:]
+	sp.log.WithField("dropped-prs", prNumbers(dropped)).Warnf("Subpool has %d PRs, only syncing the first %d.", len(sp.prs), max)
+	sp.prs = sp.prs[:max]
+	return dropped
 }
 
 func (c *Controller) initSubpoolData(sp *subpool) error {
@@ -500,6 +659,16 @@ func (c *Controller) initSubpoolData(sp *subpool) error {
 	if err != nil {
 		return fmt.Errorf("error determining required presubmit prowjobs: %v", err)
 	}
+	for _, q := range c.config().Tide.Queries {
+		if q.ForRepo(sp.org, sp.repo) {
+			sp.queries = append(sp.queries, q)
+		}
+	}
+	sp.autoMergeLabel = c.config().Tide.AutoMergeLabel
+	sp.retests, err = retestCounts(c.ctx, c.prowJobClient, c.config().ProwJobNamespace, sp)
+	if err != nil {
+		return fmt.Errorf("error counting the retests of the PRs: %v", err)
+	}
 	sp.cc = make(map[int]contextChecker, len(sp.prs))
 	for _, pr := range sp.prs {
 		sp.cc[int(pr.Number)], err = c.config().GetTideContextPolicy(c.gc, sp.org, sp.repo, sp.branch, refGetterFactory(string(sp.sha)), string(pr.HeadRefOID))
@@ -531,6 +700,7 @@ func filterSubpool(ghc githubClient, sp *subpool) *subpool {
 // filterPR indicates if a PR should be filtered out of the subpool.
 // Specifically we filter out PRs that:
 // - Have known merge conflicts.
+// - Miss any of the required merge labels of the queries they match.
 // - Have failing or missing status contexts.
 // - Have pending required status contexts that are not associated with a
 //   ProwJob. (This ensures that the 'tide' context indicates that the pending
@@ -544,6 +714,10 @@ func filterPR(ghc githubClient, sp *subpool, pr *PullRequest) bool {
 		log.Debug("filtering out PR as it is unmergeable")
 		return true
 	}
+	if missing := missingRequiredMergeLabels(sp.queries, sp.autoMergeLabel, pr); len(missing) > 0 {
+		log.WithField("missing_labels", missing).Debug("filtering out PR as it is missing required merge labels")
+		return true
+	}
 	// Filter out PRs with unsuccessful contexts unless the only unsuccessful
 	// contexts are pending required prowjobs.
 	contexts, err := headContexts(log, ghc, pr)
@@ -606,6 +780,19 @@ func requiredContextsMap(subpoolMap map[string]*subpool) map[string][]string {
 	return requiredContextsMap
 }
 
+// retestsMap collects the retest counts of all subpool PRs, keyed by PR.
+func retestsMap(subpoolMap map[string]*subpool) map[string]map[string]int {
+	retests := map[string]map[string]int{}
+	for _, sp := range subpoolMap {
+		for _, pr := range sp.prs {
+			if counts, ok := sp.retests[int(pr.Number)]; ok {
+				retests[prKey(&pr)] = counts
+			}
+		}
+	}
+	return retests
+}
+
 type simpleState string
 
 const (
@@ -663,11 +850,12 @@ func unsuccessfulContexts(contexts []Context, cc contextChecker, log *logrus.Ent
 	return failed
 }
 
-func pickSmallestPassingNumber(log *logrus.Entry, ghc githubClient, prs []PullRequest, cc map[int]contextChecker) (bool, PullRequest) {
-	smallestNumber := -1
+func pickSmallestPassingNumber(log *logrus.Entry, ghc githubClient, prs []PullRequest, cc map[int]contextChecker, order config.TidePoolSortOrder) (bool, PullRequest) {
+	less := prLess(order)
+	found := false
 	var smallestPR PullRequest
 	for _, pr := range prs {
-		if smallestNumber != -1 && int(pr.Number) >= smallestNumber {
+		if found && !less(pr, smallestPR) {
 			continue
 		}
 		if len(pr.Commits.Nodes) < 1 {
@@ -676,16 +864,36 @@ func pickSmallestPassingNumber(log *logrus.Entry, ghc githubClient, prs []PullRe
 		if !isPassingTests(log, ghc, pr, cc[int(pr.Number)]) {
 			continue
 		}
-		smallestNumber = int(pr.Number)
+		found = true
 		smallestPR = pr
 	}
-	return smallestNumber > -1, smallestPR
+	return found, smallestPR
+}
+
+// prLess returns a function that reports whether a PR should be preferred
+// over another one according to the configured pool sort order. Ties are
+// broken by PR number.
+func prLess(order config.TidePoolSortOrder) func(a, b PullRequest) bool {
+	return func(a, b PullRequest) bool {
+		switch order {
+		case config.PoolSortOrderCreated:
+			if !a.CreatedAt.Equal(b.CreatedAt.Time) {
+				return a.CreatedAt.Before(b.CreatedAt.Time)
+			}
+		case config.PoolSortOrderUpdated:
+			if !a.UpdatedAt.Equal(b.UpdatedAt.Time) {
+				return a.UpdatedAt.Before(b.UpdatedAt.Time)
+			}
+		}
+		return a.Number < b.Number
+	}
 }
 
 // accumulateBatch looks at existing batch ProwJobs and, if applicable, returns:
 // * A list of PRs that are part of a batch test that finished successfully
-// * A list of PRs that are part of a batch test that hasn't finished yet but didn't have any failures so far
-func (c *Controller) accumulateBatch(sp subpool) (successBatch []PullRequest, pendingBatch []PullRequest) {
+// * A list of PRs that are part of batch tests that haven't finished yet but didn't have any failures so far
+// * The number of such pending batches
+func (c *Controller) accumulateBatch(sp subpool) (successBatch []PullRequest, pendingBatch []PullRequest, pendingBatches int) {
 	sp.log.Debug("accumulating PRs for batch testing")
 	prNums := make(map[int]PullRequest)
 	for _, pr := range sp.prs {
@@ -700,7 +908,8 @@ func (c *Controller) accumulateBatch(sp subpool) (successBatch []PullRequest, pe
 	}
 	states := make(map[string]*accState)
 	for _, pj := range sp.pjs {
-		if pj.Spec.Type != prowapi.BatchJob {
+		// Batches of batch groups are accumulated by accumulateGroupBatch.
+		if pj.Spec.Type != prowapi.BatchJob || isGroupBatch(pj) {
 			continue
 		}
 		// First validate the batch job's refs.
@@ -749,26 +958,32 @@ func (c *Controller) accumulateBatch(sp subpool) (successBatch []PullRequest, pe
 			continue
 		}
 
-		overallState := successState
-		for _, p := range requiredPresubmits {
-			if s, ok := state.jobStates[p.Context]; !ok || s == failureState {
-				overallState = failureState
-				sp.log.WithField("batch", ref).Debugf("batch invalid, required presubmit %s is not passing", p.Context)
-				break
-			} else if s == pendingState && overallState == successState {
-				overallState = pendingState
-			}
-		}
-		switch overallState {
-		// Currently we only consider 1 pending batch and 1 success batch at a time.
-		// If more are somehow present they will be ignored.
+		switch overallBatchState(requiredPresubmits, state.jobStates, sp.log.WithField("batch", ref)) {
+		// Currently we only consider 1 success batch at a time. If more are
+		// somehow present they will be ignored.
 		case pendingState:
-			pendingBatch = state.prs
+			pendingBatch = append(pendingBatch, state.prs...)
+			pendingBatches++
 		case successState:
 			successBatch = state.prs
 		}
 	}
-	return successBatch, pendingBatch
+	return successBatch, pendingBatch, pendingBatches
+}
+
+// overallBatchState combines the states of the jobs of a batch into the
+// state of the batch: it fails if any required presubmit is missing or failed.
+func overallBatchState(requiredPresubmits []config.Presubmit, jobStates map[string]simpleState, log *logrus.Entry) simpleState {
+	overallState := successState
+	for _, p := range requiredPresubmits {
+		if s, ok := jobStates[p.Context]; !ok || s == failureState {
+			log.Debugf("batch invalid, required presubmit %s is not passing", p.Context)
+			return failureState
+		} else if s == pendingState {
+			overallState = pendingState
+		}
+	}
+	return overallState
 }
 
 // accumulate returns the supplied PRs sorted into three buckets based on their
@@ -848,7 +1063,8 @@ func (c *Controller) pickBatch(sp subpool, cc map[int]contextChecker) ([]PullReq
 	}
 
 	// we must choose the oldest PRs for the batch
-	sort.Slice(sp.prs, func(i, j int) bool { return sp.prs[i].Number < sp.prs[j].Number })
+	less := prLess(c.config().Tide.PoolSortOrder)
+	sort.Slice(sp.prs, func(i, j int) bool { return less(sp.prs[i], sp.prs[j]) })
 
 	var candidates []PullRequest
 	for _, pr := range sp.prs {
@@ -951,9 +1167,37 @@ func (c *Controller) prepareMergeDetails(commitTemplates config.TideMergeCommitT
 		}
 	}
 
+	prefixes := c.config().Tide.MergeLabelPrefixesFor(string(pr.Repository.Owner.Login), string(pr.Repository.Name))
+	if prefix := labelPrefix(pr, prefixes); prefix != "" {
+		title := ghMergeDetails.CommitTitle
+		if title == "" && mergeMethod == github.MergeSquash {
+			// Mirror the title GitHub would use for the squashed commit.
+			title = fmt.Sprintf("%s (#%d)", pr.Title, pr.Number)
+		}
+		if title != "" && !strings.HasPrefix(title, prefix) {
+			ghMergeDetails.CommitTitle = prefix + " " + title
+		}
+	}
+
 	return ghMergeDetails
 }
 
+// labelPrefix returns the merge commit title prefix configured for the labels
+// of the PR. If several labels have a prefix the first label in sorted order wins.
+func labelPrefix(pr PullRequest, prefixes map[string]string) string {
+	var labels []string
+	for _, label := range pr.Labels.Nodes {
+		if _, ok := prefixes[string(label.Name)]; ok {
+			labels = append(labels, string(label.Name))
+		}
+	}
+	if len(labels) == 0 {
+		return ""
+	}
+	sort.Strings(labels)
+	return prefixes[labels[0]]
+}
+
 func (c *Controller) mergePRs(sp subpool, prs []PullRequest) error {
 	var merged, failed []int
 	defer func() {
@@ -983,6 +1227,19 @@ func (c *Controller) mergePRs(sp subpool, prs []PullRequest) error {
 			}
 		}
 
+		if c.dryRun {
+			c.planDryRunAction(DryRunAction{
+				Org:         sp.org,
+				Repo:        sp.repo,
+				Branch:      sp.branch,
+				Kind:        DryRunMerge,
+				PRs:         []int{int(pr.Number)},
+				MergeMethod: mergeMethod,
+			})
+			log.WithField("merge-method", mergeMethod).Info("Would merge (dry-run).")
+			continue
+		}
+
 		keepTrying, err := tryMerge(func() error {
 			ghMergeDetails := c.prepareMergeDetails(commitTemplates, pr, mergeMethod)
 			return c.ghc.Merge(sp.org, sp.repo, int(pr.Number), ghMergeDetails)
@@ -1082,7 +1339,9 @@ func tryMerge(mergeFunc func() error) (bool, error) {
 	return true, err
 }
 
-func (c *Controller) trigger(sp subpool, presubmits []config.Presubmit, prs []PullRequest) error {
+// trigger creates the ProwJobs for the presubmits to test the PRs. The PRs
+// of other repos of a batch group are passed as extra refs.
+func (c *Controller) trigger(sp subpool, presubmits []config.Presubmit, prs []PullRequest, extraRefs ...prowapi.Refs) error {
 	refs := prowapi.Refs{
 		Org:     sp.org,
 		Repo:    sp.repo,
@@ -1100,6 +1359,15 @@ func (c *Controller) trigger(sp subpool, presubmits []config.Presubmit, prs []Pu
 		)
 	}
 
+	// Count the serial retests of a PR, so that they can be limited.
+	var retests map[string]int
+	if len(prs) == 1 && len(extraRefs) == 0 {
+		retests = sp.retests[int(prs[0].Number)]
+		if retests == nil {
+			retests = map[string]int{}
+		}
+	}
+
 	// If PRs require the same job, we only want to trigger it once.
 	// If multiple required jobs have the same context, we assume the
 	// same shard will be run to provide those contexts
@@ -1110,14 +1378,30 @@ func (c *Controller) trigger(sp subpool, presubmits []config.Presubmit, prs []Pu
 		}
 		triggeredContexts.Insert(string(ps.Context))
 		var spec prowapi.ProwJobSpec
-		if len(prs) == 1 {
+		if len(prs) == 1 && len(extraRefs) == 0 {
 			spec = pjutil.PresubmitSpec(ps, refs)
 		} else {
 			spec = pjutil.BatchSpec(ps, refs)
+			spec.ExtraRefs = append(spec.ExtraRefs, extraRefs...)
 		}
 		pj := pjutil.NewProwJob(spec, ps.Labels, ps.Annotations)
 		pj.Namespace = c.config().ProwJobNamespace
+		if retests != nil {
+			pj.Annotations[kube.TideRetestCountAnnotation] = strconv.Itoa(retests[string(ps.Context)] + 1)
+		}
 		log := c.logger.WithFields(pjutil.ProwJobFields(&pj))
+		if c.dryRun {
+			c.planDryRunAction(DryRunAction{
+				Org:    sp.org,
+				Repo:   sp.repo,
+				Branch: sp.branch,
+				Kind:   DryRunCreateProwJob,
+				PRs:    prNumbers(prs),
+				Job:    spec.Job,
+			})
+			log.Info("Would create ProwJob (dry-run).")
+			continue
+		}
 		start := time.Now()
 		if err := c.prowJobClient.Create(c.ctx, &pj); err != nil {
 			log.WithField("duration", time.Since(start).String()).Debug("Failed to create ProwJob on the cluster.")
@@ -1128,15 +1412,46 @@ func (c *Controller) trigger(sp subpool, presubmits []config.Presubmit, prs []Pu
 	return nil
 }
 
-func (c *Controller) takeAction(sp subpool, batchPending, successes, pendings, missings, batchMerges []PullRequest, missingSerialTests map[int][]config.Presubmit) (Action, []PullRequest, error) {
+// takeAction picks and performs the next action for the subpool. While the
+// pool is frozen, or its base is failing while RequireBaseGreen is set, PRs
+// are tested but not merged.
+func (c *Controller) takeAction(sp subpool, batchPending, successes, pendings, missings, batchMerges []PullRequest, missingSerialTests map[int][]config.Presubmit, frozen bool) (Action, []PullRequest, error) {
+	if c.config().Tide.AbortStaleBatches {
+		c.abortStaleBatches(sp)
+	}
+	if minAge := c.config().Tide.MinSHAAge; minAge != nil && minAge.Duration > 0 {
+		now := time.Now()
+		successes = withoutYoungHeads(sp, successes, minAge.Duration, now)
+		if young := withoutYoungHeads(sp, batchMerges, minAge.Duration, now); len(young) < len(batchMerges) {
+			// Hold on to the passing batch until all of its heads are old
+			// enough to merge.
+			return Wait, nil, nil
+		}
+	}
+	if c.config().Tide.RequireBaseGreen && !frozen && (len(successes) > 0 || len(batchMerges) > 0) {
+		failing, err := c.baseIsFailing(sp)
+		if err != nil {
+			return Wait, nil, err
+		}
+		if failing {
+			// Keep testing PRs but hold on to the merges until the base is
+			// green again, as if the pool was frozen.
+			sp.log.Info("Base is failing, holding merges.")
+			frozen = true
+		}
+	}
 	// Merge the batch!
 	if len(batchMerges) > 0 {
+		if frozen {
+			// Hold on to the passing batch until the freeze is lifted.
+			return Wait, nil, nil
+		}
 		return MergeBatch, batchMerges, c.mergePRs(sp, batchMerges)
 	}
 	// Do not merge PRs while waiting for a batch to complete. We don't want to
 	// invalidate the old batch result.
-	if len(successes) > 0 && len(batchPending) == 0 {
-		if ok, pr := pickSmallestPassingNumber(sp.log, c.ghc, successes, sp.cc); ok {
+	if len(successes) > 0 && len(batchPending) == 0 && !frozen {
+		if ok, pr := pickSmallestPassingNumber(sp.log, c.ghc, successes, sp.cc, c.config().Tide.PoolSortOrder); ok {
 			return Merge, []PullRequest{pr}, c.mergePRs(sp, []PullRequest{pr})
 		}
 	}
@@ -1144,25 +1459,279 @@ func (c *Controller) takeAction(sp subpool, batchPending, successes, pendings, m
 	if len(sp.presubmits) == 0 {
 		return Wait, nil, nil
 	}
-	// If we have no batch, trigger one.
-	if len(sp.prs) > 1 && len(batchPending) == 0 {
-		batch, presubmits, err := c.pickBatch(sp, sp.cc)
-		if err != nil {
-			return Wait, nil, err
-		}
-		if len(batch) > 1 {
-			return TriggerBatch, batch, c.trigger(sp, presubmits, batch)
+	// If we have fewer batches in flight than allowed, trigger one out of the
+	// PRs that are not in a pending batch yet. Subpools of batch groups are
+	// batched together by syncBatchGroups.
+	if sp.batchesInFlight < c.config().Tide.MaxBatchesInFlight(sp.org, sp.repo) && !sp.grouped {
+		batchSP := sp
+		batchSP.prs = withoutPRs(sp.prs, batchPending)
+		if len(batchSP.prs) > 1 {
+			batch, presubmits, err := c.pickBatch(batchSP, sp.cc)
+			if err != nil {
+				return Wait, nil, err
+			}
+			if len(batch) > 1 {
+				return TriggerBatch, batch, c.trigger(sp, presubmits, batch)
+			}
 		}
 	}
 	// If we have no serial jobs pending or successful, trigger one.
+	if cooldown := c.config().Tide.RetestCooldown; cooldown != nil && cooldown.Duration > 0 {
+		missings, missingSerialTests = withoutCoolingDown(sp, missings, missingSerialTests, cooldown.Duration, time.Now())
+	}
+	if maxRetests := c.config().Tide.MaxRetestsPerPR; maxRetests > 0 {
+		missings, missingSerialTests = c.withinRetestLimit(sp, missings, missingSerialTests, maxRetests)
+	}
 	if len(missings) > 0 && len(pendings) == 0 && len(successes) == 0 {
-		if ok, pr := pickSmallestPassingNumber(sp.log, c.ghc, missings, sp.cc); ok {
+		if ok, pr := pickSmallestPassingNumber(sp.log, c.ghc, missings, sp.cc, c.config().Tide.PoolSortOrder); ok {
 			return Trigger, []PullRequest{pr}, c.trigger(sp, missingSerialTests[int(pr.Number)], []PullRequest{pr})
 		}
 	}
 	return Wait, nil, nil
 }
 
+// baseIsFailing reports whether any status context of the base commit of
+// the subpool is failing.
+func (c *Controller) baseIsFailing(sp subpool) (bool, error) {
+	combined, err := c.ghc.GetCombinedStatus(sp.org, sp.repo, sp.sha)
+	if err != nil {
+		return false, fmt.Errorf("failed to get combined status of base %s: %v", sp.sha, err)
+	}
+	for _, status := range combined.Statuses {
+		if status.State == github.StatusFailure || status.State == github.StatusError {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// withoutPRs returns the PRs that are not among the excluded PRs.
+func withoutPRs(prs, excluded []PullRequest) []PullRequest {
+	excludedNums := sets.NewInt()
+	for _, pr := range excluded {
+		excludedNums.Insert(int(pr.Number))
+	}
+	var remaining []PullRequest
+	for _, pr := range prs {
+		if !excludedNums.Has(int(pr.Number)) {
+			remaining = append(remaining, pr)
+		}
+	}
+	return remaining
+}
+
+// withoutYoungHeads drops the PRs whose head commit was pushed less than
+// minAge ago, so that external CI has time to report its statuses before the
+// PRs merge. PRs whose head commit is not known are kept.
+func withoutYoungHeads(sp subpool, prs []PullRequest, minAge time.Duration, now time.Time) []PullRequest {
+	var old []PullRequest
+	for _, pr := range prs {
+		if age, ok := headAge(pr, now); ok && age < minAge {
+			sp.log.WithFields(pr.logFields()).Debugf("head was pushed %v ago, waiting for it to be at least %v old", age, minAge)
+			continue
+		}
+		old = append(old, pr)
+	}
+	return old
+}
+
+// headAge returns how long ago the head commit of the PR was pushed, and
+// false if the head commit is not among the commits of the PR.
+func headAge(pr PullRequest, now time.Time) (time.Duration, bool) {
+	for _, node := range pr.Commits.Nodes {
+		if node.Commit.OID != pr.HeadRefOID {
+			continue
+		}
+		pushed := node.Commit.CommittedDate.Time
+		if node.Commit.PushedDate != nil {
+			pushed = node.Commit.PushedDate.Time
+		}
+		if pushed.IsZero() {
+			return 0, false
+		}
+		return now.Sub(pushed), true
+	}
+	return 0, false
+}
+
+// withoutCoolingDown drops the missing presubmits of the PRs that failed less
+// than the cooldown ago, along with the PRs that are left without any
+// presubmit to trigger.
+func withoutCoolingDown(sp subpool, missings []PullRequest, missingTests map[int][]config.Presubmit, cooldown time.Duration, now time.Time) ([]PullRequest, map[int][]config.Presubmit) {
+	// lastFailures maps the PR heads to the last failure of each context.
+	type head struct {
+		number int
+		sha    string
+	}
+	lastFailures := map[head]map[string]time.Time{}
+	for _, pj := range sp.pjs {
+		if pj.Spec.Type != prowapi.PresubmitJob || toSimpleState(pj.Status.State) != failureState || pj.Status.CompletionTime == nil {
+			continue
+		}
+		key := head{number: pj.Spec.Refs.Pulls[0].Number, sha: pj.Spec.Refs.Pulls[0].SHA}
+		if lastFailures[key] == nil {
+			lastFailures[key] = map[string]time.Time{}
+		}
+		if completed := pj.Status.CompletionTime.Time; completed.After(lastFailures[key][pj.Spec.Context]) {
+			lastFailures[key][pj.Spec.Context] = completed
+		}
+	}
+
+	var retestable []PullRequest
+	retestableTests := map[int][]config.Presubmit{}
+	for _, pr := range missings {
+		num := int(pr.Number)
+		for _, ps := range missingTests[num] {
+			if failed, ok := lastFailures[head{number: num, sha: string(pr.HeadRefOID)}][ps.Context]; ok && now.Sub(failed) < cooldown {
+				sp.log.WithFields(pr.logFields()).Debugf("presubmit %s failed %v ago, waiting for the retest cooldown", ps.Context, now.Sub(failed))
+				continue
+			}
+			retestableTests[num] = append(retestableTests[num], ps)
+		}
+		if len(retestableTests[num]) > 0 {
+			retestable = append(retestable, pr)
+		}
+	}
+	return retestable, retestableTests
+}
+
+// withinRetestLimit drops the missing presubmits that Tide already retested
+// the maximum number of times for the head of their PR, along with the PRs
+// that are left without any presubmit to trigger.
+func (c *Controller) withinRetestLimit(sp subpool, missings []PullRequest, missingTests map[int][]config.Presubmit, maxRetests int) ([]PullRequest, map[int][]config.Presubmit) {
+	var retestable []PullRequest
+	retestableTests := map[int][]config.Presubmit{}
+	for _, pr := range missings {
+		num := int(pr.Number)
+		retests := sp.retests[num]
+		for _, ps := range missingTests[num] {
+			if retests[ps.Context] >= maxRetests {
+				sp.log.WithFields(pr.logFields()).Debugf("presubmit %s was retested %d times, it needs manual attention", ps.Context, retests[ps.Context])
+				continue
+			}
+			retestableTests[num] = append(retestableTests[num], ps)
+		}
+		if len(retestableTests[num]) > 0 {
+			retestable = append(retestable, pr)
+		}
+	}
+	return retestable, retestableTests
+}
+
+// retestCounts returns how many times Tide triggered each presubmit context
+// for the head of each PR of the subpool, keyed by PR number, according to the
+// retest count annotations of the presubmits of the repo. Unlike the ProwJobs
+// of a subpool these are not limited to the current base SHA. The presubmits
+// are listed once per subpool and the counts are shared with the status
+// controller.
+func retestCounts(ctx context.Context, client ctrlruntimeclient.Client, namespace string, sp *subpool) (map[int]map[string]int, error) {
+	pjs := &prowapi.ProwJobList{}
+	if err := client.List(
+		ctx,
+		pjs,
+		ctrlruntimeclient.InNamespace(namespace),
+		ctrlruntimeclient.MatchingLabels{
+			kube.ProwJobTypeLabel: string(prowapi.PresubmitJob),
+			kube.OrgLabel:         sp.org,
+			kube.RepoLabel:        sp.repo,
+		},
+	); err != nil {
+		return nil, err
+	}
+	heads := make(map[int]string, len(sp.prs))
+	for _, pr := range sp.prs {
+		heads[int(pr.Number)] = string(pr.HeadRefOID)
+	}
+	retests := map[int]map[string]int{}
+	for _, pj := range pjs.Items {
+		if pj.Spec.Refs == nil || len(pj.Spec.Refs.Pulls) != 1 {
+			continue
+		}
+		pull := pj.Spec.Refs.Pulls[0]
+		if head, ok := heads[pull.Number]; !ok || pull.SHA != head {
+			continue
+		}
+		count, err := strconv.Atoi(pj.Annotations[kube.TideRetestCountAnnotation])
+		if err != nil {
+			// Not triggered by Tide.
+			continue
+		}
+		if retests[pull.Number] == nil {
+			retests[pull.Number] = map[string]int{}
+		}
+		if count > retests[pull.Number][pj.Spec.Context] {
+			retests[pull.Number][pj.Spec.Context] = count
+		}
+	}
+	return retests, nil
+}
+
+// abortStaleBatches aborts the pending batch ProwJobs of the subpool that
+// test a PR which left the pool or whose head changed. For group batches, the
+// PRs of the other repos of the group, passed as extra refs, are checked
+// against their subpools as well. accumulateBatch and accumulateGroupBatch
+// already ignore these batches, so letting them run only wastes capacity.
+func (c *Controller) abortStaleBatches(sp subpool) {
+	for _, pj := range sp.pjs {
+		if pj.Spec.Type != prowapi.BatchJob || pj.Complete() || pj.Spec.Refs == nil {
+			continue
+		}
+		stale := stalePulls(sp.prs, pj.Spec.Refs.Pulls)
+		for _, ref := range pj.Spec.ExtraRefs {
+			// The job's own extra refs do not test any PRs.
+			if stale || len(ref.Pulls) == 0 {
+				continue
+			}
+			member, ok := sp.groupMembers[ref.Org+"/"+ref.Repo]
+			stale = !ok || member.sha != ref.BaseSHA || stalePulls(member.prs, ref.Pulls)
+		}
+		if !stale {
+			continue
+		}
+		log := sp.log.WithField("batch", pj.Spec.Refs.String()).WithFields(pjutil.ProwJobFields(&pj))
+		if c.dryRun {
+			var prs []int
+			for _, pull := range pj.Spec.Refs.Pulls {
+				prs = append(prs, pull.Number)
+			}
+			c.planDryRunAction(DryRunAction{
+				Org:    sp.org,
+				Repo:   sp.repo,
+				Branch: sp.branch,
+				Kind:   DryRunAbortStaleBatch,
+				PRs:    prs,
+				Job:    pj.Spec.Job,
+			})
+			log.Info("Would abort stale batch job (dry-run).")
+			continue
+		}
+		aborted := pj.DeepCopy()
+		aborted.SetComplete()
+		aborted.Status.State = prowapi.AbortedState
+		aborted.Status.Description = "Aborted by Tide as the batch is no longer valid."
+		if err := c.prowJobClient.Patch(c.ctx, aborted, ctrlruntimeclient.MergeFrom(&pj)); err != nil {
+			log.WithError(err).Warn("Failed to abort stale batch job.")
+			continue
+		}
+		log.Info("Aborted stale batch job.")
+	}
+}
+
+// stalePulls reports whether any of the pulls no longer points to the head of
+// one of the PRs.
+func stalePulls(prs []PullRequest, pulls []prowapi.Pull) bool {
+	heads := make(map[int]string, len(prs))
+	for _, pr := range prs {
+		heads[int(pr.Number)] = string(pr.HeadRefOID)
+	}
+	for _, pull := range pulls {
+		if sha, ok := heads[pull.Number]; !ok || sha != pull.SHA {
+			return true
+		}
+	}
+	return false
+}
+
 // changedFilesAgent queries and caches the names of files changed by PRs.
 // Cache entries expire if they are not used during a sync loop.
 type changedFilesAgent struct {
@@ -1331,10 +1900,12 @@ func (c *Controller) presubmitsForBatch(prs []PullRequest, org, repo, baseSHA, b
 	return result, nil
 }
 
-func (c *Controller) syncSubpool(sp subpool, blocks []blockers.Blocker) (Pool, error) {
+func (c *Controller) syncSubpool(sp subpool, blocks, freezes, pauses []blockers.Blocker) (Pool, error) {
 	sp.log.Infof("Syncing subpool: %d PRs, %d PJs.", len(sp.prs), len(sp.pjs))
 	successes, pendings, missings, missingSerialTests := accumulate(sp.presubmits, sp.prs, sp.pjs, sp.log)
-	batchMerge, batchPending := c.accumulateBatch(sp)
+	batchMerge, batchPending, batchesInFlight := c.accumulateBatch(sp)
+	sp.batchesInFlight = batchesInFlight
+	batchPending = append(batchPending, sp.groupBatchPending...)
 	sp.log.WithFields(logrus.Fields{
 		"prs-passing":   prNumbers(successes),
 		"prs-pending":   prNumbers(pendings),
@@ -1349,8 +1920,17 @@ func (c *Controller) syncSubpool(sp subpool, blocks []blockers.Blocker) (Pool, e
 	var errorString string
 	if len(blocks) > 0 {
 		act = PoolBlocked
+	} else if len(pauses) > 0 {
+		act = PoolPaused
 	} else {
-		act, targets, err = c.takeAction(sp, batchPending, successes, pendings, missings, batchMerge, missingSerialTests)
+		if sp.groupAction != "" {
+			if c.config().Tide.AbortStaleBatches {
+				c.abortStaleBatches(sp)
+			}
+			act, targets, err = sp.groupAction, sp.groupTargets, sp.groupErr
+		} else {
+			act, targets, err = c.takeAction(sp, batchPending, successes, pendings, missings, batchMerge, missingSerialTests, len(freezes) > 0)
+		}
 		if err != nil {
 			errorString = err.Error()
 		}
@@ -1385,6 +1965,8 @@ func (c *Controller) syncSubpool(sp subpool, blocks []blockers.Blocker) (Pool, e
 			Action:   act,
 			Target:   targets,
 			Blockers: blocks,
+			Freezes:  freezes,
+			Pauses:   pauses,
 			Error:    errorString,
 		},
 		err
@@ -1439,9 +2021,80 @@ type subpool struct {
 	prs []PullRequest
 
 	cc map[int]contextChecker
+	// queries contains the tide queries for the repo of this subpool
+	queries config.TideQueries
+	// autoMergeLabel is the label PRs of queries that require it must have
+	// to be merged.
+	autoMergeLabel string
+	// retests holds how many times Tide retested each context for the head
+	// of each PR, keyed by PR number.
+	retests map[int]map[string]int
 	// presubmit contains all required presubmits for each PR
 	// in this subpool
 	presubmits map[int][]config.Presubmit
+
+	// grouped is set if the subpool is batched together with the subpools
+	// of its batch group. The action syncBatchGroups took for the subpool,
+	// if any, is recorded in groupAction, groupTargets and groupErr.
+	grouped           bool
+	groupAction       Action
+	groupTargets      []PullRequest
+	groupErr          error
+	groupBatchPending []PullRequest
+	// groupMembers holds the subpools of the batch group of a primary
+	// subpool, keyed by org/repo, so that its group batches can be checked
+	// for staleness.
+	groupMembers map[string]*subpool
+
+	// batchesInFlight is the number of pending batches of the subpool.
+	batchesInFlight int
+}
+
+// missingRequiredMergeLabels returns the required merge labels, including
+// Tide's auto merge label where a query requires it, that the PR is
+// missing. A PR only needs to have the required merge labels of one of the
+// queries it matches, so this returns nothing if any of them is satisfied and
+// otherwise the labels missing for the query the PR is closest to satisfying.
+// Queries for the repo that the PR does not match are ignored, so that they
+// cannot lift the required merge labels of the queries it does match.
+func missingRequiredMergeLabels(queries config.TideQueries, autoMergeLabel string, pr *PullRequest) []string {
+	var labels []string
+	for _, l := range pr.Labels.Nodes {
+		labels = append(labels, string(l.Name))
+	}
+	var closest []string
+	matched := false
+	for _, q := range queries {
+		if !queryMatchesPR(q, pr, labels) {
+			continue
+		}
+		missing := q.MissingRequiredMergeLabels(labels, autoMergeLabel)
+		if len(missing) == 0 {
+			return nil
+		}
+		if !matched || len(missing) < len(closest) {
+			closest = missing
+		}
+		matched = true
+	}
+	return closest
+}
+
+// queryMatchesPR indicates if the PR meets the branch, label and milestone
+// requirements of the query. The repo is not checked as the queries are
+// already selected per repo.
+func queryMatchesPR(q config.TideQuery, pr *PullRequest, labels []string) bool {
+	if !q.ForBranch(string(pr.BaseRef.Name)) {
+		return false
+	}
+	present := sets.NewString(labels...)
+	if !present.HasAll(q.Labels...) || present.HasAny(q.MissingLabels...) {
+		return false
+	}
+	if q.Milestone != "" && (pr.Milestone == nil || string(pr.Milestone.Title) != q.Milestone) {
+		return false
+	}
+	return true
 }
 
 func poolKey(org, repo, branch string) string {
@@ -1535,6 +2188,7 @@ type PullRequest struct {
 	}
 	Body      githubql.String
 	Title     githubql.String
+	CreatedAt githubql.DateTime
 	UpdatedAt githubql.DateTime
 }
 
@@ -1544,6 +2198,10 @@ type Commit struct {
 		Contexts []Context
 	}
 	OID githubql.String `graphql:"oid"`
+	// PushedDate is null for commits GitHub did not see pushed, so
+	// CommittedDate is used in its place.
+	PushedDate    *githubql.DateTime `graphql:"pushedDate"`
+	CommittedDate githubql.DateTime  `graphql:"committedDate"`
 }
 
 // Context holds graphql response data for github contexts.
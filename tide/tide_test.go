@@ -24,6 +24,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strconv"
 	"testing"
 	"text/template"
 	"time"
@@ -46,6 +47,8 @@ import (
 	"github.com/clarketm/prow/git"
 	"github.com/clarketm/prow/git/localgit"
 	"github.com/clarketm/prow/github"
+	"github.com/clarketm/prow/kube"
+	"github.com/clarketm/prow/tide/blockers"
 	"github.com/clarketm/prow/tide/history"
 )
 
@@ -279,7 +282,7 @@ func TestAccumulateBatch(t *testing.T) {
 				changedFiles: &changedFilesAgent{},
 				logger:       logrus.WithField("test", test.name),
 			}
-			merges, pending := c.accumulateBatch(subpool{org: "org", repo: "repo", prs: pulls, pjs: pjs, log: logrus.WithField("test", test.name)})
+			merges, pending, _ := c.accumulateBatch(subpool{org: "org", repo: "repo", prs: pulls, pjs: pjs, log: logrus.WithField("test", test.name)})
 			if (len(pending) > 0) != test.pending {
 				t.Errorf("For case \"%s\", got wrong pending.", test.name)
 			}
@@ -521,6 +524,8 @@ type fgc struct {
 
 	expectedSHA    string
 	combinedStatus map[string]string
+
+	comments map[int][]github.IssueComment
 }
 
 func (f *fgc) GetRef(o, r, ref string) (string, error) {
@@ -578,6 +583,30 @@ func (f *fgc) GetCombinedStatus(org, repo, ref string) (*github.CombinedStatus,
 		nil
 }
 
+func (f *fgc) ListIssueComments(org, repo string, number int) ([]github.IssueComment, error) {
+	return f.comments[number], nil
+}
+
+func (f *fgc) CreateComment(org, repo string, number int, comment string) error {
+	if f.comments == nil {
+		f.comments = map[int][]github.IssueComment{}
+	}
+	f.comments[number] = append(f.comments[number], github.IssueComment{ID: len(f.comments[number]) + 1, Body: comment})
+	return nil
+}
+
+func (f *fgc) EditComment(org, repo string, id int, comment string) error {
+	for number, comments := range f.comments {
+		for i := range comments {
+			if comments[i].ID == id {
+				f.comments[number][i].Body = comment
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("comment %d not found", id)
+}
+
 func (f *fgc) GetPullRequestChanges(org, repo string, number int) ([]github.PullRequestChange, error) {
 	if number != 100 {
 		return nil, nil
@@ -694,7 +723,7 @@ func TestDividePool(t *testing.T) {
 
 	mgr := newFakeManager()
 	c, err := newSyncController(
-		logrus.NewEntry(logrus.StandardLogger()), fc, mgr, configGetter, &git.Client{}, nil, nil,
+		logrus.NewEntry(logrus.StandardLogger()), fc, mgr, configGetter, &git.Client{}, nil, nil, false,
 	)
 	if err != nil {
 		t.Fatalf("failed to construct sync controller: %v", err)
@@ -928,6 +957,121 @@ func TestPickBatch(t *testing.T) {
 	}
 }
 
+func TestPoolSortOrder(t *testing.T) {
+	now := time.Now()
+	// PR 1 has the lowest number, PR 2 was created first and PR 3 was
+	// updated least recently.
+	testprs := []struct {
+		number  int
+		created time.Time
+		updated time.Time
+	}{
+		{number: 3, created: now.Add(-2 * time.Hour), updated: now.Add(-3 * time.Hour)},
+		{number: 1, created: now.Add(-1 * time.Hour), updated: now.Add(-1 * time.Hour)},
+		{number: 2, created: now.Add(-4 * time.Hour), updated: now.Add(-2 * time.Hour)},
+	}
+	testCases := []struct {
+		name          string
+		order         config.TidePoolSortOrder
+		expectedPick  int
+		expectedBatch []int
+	}{
+		{
+			name:          "number order prefers the lowest PR number",
+			order:         config.PoolSortOrderNumber,
+			expectedPick:  1,
+			expectedBatch: []int{1, 2},
+		},
+		{
+			name:          "created order prefers the oldest PR",
+			order:         config.PoolSortOrderCreated,
+			expectedPick:  2,
+			expectedBatch: []int{2, 3},
+		},
+		{
+			name:          "updated order prefers the least recently updated PR",
+			order:         config.PoolSortOrderUpdated,
+			expectedPick:  3,
+			expectedBatch: []int{3, 2},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			lg, gc, err := localgit.New()
+			if err != nil {
+				t.Fatalf("Error making local git: %v", err)
+			}
+			defer gc.Clean()
+			defer lg.Clean()
+			if err := lg.MakeFakeRepo("o", "r"); err != nil {
+				t.Fatalf("Error making fake repo: %v", err)
+			}
+			if err := lg.AddCommit("o", "r", map[string][]byte{"foo": []byte("foo")}); err != nil {
+				t.Fatalf("Adding initial commit: %v", err)
+			}
+			sp := subpool{
+				log:    logrus.WithField("component", "tide"),
+				org:    "o",
+				repo:   "r",
+				branch: "master",
+				sha:    "master",
+			}
+			cc := map[int]contextChecker{}
+			for _, testpr := range testprs {
+				if err := lg.CheckoutNewBranch("o", "r", fmt.Sprintf("pr-%d", testpr.number)); err != nil {
+					t.Fatalf("Error checking out new branch: %v", err)
+				}
+				if err := lg.AddCommit("o", "r", map[string][]byte{fmt.Sprintf("file-%d", testpr.number): []byte("ok")}); err != nil {
+					t.Fatalf("Error adding commit: %v", err)
+				}
+				if err := lg.Checkout("o", "r", "master"); err != nil {
+					t.Fatalf("Error checking out master: %v", err)
+				}
+				oid := githubql.String(fmt.Sprintf("origin/pr-%d", testpr.number))
+				var pr PullRequest
+				pr.Number = githubql.Int(testpr.number)
+				pr.HeadRefOID = oid
+				pr.CreatedAt = githubql.DateTime{Time: testpr.created}
+				pr.UpdatedAt = githubql.DateTime{Time: testpr.updated}
+				pr.Commits.Nodes = []struct {
+					Commit Commit
+				}{{Commit: Commit{OID: oid}}}
+				pr.Commits.Nodes[0].Commit.Status.Contexts = []Context{{State: githubql.StatusStateSuccess}}
+				sp.prs = append(sp.prs, pr)
+				cc[testpr.number] = &config.TideContextPolicy{}
+			}
+
+			if ok, pr := pickSmallestPassingNumber(sp.log, nil, sp.prs, cc, tc.order); !ok {
+				t.Errorf("expected PR %d to be picked, got none", tc.expectedPick)
+			} else if int(pr.Number) != tc.expectedPick {
+				t.Errorf("expected PR %d to be picked, got %d", tc.expectedPick, pr.Number)
+			}
+
+			ca := &config.Agent{}
+			ca.Set(&config.Config{
+				ProwConfig: config.ProwConfig{
+					Tide: config.Tide{
+						BatchSizeLimitMap: map[string]int{"*": 2},
+						PoolSortOrder:     tc.order,
+					},
+				},
+			})
+			c := &Controller{
+				logger: logrus.WithField("component", "tide"),
+				gc:     gc,
+				config: ca.Config,
+			}
+			batch, _, err := c.pickBatch(sp, cc)
+			if err != nil {
+				t.Fatalf("Error from pickBatch: %v", err)
+			}
+			if actual := prNumbers(batch); !reflect.DeepEqual(actual, tc.expectedBatch) {
+				t.Errorf("expected batch %v, got %v", tc.expectedBatch, actual)
+			}
+		})
+	}
+}
+
 func TestCheckMergeLabels(t *testing.T) {
 	squashLabel := "tide/squash"
 	mergeLabel := "tide/merge"
@@ -1015,6 +1159,13 @@ func TestTakeAction(t *testing.T) {
 		batchMerges  []int
 		presubmits   map[int][]config.Presubmit
 		mergeErrs    map[int]error
+		frozen       bool
+
+		// batchPendingPRs are the PRs of the pending batches and
+		// batchesInFlight their number, defaulting to a single batch.
+		batchPendingPRs    []int
+		batchesInFlight    int
+		maxBatchesInFlight map[string]int
 
 		merged           int
 		triggered        int
@@ -1167,6 +1318,63 @@ func TestTakeAction(t *testing.T) {
 			triggered: 0,
 			action:    MergeBatch,
 		},
+		{
+			name: "frozen successful PR, should not merge",
+
+			batchPending: false,
+			successes:    []int{0},
+			pendings:     []int{},
+			nones:        []int{},
+			batchMerges:  []int{},
+			presubmits: map[int][]config.Presubmit{
+				100: {
+					{Reporter: config.Reporter{Context: "foo"}},
+					{Reporter: config.Reporter{Context: "if-changed"}},
+				},
+			},
+			frozen:    true,
+			merged:    0,
+			triggered: 0,
+			action:    Wait,
+		},
+		{
+			name: "frozen successful batch, should wait",
+
+			batchPending: false,
+			successes:    []int{0, 1},
+			pendings:     []int{2, 3},
+			nones:        []int{4, 5},
+			batchMerges:  []int{6, 7, 8},
+			presubmits: map[int][]config.Presubmit{
+				100: {
+					{Reporter: config.Reporter{Context: "foo"}},
+					{Reporter: config.Reporter{Context: "if-changed"}},
+				},
+			},
+			frozen:    true,
+			merged:    0,
+			triggered: 0,
+			action:    Wait,
+		},
+		{
+			name: "frozen pool, should still trigger tests",
+
+			batchPending: false,
+			successes:    []int{},
+			pendings:     []int{},
+			nones:        []int{0},
+			batchMerges:  []int{},
+			presubmits: map[int][]config.Presubmit{
+				100: {
+					{Reporter: config.Reporter{Context: "foo"}},
+					{Reporter: config.Reporter{Context: "if-changed"}},
+				},
+			},
+			frozen:    true,
+			merged:    0,
+			triggered: 1,
+			action:    Trigger,
+		},
 		{
 			name: "one PR that triggers RunIfChangedJob",
 
@@ -1298,12 +1506,93 @@ func TestTakeAction(t *testing.T) {
 			action:      MergeBatch,
 			expectErr:   true,
 		},
+		{
+			name: "pending batch with room for another batch, should trigger non-overlapping batch",
+
+			batchPending:    true,
+			batchPendingPRs: []int{1, 2},
+			successes:       []int{},
+			pendings:        []int{},
+			nones:           []int{1, 2, 3, 4},
+			batchMerges:     []int{},
+			presubmits: map[int][]config.Presubmit{
+				100: {
+					{Reporter: config.Reporter{Context: "foo"}},
+				},
+			},
+			maxBatchesInFlight: map[string]int{"*": 2},
+			merged:             0,
+			triggered:          1,
+			triggeredBatches:   1,
+			action:             TriggerBatch,
+		},
+		{
+			name: "max batches in flight, should trigger serial",
+
+			batchPending:    true,
+			batchPendingPRs: []int{1, 2},
+			batchesInFlight: 2,
+			successes:       []int{},
+			pendings:        []int{},
+			nones:           []int{1, 2, 3, 4},
+			batchMerges:     []int{},
+			presubmits: map[int][]config.Presubmit{
+				100: {
+					{Reporter: config.Reporter{Context: "foo"}},
+				},
+			},
+			maxBatchesInFlight: map[string]int{"o/r": 2},
+			merged:             0,
+			triggered:          1,
+			triggeredBatches:   0,
+			action:             Trigger,
+		},
+		{
+			name: "pending batch leaves a single PR, should trigger serial",
+
+			batchPending:    true,
+			batchPendingPRs: []int{1, 2, 3},
+			successes:       []int{},
+			pendings:        []int{},
+			nones:           []int{1, 2, 3, 4},
+			batchMerges:     []int{},
+			presubmits: map[int][]config.Presubmit{
+				100: {
+					{Reporter: config.Reporter{Context: "foo"}},
+				},
+			},
+			maxBatchesInFlight: map[string]int{"o": 3},
+			merged:             0,
+			triggered:          1,
+			triggeredBatches:   0,
+			action:             Trigger,
+		},
+		{
+			name: "batching disabled by max batches in flight, should trigger serial",
+
+			batchPending: false,
+			successes:    []int{},
+			pendings:     []int{},
+			nones:        []int{1, 2, 3},
+			batchMerges:  []int{},
+			presubmits: map[int][]config.Presubmit{
+				100: {
+					{Reporter: config.Reporter{Context: "foo"}},
+				},
+			},
+			maxBatchesInFlight: map[string]int{"*": 0},
+			merged:             0,
+			triggered:          1,
+			triggeredBatches:   0,
+			action:             Trigger,
+		},
 	}
 
 	for _, tc := range testcases {
 		ca := &config.Agent{}
 		pjNamespace := "pj-ns"
 		cfg := &config.Config{ProwConfig: config.ProwConfig{ProwJobNamespace: pjNamespace}}
+		cfg.Tide.MaxBatchesInFlightMap = tc.maxBatchesInFlight
 		if err := cfg.SetPresubmits(
 			map[string][]config.Presubmit{
 				"o/r": {
@@ -1387,67 +1676,888 @@ func TestTakeAction(t *testing.T) {
 				sp.prs = append(sp.prs, pr)
 				prs = append(prs, pr)
 			}
-			return prs
-		}
-		fgc := fgc{mergeErrs: tc.mergeErrs}
-		client := fakectrlruntimeclient.NewFakeClient()
-		c := &Controller{
-			logger:        logrus.WithField("controller", "tide"),
-			gc:            gc,
-			config:        ca.Config,
-			ghc:           &fgc,
-			prowJobClient: client,
-			changedFiles: &changedFilesAgent{
-				ghc:             &fgc,
-				nextChangeCache: make(map[changeCacheKey][]string),
+			return prs
+		}
+		fgc := fgc{mergeErrs: tc.mergeErrs}
+		client := fakectrlruntimeclient.NewFakeClient()
+		c := &Controller{
+			logger:        logrus.WithField("controller", "tide"),
+			gc:            gc,
+			config:        ca.Config,
+			ghc:           &fgc,
+			prowJobClient: client,
+			changedFiles: &changedFilesAgent{
+				ghc:             &fgc,
+				nextChangeCache: make(map[changeCacheKey][]string),
+			},
+		}
+		var batchPending []PullRequest
+		if tc.batchPending {
+			batchPending = []PullRequest{{}}
+			sp.batchesInFlight = 1
+		}
+		if len(tc.batchPendingPRs) > 0 {
+			batchPending = nil
+			for _, num := range tc.batchPendingPRs {
+				var pr PullRequest
+				pr.Number = githubql.Int(num)
+				batchPending = append(batchPending, pr)
+			}
+		}
+		if tc.batchesInFlight > 0 {
+			sp.batchesInFlight = tc.batchesInFlight
+		}
+		t.Logf("Test case: %s", tc.name)
+		if act, _, err := c.takeAction(sp, batchPending, genPulls(tc.successes), genPulls(tc.pendings), genPulls(tc.nones), genPulls(tc.batchMerges), sp.presubmits, tc.frozen); err != nil && !tc.expectErr {
+			t.Errorf("Unexpected error in takeAction: %v", err)
+			continue
+		} else if err == nil && tc.expectErr {
+			t.Error("Missing expected error from takeAction.")
+		} else if act != tc.action {
+			t.Errorf("Wrong action. Got %v, wanted %v.", act, tc.action)
+		}
+
+		prowJobs := &prowapi.ProwJobList{}
+		if err := client.List(context.Background(), prowJobs); err != nil {
+			t.Fatalf("failed to list ProwJobs: %v", err)
+		}
+		numCreated := len(prowJobs.Items)
+
+		var batchJobs []*prowapi.ProwJob
+		for _, pj := range prowJobs.Items {
+			if pj.Namespace != pjNamespace {
+				t.Errorf("prowjob %q didn't have expected namespace %q but %q", pj.Name, pjNamespace, pj.Namespace)
+			}
+			if pj.Spec.Type == prowapi.BatchJob {
+				batchJobs = append(batchJobs, &pj)
+			}
+		}
+
+		if tc.triggered != numCreated {
+			t.Errorf("Wrong number of jobs triggered. Got %d, expected %d.", numCreated, tc.triggered)
+		}
+		if tc.merged != fgc.merged {
+			t.Errorf("Wrong number of merges. Got %d, expected %d.", fgc.merged, tc.merged)
+		}
+		// Ensure that the correct number of batch jobs were triggered
+		if tc.triggeredBatches != len(batchJobs) {
+			t.Errorf("Wrong number of batches triggered. Got %d, expected %d.", len(batchJobs), tc.triggeredBatches)
+		}
+		for _, job := range batchJobs {
+			if len(job.Spec.Refs.Pulls) <= 1 {
+				t.Error("Found a batch job that doesn't contain multiple pull refs!")
+			}
+			for _, pull := range job.Spec.Refs.Pulls {
+				for _, num := range tc.batchPendingPRs {
+					if pull.Number == num {
+						t.Errorf("Found a batch job that contains PR %d of a pending batch!", num)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestAbortStaleBatches(t *testing.T) {
+	batch := func(name string, state prowapi.ProwJobState, pulls ...prowapi.Pull) prowapi.ProwJob {
+		return prowapi.ProwJob{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "prowjobs"},
+			Spec: prowapi.ProwJobSpec{
+				Type: prowapi.BatchJob,
+				Refs: &prowapi.Refs{Org: "o", Repo: "r", BaseRef: "master", BaseSHA: "master", Pulls: pulls},
+			},
+			Status: prowapi.ProwJobStatus{State: state},
+		}
+	}
+	complete := batch("complete", prowapi.SuccessState, prowapi.Pull{Number: 1, SHA: "a"}, prowapi.Pull{Number: 3, SHA: "c"})
+	complete.SetComplete()
+	presubmit := batch("presubmit", prowapi.PendingState, prowapi.Pull{Number: 3, SHA: "old"})
+	presubmit.Spec.Type = prowapi.PresubmitJob
+	groupBatch := func(name string, base string, pulls ...prowapi.Pull) prowapi.ProwJob {
+		pj := batch(name, prowapi.PendingState, prowapi.Pull{Number: 1, SHA: "a"})
+		pj.Spec.ExtraRefs = []prowapi.Refs{
+			{Org: "o", Repo: "tools", BaseRef: "master"},
+			{Org: "o", Repo: "other", BaseRef: "master", BaseSHA: base, Pulls: pulls},
+		}
+		return pj
+	}
+	pjs := []prowapi.ProwJob{
+		batch("valid", prowapi.PendingState, prowapi.Pull{Number: 1, SHA: "a"}, prowapi.Pull{Number: 2, SHA: "b"}),
+		batch("left-pool", prowapi.PendingState, prowapi.Pull{Number: 1, SHA: "a"}, prowapi.Pull{Number: 3, SHA: "c"}),
+		batch("head-changed", prowapi.TriggeredState, prowapi.Pull{Number: 1, SHA: "a"}, prowapi.Pull{Number: 2, SHA: "old"}),
+		complete,
+		presubmit,
+		groupBatch("group-valid", "other-master", prowapi.Pull{Number: 5, SHA: "e"}),
+		groupBatch("group-left-pool", "other-master", prowapi.Pull{Number: 5, SHA: "e"}, prowapi.Pull{Number: 6, SHA: "f"}),
+		groupBatch("group-head-changed", "other-master", prowapi.Pull{Number: 5, SHA: "old"}),
+		groupBatch("group-base-changed", "old-master", prowapi.Pull{Number: 5, SHA: "e"}),
+	}
+	var otherPR PullRequest
+	otherPR.Number = githubql.Int(5)
+	otherPR.HeadRefOID = githubql.String("e")
+	other := &subpool{org: "o", repo: "other", branch: "master", sha: "other-master", prs: []PullRequest{otherPR}}
+
+	var prs []PullRequest
+	for num, sha := range map[int]string{1: "a", 2: "b"} {
+		var pr PullRequest
+		pr.Number = githubql.Int(num)
+		pr.HeadRefOID = githubql.String(sha)
+		prs = append(prs, pr)
+	}
+
+	testCases := []struct {
+		name            string
+		enabled         bool
+		expectedAborted sets.String
+	}{
+		{
+			name:            "disabled leaves all jobs alone",
+			expectedAborted: sets.NewString(),
+		},
+		{
+			name:            "enabled aborts pending batches no longer matching the pool",
+			enabled:         true,
+			expectedAborted: sets.NewString("left-pool", "head-changed", "group-left-pool", "group-head-changed", "group-base-changed"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var objs []runtime.Object
+			for i := range pjs {
+				objs = append(objs, pjs[i].DeepCopy())
+			}
+			client := fakectrlruntimeclient.NewFakeClient(objs...)
+			cfg := &config.Config{}
+			cfg.Tide.AbortStaleBatches = tc.enabled
+			c := &Controller{
+				ctx:           context.Background(),
+				logger:        logrus.WithField("controller", "tide"),
+				config:        func() *config.Config { return cfg },
+				prowJobClient: client,
+			}
+			sp := subpool{
+				log:    logrus.WithField("test", tc.name),
+				org:    "o",
+				repo:   "r",
+				branch: "master",
+				sha:    "master",
+				prs:    prs,
+				pjs:    pjs,
+			}
+			sp.groupMembers = map[string]*subpool{"o/r": &sp, "o/other": other}
+			if act, _, err := c.takeAction(sp, nil, nil, nil, nil, nil, nil, false); err != nil {
+				t.Fatalf("unexpected error from takeAction: %v", err)
+			} else if act != Wait {
+				t.Errorf("expected action %v, got %v", Wait, act)
+			}
+
+			prowJobs := &prowapi.ProwJobList{}
+			if err := client.List(context.Background(), prowJobs); err != nil {
+				t.Fatalf("failed to list ProwJobs: %v", err)
+			}
+			aborted := sets.NewString()
+			for _, pj := range prowJobs.Items {
+				if pj.Status.State == prowapi.AbortedState {
+					if !pj.Complete() {
+						t.Errorf("aborted job %q was not marked complete", pj.Name)
+					}
+					aborted.Insert(pj.Name)
+				}
+			}
+			if !aborted.Equal(tc.expectedAborted) {
+				t.Errorf("expected aborted jobs %v, got %v", tc.expectedAborted.List(), aborted.List())
+			}
+		})
+	}
+}
+
+func TestRetestCooldown(t *testing.T) {
+	now := time.Now()
+	var pr PullRequest
+	pr.Number = githubql.Int(1)
+	pr.HeadRefOID = githubql.String("head")
+	pr.Commits.Nodes = []struct {
+		Commit Commit
+	}{{Commit: Commit{OID: pr.HeadRefOID}}}
+	failedJob := func(sha string, completed time.Time) prowapi.ProwJob {
+		return prowapi.ProwJob{
+			Spec: prowapi.ProwJobSpec{
+				Type:    prowapi.PresubmitJob,
+				Context: "job",
+				Refs:    &prowapi.Refs{Org: "o", Repo: "r", BaseRef: "master", BaseSHA: "master", Pulls: []prowapi.Pull{{Number: 1, SHA: sha}}},
+			},
+			Status: prowapi.ProwJobStatus{State: prowapi.FailureState, CompletionTime: &metav1.Time{Time: completed}},
+		}
+	}
+	testCases := []struct {
+		name     string
+		cooldown *metav1.Duration
+		pjs      []prowapi.ProwJob
+
+		expectedAction Action
+	}{
+		{
+			name:           "recent failure is retriggered without a cooldown",
+			pjs:            []prowapi.ProwJob{failedJob("head", now.Add(-10*time.Minute))},
+			expectedAction: Trigger,
+		},
+		{
+			name:           "recent failure is not retriggered within the cooldown",
+			cooldown:       &metav1.Duration{Duration: time.Hour},
+			pjs:            []prowapi.ProwJob{failedJob("head", now.Add(-2*time.Hour)), failedJob("head", now.Add(-10*time.Minute))},
+			expectedAction: Wait,
+		},
+		{
+			name:           "failure is retriggered after the cooldown",
+			cooldown:       &metav1.Duration{Duration: time.Hour},
+			pjs:            []prowapi.ProwJob{failedJob("head", now.Add(-2*time.Hour))},
+			expectedAction: Trigger,
+		},
+		{
+			name:           "failure of an old head does not delay the new head",
+			cooldown:       &metav1.Duration{Duration: time.Hour},
+			pjs:            []prowapi.ProwJob{failedJob("old", now.Add(-10*time.Minute))},
+			expectedAction: Trigger,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.Tide.RetestCooldown = tc.cooldown
+			client := fakectrlruntimeclient.NewFakeClient()
+			c := &Controller{
+				ctx:           context.Background(),
+				logger:        logrus.WithField("controller", "tide"),
+				config:        func() *config.Config { return cfg },
+				ghc:           &fgc{},
+				prowJobClient: client,
+			}
+			presubmits := map[int][]config.Presubmit{1: {{JobBase: config.JobBase{Name: "job"}, Reporter: config.Reporter{Context: "job"}}}}
+			sp := subpool{
+				log:        logrus.WithField("test", tc.name),
+				org:        "o",
+				repo:       "r",
+				branch:     "master",
+				sha:        "master",
+				prs:        []PullRequest{pr},
+				pjs:        tc.pjs,
+				presubmits: presubmits,
+				cc:         map[int]contextChecker{1: &config.TideContextPolicy{}},
+			}
+			var err error
+			if sp.retests, err = retestCounts(context.Background(), client, cfg.ProwJobNamespace, &sp); err != nil {
+				t.Fatalf("unexpected error counting retests: %v", err)
+			}
+			if act, _, err := c.takeAction(sp, nil, nil, nil, []PullRequest{pr}, nil, presubmits, false); err != nil {
+				t.Fatalf("unexpected error from takeAction: %v", err)
+			} else if act != tc.expectedAction {
+				t.Errorf("expected action %v, got %v", tc.expectedAction, act)
+			}
+
+			prowJobs := &prowapi.ProwJobList{}
+			if err := client.List(context.Background(), prowJobs); err != nil {
+				t.Fatalf("failed to list ProwJobs: %v", err)
+			}
+			if triggered := tc.expectedAction == Trigger; triggered != (len(prowJobs.Items) == 1) {
+				t.Errorf("expected a job to be triggered: %t, got %d jobs", triggered, len(prowJobs.Items))
+			}
+		})
+	}
+}
+
+func TestMinSHAAge(t *testing.T) {
+	now := time.Now()
+	pushedPR := func(num int, pushed, committed time.Time) PullRequest {
+		var pr PullRequest
+		pr.Number = githubql.Int(num)
+		pr.HeadRefOID = githubql.String(fmt.Sprintf("head%d", num))
+		commit := Commit{OID: pr.HeadRefOID, CommittedDate: githubql.DateTime{Time: committed}}
+		if !pushed.IsZero() {
+			commit.PushedDate = &githubql.DateTime{Time: pushed}
+		}
+		pr.Commits.Nodes = []struct {
+			Commit Commit
+		}{{Commit: commit}}
+		return pr
+	}
+	testCases := []struct {
+		name   string
+		minAge time.Duration
+		prs    []PullRequest
+
+		expected []int
+	}{
+		{
+			name:     "head younger than the minimum age is dropped",
+			minAge:   10 * time.Minute,
+			prs:      []PullRequest{pushedPR(1, now.Add(-10*time.Minute+time.Second), time.Time{})},
+			expected: nil,
+		},
+		{
+			name:     "head exactly as old as the minimum age is kept",
+			minAge:   10 * time.Minute,
+			prs:      []PullRequest{pushedPR(1, now.Add(-10*time.Minute), time.Time{})},
+			expected: []int{1},
+		},
+		{
+			name:     "head older than the minimum age is kept",
+			minAge:   10 * time.Minute,
+			prs:      []PullRequest{pushedPR(1, now.Add(-time.Hour), time.Time{}), pushedPR(2, now.Add(-time.Minute), time.Time{})},
+			expected: []int{1},
+		},
+		{
+			name:     "push date takes precedence over the commit date",
+			minAge:   10 * time.Minute,
+			prs:      []PullRequest{pushedPR(1, now.Add(-time.Minute), now.Add(-time.Hour))},
+			expected: nil,
+		},
+		{
+			name:     "commit date is used without a push date",
+			minAge:   10 * time.Minute,
+			prs:      []PullRequest{pushedPR(1, time.Time{}, now.Add(-time.Minute)), pushedPR(2, time.Time{}, now.Add(-time.Hour))},
+			expected: []int{2},
+		},
+		{
+			name:     "head of unknown age is kept",
+			minAge:   10 * time.Minute,
+			prs:      []PullRequest{pushedPR(1, time.Time{}, time.Time{})},
+			expected: []int{1},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sp := subpool{log: logrus.WithField("test", tc.name)}
+			var got []int
+			for _, pr := range withoutYoungHeads(sp, tc.prs, tc.minAge, now) {
+				got = append(got, int(pr.Number))
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected PRs %v to be old enough, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestTakeActionMinSHAAge(t *testing.T) {
+	now := time.Now()
+	var pr PullRequest
+	pr.Number = githubql.Int(1)
+	pr.HeadRefOID = githubql.String("head")
+	testCases := []struct {
+		name   string
+		minAge *metav1.Duration
+		pushed time.Time
+
+		expectedAction Action
+	}{
+		{
+			name:           "young head is merged without a minimum age",
+			pushed:         now.Add(-time.Minute),
+			expectedAction: Merge,
+		},
+		{
+			name:           "young head is not merged",
+			minAge:         &metav1.Duration{Duration: 10 * time.Minute},
+			pushed:         now.Add(-time.Minute),
+			expectedAction: Wait,
+		},
+		{
+			name:           "old head is merged",
+			minAge:         &metav1.Duration{Duration: 10 * time.Minute},
+			pushed:         now.Add(-time.Hour),
+			expectedAction: Merge,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.Tide.MinSHAAge = tc.minAge
+			pr := pr
+			pr.Commits.Nodes = []struct {
+				Commit Commit
+			}{{Commit: Commit{OID: pr.HeadRefOID, PushedDate: &githubql.DateTime{Time: tc.pushed}}}}
+			ghc := &fgc{}
+			c := &Controller{
+				ctx:           context.Background(),
+				logger:        logrus.WithField("controller", "tide"),
+				config:        func() *config.Config { return cfg },
+				ghc:           ghc,
+				prowJobClient: fakectrlruntimeclient.NewFakeClient(),
+			}
+			sp := subpool{
+				log:    logrus.WithField("test", tc.name),
+				org:    "o",
+				repo:   "r",
+				branch: "master",
+				sha:    "master",
+				prs:    []PullRequest{pr},
+				cc:     map[int]contextChecker{1: &config.TideContextPolicy{}},
+			}
+			act, _, err := c.takeAction(sp, nil, []PullRequest{pr}, nil, nil, nil, nil, false)
+			if err != nil {
+				t.Fatalf("unexpected error from takeAction: %v", err)
+			}
+			if act != tc.expectedAction {
+				t.Errorf("expected action %v, got %v", tc.expectedAction, act)
+			}
+			if merged := tc.expectedAction == Merge; merged != (ghc.merged == 1) {
+				t.Errorf("expected the PR to be merged: %t, got %d merges", merged, ghc.merged)
+			}
+		})
+	}
+}
+
+func TestTakeActionRequireBaseGreen(t *testing.T) {
+	var pr PullRequest
+	pr.Number = githubql.Int(1)
+	pr.HeadRefOID = githubql.String("head")
+	pr.Commits.Nodes = []struct {
+		Commit Commit
+	}{{Commit: Commit{OID: pr.HeadRefOID}}}
+	testCases := []struct {
+		name             string
+		requireBaseGreen bool
+		baseStatuses     map[string]string
+
+		expectedAction Action
+	}{
+		{
+			name:           "red base is ignored by default",
+			baseStatuses:   map[string]string{"build": github.StatusFailure},
+			expectedAction: Merge,
+		},
+		{
+			name:             "green base merges",
+			requireBaseGreen: true,
+			baseStatuses:     map[string]string{"build": github.StatusSuccess, "e2e": github.StatusPending},
+			expectedAction:   Merge,
+		},
+		{
+			name:             "base without statuses merges",
+			requireBaseGreen: true,
+			expectedAction:   Merge,
+		},
+		{
+			name:             "failing base waits",
+			requireBaseGreen: true,
+			baseStatuses:     map[string]string{"build": github.StatusSuccess, "e2e": github.StatusFailure},
+			expectedAction:   Wait,
+		},
+		{
+			name:             "erroring base waits",
+			requireBaseGreen: true,
+			baseStatuses:     map[string]string{"build": github.StatusError},
+			expectedAction:   Wait,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.Tide.RequireBaseGreen = tc.requireBaseGreen
+			ghc := &fgc{expectedSHA: "master", combinedStatus: tc.baseStatuses}
+			c := &Controller{
+				ctx:           context.Background(),
+				logger:        logrus.WithField("controller", "tide"),
+				config:        func() *config.Config { return cfg },
+				ghc:           ghc,
+				prowJobClient: fakectrlruntimeclient.NewFakeClient(),
+			}
+			sp := subpool{
+				log:    logrus.WithField("test", tc.name),
+				org:    "o",
+				repo:   "r",
+				branch: "master",
+				sha:    "master",
+				prs:    []PullRequest{pr},
+				cc:     map[int]contextChecker{1: &config.TideContextPolicy{}},
+			}
+			act, _, err := c.takeAction(sp, nil, []PullRequest{pr}, nil, nil, nil, nil, false)
+			if err != nil {
+				t.Fatalf("unexpected error from takeAction: %v", err)
+			}
+			if act != tc.expectedAction {
+				t.Errorf("expected action %v, got %v", tc.expectedAction, act)
+			}
+			if merged := tc.expectedAction == Merge; merged != (ghc.merged == 1) {
+				t.Errorf("expected the PR to be merged: %t, got %d merges", merged, ghc.merged)
+			}
+
+			ghc.merged = 0
+			act, _, err = c.takeAction(sp, nil, nil, nil, nil, []PullRequest{pr}, nil, false)
+			if err != nil {
+				t.Fatalf("unexpected error from takeAction for a batch: %v", err)
+			}
+			expectedBatchAction := Action(MergeBatch)
+			if tc.expectedAction == Wait {
+				expectedBatchAction = Wait
+			}
+			if act != expectedBatchAction {
+				t.Errorf("expected batch action %v, got %v", expectedBatchAction, act)
+			}
+		})
+	}
+}
+
+func TestRetestLimit(t *testing.T) {
+	var pr PullRequest
+	pr.Number = githubql.Int(1)
+	pr.HeadRefOID = githubql.String("head")
+	pr.Commits.Nodes = []struct {
+		Commit Commit
+	}{{Commit: Commit{OID: pr.HeadRefOID}}}
+	retestedJob := func(name, baseSHA, sha, count string) runtime.Object {
+		pj := &prowapi.ProwJob{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+				Labels: map[string]string{
+					kube.ProwJobTypeLabel: string(prowapi.PresubmitJob),
+					kube.OrgLabel:         "o",
+					kube.RepoLabel:        "r",
+					kube.PullLabel:        "1",
+				},
+			},
+			Spec: prowapi.ProwJobSpec{
+				Type:    prowapi.PresubmitJob,
+				Context: "job",
+				Refs:    &prowapi.Refs{Org: "o", Repo: "r", BaseRef: "master", BaseSHA: baseSHA, Pulls: []prowapi.Pull{{Number: 1, SHA: sha}}},
+			},
+			Status: prowapi.ProwJobStatus{State: prowapi.FailureState},
+		}
+		if count != "" {
+			pj.Annotations = map[string]string{kube.TideRetestCountAnnotation: count}
+		}
+		return pj
+	}
+	testCases := []struct {
+		name       string
+		maxRetests int
+		pjs        []runtime.Object
+
+		expectedAction Action
+		expectedCount  string
+	}{
+		{
+			name:           "first retest is counted",
+			maxRetests:     2,
+			expectedAction: Trigger,
+			expectedCount:  "1",
+		},
+		{
+			name:           "retests are counted across base SHAs",
+			maxRetests:     3,
+			pjs:            []runtime.Object{retestedJob("first", "old", "head", "1"), retestedJob("second", "older", "head", "2")},
+			expectedAction: Trigger,
+			expectedCount:  "3",
+		},
+		{
+			name:           "PR is not retested after reaching the limit",
+			maxRetests:     2,
+			pjs:            []runtime.Object{retestedJob("first", "old", "head", "1"), retestedJob("second", "older", "head", "2")},
+			expectedAction: Wait,
+		},
+		{
+			name:           "retests of an old head do not count",
+			maxRetests:     2,
+			pjs:            []runtime.Object{retestedJob("first", "old", "oldhead", "2")},
+			expectedAction: Trigger,
+			expectedCount:  "1",
+		},
+		{
+			name:           "runs not triggered by Tide do not count",
+			maxRetests:     1,
+			pjs:            []runtime.Object{retestedJob("first", "old", "head", ""), retestedJob("second", "older", "head", "")},
+			expectedAction: Trigger,
+			expectedCount:  "1",
+		},
+		{
+			name:           "retests are not limited without a limit",
+			pjs:            []runtime.Object{retestedJob("first", "old", "head", "5")},
+			expectedAction: Trigger,
+			expectedCount:  "6",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.Tide.MaxRetestsPerPR = tc.maxRetests
+			client := fakectrlruntimeclient.NewFakeClient(tc.pjs...)
+			c := &Controller{
+				ctx:           context.Background(),
+				logger:        logrus.WithField("controller", "tide"),
+				config:        func() *config.Config { return cfg },
+				ghc:           &fgc{},
+				prowJobClient: client,
+			}
+			presubmits := map[int][]config.Presubmit{1: {{JobBase: config.JobBase{Name: "job"}, Reporter: config.Reporter{Context: "job"}}}}
+			sp := subpool{
+				log:        logrus.WithField("test", tc.name),
+				org:        "o",
+				repo:       "r",
+				branch:     "master",
+				sha:        "master",
+				prs:        []PullRequest{pr},
+				presubmits: presubmits,
+				cc:         map[int]contextChecker{1: &config.TideContextPolicy{}},
+			}
+			var err error
+			if sp.retests, err = retestCounts(context.Background(), client, cfg.ProwJobNamespace, &sp); err != nil {
+				t.Fatalf("unexpected error counting retests: %v", err)
+			}
+			if act, _, err := c.takeAction(sp, nil, nil, nil, []PullRequest{pr}, nil, presubmits, false); err != nil {
+				t.Fatalf("unexpected error from takeAction: %v", err)
+			} else if act != tc.expectedAction {
+				t.Errorf("expected action %v, got %v", tc.expectedAction, act)
+			}
+
+			prowJobs := &prowapi.ProwJobList{}
+			if err := client.List(context.Background(), prowJobs); err != nil {
+				t.Fatalf("failed to list ProwJobs: %v", err)
+			}
+			var triggered []prowapi.ProwJob
+			for _, pj := range prowJobs.Items {
+				if pj.Spec.Refs.BaseSHA == "master" {
+					triggered = append(triggered, pj)
+				}
+			}
+			if tc.expectedCount == "" {
+				if len(triggered) != 0 {
+					t.Errorf("expected no job to be triggered, got %d", len(triggered))
+				}
+				return
+			}
+			if len(triggered) != 1 {
+				t.Fatalf("expected one job to be triggered, got %d", len(triggered))
+			}
+			if count := triggered[0].Annotations[kube.TideRetestCountAnnotation]; count != tc.expectedCount {
+				t.Errorf("expected the retest count %q, got %q", tc.expectedCount, count)
+			}
+		})
+	}
+}
+
+func TestRetestCounts(t *testing.T) {
+	pr := func(number int, head string) PullRequest {
+		var pr PullRequest
+		pr.Number = githubql.Int(number)
+		pr.HeadRefOID = githubql.String(head)
+		return pr
+	}
+	job := func(name, repo string, number int, sha, context, count string) runtime.Object {
+		return &prowapi.ProwJob{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+				Labels: map[string]string{
+					kube.ProwJobTypeLabel: string(prowapi.PresubmitJob),
+					kube.OrgLabel:         "o",
+					kube.RepoLabel:        repo,
+					kube.PullLabel:        strconv.Itoa(number),
+				},
+				Annotations: map[string]string{kube.TideRetestCountAnnotation: count},
+			},
+			Spec: prowapi.ProwJobSpec{
+				Type:    prowapi.PresubmitJob,
+				Context: context,
+				Refs:    &prowapi.Refs{Org: "o", Repo: repo, Pulls: []prowapi.Pull{{Number: number, SHA: sha}}},
 			},
 		}
-		var batchPending []PullRequest
-		if tc.batchPending {
-			batchPending = []PullRequest{{}}
-		}
-		t.Logf("Test case: %s", tc.name)
-		if act, _, err := c.takeAction(sp, batchPending, genPulls(tc.successes), genPulls(tc.pendings), genPulls(tc.nones), genPulls(tc.batchMerges), sp.presubmits); err != nil && !tc.expectErr {
-			t.Errorf("Unexpected error in takeAction: %v", err)
-			continue
-		} else if err == nil && tc.expectErr {
-			t.Error("Missing expected error from takeAction.")
-		} else if act != tc.action {
-			t.Errorf("Wrong action. Got %v, wanted %v.", act, tc.action)
-		}
+	}
+	client := fakectrlruntimeclient.NewFakeClient(
+		job("a", "r", 1, "head1", "unit", "1"),
+		job("b", "r", 1, "head1", "unit", "2"),
+		job("c", "r", 1, "head1", "e2e", "1"),
+		job("d", "r", 2, "head2", "unit", "3"),
+		job("e", "r", 2, "oldhead", "e2e", "4"),
+		job("f", "other", 3, "head3", "unit", "1"),
+	)
+	sp := &subpool{org: "o", repo: "r", prs: []PullRequest{pr(1, "head1"), pr(2, "head2"), pr(3, "head3")}}
 
-		prowJobs := &prowapi.ProwJobList{}
-		if err := client.List(context.Background(), prowJobs); err != nil {
-			t.Fatalf("failed to list ProwJobs: %v", err)
-		}
-		numCreated := len(prowJobs.Items)
+	retests, err := retestCounts(context.Background(), client, "", sp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[int]map[string]int{
+		1: {"unit": 2, "e2e": 1},
+		2: {"unit": 3},
+	}
+	if !reflect.DeepEqual(retests, expected) {
+		t.Errorf("expected retests %v, got %v", expected, retests)
+	}
+}
 
-		var batchJobs []*prowapi.ProwJob
-		for _, pj := range prowJobs.Items {
-			if pj.Namespace != pjNamespace {
-				t.Errorf("prowjob %q didn't have expected namespace %q but %q", pj.Name, pjNamespace, pj.Namespace)
+func TestSyncSubpoolPaused(t *testing.T) {
+	var pr PullRequest
+	pr.Number = githubql.Int(1)
+	pr.HeadRefOID = githubql.String("head")
+	pr.Commits.Nodes = []struct {
+		Commit Commit
+	}{{Commit: Commit{OID: pr.HeadRefOID}}}
+	pause := blockers.Blocker{Number: 10, Title: "Pause Tide on master", URL: "https://github.com/o/r/issues/10"}
+	testCases := []struct {
+		name   string
+		pauses []blockers.Blocker
+
+		expectedAction Action
+	}{
+		{
+			name:           "unpaused pool triggers the missing test",
+			expectedAction: Trigger,
+		},
+		{
+			name:           "paused pool takes no action",
+			pauses:         []blockers.Blocker{pause},
+			expectedAction: PoolPaused,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hist, err := history.New(100, nil, "")
+			if err != nil {
+				t.Fatalf("Failed to create history client: %v", err)
 			}
-			if pj.Spec.Type == prowapi.BatchJob {
-				batchJobs = append(batchJobs, &pj)
+			client := fakectrlruntimeclient.NewFakeClient()
+			ghc := &fgc{}
+			c := &Controller{
+				ctx:           context.Background(),
+				logger:        logrus.WithField("controller", "tide"),
+				config:        func() *config.Config { return &config.Config{} },
+				ghc:           ghc,
+				prowJobClient: client,
+				History:       hist,
+			}
+			sp := subpool{
+				log:        logrus.WithField("test", tc.name),
+				org:        "o",
+				repo:       "r",
+				branch:     "master",
+				sha:        "master",
+				prs:        []PullRequest{pr},
+				presubmits: map[int][]config.Presubmit{1: {{JobBase: config.JobBase{Name: "job"}, Reporter: config.Reporter{Context: "job"}}}},
+				cc:         map[int]contextChecker{1: &config.TideContextPolicy{}},
+			}
+			pool, err := c.syncSubpool(sp, nil, nil, tc.pauses)
+			if err != nil {
+				t.Fatalf("unexpected error from syncSubpool: %v", err)
+			}
+			if pool.Action != tc.expectedAction {
+				t.Errorf("expected action %v, got %v", tc.expectedAction, pool.Action)
+			}
+			if !reflect.DeepEqual(pool.Pauses, tc.pauses) {
+				t.Errorf("expected the pool to report pauses %v, got %v", tc.pauses, pool.Pauses)
+			}
+			if len(pool.MissingPRs) != 1 {
+				t.Errorf("expected the pool to still report the PR missing tests, got %v", pool.MissingPRs)
 			}
-		}
 
-		if tc.triggered != numCreated {
-			t.Errorf("Wrong number of jobs triggered. Got %d, expected %d.", numCreated, tc.triggered)
-		}
-		if tc.merged != fgc.merged {
-			t.Errorf("Wrong number of merges. Got %d, expected %d.", fgc.merged, tc.merged)
-		}
-		// Ensure that the correct number of batch jobs were triggered
-		if tc.triggeredBatches != len(batchJobs) {
-			t.Errorf("Wrong number of batches triggered. Got %d, expected %d.", len(batchJobs), tc.triggeredBatches)
-		}
-		for _, job := range batchJobs {
-			if len(job.Spec.Refs.Pulls) <= 1 {
-				t.Error("Found a batch job that doesn't contain multiple pull refs!")
+			prowJobs := &prowapi.ProwJobList{}
+			if err := client.List(context.Background(), prowJobs); err != nil {
+				t.Fatalf("failed to list ProwJobs: %v", err)
 			}
+			if triggered := tc.expectedAction == Trigger; triggered != (len(prowJobs.Items) == 1) {
+				t.Errorf("expected a job to be triggered: %t, got %d jobs", triggered, len(prowJobs.Items))
+			}
+			if ghc.merged != 0 {
+				t.Errorf("expected no merges, got %d", ghc.merged)
+			}
+			if records := hist.AllRecords(); tc.pauses != nil && len(records) != 0 {
+				t.Errorf("expected no history for a paused pool, got %v", records)
+			}
+		})
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	newPR := func(number int) PullRequest {
+		var pr PullRequest
+		pr.Number = githubql.Int(number)
+		pr.HeadRefOID = githubql.String(fmt.Sprintf("sha-%d", number))
+		pr.Commits.Nodes = []struct {
+			Commit Commit
+		}{{Commit: Commit{OID: pr.HeadRefOID}}}
+		return pr
+	}
+	stale := prowapi.ProwJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "stale", Namespace: "prowjobs"},
+		Spec: prowapi.ProwJobSpec{
+			Type: prowapi.BatchJob,
+			Job:  "batch-job",
+			Refs: &prowapi.Refs{Org: "o", Repo: "r", BaseRef: "master", BaseSHA: "master", Pulls: []prowapi.Pull{{Number: 1, SHA: "old"}, {Number: 3, SHA: "sha-3"}}},
+		},
+		Status: prowapi.ProwJobStatus{State: prowapi.PendingState},
+	}
+	client := fakectrlruntimeclient.NewFakeClient(stale.DeepCopy())
+	cfg := &config.Config{ProwConfig: config.ProwConfig{ProwJobNamespace: "prowjobs"}}
+	cfg.Tide.AbortStaleBatches = true
+	fgc := &fgc{}
+	c := &Controller{
+		ctx:           context.Background(),
+		logger:        logrus.WithField("controller", "tide"),
+		config:        func() *config.Config { return cfg },
+		ghc:           fgc,
+		prowJobClient: client,
+		dryRun:        true,
+	}
+	presubmits := []config.Presubmit{{JobBase: config.JobBase{Name: "job"}, Reporter: config.Reporter{Context: "job"}}}
+	sp := subpool{
+		log:        logrus.WithField("test", "dry-run"),
+		org:        "o",
+		repo:       "r",
+		branch:     "master",
+		sha:        "master",
+		prs:        []PullRequest{newPR(1)},
+		pjs:        []prowapi.ProwJob{stale},
+		presubmits: map[int][]config.Presubmit{1: presubmits, 2: presubmits},
+		cc: map[int]contextChecker{
+			1: &config.TideContextPolicy{},
+			2: &config.TideContextPolicy{},
+		},
+	}
+	if act, _, err := c.takeAction(sp, nil, []PullRequest{newPR(1)}, nil, nil, nil, nil, false); err != nil {
+		t.Fatalf("unexpected error from takeAction: %v", err)
+	} else if act != Merge {
+		t.Errorf("expected action %v, got %v", Merge, act)
+	}
+	sp.prs = []PullRequest{newPR(2)}
+	sp.pjs = nil
+	if act, _, err := c.takeAction(sp, nil, nil, nil, []PullRequest{newPR(2)}, nil, map[int][]config.Presubmit{2: presubmits}, false); err != nil {
+		t.Fatalf("unexpected error from takeAction: %v", err)
+	} else if act != Trigger {
+		t.Errorf("expected action %v, got %v", Trigger, act)
+	}
+
+	if fgc.merged != 0 {
+		t.Errorf("expected no merges in dry-run mode, got %d", fgc.merged)
+	}
+	prowJobs := &prowapi.ProwJobList{}
+	if err := client.List(context.Background(), prowJobs); err != nil {
+		t.Fatalf("failed to list ProwJobs: %v", err)
+	}
+	if len(prowJobs.Items) != 1 {
+		t.Errorf("expected no ProwJobs to be created in dry-run mode, got %d jobs", len(prowJobs.Items))
+	}
+	for _, pj := range prowJobs.Items {
+		if pj.Status.State != prowapi.PendingState {
+			t.Errorf("expected ProwJob %q to be left alone in dry-run mode, got state %q", pj.Name, pj.Status.State)
 		}
 	}
+
+	c.publishDryRunReport()
+	rr := httptest.NewRecorder()
+	c.ServeDryRunReport(rr, httptest.NewRequest(http.MethodGet, "/dry-run", nil))
+	var report DryRunReport
+	if err := json.NewDecoder(rr.Body).Decode(&report); err != nil {
+		t.Fatalf("JSON decoding error: %v", err)
+	}
+	expected := []DryRunAction{
+		{Org: "o", Repo: "r", Branch: "master", Kind: DryRunAbortStaleBatch, PRs: []int{1, 3}, Job: "batch-job"},
+		{Org: "o", Repo: "r", Branch: "master", Kind: DryRunCreateProwJob, PRs: []int{2}, Job: "job"},
+		{Org: "o", Repo: "r", Branch: "master", Kind: DryRunMerge, PRs: []int{1}, MergeMethod: github.MergeMerge},
+	}
+	if !reflect.DeepEqual(report.Actions, expected) {
+		t.Errorf("wrong dry-run report actions: %s", diff.ObjectReflectDiff(expected, report.Actions))
+	}
+	if len(c.planned) != 0 {
+		t.Errorf("expected planned actions to be reset after publishing the report, got %v", c.planned)
+	}
 }
 
 func TestServeHTTP(t *testing.T) {
@@ -2081,6 +3191,256 @@ func TestFilterSubpool(t *testing.T) {
 	}
 }
 
+func TestTruncateSubpool(t *testing.T) {
+	testCases := []struct {
+		name     string
+		prs      []int
+		max      int
+		order    config.TidePoolSortOrder
+		expected []int
+		dropped  []int
+	}{
+		{
+			name:     "no limit keeps all PRs",
+			prs:      []int{5, 3, 9},
+			expected: []int{5, 3, 9},
+		},
+		{
+			name:     "pool within the limit is untouched",
+			prs:      []int{5, 3, 9},
+			max:      3,
+			expected: []int{5, 3, 9},
+		},
+		{
+			name:     "pool over the limit keeps the oldest PRs",
+			prs:      []int{5, 3, 9, 1, 7},
+			max:      3,
+			expected: []int{1, 3, 5},
+			dropped:  []int{7, 9},
+		},
+		{
+			name:     "pool over the limit follows the sort order",
+			prs:      []int{5, 3, 9, 1, 7},
+			max:      3,
+			order:    config.PoolSortOrderUpdated,
+			expected: []int{9, 7, 5},
+			dropped:  []int{3, 1},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sp := &subpool{log: logrus.WithField("test", tc.name)}
+			for _, num := range tc.prs {
+				var pr PullRequest
+				pr.Number = githubql.Int(num)
+				// Higher numbers were updated longer ago.
+				pr.UpdatedAt = githubql.DateTime{Time: time.Unix(int64(100-num), 0)}
+				sp.prs = append(sp.prs, pr)
+			}
+			dropped := truncateSubpool(sp, tc.max, tc.order)
+			if actual := prNumbers(sp.prs); !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected PRs %v, got %v", tc.expected, actual)
+			}
+			if actual := prNumbers(dropped); !reflect.DeepEqual(actual, tc.dropped) {
+				t.Errorf("expected dropped PRs %v, got %v", tc.dropped, actual)
+			}
+		})
+	}
+}
+
+func TestFilterSubpoolRequiredMergeLabels(t *testing.T) {
+	queries := config.TideQueries{
+		{Orgs: []string{"org"}, RequiredMergeLabels: []string{"lgtm", "approved"}},
+		{Orgs: []string{"org"}, RequiredMergeLabels: []string{"lgtm", "approved", "cherry-pick-approved"}},
+	}
+	tcs := []struct {
+		name            string
+		queries         config.TideQueries
+		labels          []string
+		expectedMissing []string
+	}{
+		{
+			name:   "no required merge labels",
+			labels: []string{"lgtm"},
+		},
+		{
+			name:    "all required merge labels present",
+			queries: queries,
+			labels:  []string{"lgtm", "approved"},
+		},
+		{
+			name:            "one required merge label missing",
+			queries:         queries,
+			labels:          []string{"lgtm"},
+			expectedMissing: []string{"approved"},
+		},
+		{
+			name:            "all required merge labels missing",
+			queries:         queries,
+			labels:          []string{"something-else"},
+			expectedMissing: []string{"lgtm", "approved"},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			pr := PullRequest{Number: githubql.Int(1)}
+			pr.Commits.Nodes = []struct{ Commit Commit }{{Commit{}}}
+			for _, label := range tc.labels {
+				pr.Labels.Nodes = append(pr.Labels.Nodes, struct{ Name githubql.String }{Name: githubql.String(label)})
+			}
+			if missing := missingRequiredMergeLabels(tc.queries, "", &pr); !reflect.DeepEqual(missing, tc.expectedMissing) {
+				t.Errorf("Expected missing labels %v, got %v.", tc.expectedMissing, missing)
+			}
+
+			sp := &subpool{
+				org:     "org",
+				repo:    "repo",
+				branch:  "branch",
+				queries: tc.queries,
+				cc:      map[int]contextChecker{1: &config.TideContextPolicy{}},
+				prs:     []PullRequest{pr},
+				log:     logrus.WithFields(logrus.Fields{"org": "org", "repo": "repo", "branch": "branch"}),
+			}
+			filtered := filterSubpool(nil, sp)
+			if len(tc.expectedMissing) > 0 && filtered != nil {
+				t.Errorf("Expected PR missing required merge labels to be filtered out, but got: %v", filtered)
+			}
+			if len(tc.expectedMissing) == 0 && filtered == nil {
+				t.Error("Expected PR with required merge labels to be kept, but the subpool was pruned.")
+			}
+		})
+	}
+}
+
+func TestMissingRequiredMergeLabelsOnlyMatchedQueries(t *testing.T) {
+	tcs := []struct {
+		name            string
+		queries         config.TideQueries
+		branch          string
+		labels          []string
+		expectedMissing []string
+	}{
+		{
+			name: "query for another branch does not lift the required merge labels",
+			queries: config.TideQueries{
+				{Repos: []string{"org/repo"}, IncludedBranches: []string{"release"}, RequiredMergeLabels: []string{"cherry-pick-approved"}},
+				{Repos: []string{"org/repo"}, IncludedBranches: []string{"master"}},
+			},
+			branch:          "release",
+			expectedMissing: []string{"cherry-pick-approved"},
+		},
+		{
+			name: "query for the other branch applies",
+			queries: config.TideQueries{
+				{Repos: []string{"org/repo"}, IncludedBranches: []string{"release"}, RequiredMergeLabels: []string{"cherry-pick-approved"}},
+				{Repos: []string{"org/repo"}, IncludedBranches: []string{"master"}},
+			},
+			branch: "master",
+		},
+		{
+			name: "query with other labels does not lift the required merge labels",
+			queries: config.TideQueries{
+				{Repos: []string{"org/repo"}, Labels: []string{"lgtm"}, RequiredMergeLabels: []string{"approved"}},
+				{Repos: []string{"org/repo"}, Labels: []string{"skip-review"}},
+			},
+			branch:          "master",
+			labels:          []string{"lgtm"},
+			expectedMissing: []string{"approved"},
+		},
+		{
+			name: "query for another branch does not lift the auto merge label",
+			queries: config.TideQueries{
+				{Repos: []string{"org/repo"}, IncludedBranches: []string{"master"}, AutoMergeLabelRequired: true},
+				{Repos: []string{"org/repo"}, IncludedBranches: []string{"release"}},
+			},
+			branch:          "master",
+			expectedMissing: []string{config.DefaultAutoMergeLabel},
+		},
+		{
+			name: "query with other milestone does not lift the required merge labels",
+			queries: config.TideQueries{
+				{Repos: []string{"org/repo"}, RequiredMergeLabels: []string{"approved"}},
+				{Repos: []string{"org/repo"}, Milestone: "v1.0"},
+			},
+			branch:          "master",
+			expectedMissing: []string{"approved"},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			pr := PullRequest{Number: githubql.Int(1)}
+			pr.BaseRef.Name = githubql.String(tc.branch)
+			for _, label := range tc.labels {
+				pr.Labels.Nodes = append(pr.Labels.Nodes, struct{ Name githubql.String }{Name: githubql.String(label)})
+			}
+			if missing := missingRequiredMergeLabels(tc.queries, "", &pr); !reflect.DeepEqual(missing, tc.expectedMissing) {
+				t.Errorf("Expected missing labels %v, got %v.", tc.expectedMissing, missing)
+			}
+		})
+	}
+}
+
+func TestFilterSubpoolAutoMergeLabel(t *testing.T) {
+	pr := func(number int, labels ...string) PullRequest {
+		pr := PullRequest{Number: githubql.Int(number)}
+		pr.Commits.Nodes = []struct{ Commit Commit }{{Commit{}}}
+		for _, label := range labels {
+			pr.Labels.Nodes = append(pr.Labels.Nodes, struct{ Name githubql.String }{Name: githubql.String(label)})
+		}
+		return pr
+	}
+	prs := []PullRequest{pr(1, "lgtm"), pr(2, "lgtm", config.DefaultAutoMergeLabel), pr(3, config.DefaultAutoMergeLabel)}
+	tcs := []struct {
+		name     string
+		queries  config.TideQueries
+		expected []int
+	}{
+		{
+			name:     "all PRs are merge candidates without the flag",
+			queries:  config.TideQueries{{Orgs: []string{"org"}}},
+			expected: []int{1, 2, 3},
+		},
+		{
+			name:     "only labeled PRs are merge candidates",
+			queries:  config.TideQueries{{Orgs: []string{"org"}, AutoMergeLabelRequired: true}},
+			expected: []int{2, 3},
+		},
+		{
+			name:     "labeled PRs still need the required merge labels",
+			queries:  config.TideQueries{{Orgs: []string{"org"}, RequiredMergeLabels: []string{"lgtm"}, AutoMergeLabelRequired: true}},
+			expected: []int{2},
+		},
+		{
+			name: "PRs only need the label of one query",
+			queries: config.TideQueries{
+				{Orgs: []string{"org"}, AutoMergeLabelRequired: true},
+				{Orgs: []string{"org"}, Labels: []string{"lgtm"}},
+			},
+			expected: []int{1, 2, 3},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			sp := &subpool{
+				org:     "org",
+				repo:    "repo",
+				branch:  "branch",
+				queries: tc.queries,
+				cc:      map[int]contextChecker{1: &config.TideContextPolicy{}, 2: &config.TideContextPolicy{}, 3: &config.TideContextPolicy{}},
+				prs:     prs,
+				log:     logrus.WithFields(logrus.Fields{"org": "org", "repo": "repo", "branch": "branch"}),
+			}
+			filtered := filterSubpool(nil, sp)
+			if filtered == nil {
+				t.Fatal("Expected some PRs to be kept, but the subpool was pruned.")
+			}
+			if actual := prNumbers(filtered.prs); !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("Expected merge candidates %v, got %v.", tc.expected, actual)
+			}
+		})
+	}
+}
+
 func TestIsPassing(t *testing.T) {
 	yes := true
 	no := false
@@ -2587,6 +3947,84 @@ func TestPrepareMergeDetails(t *testing.T) {
 	}
 }
 
+func TestPrepareMergeDetailsLabelPrefix(t *testing.T) {
+	pr := func(labels ...string) PullRequest {
+		pr := PullRequest{
+			Number:     githubql.Int(1),
+			HeadRefOID: githubql.String("SHA"),
+			Title:      "my commit title",
+		}
+		pr.Repository.Owner.Login = "org"
+		pr.Repository.Name = "repo"
+		for _, label := range labels {
+			pr.Labels.Nodes = append(pr.Labels.Nodes, struct{ Name githubql.String }{Name: githubql.String(label)})
+		}
+		return pr
+	}
+
+	testCases := []struct {
+		name        string
+		tpl         config.TideMergeCommitTemplate
+		pr          PullRequest
+		mergeMethod github.PullRequestMergeType
+		expected    string
+	}{
+		{
+			name:        "squash merge with a mapped label",
+			pr:          pr("lgtm", "kind/feature"),
+			mergeMethod: github.MergeSquash,
+			expected:    "feat: my commit title (#1)",
+		},
+		{
+			name:        "squash merge without a mapped label",
+			pr:          pr("lgtm"),
+			mergeMethod: github.MergeSquash,
+		},
+		{
+			name:        "several mapped labels use the first in sorted order",
+			pr:          pr("kind/fix", "kind/feature"),
+			mergeMethod: github.MergeSquash,
+			expected:    "feat: my commit title (#1)",
+		},
+		{
+			name:        "prefix composes with the title template",
+			tpl:         config.TideMergeCommitTemplate{Title: getTemplate("CommitTitle", "{{ .Title }} [{{ .Number }}]")},
+			pr:          pr("kind/fix"),
+			mergeMethod: github.MergeMerge,
+			expected:    "fix: my commit title [1]",
+		},
+		{
+			name:        "merge without template keeps the GitHub title",
+			pr:          pr("kind/fix"),
+			mergeMethod: github.MergeMerge,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := &config.Config{
+				ProwConfig: config.ProwConfig{
+					Tide: config.Tide{
+						MergeLabelPrefixes: map[string]map[string]string{
+							"org/repo": {"kind/feature": "feat:", "kind/fix": "fix:"},
+						},
+					},
+				},
+			}
+			c := &Controller{
+				config: func() *config.Config { return cfg },
+				ghc:    &fgc{},
+				logger: logrus.WithField("component", "tide"),
+			}
+
+			actual := c.prepareMergeDetails(test.tpl, test.pr, test.mergeMethod)
+			if actual.CommitTitle != test.expected {
+				t.Errorf("expected commit title %q, got %q", test.expected, actual.CommitTitle)
+			}
+		})
+	}
+}
+
 func TestAccumulateReturnsCorrectMissingTests(t *testing.T) {
 	testCases := []struct {
 		name               string
@@ -3237,6 +4675,9 @@ func (c *indexingClient) List(ctx context.Context, list runtime.Object, opts ...
 		opt.ApplyToList(listOpts)
 	}
 
+	if listOpts.FieldSelector == nil {
+		return nil
+	}
 	if n := len(listOpts.FieldSelector.Requirements()); n == 0 {
 		return nil
 	} else if n > 1 {